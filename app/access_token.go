@@ -0,0 +1,219 @@
+package gomoney
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/joaosoft/errors"
+	"github.com/labstack/echo"
+)
+
+const (
+	accessTokenPrefix = "gm_"
+
+	scopeRead  = "read"
+	scopeWrite = "write"
+)
+
+var errInvalidScope = errors.New(errors.LevelError, 1, "invalid scope, the scopes are read or write of wallets, transactions, categories, images, exports, reports and households")
+
+// scopeResources are the resources of the scopes
+var scopeResources = []string{"wallets", "transactions", "categories", "images", "exports", "reports", "households"}
+
+// routeScopes are the scopes required by the routes that can be used with an access token. The other routes,
+// like the account, the sessions, the tokens, the members of the wallets and the households, the invitations,
+// joining a household, attaching a wallet to a household and the import of an account, can only be used with a session.
+var routeScopes = map[string]string{
+	"GET /api/1/users/:user_id/wallets":                                    "read:wallets",
+	"GET /api/1/users/:user_id/wallets/:wallet_id":                         "read:wallets",
+	"POST /api/1/users/:user_id/wallets":                                   "write:wallets",
+	"PUT /api/1/users/:user_id/wallets/:wallet_id":                         "write:wallets",
+	"DELETE /api/1/users/:user_id/wallets/:wallet_id":                      "write:wallets",
+	"POST /api/1/users/:user_id/wallets/:wallet_id/unlock":                 "write:wallets",
+	"GET /api/1/users/:user_id/wallets/:wallet_id/members":                 "read:wallets",
+	"GET /api/1/users/:user_id/wallets/:wallet_id/statements/:year/:month": "read:reports",
+
+	"GET /api/1/users/:user_id/wallets/:wallet_id/transactions":                                               "read:transactions",
+	"GET /api/1/users/:user_id/wallets/:wallet_id/transactions/:transaction_id":                               "read:transactions",
+	"POST /api/1/users/:user_id/wallets/:wallet_id/transactions":                                              "write:transactions",
+	"PUT /api/1/users/:user_id/wallets/:wallet_id/transactions/:transaction_id":                               "write:transactions",
+	"DELETE /api/1/users/:user_id/wallets/:wallet_id/transactions/:transaction_id":                            "write:transactions",
+	"GET /api/1/users/:user_id/wallets/:wallet_id/transactions/:transaction_id/attachments":                   "read:transactions",
+	"GET /api/1/users/:user_id/wallets/:wallet_id/transactions/:transaction_id/attachments/:attachment_id":    "read:transactions",
+	"POST /api/1/users/:user_id/wallets/:wallet_id/transactions/:transaction_id/attachments":                  "write:transactions",
+	"DELETE /api/1/users/:user_id/wallets/:wallet_id/transactions/:transaction_id/attachments/:attachment_id": "write:transactions",
+	"POST /api/1/users/:user_id/receipts/prefill":                                                             "write:transactions",
+
+	"GET /api/1/users/:user_id/categories":                 "read:categories",
+	"GET /api/1/users/:user_id/categories/:category_id":    "read:categories",
+	"POST /api/1/users/:user_id/categories":                "write:categories",
+	"PUT /api/1/users/:user_id/categories/:category_id":    "write:categories",
+	"DELETE /api/1/users/:user_id/categories/:category_id": "write:categories",
+
+	"GET /api/1/users/:user_id/images":                            "read:images",
+	"GET /api/1/users/:user_id/images/:image_id":                  "read:images",
+	"GET /api/1/users/:user_id/images/:image_id/raw":              "read:images",
+	"GET /api/1/users/:user_id/images/:image_id/thumbnails/:name": "read:images",
+	"GET /api/1/users/:user_id/images/:image_id/resize":           "read:images",
+	"POST /api/1/users/:user_id/images":                           "write:images",
+	"PUT /api/1/users/:user_id/images/:image_id":                  "write:images",
+	"DELETE /api/1/users/:user_id/images/:image_id":               "write:images",
+
+	"POST /api/1/users/:user_id/export":                    "write:exports",
+	"POST /api/1/users/:user_id/exports":                   "write:exports",
+	"GET /api/1/users/:user_id/exports/:export_id":         "read:exports",
+	"GET /api/1/users/:user_id/exports/:export_id/archive": "read:exports",
+	"GET /api/1/users/:user_id/ledger":                     "read:exports",
+
+	"GET /api/1/users/:user_id/reports":                   "read:reports",
+	"GET /api/1/users/:user_id/spreadsheets/transactions": "read:reports",
+	"GET /api/1/users/:user_id/spreadsheets/reports":      "read:reports",
+
+	"GET /api/1/users/:user_id/households":                                          "read:households",
+	"GET /api/1/users/:user_id/households/:household_id":                            "read:households",
+	"POST /api/1/users/:user_id/households":                                         "write:households",
+	"PUT /api/1/users/:user_id/households/:household_id":                            "write:households",
+	"DELETE /api/1/users/:user_id/households/:household_id":                         "write:households",
+	"GET /api/1/users/:user_id/households/:household_id/members":                    "read:households",
+	"GET /api/1/users/:user_id/households/:household_id/wallets":                    "read:households",
+	"GET /api/1/users/:user_id/households/:household_id/categories":                 "read:households",
+	"POST /api/1/users/:user_id/households/:household_id/categories":                "write:households",
+	"PUT /api/1/users/:user_id/households/:household_id/categories/:category_id":    "write:households",
+	"DELETE /api/1/users/:user_id/households/:household_id/categories/:category_id": "write:households",
+	"GET /api/1/users/:user_id/households/:household_id/budgets":                    "read:households",
+	"POST /api/1/users/:user_id/households/:household_id/budgets":                   "write:households",
+	"PUT /api/1/users/:user_id/households/:household_id/budgets/:budget_id":         "write:households",
+	"DELETE /api/1/users/:user_id/households/:household_id/budgets/:budget_id":      "write:households",
+	"GET /api/1/users/:user_id/households/:household_id/reports":                    "read:reports",
+}
+
+// isAccessToken checks if the token of a request is a personal access token instead of a session token
+func isAccessToken(token string) bool {
+	return strings.HasPrefix(token, accessTokenPrefix)
+}
+
+// parseScopes validates the scopes, returning them sorted and without duplicates
+func parseScopes(scopes []string) ([]string, error) {
+	resources := make(map[string]bool)
+	for _, resource := range scopeResources {
+		resources[resource] = true
+	}
+
+	unique := make(map[string]bool)
+	for _, scope := range scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		parts := strings.SplitN(scope, ":", 2)
+		if len(parts) != 2 || (parts[0] != scopeRead && parts[0] != scopeWrite) || !resources[parts[1]] {
+			return nil, errInvalidScope
+		}
+		unique[scope] = true
+	}
+	if len(unique) == 0 {
+		return nil, errInvalidScope
+	}
+
+	parsed := make([]string, 0, len(unique))
+	for scope := range unique {
+		parsed = append(parsed, scope)
+	}
+	sort.Strings(parsed)
+
+	return parsed, nil
+}
+
+// routeScope returns the scope required by the route of the request, or an empty scope when the route
+// can't be used with an access token
+func routeScope(ctx echo.Context) string {
+	method := ctx.Request().Method
+	if method == http.MethodHead {
+		method = http.MethodGet
+	}
+	return routeScopes[method+" "+ctx.Path()]
+}
+
+// hasScope ...
+func hasScope(scopes []string, scope string) bool {
+	for _, item := range scopes {
+		if item == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// createAccessToken creates a personal access token of the user, that expires after the seconds or never when they are zero.
+// The token is only returned here, the database has its hash.
+func (interactor *interactor) createAccessToken(userID string, name string, scopes []string, expiresIn int) (*accessToken, error) {
+	log.WithFields(map[string]interface{}{"method": "createAccessToken"})
+	log.Infof("creating access token %s of user %s", name, userID)
+
+	scopes, err := parseScopes(scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	newAccessToken := &accessToken{
+		AccessTokenID: genUI(),
+		UserID:        userID,
+		Name:          name,
+		Token:         accessTokenPrefix + value,
+		Prefix:        accessTokenPrefix + value[:8],
+		Scopes:        scopes,
+	}
+
+	if created, err := interactor.storageDB.createAccessToken(newAccessToken, hashToken(newAccessToken.Token), expiresIn); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error creating access token on storage database %s", err)
+		return nil, err
+	} else {
+		created.Token = newAccessToken.Token
+		return created, nil
+	}
+}
+
+// getAccessTokens ...
+func (interactor *interactor) getAccessTokens(userID string) ([]*accessToken, error) {
+	log.WithFields(map[string]interface{}{"method": "getAccessTokens"})
+	log.Infof("getting access tokens of user %s", userID)
+
+	if accessTokens, err := interactor.storageDB.getAccessTokens(userID); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error getting access tokens on storage database %s", err)
+		return nil, err
+	} else {
+		return accessTokens, nil
+	}
+}
+
+// revokeAccessToken deletes an access token of the user, returning false when it doesn't exist
+func (interactor *interactor) revokeAccessToken(userID string, accessTokenID string) (bool, error) {
+	log.WithFields(map[string]interface{}{"method": "revokeAccessToken"})
+	log.Infof("revoking access token %s of user %s", accessTokenID, userID)
+
+	if deleted, err := interactor.storageDB.deleteAccessToken(userID, accessTokenID); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error revoking access token %s on storage database %s", accessTokenID, err)
+		return false, err
+	} else {
+		return deleted, nil
+	}
+}
+
+// useAccessToken returns the access token when it isn't expired, updating when it was last used
+func (interactor *interactor) useAccessToken(token string) (*accessToken, error) {
+	log.WithFields(map[string]interface{}{"method": "useAccessToken"})
+
+	if accessToken, err := interactor.storageDB.useAccessToken(hashToken(token)); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error using access token on storage database %s", err)
+		return nil, err
+	} else {
+		return accessToken, nil
+	}
+}
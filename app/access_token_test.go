@@ -0,0 +1,191 @@
+package gomoney
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo"
+)
+
+// registeredRoutes returns every route of the api
+func registeredRoutes() []string {
+	_, client := newTestApi(newFakeStorageDB())
+	return client.routes
+}
+
+func TestRouteScope(t *testing.T) {
+	// every route of the api, the routes without a scope can only be used with a session
+	expected := map[string]string{
+		"GET /api/1/users":                                  "",
+		"GET /api/1/users/:user_id":                         "",
+		"POST /api/1/users":                                 "",
+		"PUT /api/1/users/:user_id":                         "",
+		"DELETE /api/1/users/:user_id":                      "",
+		"PUT /api/1/users/:user_id/role":                    "",
+		"PUT /api/1/users/:user_id/status":                  "",
+		"GET /api/1/verifications":                          "",
+		"POST /api/1/verifications/resend":                  "",
+		"POST /api/1/sessions":                              "",
+		"POST /api/1/sessions/refresh":                      "",
+		"POST /api/1/sessions/challenge":                    "",
+		"DELETE /api/1/users/:user_id/session":              "",
+		"DELETE /api/1/users/:user_id/sessions":             "",
+		"GET /api/1/users/:user_id/sessions":                "",
+		"DELETE /api/1/users/:user_id/sessions/others":      "",
+		"DELETE /api/1/users/:user_id/sessions/:session_id": "",
+		"POST /api/1/users/:user_id/totp":                   "",
+		"POST /api/1/users/:user_id/totp/confirm":           "",
+		"POST /api/1/users/:user_id/totp/recovery-codes":    "",
+		"DELETE /api/1/users/:user_id/totp":                 "",
+		"GET /api/1/users/:user_id/tokens":                  "",
+		"POST /api/1/users/:user_id/tokens":                 "",
+		"DELETE /api/1/users/:user_id/tokens/:token_id":     "",
+		"POST /api/1/passwords/forgot":                      "",
+		"POST /api/1/passwords/reset":                       "",
+
+		"GET /api/1/users/:user_id/wallets":                                    "read:wallets",
+		"GET /api/1/users/:user_id/wallets/:wallet_id":                         "read:wallets",
+		"POST /api/1/users/:user_id/wallets":                                   "write:wallets",
+		"PUT /api/1/users/:user_id/wallets/:wallet_id":                         "write:wallets",
+		"DELETE /api/1/users/:user_id/wallets/:wallet_id":                      "write:wallets",
+		"POST /api/1/users/:user_id/wallets/:wallet_id/unlock":                 "write:wallets",
+		"GET /api/1/users/:user_id/wallets/:wallet_id/members":                 "read:wallets",
+		"POST /api/1/users/:user_id/wallets/:wallet_id/members":                "",
+		"PUT /api/1/users/:user_id/wallets/:wallet_id/members/:member_id":      "",
+		"DELETE /api/1/users/:user_id/wallets/:wallet_id/members/:member_id":   "",
+		"GET /api/1/users/:user_id/wallets/:wallet_id/statements/:year/:month": "read:reports",
+
+		"GET /api/1/users/:user_id/wallets/:wallet_id/transactions":                                               "read:transactions",
+		"GET /api/1/users/:user_id/wallets/:wallet_id/transactions/:transaction_id":                               "read:transactions",
+		"POST /api/1/users/:user_id/wallets/:wallet_id/transactions":                                              "write:transactions",
+		"PUT /api/1/users/:user_id/wallets/:wallet_id/transactions/:transaction_id":                               "write:transactions",
+		"DELETE /api/1/users/:user_id/wallets/:wallet_id/transactions/:transaction_id":                            "write:transactions",
+		"GET /api/1/users/:user_id/wallets/:wallet_id/transactions/:transaction_id/attachments":                   "read:transactions",
+		"GET /api/1/users/:user_id/wallets/:wallet_id/transactions/:transaction_id/attachments/:attachment_id":    "read:transactions",
+		"POST /api/1/users/:user_id/wallets/:wallet_id/transactions/:transaction_id/attachments":                  "write:transactions",
+		"DELETE /api/1/users/:user_id/wallets/:wallet_id/transactions/:transaction_id/attachments/:attachment_id": "write:transactions",
+		"POST /api/1/users/:user_id/receipts/prefill":                                                             "write:transactions",
+
+		"GET /api/1/users/:user_id/categories":                 "read:categories",
+		"GET /api/1/users/:user_id/categories/:category_id":    "read:categories",
+		"POST /api/1/users/:user_id/categories":                "write:categories",
+		"PUT /api/1/users/:user_id/categories/:category_id":    "write:categories",
+		"DELETE /api/1/users/:user_id/categories/:category_id": "write:categories",
+
+		"GET /api/1/users/:user_id/images":                            "read:images",
+		"GET /api/1/users/:user_id/images/:image_id":                  "read:images",
+		"GET /api/1/users/:user_id/images/:image_id/raw":              "read:images",
+		"GET /api/1/users/:user_id/images/:image_id/thumbnails/:name": "read:images",
+		"GET /api/1/users/:user_id/images/:image_id/resize":           "read:images",
+		"POST /api/1/users/:user_id/images":                           "write:images",
+		"PUT /api/1/users/:user_id/images/:image_id":                  "write:images",
+		"DELETE /api/1/users/:user_id/images/:image_id":               "write:images",
+
+		"POST /api/1/users/:user_id/export":                    "write:exports",
+		"POST /api/1/users/:user_id/exports":                   "write:exports",
+		"GET /api/1/users/:user_id/exports/:export_id":         "read:exports",
+		"GET /api/1/users/:user_id/exports/:export_id/archive": "read:exports",
+		"POST /api/1/users/:user_id/import":                    "",
+		"GET /api/1/users/:user_id/ledger":                     "read:exports",
+
+		"GET /api/1/users/:user_id/reports":                   "read:reports",
+		"GET /api/1/users/:user_id/spreadsheets/transactions": "read:reports",
+		"GET /api/1/users/:user_id/spreadsheets/reports":      "read:reports",
+
+		"GET /api/1/users/:user_id/households":                                             "read:households",
+		"POST /api/1/users/:user_id/households":                                            "write:households",
+		"POST /api/1/users/:user_id/households/join":                                       "",
+		"GET /api/1/users/:user_id/households/:household_id":                               "read:households",
+		"PUT /api/1/users/:user_id/households/:household_id":                               "write:households",
+		"DELETE /api/1/users/:user_id/households/:household_id":                            "write:households",
+		"GET /api/1/users/:user_id/households/:household_id/members":                       "read:households",
+		"PUT /api/1/users/:user_id/households/:household_id/members/:member_id":            "",
+		"DELETE /api/1/users/:user_id/households/:household_id/members/:member_id":         "",
+		"GET /api/1/users/:user_id/households/:household_id/invitations":                   "",
+		"POST /api/1/users/:user_id/households/:household_id/invitations":                  "",
+		"DELETE /api/1/users/:user_id/households/:household_id/invitations/:invitation_id": "",
+		"GET /api/1/users/:user_id/households/:household_id/wallets":                       "read:households",
+		"POST /api/1/users/:user_id/households/:household_id/wallets":                      "",
+		"DELETE /api/1/users/:user_id/households/:household_id/wallets/:wallet_id":         "",
+		"GET /api/1/users/:user_id/households/:household_id/categories":                    "read:households",
+		"POST /api/1/users/:user_id/households/:household_id/categories":                   "write:households",
+		"PUT /api/1/users/:user_id/households/:household_id/categories/:category_id":       "write:households",
+		"DELETE /api/1/users/:user_id/households/:household_id/categories/:category_id":    "write:households",
+		"GET /api/1/users/:user_id/households/:household_id/budgets":                       "read:households",
+		"POST /api/1/users/:user_id/households/:household_id/budgets":                      "write:households",
+		"PUT /api/1/users/:user_id/households/:household_id/budgets/:budget_id":            "write:households",
+		"DELETE /api/1/users/:user_id/households/:household_id/budgets/:budget_id":         "write:households",
+		"GET /api/1/users/:user_id/households/:household_id/reports":                       "read:reports",
+	}
+
+	routes := registeredRoutes()
+	if len(routes) != len(expected) {
+		t.Errorf("expected %d routes, got %d", len(expected), len(routes))
+	}
+
+	for _, route := range routes {
+		scope, ok := expected[route]
+		if !ok {
+			t.Errorf("the route %s doesn't have an expected scope", route)
+			continue
+		}
+
+		parts := strings.SplitN(route, " ", 2)
+		ctx := echo.New().NewContext(httptest.NewRequest(parts[0], "/", nil), httptest.NewRecorder())
+		ctx.SetPath(parts[1])
+		if got := routeScope(ctx); got != scope {
+			t.Errorf("expected the scope %q on %s, got %q", scope, route, got)
+		}
+	}
+}
+
+func TestRouteScopeHead(t *testing.T) {
+	ctx := echo.New().NewContext(httptest.NewRequest(http.MethodHead, "/", nil), httptest.NewRecorder())
+	ctx.SetPath("/api/1/users/:user_id/images/:image_id/raw")
+
+	if scope := routeScope(ctx); scope != "read:images" {
+		t.Errorf("expected the scope read:images, got %q", scope)
+	}
+}
+
+func TestParseScopes(t *testing.T) {
+	tests := []struct {
+		name     string
+		scopes   []string
+		expected []string
+		err      bool
+	}{
+		{name: "sorted without duplicates", scopes: []string{"write:wallets", " READ:wallets ", "read:wallets"}, expected: []string{"read:wallets", "write:wallets"}},
+		{name: "every resource", scopes: []string{"read:transactions", "read:categories", "read:images", "read:exports", "read:reports", "read:households"},
+			expected: []string{"read:categories", "read:exports", "read:households", "read:images", "read:reports", "read:transactions"}},
+		{name: "invalid access", scopes: []string{"admin:wallets"}, err: true},
+		{name: "invalid resource", scopes: []string{"read:users"}, err: true},
+		{name: "without resource", scopes: []string{"read"}, err: true},
+		{name: "empty", scopes: []string{}, err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scopes, err := parseScopes(test.scopes)
+			if test.err {
+				if err == nil {
+					t.Errorf("expected an error, got %v", scopes)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error %s", err)
+			}
+			if len(scopes) != len(test.expected) {
+				t.Fatalf("expected %v, got %v", test.expected, scopes)
+			}
+			for i := range scopes {
+				if scopes[i] != test.expected[i] {
+					t.Errorf("expected %v, got %v", test.expected, scopes)
+				}
+			}
+		})
+	}
+}
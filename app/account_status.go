@@ -0,0 +1,204 @@
+package gomoney
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// the status of the users, the accounts from before the verification are active
+const (
+	userStatusActive     = 0
+	userStatusUnverified = 1
+	userStatusLocked     = 2
+	userStatusDeleted    = 3
+)
+
+const (
+	defaultVerificationLifetime = 7 * 24 * 60 * 60
+)
+
+var userStatusNames = map[int]string{
+	userStatusActive:     "active",
+	userStatusUnverified: "unverified",
+	userStatusLocked:     "locked",
+	userStatusDeleted:    "deleted",
+}
+
+var (
+	// generatedSecret signs the links when no secret is configured, so they are only valid until a restart
+	generatedSecret     []byte
+	generatedSecretOnce sync.Once
+)
+
+// userStatusName ...
+func userStatusName(status int) string {
+	if name, ok := userStatusNames[status]; ok {
+		return name
+	}
+	return strconv.Itoa(status)
+}
+
+// parseUserStatus ...
+func parseUserStatus(name string) (int, bool) {
+	for status, item := range userStatusNames {
+		if item == name {
+			return status, true
+		}
+	}
+	return 0, false
+}
+
+// secret returns the configured secret used to sign the links
+func (interactor *interactor) secret() []byte {
+	if interactor.config.Security.Secret != "" {
+		return []byte(interactor.config.Security.Secret)
+	}
+
+	generatedSecretOnce.Do(func() {
+		log.Warn("security.secret is not configured, the links are only valid until a restart")
+		token, _ := randomToken()
+		generatedSecret = []byte(token)
+	})
+	return generatedSecret
+}
+
+// signVerification signs the user and the email with the expiration, so the link is invalid when the email changes
+func (interactor *interactor) signVerification(userID string, email string, expires int64) string {
+	mac := hmac.New(sha256.New, interactor.secret())
+	fmt.Fprintf(mac, "verification\n%s\n%s\n%d", userID, strings.ToLower(email), expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// verificationLink returns the signed link to verify the email of the user
+func (interactor *interactor) verificationLink(user *user) string {
+	lifetime := interactor.config.Verification.Lifetime
+	if lifetime <= 0 {
+		lifetime = defaultVerificationLifetime
+	}
+	expires := time.Now().Add(time.Duration(lifetime) * time.Second).Unix()
+
+	query := url.Values{}
+	query.Set("user_id", user.UserID)
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	query.Set("signature", interactor.signVerification(user.UserID, user.Email, expires))
+
+	link := interactor.config.Verification.Link
+	separator := "?"
+	if strings.Contains(link, "?") {
+		separator = "&"
+	}
+	return fmt.Sprintf("%s%s%s", link, separator, query.Encode())
+}
+
+// sendVerification mails the verification link to the user
+func (interactor *interactor) sendVerification(user *user) error {
+	log.Infof("sending verification to user %s", user.UserID)
+
+	body := fmt.Sprintf("Hello %s,\n\n"+
+		"to activate your Go Money account confirm your email with the link below.\n\n"+
+		"%s\n\n"+
+		"If you didn't create an account, ignore this email.\n",
+		user.Name, interactor.verificationLink(user))
+
+	if err := interactor.mailer.send(user.Email, "Confirm your Go Money email", body); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error sending verification to user %s %s", user.UserID, err)
+		return err
+	}
+	return nil
+}
+
+// resendVerification mails the verification link again when the account with the email is unverified.
+// Nothing is returned about the account, so the email of a user can't be discovered.
+func (interactor *interactor) resendVerification(email string) error {
+	log.WithFields(map[string]interface{}{"method": "resendVerification"})
+	log.Infof("resending verification to %s", email)
+
+	user, err := interactor.getUserByEmail(email)
+	if err != nil {
+		return err
+	}
+	if user == nil || user.Status != userStatusUnverified {
+		return nil
+	}
+
+	interactor.sendVerification(user)
+	return nil
+}
+
+// verifyEmail activates the unverified user of a signed link, returning false when the link is invalid or expired
+func (interactor *interactor) verifyEmail(userID string, expires int64, signature string) (bool, error) {
+	log.WithFields(map[string]interface{}{"method": "verifyEmail"})
+	log.Infof("verifying email of user %s", userID)
+
+	if time.Now().Unix() > expires {
+		return false, nil
+	}
+
+	user, err := interactor.getUser(userID)
+	if err != nil {
+		return false, err
+	}
+	if user == nil {
+		return false, nil
+	}
+
+	expected := interactor.signVerification(user.UserID, user.Email, expires)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return false, nil
+	}
+
+	switch user.Status {
+	case userStatusActive:
+		return true, nil
+	case userStatusUnverified:
+		_, err := interactor.updateUserStatus(user.UserID, userStatusActive)
+		return err == nil, err
+	default:
+		return false, nil
+	}
+}
+
+// updateUserStatus changes the status of the user, revoking the sessions when the user can't login anymore
+func (interactor *interactor) updateUserStatus(userID string, status int) (*user, error) {
+	log.WithFields(map[string]interface{}{"method": "updateUserStatus"})
+	log.Infof("updating status of user %s to %s", userID, userStatusName(status))
+
+	user, err := interactor.storageDB.updateUserStatus(userID, status)
+	if err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error updating status of user on storage database %s", err)
+		return nil, err
+	}
+
+	if user != nil && status != userStatusActive {
+		if err := interactor.deleteSessions(userID); err != nil {
+			return nil, err
+		}
+	}
+
+	return user, nil
+}
+
+// loginDenied returns the http status and the reason when the user can't login, or zero when the account is active.
+// The deleted accounts are answered like the accounts that don't exist.
+func loginDenied(user *user) (int, string) {
+	switch user.Status {
+	case userStatusActive:
+		return 0, ""
+	case userStatusUnverified:
+		return http.StatusForbidden, "the email of the account is not verified"
+	case userStatusLocked:
+		return http.StatusForbidden, "the account is locked"
+	default:
+		return http.StatusUnauthorized, "invalid email or password"
+	}
+}
@@ -0,0 +1,131 @@
+package gomoney
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// fakeStatusStorage updates the status of the users of the fake storage database
+type fakeStatusStorage struct {
+	*fakeStorageDB
+}
+
+func (storage *fakeStatusStorage) updateUserStatus(userID string, status int) (*user, error) {
+	user, ok := storage.users[userID]
+	if !ok {
+		return nil, nil
+	}
+	user.Status = status
+	return user, nil
+}
+
+func TestVerifyEmail(t *testing.T) {
+	storage := &fakeStatusStorage{fakeStorageDB: newFakeStorageDB()}
+	config := &MoneyConfig{}
+	config.Security.Secret = "secret"
+	interactor := newInteractor(storage, nil, newMailerLog(), newLimiterMemory(), config)
+
+	expires := time.Now().Add(time.Hour).Unix()
+	expired := time.Now().Add(-time.Second).Unix()
+
+	tests := []struct {
+		name      string
+		status    int
+		email     string
+		expires   int64
+		signature string
+		expected  bool
+		// active is the status of the user after the verification
+		active bool
+	}{
+		{name: "unverified user", status: userStatusUnverified, email: "user@example.com", expires: expires, expected: true, active: true},
+		{name: "email in another case", status: userStatusUnverified, email: "USER@example.com", expires: expires, expected: true, active: true},
+		{name: "active user", status: userStatusActive, email: "user@example.com", expires: expires, expected: true, active: true},
+		{name: "locked user", status: userStatusLocked, email: "user@example.com", expires: expires},
+		{name: "expired link", status: userStatusUnverified, email: "user@example.com", expires: expired},
+		{name: "changed email", status: userStatusUnverified, email: "other@example.com", expires: expires},
+		{name: "changed expiration", status: userStatusUnverified, email: "user@example.com", expires: expires, signature: interactor.signVerification("user", "user@example.com", expires-1)},
+		{name: "wrong signature", status: userStatusUnverified, email: "user@example.com", expires: expires, signature: "wrong"},
+	}
+
+	for _, test := range tests {
+		storage.users["user"] = &user{UserID: "user", Email: "user@example.com", Status: test.status}
+
+		signature := test.signature
+		if signature == "" {
+			signature = interactor.signVerification("user", test.email, test.expires)
+		}
+
+		verified, err := interactor.verifyEmail("user", test.expires, signature)
+		if err != nil {
+			t.Fatalf("%s: unexpected error %s", test.name, err)
+		}
+		if verified != test.expected {
+			t.Errorf("%s: expected verified %t, got %t", test.name, test.expected, verified)
+		}
+		if active := storage.users["user"].Status == userStatusActive; active != test.active {
+			t.Errorf("%s: expected active %t, got the status %s", test.name, test.active, userStatusName(storage.users["user"].Status))
+		}
+	}
+
+	if verified, err := interactor.verifyEmail("unknown", expires, interactor.signVerification("unknown", "", expires)); err != nil || verified {
+		t.Errorf("expected an unknown user not verified, got %t with error %v", verified, err)
+	}
+}
+
+func TestVerificationLink(t *testing.T) {
+	config := &MoneyConfig{}
+	config.Security.Secret = "secret"
+	config.Verification.Link = "https://example.com/verify?source=email"
+	interactor := newInteractor(newFakeStorageDB(), nil, newMailerLog(), newLimiterMemory(), config)
+
+	link, err := url.Parse(interactor.verificationLink(&user{UserID: "user", Email: "user@example.com"}))
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+
+	query := link.Query()
+	expires, _ := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if query.Get("source") != "email" || query.Get("user_id") != "user" {
+		t.Errorf("expected the query of the link and the user, got %s", link.RawQuery)
+	}
+	if lifetime := time.Until(time.Unix(expires, 0)); lifetime < defaultVerificationLifetime*time.Second-time.Minute || lifetime > defaultVerificationLifetime*time.Second {
+		t.Errorf("expected the default lifetime, got %s", lifetime)
+	}
+	if query.Get("signature") != interactor.signVerification("user", "user@example.com", expires) {
+		t.Errorf("expected the signature of the user, got %s", query.Get("signature"))
+	}
+}
+
+func TestLoginDenied(t *testing.T) {
+	tests := []struct {
+		status   int
+		expected int
+	}{
+		{status: userStatusActive, expected: 0},
+		{status: userStatusUnverified, expected: http.StatusForbidden},
+		{status: userStatusLocked, expected: http.StatusForbidden},
+		{status: userStatusDeleted, expected: http.StatusUnauthorized},
+		{status: 100, expected: http.StatusUnauthorized},
+	}
+
+	for _, test := range tests {
+		if got, _ := loginDenied(&user{Status: test.status}); got != test.expected {
+			t.Errorf("%s: expected the status %d, got %d", userStatusName(test.status), test.expected, got)
+		}
+	}
+}
+
+func TestParseUserStatus(t *testing.T) {
+	for status, name := range userStatusNames {
+		if got, ok := parseUserStatus(name); !ok || got != status {
+			t.Errorf("%s: expected the status %d, got %d", name, status, got)
+		}
+	}
+	if _, ok := parseUserStatus("unknown"); ok {
+		t.Error("expected an unknown status to be invalid")
+	}
+}
@@ -16,11 +16,11 @@
 package gomoney
 
 import (
+	"bytes"
 	"fmt"
-	"github.com/dgrijalva/jwt-go"
 	"github.com/joaosoft/manager"
 	"net/http"
-	"strings"
+	"strconv"
 	"time"
 
 	"github.com/joaosoft/validator"
@@ -38,6 +38,8 @@ const (
 type apiWeb struct {
 	host       string
 	auth       echo.MiddlewareFunc
+	admin      echo.MiddlewareFunc
+	grant      echo.MiddlewareFunc
 	client     manager.IWeb
 	interactor *interactor
 }
@@ -63,61 +65,28 @@ func (m *Money) newApiWeb(host string, interactor *interactor) *apiWeb {
 func (api *apiWeb) registerRoutes(m *Money) error {
 	api.client = m.pm.NewSimpleWebEcho(api.host)
 	api.auth = api.authenticate()
+	api.admin = api.requireAdmin()
+	api.grant = api.requireWalletGrant()
 
 	api.registerRoutesForUsers()
 	api.registerRoutesForSessions()
+	api.registerRoutesForPasswords()
+	api.registerRoutesForTOTP()
+	api.registerRoutesForAccessTokens()
 	api.registerRoutesForWallets()
+	api.registerRoutesForWalletMembers()
+	api.registerRoutesForHouseholds()
 	api.registerRoutesForCategories()
 	api.registerRoutesForImages()
 	api.registerRoutesForTransactions()
+	api.registerRoutesForAttachments()
+	api.registerRoutesForReceipts()
+	api.registerRoutesForExports()
+	api.registerRoutesForReports()
 
 	return nil
 }
 
-type authenticateRequest struct {
-	UserID string `json:"user_id" validate:"ui"`
-}
-
-func (api *apiWeb) authenticate() echo.MiddlewareFunc {
-	return func(next echo.HandlerFunc) echo.HandlerFunc {
-		return func(ctx echo.Context) error {
-			request := authenticateRequest{UserID: ctx.Param("user_id")}
-			if err := validator.Validate(request); err != nil {
-				log.WithFields(map[string]interface{}{"error": err}).
-					Error("error when validating body request")
-				return ctx.JSON(http.StatusNetworkAuthenticationRequired, errorResponse{Code: http.StatusNetworkAuthenticationRequired, Message: err[0].Error(), Cause: ""})
-			}
-
-			sessionKeyValue := ctx.Request().Header.Get(session_key)
-			sessionKeyValue = strings.Replace(sessionKeyValue, fmt.Sprintf("%s ", authentication), "", 1)
-
-			token, err := jwt.Parse(sessionKeyValue, func(token *jwt.Token) (interface{}, error) {
-				if session, err := api.interactor.getSession(request.UserID, sessionKeyValue); err != nil {
-					log.WithFields(map[string]interface{}{"error": err}).
-						Error("error when validating body request")
-					return nil, err
-				} else if session == nil {
-					log.WithFields(map[string]interface{}{"error": err}).
-						Error("error when validating body request")
-					return nil, err
-				} else {
-					return []byte(session.Original), nil
-				}
-			})
-
-			if err == nil && token.Valid {
-				log.Infof("valid token %s", sessionKeyValue)
-				return next(ctx)
-			} else {
-				log.Infof("invalid token %s with error %s", sessionKeyValue, err)
-				return ctx.NoContent(http.StatusNetworkAuthenticationRequired)
-			}
-
-			return next(ctx)
-		}
-	}
-}
-
 type getUserRequest struct {
 	UserID string `json:"user_id" validate:"ui"`
 }
@@ -134,10 +103,11 @@ type createUserRequest struct {
 type updateUserRequest struct {
 	UserID string `json:"user_id" validate:"ui"`
 	Body   struct {
-		Name        string `json:"name" validate:"nonzero"`
-		Email       string `json:"email" validate:"nonzero"`
-		Password    string `json:"password" validate:"nonzero"`
-		Description string `json:"description"`
+		Name            string `json:"name" validate:"nonzero"`
+		Email           string `json:"email" validate:"nonzero"`
+		Password        string `json:"password"`
+		CurrentPassword string `json:"current_password"`
+		Description     string `json:"description"`
 	}
 }
 
@@ -145,7 +115,8 @@ type userResponse struct {
 	UserID      string `json:"user_id"`
 	Name        string `json:"name"`
 	Email       string `json:"email"`
-	Password    string `json:"password"`
+	Role        string `json:"role"`
+	Status      string `json:"status"`
 	Description string `json:"description,omitempty"`
 	UpdatedAt   string `json:"updated_at"`
 	CreatedAt   string `json:"created_at"`
@@ -155,12 +126,42 @@ type deleteUserRequest struct {
 	UserID string `json:"user_id" validate:"ui"`
 }
 
+type updateUserStatusRequest struct {
+	UserID string `json:"user_id" validate:"ui"`
+	Body   struct {
+		Status string `json:"status" validate:"nonzero"`
+	}
+}
+
+type verifyEmailRequest struct {
+	UserID    string `json:"user_id" validate:"ui"`
+	Expires   string `json:"expires" validate:"nonzero"`
+	Signature string `json:"signature" validate:"nonzero"`
+}
+
+type resendVerificationRequest struct {
+	Body struct {
+		Email string `json:"email" validate:"nonzero"`
+	}
+}
+
+type updateUserRoleRequest struct {
+	UserID string `json:"user_id" validate:"ui"`
+	Body   struct {
+		Role string `json:"role" validate:"nonzero"`
+	}
+}
+
 func (api *apiWeb) registerRoutesForUsers() error {
-	api.client.AddRoute(http.MethodGet, "/api/1/users", api.getUsersHandler, api.auth)
+	api.client.AddRoute(http.MethodGet, "/api/1/users", api.getUsersHandler, api.auth, api.admin)
 	api.client.AddRoute(http.MethodGet, "/api/1/users/:user_id", api.getUserHandler, api.auth)
 	api.client.AddRoute(http.MethodPost, "/api/1/users", api.createUserHandler)
 	api.client.AddRoute(http.MethodPut, "/api/1/users/:user_id", api.updateUserHandler, api.auth)
 	api.client.AddRoute(http.MethodDelete, "/api/1/users/:user_id", api.deleteUserHandler, api.auth)
+	api.client.AddRoute(http.MethodPut, "/api/1/users/:user_id/role", api.updateUserRoleHandler, api.auth, api.admin)
+	api.client.AddRoute(http.MethodPut, "/api/1/users/:user_id/status", api.updateUserStatusHandler, api.auth, api.admin)
+	api.client.AddRoute(http.MethodGet, "/api/1/verifications", api.verifyEmailHandler)
+	api.client.AddRoute(http.MethodPost, "/api/1/verifications/resend", api.resendVerificationHandler)
 
 	return nil
 }
@@ -196,7 +197,8 @@ func (api *apiWeb) getUsersHandler(ctx echo.Context) error {
 				UserID:      user.UserID,
 				Name:        user.Name,
 				Email:       user.Email,
-				Password:    user.Password,
+				Role:        user.Role,
+				Status:      userStatusName(user.Status),
 				Description: user.Description,
 				CreatedAt:   user.CreatedAt.String(),
 				UpdatedAt:   user.UpdatedAt.String(),
@@ -244,7 +246,8 @@ func (api *apiWeb) getUserHandler(ctx echo.Context) error {
 				UserID:      user.UserID,
 				Name:        user.Name,
 				Email:       user.Email,
-				Password:    user.Password,
+				Role:        user.Role,
+				Status:      userStatusName(user.Status),
 				Description: user.Description,
 				CreatedAt:   user.CreatedAt.String(),
 				UpdatedAt:   user.UpdatedAt.String(),
@@ -299,7 +302,8 @@ func (api *apiWeb) createUserHandler(ctx echo.Context) error {
 			UserID:      createdUser.UserID,
 			Name:        createdUser.Name,
 			Email:       createdUser.Email,
-			Password:    createdUser.Password,
+			Role:        createdUser.Role,
+			Status:      userStatusName(createdUser.Status),
 			Description: createdUser.Description,
 			CreatedAt:   createdUser.CreatedAt.String(),
 			UpdatedAt:   createdUser.UpdatedAt.String(),
@@ -324,6 +328,7 @@ func (api *apiWeb) createUserHandler(ctx echo.Context) error {
 //	    Responses:
 //	      200: userResponse
 //			 400:
+//			 403:
 //	      404:
 //			 500:
 func (api *apiWeb) updateUserHandler(ctx echo.Context) error {
@@ -340,6 +345,12 @@ func (api *apiWeb) updateUserHandler(ctx echo.Context) error {
 		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
 	}
 
+	// the session of the request is kept when the user changes the password
+	var sessionID string
+	if principal := principalFromContext(ctx); principal != nil && principal.UserID == request.UserID {
+		sessionID = principal.SessionID
+	}
+
 	if updatedUser, err := api.interactor.updateUser(
 		&user{
 			UserID:      request.UserID,
@@ -347,7 +358,10 @@ func (api *apiWeb) updateUserHandler(ctx echo.Context) error {
 			Email:       request.Body.Email,
 			Password:    request.Body.Password,
 			Description: request.Body.Description,
-		}); err != nil {
+		}, request.Body.CurrentPassword, sessionID); err != nil {
+		if err == errInvalidCurrentPassword {
+			return ctx.JSON(http.StatusForbidden, errorResponse{Code: http.StatusForbidden, Message: err.Error(), Cause: ""})
+		}
 		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
 	} else if updatedUser == nil {
 		return ctx.NoContent(http.StatusNotFound)
@@ -356,7 +370,8 @@ func (api *apiWeb) updateUserHandler(ctx echo.Context) error {
 			UserID:      updatedUser.UserID,
 			Name:        updatedUser.Name,
 			Email:       updatedUser.Email,
-			Password:    updatedUser.Password,
+			Role:        updatedUser.Role,
+			Status:      userStatusName(updatedUser.Status),
 			Description: updatedUser.Description,
 			CreatedAt:   updatedUser.CreatedAt.String(),
 			UpdatedAt:   updatedUser.UpdatedAt.String(),
@@ -397,6 +412,184 @@ func (api *apiWeb) deleteUserHandler(ctx echo.Context) error {
 	}
 }
 
+// swagger:route PUT /api/1/users/{user_id}/role user updateUserRoleRequest
+//
+// Updates the role of a user.
+//
+// This api updates the role of a user, only the admins can use it.
+//
+//	    Consumes:
+//	    - application/json
+//
+//	    Produces:
+//	    - application/json
+//
+//	    Schemes: http
+//
+//	    Responses:
+//	      200:
+//			 400:
+//			 403:
+//			 404:
+//			 500:
+func (api *apiWeb) updateUserRoleHandler(ctx echo.Context) error {
+	request := updateUserRoleRequest{UserID: ctx.Param("user_id")}
+	if err := ctx.Bind(&request.Body); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
+	}
+
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	if !isRole(request.Body.Role) {
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: fmt.Sprintf("invalid role %s", request.Body.Role), Cause: ""})
+	}
+
+	if updatedUser, err := api.interactor.updateUserRole(request.UserID, request.Body.Role); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else if updatedUser == nil {
+		return ctx.NoContent(http.StatusNotFound)
+	} else {
+		return ctx.JSON(http.StatusOK, userResponse{
+			UserID:      updatedUser.UserID,
+			Name:        updatedUser.Name,
+			Email:       updatedUser.Email,
+			Role:        updatedUser.Role,
+			Status:      userStatusName(updatedUser.Status),
+			Description: updatedUser.Description,
+			CreatedAt:   updatedUser.CreatedAt.String(),
+			UpdatedAt:   updatedUser.UpdatedAt.String(),
+		})
+	}
+}
+
+// swagger:route PUT /api/1/users/{user_id}/status user updateUserStatusRequest
+//
+// Updates the status of a user.
+//
+// This api activates, locks or deletes a user, only the admins can use it.
+// The sessions of the user are revoked when the user isn't active.
+//
+//	    Consumes:
+//	    - application/json
+//
+//	    Produces:
+//	    - application/json
+//
+//	    Schemes: http
+//
+//	    Responses:
+//	      200:
+//			 400:
+//			 403:
+//			 404:
+//			 500:
+func (api *apiWeb) updateUserStatusHandler(ctx echo.Context) error {
+	request := updateUserStatusRequest{UserID: ctx.Param("user_id")}
+	if err := ctx.Bind(&request.Body); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
+	}
+
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	status, ok := parseUserStatus(request.Body.Status)
+	if !ok {
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: fmt.Sprintf("invalid status %s", request.Body.Status), Cause: ""})
+	}
+
+	if updatedUser, err := api.interactor.updateUserStatus(request.UserID, status); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else if updatedUser == nil {
+		return ctx.NoContent(http.StatusNotFound)
+	} else {
+		return ctx.JSON(http.StatusOK, userResponse{
+			UserID:      updatedUser.UserID,
+			Name:        updatedUser.Name,
+			Email:       updatedUser.Email,
+			Role:        updatedUser.Role,
+			Status:      userStatusName(updatedUser.Status),
+			Description: updatedUser.Description,
+			CreatedAt:   updatedUser.CreatedAt.String(),
+			UpdatedAt:   updatedUser.UpdatedAt.String(),
+		})
+	}
+}
+
+// swagger:route GET /api/1/verifications user verifyEmailRequest
+//
+// Verifies the email of a user.
+//
+// This api activates the user with the signed link mailed when the user was created.
+//
+//	    Produces:
+//	    - application/json
+//
+//	    Schemes: http
+//
+//	    Responses:
+//	      200:
+//			 400:
+//			 500:
+func (api *apiWeb) verifyEmailHandler(ctx echo.Context) error {
+	request := verifyEmailRequest{
+		UserID:    ctx.QueryParam("user_id"),
+		Expires:   ctx.QueryParam("expires"),
+		Signature: ctx.QueryParam("signature"),
+	}
+
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	expires, err := strconv.ParseInt(request.Expires, 10, 64)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: "invalid or expired verification link", Cause: ""})
+	}
+
+	if verified, err := api.interactor.verifyEmail(request.UserID, expires, request.Signature); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else if !verified {
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: "invalid or expired verification link", Cause: ""})
+	} else {
+		return ctx.NoContent(http.StatusOK)
+	}
+}
+
+// resendVerificationHandler always accepts the request, so the emails of the users can't be discovered
+func (api *apiWeb) resendVerificationHandler(ctx echo.Context) error {
+	request := resendVerificationRequest{}
+	if err := ctx.Bind(&request.Body); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
+	}
+
+	if err := validator.Validate(request.Body); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	if err := api.interactor.resendVerification(request.Body.Email); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else {
+		return ctx.NoContent(http.StatusAccepted)
+	}
+}
+
 type createSessionRequest struct {
 	Body struct {
 		Email       string `json:"email" validate:"nonzero"`
@@ -405,27 +598,66 @@ type createSessionRequest struct {
 	}
 }
 
-type deleteSessionRequest struct {
-	Email string `json:"email" validate:"nonzero"`
+type deleteSessionsRequest struct {
+	UserID string `json:"user_id" validate:"ui"`
 }
 
-type deleteSessionsRequest struct {
+type getSessionsRequest struct {
 	UserID string `json:"user_id" validate:"ui"`
 }
 
+type revokeSessionRequest struct {
+	UserID    string `json:"user_id" validate:"ui"`
+	SessionID string `json:"session_id" validate:"ui"`
+}
+
+type activeSessionResponse struct {
+	SessionID   string `json:"session_id"`
+	Description string `json:"description,omitempty"`
+	UserAgent   string `json:"user_agent"`
+	IP          string `json:"ip"`
+	Current     bool   `json:"current"`
+	ExpiresAt   string `json:"expires_at"`
+	LastUsedAt  string `json:"last_used_at"`
+	CreatedAt   string `json:"created_at"`
+}
+
+type completeChallengeRequest struct {
+	Body struct {
+		ChallengeToken string `json:"challenge_token" validate:"nonzero"`
+		Code           string `json:"code" validate:"nonzero"`
+		Description    string `json:"description"`
+	}
+}
+
+type challengeResponse struct {
+	ChallengeToken string `json:"challenge_token"`
+	ExpiresAt      string `json:"expires_at"`
+}
+
+type refreshSessionRequest struct {
+	Body struct {
+		RefreshToken string `json:"refresh_token" validate:"nonzero"`
+	}
+}
+
 type sessionResponse struct {
-	User        sessionUserResponse `json:"user"`
-	SessionID   string              `json:"session_id"`
-	Token       string              `json:"token"`
-	Description string              `json:"description"`
-	UpdatedAt   string              `json:"updated_at"`
-	CreatedAt   string              `json:"created_at"`
+	User             sessionUserResponse `json:"user"`
+	SessionID        string              `json:"session_id"`
+	Token            string              `json:"token"`
+	RefreshToken     string              `json:"refresh_token"`
+	Description      string              `json:"description"`
+	ExpiresAt        string              `json:"expires_at"`
+	RefreshExpiresAt string              `json:"refresh_expires_at"`
+	UpdatedAt        string              `json:"updated_at"`
+	CreatedAt        string              `json:"created_at"`
 }
 
 type sessionUserResponse struct {
 	UserID      string `json:"user_id"`
 	Name        string `json:"name"`
 	Email       string `json:"email"`
+	Role        string `json:"role"`
 	Description string `json:"description"`
 	UpdatedAt   string `json:"updated_at"`
 	CreatedAt   string `json:"created_at"`
@@ -433,8 +665,13 @@ type sessionUserResponse struct {
 
 func (api *apiWeb) registerRoutesForSessions() error {
 	api.client.AddRoute(http.MethodPost, "/api/1/sessions", api.createSessionHandler)
-	api.client.AddRoute(http.MethodDelete, "/api/1/users/:email/session", api.deleteSessionHandler, api.auth)
+	api.client.AddRoute(http.MethodPost, "/api/1/sessions/refresh", api.refreshSessionHandler)
+	api.client.AddRoute(http.MethodPost, "/api/1/sessions/challenge", api.completeChallengeHandler)
+	api.client.AddRoute(http.MethodDelete, "/api/1/users/:user_id/session", api.deleteSessionHandler, api.auth)
 	api.client.AddRoute(http.MethodDelete, "/api/1/users/:user_id/sessions", api.deleteSessionsHandler, api.auth)
+	api.client.AddRoute(http.MethodGet, "/api/1/users/:user_id/sessions", api.getSessionsHandler, api.auth)
+	api.client.AddRoute(http.MethodDelete, "/api/1/users/:user_id/sessions/others", api.deleteOtherSessionsHandler, api.auth)
+	api.client.AddRoute(http.MethodDelete, "/api/1/users/:user_id/sessions/:session_id", api.revokeSessionHandler, api.auth)
 
 	return nil
 }
@@ -453,26 +690,33 @@ func (api *apiWeb) createSessionHandler(ctx echo.Context) error {
 		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
 	}
 
-	if user, err := api.interactor.getUserByEmail(request.Body.Email); err != nil {
+	if user, retryAfter, err := api.interactor.login(request.Body.Email, request.Body.Password, ctx.RealIP(), ctx.Request().UserAgent()); err != nil {
 		log.WithFields(map[string]interface{}{"error": err, "cause": ""}).
-			Errorf("error getting user by email %s", request.Body.Email)
-		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
-	} else {
-		passwordToken, err := generateToken(authentication, []byte(request.Body.Password))
-		if err != nil {
-			log.WithFields(map[string]interface{}{"error": err}).
-				Error("error when comparing password")
+			Errorf("error authenticating user %s", request.Body.Email)
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else if retryAfter > 0 {
+		ctx.Response().Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		return ctx.JSON(http.StatusTooManyRequests, errorResponse{Code: http.StatusTooManyRequests, Message: "too many login attempts, try again later", Cause: ""})
+	} else if user == nil {
+		return ctx.JSON(http.StatusUnauthorized, errorResponse{Code: http.StatusUnauthorized, Message: "invalid email or password", Cause: ""})
+	} else if status, message := loginDenied(user); status != 0 {
+		return ctx.JSON(status, errorResponse{Code: status, Message: message, Cause: ""})
+	} else if user.TOTPEnabled {
+		// the session is only created after the second factor
+		if challenge, expiresAt, err := api.interactor.createChallenge(user); err != nil {
 			return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+		} else {
+			return ctx.JSON(http.StatusAccepted, challengeResponse{
+				ChallengeToken: challenge,
+				ExpiresAt:      expiresAt.String(),
+			})
 		}
-
-		if user.Token != passwordToken {
-			log.WithFields(map[string]interface{}{}).Error(err)
-			return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
-		}
-
+	} else {
 		if createdSession, err := api.interactor.createSession(&session{
 			UserID:      user.UserID,
 			Description: request.Body.Description,
+			UserAgent:   ctx.Request().UserAgent(),
+			IP:          ctx.RealIP(),
 		}); err != nil {
 			log.WithFields(map[string]interface{}{"error": err.Error(), "cause": ""}).
 				Error("error when creating session")
@@ -480,58 +724,120 @@ func (api *apiWeb) createSessionHandler(ctx echo.Context) error {
 		} else if createdSession == nil {
 			return ctx.NoContent(http.StatusInternalServerError)
 		} else {
-			token := fmt.Sprintf("%s %s", authentication, createdSession.Token)
-			ctx.Response().Header().Set(session_key, token)
-
-			ctx.SetCookie(&http.Cookie{
-				Name:       session_key,
-				Value:      token,
-				Path:       "/api/1/",
-				RawExpires: "0",
-			})
-
-			return ctx.JSON(http.StatusCreated, sessionResponse{
-				User: sessionUserResponse{
-					UserID:      user.UserID,
-					Name:        user.Name,
-					Email:       user.Email,
-					Description: user.Description,
-					UpdatedAt:   user.UpdatedAt.String(),
-					CreatedAt:   user.CreatedAt.String(),
-				},
-				SessionID:   createdSession.SessionID,
-				Token:       createdSession.Token,
-				Description: createdSession.Description,
-				CreatedAt:   createdSession.CreatedAt.String(),
-				UpdatedAt:   createdSession.UpdatedAt.String(),
-			})
+			return api.sessionCreated(ctx, http.StatusCreated, user, createdSession)
 		}
 	}
 }
 
-func (api *apiWeb) deleteSessionHandler(ctx echo.Context) error {
-	request := deleteSessionRequest{
-		Email: ctx.Param("email"),
+func (api *apiWeb) refreshSessionHandler(ctx echo.Context) error {
+	request := refreshSessionRequest{}
+	if err := ctx.Bind(&request.Body); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
 	}
 
-	if err := validator.Validate(request); err != nil {
+	if err := validator.Validate(request.Body); err != nil {
 		log.WithFields(map[string]interface{}{"error": err}).
 			Error("error when validating body request")
 		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
 	}
 
-	token := ctx.Request().Header.Get(session_key)
+	if refreshedSession, err := api.interactor.refreshSession(request.Body.RefreshToken, ctx.Request().UserAgent(), ctx.RealIP()); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else if refreshedSession == nil {
+		return ctx.JSON(http.StatusUnauthorized, errorResponse{Code: http.StatusUnauthorized, Message: "invalid or expired refresh token", Cause: ""})
+	} else if user, err := api.interactor.getUser(refreshedSession.UserID); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else if user == nil {
+		return ctx.JSON(http.StatusUnauthorized, errorResponse{Code: http.StatusUnauthorized, Message: "invalid or expired refresh token", Cause: ""})
+	} else if status, message := loginDenied(user); status != 0 {
+		return ctx.JSON(status, errorResponse{Code: status, Message: message, Cause: ""})
+	} else {
+		return api.sessionCreated(ctx, http.StatusOK, user, refreshedSession)
+	}
+}
 
-	if user, err := api.interactor.getUserByEmail(request.Email); err != nil {
+func (api *apiWeb) completeChallengeHandler(ctx echo.Context) error {
+	request := completeChallengeRequest{}
+	if err := ctx.Bind(&request.Body); err != nil {
 		log.WithFields(map[string]interface{}{"error": err}).
-			Errorf("error getting user by email %s", request.Email)
+			Error("error when validating body request")
 		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
+	}
+
+	if err := validator.Validate(request.Body); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	if user, retryAfter, err := api.interactor.completeChallenge(request.Body.ChallengeToken, request.Body.Code, ctx.RealIP(), ctx.Request().UserAgent()); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else if retryAfter > 0 {
+		ctx.Response().Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		return ctx.JSON(http.StatusTooManyRequests, errorResponse{Code: http.StatusTooManyRequests, Message: "too many attempts, try again later", Cause: ""})
+	} else if user == nil {
+		return ctx.JSON(http.StatusUnauthorized, errorResponse{Code: http.StatusUnauthorized, Message: "invalid code or expired challenge", Cause: ""})
+	} else if status, message := loginDenied(user); status != 0 {
+		return ctx.JSON(status, errorResponse{Code: status, Message: message, Cause: ""})
+	} else if createdSession, err := api.interactor.createSession(&session{
+		UserID:      user.UserID,
+		Description: request.Body.Description,
+		UserAgent:   ctx.Request().UserAgent(),
+		IP:          ctx.RealIP(),
+	}); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else if createdSession == nil {
+		return ctx.NoContent(http.StatusInternalServerError)
 	} else {
-		if err := api.interactor.deleteSession(user.UserID, token); err != nil {
-			return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
-		} else {
-			return ctx.NoContent(http.StatusOK)
-		}
+		return api.sessionCreated(ctx, http.StatusCreated, user, createdSession)
+	}
+}
+
+// sessionCreated sets the header and the cookie with the access token of a new or refreshed session
+func (api *apiWeb) sessionCreated(ctx echo.Context, status int, user *user, session *session) error {
+	token := fmt.Sprintf("%s %s", authentication, session.Token)
+	ctx.Response().Header().Set(session_key, token)
+
+	ctx.SetCookie(&http.Cookie{
+		Name:     session_key,
+		Value:    token,
+		Path:     "/api/1/",
+		Expires:  session.ExpiresAt,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	})
+
+	return ctx.JSON(status, sessionResponse{
+		User: sessionUserResponse{
+			UserID:      user.UserID,
+			Name:        user.Name,
+			Email:       user.Email,
+			Role:        user.Role,
+			Description: user.Description,
+			UpdatedAt:   user.UpdatedAt.String(),
+			CreatedAt:   user.CreatedAt.String(),
+		},
+		SessionID:        session.SessionID,
+		Token:            session.Token,
+		RefreshToken:     session.RefreshToken,
+		Description:      session.Description,
+		ExpiresAt:        session.ExpiresAt.String(),
+		RefreshExpiresAt: session.RefreshExpiresAt.String(),
+		CreatedAt:        session.CreatedAt.String(),
+		UpdatedAt:        session.UpdatedAt.String(),
+	})
+}
+
+func (api *apiWeb) deleteSessionHandler(ctx echo.Context) error {
+	principal := principalFromContext(ctx)
+
+	if err := api.interactor.deleteSession(principal.UserID, principal.Token); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else {
+		return ctx.NoContent(http.StatusOK)
 	}
 }
 
@@ -553,59 +859,61 @@ func (api *apiWeb) deleteSessionsHandler(ctx echo.Context) error {
 	}
 }
 
-type getWalletsRequest struct {
-	UserID string `json:"user_id" validate:"ui"`
-}
-
-type getWalletRequest struct {
-	UserID   string `json:"user_id" validate:"ui"`
-	WalletID string `json:"wallet_id" validate:"ui"`
-}
+func (api *apiWeb) getSessionsHandler(ctx echo.Context) error {
+	request := getSessionsRequest{
+		UserID: ctx.Param("user_id"),
+	}
 
-type createWalletsRequest struct {
-	UserID string              `json:"user_id" validate:"ui"`
-	Body   []walletItemRequest `json:"wallets" validate:"min=1"`
-}
-
-type updateWalletRequest struct {
-	UserID   string `json:"user_id" validate:"ui"`
-	WalletID string `json:"wallet_id" validate:"ui"`
-	Body     walletItemRequest
-}
-
-type walletItemRequest struct {
-	Name        string `json:"name" validate:"nonzero"`
-	Description string `json:"description"`
-	Password    string `json:"password"`
-}
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
 
-type deleteWalletRequest struct {
-	UserID   string `json:"user_id" validate:"ui"`
-	WalletID string `json:"wallet_id" validate:"ui"`
+	principal := principalFromContext(ctx)
+	if sessions, err := api.interactor.getActiveSessions(request.UserID); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else {
+		sessionsResponse := make([]*activeSessionResponse, 0)
+		for _, session := range sessions {
+			sessionsResponse = append(sessionsResponse, &activeSessionResponse{
+				SessionID:   session.SessionID,
+				Description: session.Description,
+				UserAgent:   session.UserAgent,
+				IP:          session.IP,
+				Current:     session.SessionID == principal.SessionID,
+				ExpiresAt:   session.RefreshExpiresAt.String(),
+				LastUsedAt:  session.LastUsedAt.String(),
+				CreatedAt:   session.CreatedAt.String(),
+			})
+		}
+		return ctx.JSON(http.StatusOK, sessionsResponse)
+	}
 }
 
-type walletResponse struct {
-	WalletID    string `json:"wallet_id"`
-	UserID      string `json:"user_id"`
-	Name        string `json:"name"`
-	Description string `json:"description,omitempty"`
-	Password    string `json:"password,omitempty"`
-	UpdatedAt   string `json:"updated_at"`
-	CreatedAt   string `json:"created_at"`
-}
+func (api *apiWeb) revokeSessionHandler(ctx echo.Context) error {
+	request := revokeSessionRequest{
+		UserID:    ctx.Param("user_id"),
+		SessionID: ctx.Param("session_id"),
+	}
 
-func (api *apiWeb) registerRoutesForWallets() error {
-	api.client.AddRoute(http.MethodGet, "/api/1/users/:user_id/wallets", api.getWalletsHandler, api.auth)
-	api.client.AddRoute(http.MethodGet, "/api/1/users/:user_id/wallets/:wallet_id", api.getWalletHandler, api.auth)
-	api.client.AddRoute(http.MethodPost, "/api/1/users/:user_id/wallets", api.createWalletsHandler, api.auth)
-	api.client.AddRoute(http.MethodPut, "/api/1/users/:user_id/wallets/:wallet_id", api.updateWalletHandler, api.auth)
-	api.client.AddRoute(http.MethodDelete, "/api/1/users/:user_id/wallets/:wallet_id", api.deleteWalletHandler, api.auth)
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
 
-	return nil
+	if deleted, err := api.interactor.revokeSession(request.UserID, request.SessionID); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else if !deleted {
+		return ctx.NoContent(http.StatusNotFound)
+	} else {
+		return ctx.NoContent(http.StatusOK)
+	}
 }
 
-func (api *apiWeb) getWalletsHandler(ctx echo.Context) error {
-	request := getWalletsRequest{
+func (api *apiWeb) deleteOtherSessionsHandler(ctx echo.Context) error {
+	request := deleteSessionsRequest{
 		UserID: ctx.Param("user_id"),
 	}
 
@@ -615,63 +923,71 @@ func (api *apiWeb) getWalletsHandler(ctx echo.Context) error {
 		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
 	}
 
-	if wallets, err := api.interactor.getWallets(request.UserID); err != nil {
+	// an admin revoking the sessions of another user has no current session on that account
+	principal := principalFromContext(ctx)
+	if _, err := api.interactor.revokeOtherSessions(request.UserID, principal.SessionID); err != nil {
 		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
-	} else if wallets == nil {
-		return ctx.NoContent(http.StatusNotFound)
 	} else {
-		walletsResponse := make([]*walletResponse, 0)
-		for _, wallet := range wallets {
-			walletResponse := &walletResponse{
-				WalletID:    wallet.WalletID,
-				UserID:      wallet.UserID,
-				Name:        wallet.Name,
-				Description: wallet.Description,
-				Password:    wallet.Password,
-				CreatedAt:   wallet.CreatedAt.String(),
-				UpdatedAt:   wallet.UpdatedAt.String(),
-			}
-			walletsResponse = append(walletsResponse, walletResponse)
-		}
-		return ctx.JSON(http.StatusOK, walletsResponse)
+		return ctx.NoContent(http.StatusOK)
 	}
 }
 
-func (api *apiWeb) getWalletHandler(ctx echo.Context) error {
-	request := getWalletRequest{
-		UserID:   ctx.Param("user_id"),
-		WalletID: ctx.Param("wallet_id"),
+type enrollTOTPRequest struct {
+	UserID string `json:"user_id" validate:"ui"`
+}
+
+type totpCodeRequest struct {
+	UserID string `json:"user_id" validate:"ui"`
+	Body   struct {
+		Code string `json:"code" validate:"nonzero"`
 	}
+}
+
+type enrollTOTPResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+type recoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+func (api *apiWeb) registerRoutesForTOTP() error {
+	api.client.AddRoute(http.MethodPost, "/api/1/users/:user_id/totp", api.enrollTOTPHandler, api.auth)
+	api.client.AddRoute(http.MethodPost, "/api/1/users/:user_id/totp/confirm", api.confirmTOTPHandler, api.auth)
+	api.client.AddRoute(http.MethodPost, "/api/1/users/:user_id/totp/recovery-codes", api.regenerateRecoveryCodesHandler, api.auth)
+	api.client.AddRoute(http.MethodDelete, "/api/1/users/:user_id/totp", api.disableTOTPHandler, api.auth)
+
+	return nil
+}
 
+func (api *apiWeb) enrollTOTPHandler(ctx echo.Context) error {
+	request := enrollTOTPRequest{UserID: ctx.Param("user_id")}
 	if err := validator.Validate(request); err != nil {
 		log.WithFields(map[string]interface{}{"error": err}).
 			Error("error when validating body request")
 		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
 	}
 
-	if wallet, err := api.interactor.getWallet(request.UserID, request.WalletID); err != nil {
+	if secret, uri, err := api.interactor.enrollTOTP(request.UserID); err != nil {
+		if err == errTOTPEnabled || err == errTOTPNotEnrolled {
+			return ctx.JSON(http.StatusConflict, errorResponse{Code: http.StatusConflict, Message: err.Error(), Cause: ""})
+		} else if err == errInvalidCode {
+			return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
+		}
 		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
-	} else if wallet == nil {
+	} else if secret == "" {
 		return ctx.NoContent(http.StatusNotFound)
 	} else {
-		return ctx.JSON(http.StatusOK,
-			walletResponse{
-				WalletID:    wallet.WalletID,
-				Name:        wallet.Name,
-				Description: wallet.Description,
-				Password:    wallet.Password,
-				CreatedAt:   wallet.CreatedAt.String(),
-				UpdatedAt:   wallet.UpdatedAt.String(),
-			})
+		return ctx.JSON(http.StatusCreated, enrollTOTPResponse{
+			Secret: secret,
+			URI:    uri,
+		})
 	}
 }
 
-func (api *apiWeb) createWalletsHandler(ctx echo.Context) error {
-	request := createWalletsRequest{
-		UserID: ctx.Param("user_id"),
-	}
-	wallets := make([]*wallet, 0)
-
+func (api *apiWeb) confirmTOTPHandler(ctx echo.Context) error {
+	request := totpCodeRequest{UserID: ctx.Param("user_id")}
 	if err := ctx.Bind(&request.Body); err != nil {
 		log.WithFields(map[string]interface{}{"error": err}).
 			Error("error when validating body request")
@@ -684,207 +1000,161 @@ func (api *apiWeb) createWalletsHandler(ctx echo.Context) error {
 		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
 	}
 
-	for _, item := range request.Body {
-		wallets = append(wallets, &wallet{
-			UserID:      request.UserID,
-			Name:        item.Name,
-			Description: item.Description,
-			Password:    item.Password,
-		})
-	}
-
-	if createdWallets, err := api.interactor.createWallets(wallets); err != nil {
+	if codes, err := api.interactor.confirmTOTP(request.UserID, request.Body.Code); err != nil {
+		if err == errTOTPEnabled || err == errTOTPNotEnrolled {
+			return ctx.JSON(http.StatusConflict, errorResponse{Code: http.StatusConflict, Message: err.Error(), Cause: ""})
+		} else if err == errInvalidCode {
+			return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
+		}
 		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else if codes == nil {
+		return ctx.NoContent(http.StatusNotFound)
 	} else {
-		walletsResponse := make([]*walletResponse, 0)
-
-		for _, createdWallet := range createdWallets {
-			walletResponse := &walletResponse{
-				WalletID:    createdWallet.WalletID,
-				UserID:      createdWallet.UserID,
-				Name:        createdWallet.Name,
-				Description: createdWallet.Description,
-				Password:    createdWallet.Password,
-				CreatedAt:   createdWallet.CreatedAt.String(),
-				UpdatedAt:   createdWallet.UpdatedAt.String(),
-			}
-			walletsResponse = append(walletsResponse, walletResponse)
-		}
-		return ctx.JSON(http.StatusCreated, walletsResponse)
+		return ctx.JSON(http.StatusOK, recoveryCodesResponse{RecoveryCodes: codes})
 	}
 }
 
-func (api *apiWeb) updateWalletHandler(ctx echo.Context) error {
-	request := updateWalletRequest{
-		UserID:   ctx.Param("user_id"),
-		WalletID: ctx.Param("wallet_id"),
-	}
+func (api *apiWeb) regenerateRecoveryCodesHandler(ctx echo.Context) error {
+	request := totpCodeRequest{UserID: ctx.Param("user_id")}
 	if err := ctx.Bind(&request.Body); err != nil {
 		log.WithFields(map[string]interface{}{"error": err}).
 			Error("error when validating body request")
 		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
 	}
 
-	if err := validator.Validate(request.Body); err != nil {
+	if err := validator.Validate(request); err != nil {
 		log.WithFields(map[string]interface{}{"error": err}).
 			Error("error when validating body request")
 		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
 	}
 
-	if updatedWallet, err := api.interactor.updateWallet(
-		&wallet{
-			UserID:      request.UserID,
-			WalletID:    request.WalletID,
-			Name:        request.Body.Name,
-			Description: request.Body.Description,
-			Password:    request.Body.Password,
-		}); err != nil {
+	if codes, err := api.interactor.regenerateRecoveryCodes(request.UserID, request.Body.Code); err != nil {
+		if err == errTOTPEnabled || err == errTOTPNotEnrolled {
+			return ctx.JSON(http.StatusConflict, errorResponse{Code: http.StatusConflict, Message: err.Error(), Cause: ""})
+		} else if err == errInvalidCode {
+			return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
+		}
 		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
-	} else if updatedWallet == nil {
+	} else if codes == nil {
 		return ctx.NoContent(http.StatusNotFound)
 	} else {
-		return ctx.JSON(http.StatusCreated, walletResponse{
-			WalletID:    updatedWallet.WalletID,
-			UserID:      updatedWallet.UserID,
-			Name:        updatedWallet.Name,
-			Description: updatedWallet.Description,
-			Password:    updatedWallet.Password,
-			CreatedAt:   updatedWallet.CreatedAt.String(),
-			UpdatedAt:   updatedWallet.UpdatedAt.String(),
-		})
+		return ctx.JSON(http.StatusOK, recoveryCodesResponse{RecoveryCodes: codes})
 	}
 }
 
-func (api *apiWeb) deleteWalletHandler(ctx echo.Context) error {
-	request := updateWalletRequest{
-		UserID:   ctx.Param("user_id"),
-		WalletID: ctx.Param("wallet_id"),
-	}
+func (api *apiWeb) disableTOTPHandler(ctx echo.Context) error {
+	request := totpCodeRequest{UserID: ctx.Param("user_id")}
 	if err := ctx.Bind(&request.Body); err != nil {
 		log.WithFields(map[string]interface{}{"error": err}).
 			Error("error when validating body request")
 		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
 	}
 
-	if err := api.interactor.deleteWallet(request.UserID, request.WalletID); err != nil {
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	if disabled, err := api.interactor.disableTOTP(request.UserID, request.Body.Code); err != nil {
+		if err == errTOTPEnabled || err == errTOTPNotEnrolled {
+			return ctx.JSON(http.StatusConflict, errorResponse{Code: http.StatusConflict, Message: err.Error(), Cause: ""})
+		} else if err == errInvalidCode {
+			return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
+		}
 		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else if !disabled {
+		return ctx.NoContent(http.StatusNotFound)
 	} else {
 		return ctx.NoContent(http.StatusOK)
 	}
 }
 
-type getCategoriesRequest struct {
+type getAccessTokensRequest struct {
 	UserID string `json:"user_id" validate:"ui"`
 }
 
-type getCategoryRequest struct {
-	UserID     string `json:"user_id" validate:"ui"`
-	CategoryID string `json:"category_id" validate:"ui"`
-}
-
-type createCategoriesRequest struct {
-	UserID string                `json:"user_id" validate:"ui"`
-	Body   []categoryItemRequest `json:"categories" validate:"min=1"`
-}
-
-type updateCategoryRequest struct {
-	UserID     string `json:"user_id" validate:"ui"`
-	CategoryID string `json:"category_id" validate:"ui"`
-	Body       categoryItemRequest
+type createAccessTokenRequest struct {
+	UserID string `json:"user_id" validate:"ui"`
+	Body   struct {
+		Name      string   `json:"name" validate:"nonzero"`
+		Scopes    []string `json:"scopes"`
+		ExpiresIn int      `json:"expires_in"`
+	}
 }
 
-type categoryItemRequest struct {
-	Name        string `json:"name" validate:"nonzero"`
-	Description string `json:"description"`
-	ImageID     string `json:"image_id" validate:"ui"`
+type revokeAccessTokenRequest struct {
+	UserID        string `json:"user_id" validate:"ui"`
+	AccessTokenID string `json:"token_id" validate:"ui"`
 }
 
-type deleteCategoryRequest struct {
-	UserID     string `json:"user_id" validate:"ui"`
-	CategoryID string `json:"category_id" validate:"ui"`
+type accessTokenResponse struct {
+	AccessTokenID string   `json:"token_id"`
+	Name          string   `json:"name"`
+	Token         string   `json:"token,omitempty"`
+	Prefix        string   `json:"prefix"`
+	Scopes        []string `json:"scopes"`
+	ExpiresAt     string   `json:"expires_at,omitempty"`
+	LastUsedAt    string   `json:"last_used_at,omitempty"`
+	CreatedAt     string   `json:"created_at"`
 }
 
-type categoryResponse struct {
-	CategoryID  string `json:"category_id"`
-	UserID      string `json:"user_id"`
-	Name        string `json:"name"`
-	Description string `json:"description,omitempty"`
-	ImageID     string `json:"image_id"`
-	UpdatedAt   string `json:"updated_at"`
-	CreatedAt   string `json:"created_at"`
+func newAccessTokenResponse(accessToken *accessToken) *accessTokenResponse {
+	response := &accessTokenResponse{
+		AccessTokenID: accessToken.AccessTokenID,
+		Name:          accessToken.Name,
+		Token:         accessToken.Token,
+		Prefix:        accessToken.Prefix,
+		Scopes:        accessToken.Scopes,
+		CreatedAt:     accessToken.CreatedAt.String(),
+	}
+	if accessToken.ExpiresAt != nil {
+		response.ExpiresAt = accessToken.ExpiresAt.String()
+	}
+	if accessToken.LastUsedAt != nil {
+		response.LastUsedAt = accessToken.LastUsedAt.String()
+	}
+	return response
 }
 
-func (api *apiWeb) registerRoutesForCategories() error {
-	api.client.AddRoute(http.MethodGet, "/api/1/users/:user_id/categories", api.getCategoriesHandler, api.auth)
-	api.client.AddRoute(http.MethodGet, "/api/1/users/:user_id/categories/:category_id", api.getCategoryHandler, api.auth)
-	api.client.AddRoute(http.MethodPost, "/api/1/users/:user_id/categories", api.createCategoriesHandler, api.auth)
-	api.client.AddRoute(http.MethodPut, "/api/1/users/:user_id/categories/:category_id", api.updateCategoryHandler, api.auth)
-	api.client.AddRoute(http.MethodDelete, "/api/1/users/:user_id/categories/:category_id", api.deleteCategoryHandler, api.auth)
+func (api *apiWeb) registerRoutesForAccessTokens() error {
+	api.client.AddRoute(http.MethodGet, "/api/1/users/:user_id/tokens", api.getAccessTokensHandler, api.auth)
+	api.client.AddRoute(http.MethodPost, "/api/1/users/:user_id/tokens", api.createAccessTokenHandler, api.auth)
+	api.client.AddRoute(http.MethodDelete, "/api/1/users/:user_id/tokens/:token_id", api.revokeAccessTokenHandler, api.auth)
 
 	return nil
 }
 
-func (api *apiWeb) getCategoriesHandler(ctx echo.Context) error {
-	request := getCategoriesRequest{
+func (api *apiWeb) getAccessTokensHandler(ctx echo.Context) error {
+	request := getAccessTokensRequest{
 		UserID: ctx.Param("user_id"),
 	}
 
-	if categories, err := api.interactor.getCategories(request.UserID); err != nil {
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	if accessTokens, err := api.interactor.getAccessTokens(request.UserID); err != nil {
 		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
-	} else if categories == nil {
-		return ctx.NoContent(http.StatusNotFound)
 	} else {
-		categoriesResponse := make([]*categoryResponse, 0)
-
-		for _, category := range categories {
-			categoryResponse := &categoryResponse{
-				CategoryID:  category.CategoryID,
-				UserID:      category.UserID,
-				Name:        category.Name,
-				Description: category.Description,
-				ImageID:     category.ImageID,
-				CreatedAt:   category.CreatedAt.String(),
-				UpdatedAt:   category.UpdatedAt.String(),
-			}
-			categoriesResponse = append(categoriesResponse, categoryResponse)
+		accessTokensResponse := make([]*accessTokenResponse, 0)
+		for _, accessToken := range accessTokens {
+			accessTokensResponse = append(accessTokensResponse, newAccessTokenResponse(accessToken))
 		}
-		return ctx.JSON(http.StatusOK, categoriesResponse)
+		return ctx.JSON(http.StatusOK, accessTokensResponse)
 	}
 }
 
-func (api *apiWeb) getCategoryHandler(ctx echo.Context) error {
-	request := getCategoryRequest{
-		UserID:     ctx.Param("user_id"),
-		CategoryID: ctx.Param("category_id"),
+func (api *apiWeb) createAccessTokenHandler(ctx echo.Context) error {
+	request := createAccessTokenRequest{
+		UserID: ctx.Param("user_id"),
 	}
 
-	if category, err := api.interactor.getCategory(request.UserID, request.CategoryID); err != nil {
-		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
-	} else if category == nil {
-		return ctx.NoContent(http.StatusNotFound)
-	} else {
-		return ctx.JSON(http.StatusOK,
-			categoryResponse{
-				CategoryID:  category.CategoryID,
-				UserID:      category.UserID,
-				Name:        category.Name,
-				Description: category.Description,
-				ImageID:     category.ImageID,
-				CreatedAt:   category.CreatedAt.String(),
-				UpdatedAt:   category.UpdatedAt.String(),
-			})
-	}
-}
-
-func (api *apiWeb) createCategoriesHandler(ctx echo.Context) error {
-	request := createCategoriesRequest{
-		UserID: ctx.Param("user_id"),
-	}
-	categories := make([]*category, 0)
-
 	if err := ctx.Bind(&request.Body); err != nil {
-		log.WithFields(map[string]interface{}{"error": err, "cause": ""}).
-			Error("error getting body")
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
 		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
 	}
 
@@ -894,299 +1164,2636 @@ func (api *apiWeb) createCategoriesHandler(ctx echo.Context) error {
 		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
 	}
 
-	for _, item := range request.Body {
-		categories = append(categories, &category{
-			UserID:      request.UserID,
-			Name:        item.Name,
-			Description: item.Description,
-			ImageID:     item.ImageID,
-		})
+	if request.Body.ExpiresIn < 0 {
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: "the expires_in must be zero or positive", Cause: ""})
 	}
 
-	if createdCategories, err := api.interactor.createCategories(categories); err != nil {
+	if accessToken, err := api.interactor.createAccessToken(request.UserID, request.Body.Name, request.Body.Scopes, request.Body.ExpiresIn); err != nil {
+		if err == errInvalidScope {
+			return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
+		}
 		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
 	} else {
-		categoriesResponse := make([]*categoryResponse, 0)
+		return ctx.JSON(http.StatusCreated, newAccessTokenResponse(accessToken))
+	}
+}
 
-		for _, createdCategory := range createdCategories {
-			categoryResponse := &categoryResponse{
-				CategoryID:  createdCategory.CategoryID,
-				UserID:      createdCategory.UserID,
-				Name:        createdCategory.Name,
-				Description: createdCategory.Description,
-				ImageID:     createdCategory.ImageID,
-				CreatedAt:   createdCategory.CreatedAt.String(),
-				UpdatedAt:   createdCategory.UpdatedAt.String(),
-			}
-			categoriesResponse = append(categoriesResponse, categoryResponse)
-		}
-		return ctx.JSON(http.StatusCreated, categoriesResponse)
+func (api *apiWeb) revokeAccessTokenHandler(ctx echo.Context) error {
+	request := revokeAccessTokenRequest{
+		UserID:        ctx.Param("user_id"),
+		AccessTokenID: ctx.Param("token_id"),
+	}
+
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	if deleted, err := api.interactor.revokeAccessToken(request.UserID, request.AccessTokenID); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else if !deleted {
+		return ctx.NoContent(http.StatusNotFound)
+	} else {
+		return ctx.NoContent(http.StatusOK)
 	}
 }
 
-func (api *apiWeb) updateCategoryHandler(ctx echo.Context) error {
-	request := updateCategoryRequest{
-		UserID:     ctx.Param("user_id"),
-		CategoryID: ctx.Param("category_id"),
+type forgotPasswordRequest struct {
+	Body struct {
+		Email string `json:"email" validate:"nonzero"`
 	}
+}
+
+type resetPasswordRequest struct {
+	Body struct {
+		Token    string `json:"token" validate:"nonzero"`
+		Password string `json:"password" validate:"nonzero"`
+	}
+}
+
+func (api *apiWeb) registerRoutesForPasswords() error {
+	api.client.AddRoute(http.MethodPost, "/api/1/passwords/forgot", api.forgotPasswordHandler)
+	api.client.AddRoute(http.MethodPost, "/api/1/passwords/reset", api.resetPasswordHandler)
+
+	return nil
+}
+
+// forgotPasswordHandler always accepts the request, so the emails of the users can't be discovered
+func (api *apiWeb) forgotPasswordHandler(ctx echo.Context) error {
+	request := forgotPasswordRequest{}
 	if err := ctx.Bind(&request.Body); err != nil {
 		log.WithFields(map[string]interface{}{"error": err}).
 			Error("error when validating body request")
 		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
 	}
 
-	if err := validator.Validate(request); err != nil {
+	if err := validator.Validate(request.Body); err != nil {
 		log.WithFields(map[string]interface{}{"error": err}).
 			Error("error when validating body request")
 		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
 	}
 
-	if updatedCategory, err := api.interactor.updateCategory(
-		&category{
-			UserID:      request.UserID,
-			CategoryID:  request.CategoryID,
-			Name:        request.Body.Name,
-			Description: request.Body.Description,
-		}); err != nil {
+	if err := api.interactor.requestPasswordReset(request.Body.Email); err != nil {
 		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
-	} else if updatedCategory == nil {
-		return ctx.NoContent(http.StatusNotFound)
 	} else {
-		return ctx.JSON(http.StatusCreated, categoryResponse{
-			CategoryID:  updatedCategory.CategoryID,
-			UserID:      updatedCategory.UserID,
-			Name:        updatedCategory.Name,
-			Description: updatedCategory.Description,
-			ImageID:     updatedCategory.ImageID,
-			CreatedAt:   updatedCategory.CreatedAt.String(),
-			UpdatedAt:   updatedCategory.UpdatedAt.String(),
-		})
+		return ctx.NoContent(http.StatusAccepted)
 	}
 }
 
-func (api *apiWeb) deleteCategoryHandler(ctx echo.Context) error {
-	request := deleteCategoryRequest{
-		UserID:     ctx.Param("user_id"),
-		CategoryID: ctx.Param("category_id"),
+func (api *apiWeb) resetPasswordHandler(ctx echo.Context) error {
+	request := resetPasswordRequest{}
+	if err := ctx.Bind(&request.Body); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
 	}
 
-	if err := api.interactor.deleteCategory(request.UserID, request.CategoryID); err != nil {
+	if err := validator.Validate(request.Body); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	if reset, err := api.interactor.resetPassword(request.Body.Token, request.Body.Password); err != nil {
 		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else if !reset {
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: "invalid or expired reset token", Cause: ""})
 	} else {
 		return ctx.NoContent(http.StatusOK)
 	}
 }
 
-type getImagesRequest struct {
+type getWalletsRequest struct {
 	UserID string `json:"user_id" validate:"ui"`
 }
 
-type getImageRequest struct {
-	UserID  string `json:"user_id" validate:"ui"`
-	ImageID string `json:"image_id" validate:"ui"`
+type getWalletRequest struct {
+	UserID   string `json:"user_id" validate:"ui"`
+	WalletID string `json:"wallet_id" validate:"ui"`
 }
 
-type getImageRawRequest struct {
-	UserID  string `json:"user_id" validate:"ui"`
-	ImageID string `json:"image_id" validate:"ui"`
+type createWalletsRequest struct {
+	UserID string              `json:"user_id" validate:"ui"`
+	Body   []walletItemRequest `json:"wallets" validate:"min=1"`
 }
 
-type createImagesRequest struct {
-	UserID string `json:"user_id" validate:"ui"`
-	Body   struct {
-		Name        string `json:"name" validate:"nonzero"`
-		Description string `json:"description" validate:"nonzero"`
-		Url         string `json:"url"`
-		ImageKey    string `json:"image_key"`
-	} `json:"images" validate:"min=1"`
+type updateWalletRequest struct {
+	UserID   string `json:"user_id" validate:"ui"`
+	WalletID string `json:"wallet_id" validate:"ui"`
+	Body     walletItemRequest
 }
 
-type updateImageRequest struct {
-	ImageID string `json:"image_id" validate:"ui"`
-	UserID  string `json:"user_id" validate:"ui"`
-	Body    struct {
-		Name        string `json:"name" validate:"nonzero"`
-		Description string `json:"description"`
-		Url         string `json:"url"`
-		ImageKey    string `json:"image_key"`
-	} `json:"body"`
+type walletItemRequest struct {
+	Name        string  `json:"name" validate:"nonzero"`
+	Description string  `json:"description"`
+	Password    *string `json:"password"`
 }
 
-type deleteImageRawRequest struct {
-	UserID  string `json:"user_id" validate:"ui"`
-	ImageID string `json:"image_id" validate:"ui"`
+type unlockWalletRequest struct {
+	UserID   string `json:"user_id" validate:"ui"`
+	WalletID string `json:"wallet_id" validate:"ui"`
+	Body     struct {
+		Password string `json:"password" validate:"nonzero"`
+	}
 }
 
-type imageResponse struct {
-	ImageID     string `json:"image_id"`
+type walletGrantResponse struct {
+	Grant     string `json:"grant"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+type deleteWalletRequest struct {
+	UserID   string `json:"user_id" validate:"ui"`
+	WalletID string `json:"wallet_id" validate:"ui"`
+}
+
+type walletResponse struct {
+	WalletID    string `json:"wallet_id"`
 	UserID      string `json:"user_id"`
-	Name        string `json:"name,omitempty"`
+	Name        string `json:"name"`
 	Description string `json:"description,omitempty"`
-	Url         string `json:"url,omitempty"`
-	FileName    string `json:"file_name,omitempty"`
-	Format      string `json:"format,omitempty"`
-	RawImage    []byte `json:"raw_image,omitempty"`
-	UpdatedAt   string `json:"updated_at,omitempty"`
-	CreatedAt   string `json:"created_at,omitempty"`
+	Locked      bool   `json:"locked"`
+	Role        string `json:"role"`
+	HouseholdID string `json:"household_id,omitempty"`
+	UpdatedAt   string `json:"updated_at"`
+	CreatedAt   string `json:"created_at"`
 }
 
-func (api *apiWeb) registerRoutesForImages() error {
-	api.client.AddRoute(http.MethodGet, "/api/1/users/:user_id/images", api.getImagesHandler, api.auth)
-	api.client.AddRoute(http.MethodGet, "/api/1/users/:user_id/images/:image_id", api.getImageHandler, api.auth)
-	api.client.AddRoute(http.MethodGet, "/api/1/users/:user_id/images/:image_id/raw", api.getImageRawHandler, api.auth)
-	api.client.AddRoute(http.MethodPost, "/api/1/users/:user_id/images", api.createImageHandler, api.auth)
-	api.client.AddRoute(http.MethodPut, "/api/1/users/:user_id/images/:image_id", api.updateImageHandler, api.auth)
-	api.client.AddRoute(http.MethodDelete, "/api/1/users/:user_id/images/:image_id", api.deleteImageHandler, api.auth)
+func (api *apiWeb) registerRoutesForWallets() error {
+	api.client.AddRoute(http.MethodGet, "/api/1/users/:user_id/wallets", api.getWalletsHandler, api.auth)
+	api.client.AddRoute(http.MethodGet, "/api/1/users/:user_id/wallets/:wallet_id", api.getWalletHandler, api.auth)
+	api.client.AddRoute(http.MethodPost, "/api/1/users/:user_id/wallets", api.createWalletsHandler, api.auth)
+	api.client.AddRoute(http.MethodPut, "/api/1/users/:user_id/wallets/:wallet_id", api.updateWalletHandler, api.auth, api.requireWalletRole(walletRoleAdmin), api.grant)
+	api.client.AddRoute(http.MethodDelete, "/api/1/users/:user_id/wallets/:wallet_id", api.deleteWalletHandler, api.auth, api.requireWalletRole(walletRoleOwner), api.grant)
+	api.client.AddRoute(http.MethodPost, "/api/1/users/:user_id/wallets/:wallet_id/unlock", api.unlockWalletHandler, api.auth)
 
 	return nil
 }
 
-func (api *apiWeb) getImagesHandler(ctx echo.Context) error {
-	request := getImagesRequest{
+func (api *apiWeb) getWalletsHandler(ctx echo.Context) error {
+	request := getWalletsRequest{
 		UserID: ctx.Param("user_id"),
 	}
 
-	if images, err := api.interactor.getImages(request.UserID); err != nil {
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	if wallets, err := api.interactor.getWallets(request.UserID); err != nil {
 		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
-	} else if images == nil {
+	} else if wallets == nil {
 		return ctx.NoContent(http.StatusNotFound)
 	} else {
-		imagesResponse := make([]*imageResponse, 0)
-
-		for _, image := range images {
-			imageResponse := &imageResponse{
-				UserID:      image.UserID,
-				ImageID:     image.ImageID,
-				Name:        image.Name,
-				Description: image.Description,
-				Url:         image.Url,
-				FileName:    image.FileName,
-				Format:      image.Format,
-				RawImage:    image.RawImage,
-				CreatedAt:   image.CreatedAt.String(),
-				UpdatedAt:   image.UpdatedAt.String(),
+		walletsResponse := make([]*walletResponse, 0)
+		for _, wallet := range wallets {
+			walletResponse := &walletResponse{
+				WalletID:    wallet.WalletID,
+				UserID:      wallet.UserID,
+				Name:        wallet.Name,
+				Description: wallet.Description,
+				Locked:      wallet.Password != "",
+				Role:        wallet.Role,
+				HouseholdID: wallet.HouseholdID,
+				CreatedAt:   wallet.CreatedAt.String(),
+				UpdatedAt:   wallet.UpdatedAt.String(),
 			}
-			imagesResponse = append(imagesResponse, imageResponse)
+			walletsResponse = append(walletsResponse, walletResponse)
 		}
-		return ctx.JSON(http.StatusOK, imagesResponse)
+		return ctx.JSON(http.StatusOK, walletsResponse)
 	}
 }
 
-func (api *apiWeb) getImageHandler(ctx echo.Context) error {
-	request := getImageRawRequest{
-		UserID:  ctx.Param("user_id"),
-		ImageID: ctx.Param("image_id"),
+func (api *apiWeb) getWalletHandler(ctx echo.Context) error {
+	request := getWalletRequest{
+		UserID:   ctx.Param("user_id"),
+		WalletID: ctx.Param("wallet_id"),
 	}
 
-	if image, err := api.interactor.getImage(request.UserID, request.ImageID); err != nil {
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	if wallet, err := api.interactor.getWallet(request.UserID, request.WalletID); err != nil {
 		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
-	} else if image == nil {
+	} else if wallet == nil {
 		return ctx.NoContent(http.StatusNotFound)
 	} else {
 		return ctx.JSON(http.StatusOK,
-			imageResponse{
-				UserID:      image.UserID,
-				ImageID:     image.ImageID,
-				Name:        image.Name,
-				Description: image.Description,
-				Url:         image.Url,
-				FileName:    image.FileName,
-				Format:      image.Format,
-				RawImage:    image.RawImage,
-				CreatedAt:   image.CreatedAt.String(),
-				UpdatedAt:   image.UpdatedAt.String(),
+			walletResponse{
+				WalletID:    wallet.WalletID,
+				Name:        wallet.Name,
+				Description: wallet.Description,
+				Locked:      wallet.Password != "",
+				Role:        wallet.Role,
+				HouseholdID: wallet.HouseholdID,
+				CreatedAt:   wallet.CreatedAt.String(),
+				UpdatedAt:   wallet.UpdatedAt.String(),
 			})
 	}
 }
 
-func (api *apiWeb) getImageRawHandler(ctx echo.Context) error {
-	request := getImageRawRequest{
-		UserID:  ctx.Param("user_id"),
-		ImageID: ctx.Param("image_id"),
-	}
-
-	if rawImage, err := api.interactor.getImageRaw(request.UserID, request.ImageID); err != nil {
+func (api *apiWeb) createWalletsHandler(ctx echo.Context) error {
+	request := createWalletsRequest{
+		UserID: ctx.Param("user_id"),
+	}
+	wallets := make([]*wallet, 0)
+
+	if err := ctx.Bind(&request.Body); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
+	}
+
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	for _, item := range request.Body {
+		newWallet := &wallet{
+			UserID:      request.UserID,
+			Name:        item.Name,
+			Description: item.Description,
+		}
+		if item.Password != nil {
+			newWallet.Password = *item.Password
+		}
+		wallets = append(wallets, newWallet)
+	}
+
+	if createdWallets, err := api.interactor.createWallets(wallets); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else {
+		walletsResponse := make([]*walletResponse, 0)
+
+		for _, createdWallet := range createdWallets {
+			walletResponse := &walletResponse{
+				WalletID:    createdWallet.WalletID,
+				UserID:      createdWallet.UserID,
+				Name:        createdWallet.Name,
+				Description: createdWallet.Description,
+				Locked:      createdWallet.Password != "",
+				Role:        createdWallet.Role,
+				HouseholdID: createdWallet.HouseholdID,
+				CreatedAt:   createdWallet.CreatedAt.String(),
+				UpdatedAt:   createdWallet.UpdatedAt.String(),
+			}
+			walletsResponse = append(walletsResponse, walletResponse)
+		}
+		return ctx.JSON(http.StatusCreated, walletsResponse)
+	}
+}
+
+func (api *apiWeb) updateWalletHandler(ctx echo.Context) error {
+	request := updateWalletRequest{
+		UserID:   ctx.Param("user_id"),
+		WalletID: ctx.Param("wallet_id"),
+	}
+	if err := ctx.Bind(&request.Body); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
+	}
+
+	if err := validator.Validate(request.Body); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	if updatedWallet, err := api.interactor.updateWallet(
+		&wallet{
+			UserID:      request.UserID,
+			WalletID:    request.WalletID,
+			Name:        request.Body.Name,
+			Description: request.Body.Description,
+		}, request.Body.Password); err != nil {
+		if authErr, ok := err.(*authorizationError); ok {
+			return ctx.JSON(http.StatusForbidden, errorResponse{Code: http.StatusForbidden, Message: authErr.Error(), Cause: ""})
+		}
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else if updatedWallet == nil {
+		return ctx.NoContent(http.StatusNotFound)
+	} else {
+		return ctx.JSON(http.StatusCreated, walletResponse{
+			WalletID:    updatedWallet.WalletID,
+			UserID:      updatedWallet.UserID,
+			Name:        updatedWallet.Name,
+			Description: updatedWallet.Description,
+			Locked:      updatedWallet.Password != "",
+			Role:        updatedWallet.Role,
+			HouseholdID: updatedWallet.HouseholdID,
+			CreatedAt:   updatedWallet.CreatedAt.String(),
+			UpdatedAt:   updatedWallet.UpdatedAt.String(),
+		})
+	}
+}
+
+func (api *apiWeb) unlockWalletHandler(ctx echo.Context) error {
+	request := unlockWalletRequest{
+		UserID:   ctx.Param("user_id"),
+		WalletID: ctx.Param("wallet_id"),
+	}
+	if err := ctx.Bind(&request.Body); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
+	}
+
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	principal := principalFromContext(ctx)
+	if grant, expiresAt, retryAfter, err := api.interactor.unlockWallet(request.UserID, request.WalletID, request.Body.Password); err != nil {
+		if err == errWalletNotFound {
+			return ctx.NoContent(http.StatusNotFound)
+		} else if err == errWalletNotLocked {
+			return ctx.JSON(http.StatusConflict, errorResponse{Code: http.StatusConflict, Message: err.Error(), Cause: ""})
+		}
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else if retryAfter > 0 {
+		ctx.Response().Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		return ctx.JSON(http.StatusTooManyRequests, errorResponse{Code: http.StatusTooManyRequests, Message: "too many unlock attempts, try again later", Cause: ""})
+	} else if grant == "" {
+		log.Infof("user %s failed to unlock wallet %s", principal.UserID, request.WalletID)
+		return ctx.JSON(http.StatusUnauthorized, errorResponse{Code: http.StatusUnauthorized, Message: "invalid wallet password", Cause: ""})
+	} else {
+		return ctx.JSON(http.StatusCreated, walletGrantResponse{
+			Grant:     grant,
+			ExpiresAt: expiresAt.String(),
+		})
+	}
+}
+
+func (api *apiWeb) deleteWalletHandler(ctx echo.Context) error {
+	request := updateWalletRequest{
+		UserID:   ctx.Param("user_id"),
+		WalletID: ctx.Param("wallet_id"),
+	}
+	if err := ctx.Bind(&request.Body); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
+	}
+
+	if err := api.interactor.deleteWallet(request.UserID, request.WalletID); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else {
+		return ctx.NoContent(http.StatusOK)
+	}
+}
+
+type getWalletMembersRequest struct {
+	UserID   string `json:"user_id" validate:"ui"`
+	WalletID string `json:"wallet_id" validate:"ui"`
+}
+
+type addWalletMemberRequest struct {
+	UserID   string `json:"user_id" validate:"ui"`
+	WalletID string `json:"wallet_id" validate:"ui"`
+	Body     struct {
+		Email string `json:"email" validate:"nonzero"`
+		Role  string `json:"role" validate:"nonzero"`
+	}
+}
+
+type updateWalletMemberRequest struct {
+	UserID   string `json:"user_id" validate:"ui"`
+	WalletID string `json:"wallet_id" validate:"ui"`
+	MemberID string `json:"member_id" validate:"ui"`
+	Body     struct {
+		Role string `json:"role" validate:"nonzero"`
+	}
+}
+
+type removeWalletMemberRequest struct {
+	UserID   string `json:"user_id" validate:"ui"`
+	WalletID string `json:"wallet_id" validate:"ui"`
+	MemberID string `json:"member_id" validate:"ui"`
+}
+
+type walletMemberResponse struct {
+	UserID    string `json:"user_id"`
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	InvitedBy string `json:"invited_by,omitempty"`
+	UpdatedAt string `json:"updated_at"`
+	CreatedAt string `json:"created_at"`
+}
+
+func newWalletMemberResponse(member *walletMember) *walletMemberResponse {
+	return &walletMemberResponse{
+		UserID:    member.UserID,
+		Name:      member.Name,
+		Email:     member.Email,
+		Role:      member.Role,
+		InvitedBy: member.InvitedBy,
+		UpdatedAt: member.UpdatedAt.String(),
+		CreatedAt: member.CreatedAt.String(),
+	}
+}
+
+func (api *apiWeb) registerRoutesForWalletMembers() error {
+	api.client.AddRoute(http.MethodGet, "/api/1/users/:user_id/wallets/:wallet_id/members", api.getWalletMembersHandler, api.auth)
+	api.client.AddRoute(http.MethodPost, "/api/1/users/:user_id/wallets/:wallet_id/members", api.addWalletMemberHandler, api.auth, api.requireWalletRole(walletRoleAdmin))
+	api.client.AddRoute(http.MethodPut, "/api/1/users/:user_id/wallets/:wallet_id/members/:member_id", api.updateWalletMemberHandler, api.auth, api.requireWalletRole(walletRoleAdmin))
+	api.client.AddRoute(http.MethodDelete, "/api/1/users/:user_id/wallets/:wallet_id/members/:member_id", api.removeWalletMemberHandler, api.auth)
+
+	return nil
+}
+
+func (api *apiWeb) getWalletMembersHandler(ctx echo.Context) error {
+	request := getWalletMembersRequest{
+		UserID:   ctx.Param("user_id"),
+		WalletID: ctx.Param("wallet_id"),
+	}
+
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	if members, err := api.interactor.getWalletMembers(request.UserID, request.WalletID); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else if members == nil {
+		return ctx.NoContent(http.StatusNotFound)
+	} else {
+		membersResponse := make([]*walletMemberResponse, 0)
+		for _, member := range members {
+			membersResponse = append(membersResponse, newWalletMemberResponse(member))
+		}
+		return ctx.JSON(http.StatusOK, membersResponse)
+	}
+}
+
+func (api *apiWeb) addWalletMemberHandler(ctx echo.Context) error {
+	request := addWalletMemberRequest{
+		UserID:   ctx.Param("user_id"),
+		WalletID: ctx.Param("wallet_id"),
+	}
+
+	if err := ctx.Bind(&request.Body); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
+	}
+
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	if member, err := api.interactor.addWalletMember(request.UserID, request.WalletID, request.Body.Email, request.Body.Role); err != nil {
+		if authErr, ok := err.(*authorizationError); ok {
+			return ctx.JSON(http.StatusForbidden, errorResponse{Code: http.StatusForbidden, Message: authErr.Error(), Cause: ""})
+		} else if err == errInvalidWalletRole {
+			return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
+		}
+		if err == errWalletNotFound || err == errMemberNotFound {
+			return ctx.JSON(http.StatusNotFound, errorResponse{Code: http.StatusNotFound, Message: err.Error(), Cause: ""})
+		} else if err == errMemberExists || err == errMemberIsOwner {
+			return ctx.JSON(http.StatusConflict, errorResponse{Code: http.StatusConflict, Message: err.Error(), Cause: ""})
+		}
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else {
+		return ctx.JSON(http.StatusCreated, newWalletMemberResponse(member))
+	}
+}
+
+func (api *apiWeb) updateWalletMemberHandler(ctx echo.Context) error {
+	request := updateWalletMemberRequest{
+		UserID:   ctx.Param("user_id"),
+		WalletID: ctx.Param("wallet_id"),
+		MemberID: ctx.Param("member_id"),
+	}
+
+	if err := ctx.Bind(&request.Body); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
+	}
+
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	if member, err := api.interactor.updateWalletMember(request.UserID, request.WalletID, request.MemberID, request.Body.Role); err != nil {
+		if authErr, ok := err.(*authorizationError); ok {
+			return ctx.JSON(http.StatusForbidden, errorResponse{Code: http.StatusForbidden, Message: authErr.Error(), Cause: ""})
+		} else if err == errInvalidWalletRole {
+			return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
+		}
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else if member == nil {
+		return ctx.NoContent(http.StatusNotFound)
+	} else {
+		return ctx.JSON(http.StatusOK, newWalletMemberResponse(member))
+	}
+}
+
+func (api *apiWeb) removeWalletMemberHandler(ctx echo.Context) error {
+	request := removeWalletMemberRequest{
+		UserID:   ctx.Param("user_id"),
+		WalletID: ctx.Param("wallet_id"),
+		MemberID: ctx.Param("member_id"),
+	}
+
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	if deleted, err := api.interactor.removeWalletMember(request.UserID, request.WalletID, request.MemberID); err != nil {
+		if authErr, ok := err.(*authorizationError); ok {
+			return ctx.JSON(http.StatusForbidden, errorResponse{Code: http.StatusForbidden, Message: authErr.Error(), Cause: ""})
+		}
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else if !deleted {
+		return ctx.NoContent(http.StatusNotFound)
+	} else {
+		return ctx.NoContent(http.StatusOK)
+	}
+}
+
+type getHouseholdsRequest struct {
+	UserID string `json:"user_id" validate:"ui"`
+}
+
+type getHouseholdRequest struct {
+	UserID      string `json:"user_id" validate:"ui"`
+	HouseholdID string `json:"household_id" validate:"ui"`
+}
+
+type createHouseholdRequest struct {
+	UserID string `json:"user_id" validate:"ui"`
+	Body   struct {
+		Name string `json:"name" validate:"nonzero"`
+	}
+}
+
+type updateHouseholdRequest struct {
+	UserID      string `json:"user_id" validate:"ui"`
+	HouseholdID string `json:"household_id" validate:"ui"`
+	Body        struct {
+		Name string `json:"name" validate:"nonzero"`
+	}
+}
+
+type joinHouseholdRequest struct {
+	UserID string `json:"user_id" validate:"ui"`
+	Body   struct {
+		Token string `json:"token" validate:"nonzero"`
+	}
+}
+
+type updateHouseholdMemberRequest struct {
+	UserID      string `json:"user_id" validate:"ui"`
+	HouseholdID string `json:"household_id" validate:"ui"`
+	MemberID    string `json:"member_id" validate:"ui"`
+	Body        struct {
+		Role string `json:"role" validate:"nonzero"`
+	}
+}
+
+type removeHouseholdMemberRequest struct {
+	UserID      string `json:"user_id" validate:"ui"`
+	HouseholdID string `json:"household_id" validate:"ui"`
+	MemberID    string `json:"member_id" validate:"ui"`
+}
+
+type inviteHouseholdMemberRequest struct {
+	UserID      string `json:"user_id" validate:"ui"`
+	HouseholdID string `json:"household_id" validate:"ui"`
+	Body        struct {
+		Email string `json:"email" validate:"nonzero"`
+		Role  string `json:"role" validate:"nonzero"`
+	}
+}
+
+type cancelHouseholdInvitationRequest struct {
+	UserID       string `json:"user_id" validate:"ui"`
+	HouseholdID  string `json:"household_id" validate:"ui"`
+	InvitationID string `json:"invitation_id" validate:"ui"`
+}
+
+type attachHouseholdWalletRequest struct {
+	UserID      string `json:"user_id" validate:"ui"`
+	HouseholdID string `json:"household_id" validate:"ui"`
+	Body        struct {
+		WalletID string `json:"wallet_id" validate:"ui"`
+	}
+}
+
+type detachHouseholdWalletRequest struct {
+	UserID      string `json:"user_id" validate:"ui"`
+	HouseholdID string `json:"household_id" validate:"ui"`
+	WalletID    string `json:"wallet_id" validate:"ui"`
+}
+
+type createHouseholdCategoriesRequest struct {
+	UserID      string                `json:"user_id" validate:"ui"`
+	HouseholdID string                `json:"household_id" validate:"ui"`
+	Body        []categoryItemRequest `json:"categories" validate:"min=1"`
+}
+
+type updateHouseholdCategoryRequest struct {
+	UserID      string `json:"user_id" validate:"ui"`
+	HouseholdID string `json:"household_id" validate:"ui"`
+	CategoryID  string `json:"category_id" validate:"ui"`
+	Body        categoryItemRequest
+}
+
+type deleteHouseholdCategoryRequest struct {
+	UserID      string `json:"user_id" validate:"ui"`
+	HouseholdID string `json:"household_id" validate:"ui"`
+	CategoryID  string `json:"category_id" validate:"ui"`
+}
+
+type budgetItemRequest struct {
+	CategoryID string          `json:"category_id"`
+	Amount     decimal.Decimal `json:"amount"`
+	Period     string          `json:"period" validate:"nonzero"`
+}
+
+type createBudgetRequest struct {
+	UserID      string `json:"user_id" validate:"ui"`
+	HouseholdID string `json:"household_id" validate:"ui"`
+	Body        budgetItemRequest
+}
+
+type updateBudgetRequest struct {
+	UserID      string `json:"user_id" validate:"ui"`
+	HouseholdID string `json:"household_id" validate:"ui"`
+	BudgetID    string `json:"budget_id" validate:"ui"`
+	Body        budgetItemRequest
+}
+
+type deleteBudgetRequest struct {
+	UserID      string `json:"user_id" validate:"ui"`
+	HouseholdID string `json:"household_id" validate:"ui"`
+	BudgetID    string `json:"budget_id" validate:"ui"`
+}
+
+type householdResponse struct {
+	HouseholdID string `json:"household_id"`
+	Name        string `json:"name"`
+	CreatedBy   string `json:"created_by"`
+	Role        string `json:"role"`
+	UpdatedAt   string `json:"updated_at"`
+	CreatedAt   string `json:"created_at"`
+}
+
+func newHouseholdResponse(household *household) *householdResponse {
+	return &householdResponse{
+		HouseholdID: household.HouseholdID,
+		Name:        household.Name,
+		CreatedBy:   household.CreatedBy,
+		Role:        household.Role,
+		UpdatedAt:   household.UpdatedAt.String(),
+		CreatedAt:   household.CreatedAt.String(),
+	}
+}
+
+type householdMemberResponse struct {
+	UserID    string `json:"user_id"`
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	InvitedBy string `json:"invited_by,omitempty"`
+	UpdatedAt string `json:"updated_at"`
+	CreatedAt string `json:"created_at"`
+}
+
+func newHouseholdMemberResponse(member *householdMember) *householdMemberResponse {
+	return &householdMemberResponse{
+		UserID:    member.UserID,
+		Name:      member.Name,
+		Email:     member.Email,
+		Role:      member.Role,
+		InvitedBy: member.InvitedBy,
+		UpdatedAt: member.UpdatedAt.String(),
+		CreatedAt: member.CreatedAt.String(),
+	}
+}
+
+type householdInvitationResponse struct {
+	InvitationID string `json:"invitation_id"`
+	Email        string `json:"email"`
+	Role         string `json:"role"`
+	InvitedBy    string `json:"invited_by"`
+	ExpiresAt    string `json:"expires_at"`
+	CreatedAt    string `json:"created_at"`
+}
+
+func newHouseholdInvitationResponse(invitation *householdInvitation) *householdInvitationResponse {
+	return &householdInvitationResponse{
+		InvitationID: invitation.InvitationID,
+		Email:        invitation.Email,
+		Role:         invitation.Role,
+		InvitedBy:    invitation.InvitedBy,
+		ExpiresAt:    invitation.ExpiresAt.String(),
+		CreatedAt:    invitation.CreatedAt.String(),
+	}
+}
+
+type budgetResponse struct {
+	BudgetID      string `json:"budget_id"`
+	HouseholdID   string `json:"household_id"`
+	CategoryID    string `json:"category_id,omitempty"`
+	Amount        string `json:"amount"`
+	Period        string `json:"period"`
+	CurrentPeriod string `json:"current_period,omitempty"`
+	Spent         string `json:"spent,omitempty"`
+	Remaining     string `json:"remaining,omitempty"`
+	CreatedBy     string `json:"created_by"`
+	UpdatedAt     string `json:"updated_at"`
+	CreatedAt     string `json:"created_at"`
+}
+
+func newBudgetResponse(budget *budget) *budgetResponse {
+	return &budgetResponse{
+		BudgetID:    budget.BudgetID,
+		HouseholdID: budget.HouseholdID,
+		CategoryID:  budget.CategoryID,
+		Amount:      budget.Amount.String(),
+		Period:      budget.Period,
+		CreatedBy:   budget.CreatedBy,
+		UpdatedAt:   budget.UpdatedAt.String(),
+		CreatedAt:   budget.CreatedAt.String(),
+	}
+}
+
+func newWalletResponse(wallet *wallet) *walletResponse {
+	return &walletResponse{
+		WalletID:    wallet.WalletID,
+		UserID:      wallet.UserID,
+		Name:        wallet.Name,
+		Description: wallet.Description,
+		Locked:      wallet.Password != "",
+		Role:        wallet.Role,
+		HouseholdID: wallet.HouseholdID,
+		CreatedAt:   wallet.CreatedAt.String(),
+		UpdatedAt:   wallet.UpdatedAt.String(),
+	}
+}
+
+func newCategoryResponse(category *category) *categoryResponse {
+	return &categoryResponse{
+		CategoryID:  category.CategoryID,
+		UserID:      category.UserID,
+		HouseholdID: category.HouseholdID,
+		Name:        category.Name,
+		Description: category.Description,
+		ImageID:     category.ImageID,
+		CreatedAt:   category.CreatedAt.String(),
+		UpdatedAt:   category.UpdatedAt.String(),
+	}
+}
+
+// householdErrorResponse writes the errors of the households that aren't internal errors
+func householdErrorResponse(ctx echo.Context, err error) error {
+	if authErr, ok := err.(*authorizationError); ok {
+		return ctx.JSON(http.StatusForbidden, errorResponse{Code: http.StatusForbidden, Message: authErr.Error(), Cause: ""})
+	}
+	switch err {
+	case errInvalidHouseholdRole, errInvalidBudget:
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
+	case errHouseholdNotFound, errWalletNotFound:
+		return ctx.JSON(http.StatusNotFound, errorResponse{Code: http.StatusNotFound, Message: err.Error(), Cause: ""})
+	case errHouseholdMemberExists, errHouseholdMemberIsOwner, errWalletOnOtherHousehold:
+		return ctx.JSON(http.StatusConflict, errorResponse{Code: http.StatusConflict, Message: err.Error(), Cause: ""})
+	}
+	return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+}
+
+func (api *apiWeb) registerRoutesForHouseholds() error {
+	api.client.AddRoute(http.MethodGet, "/api/1/users/:user_id/households", api.getHouseholdsHandler, api.auth)
+	api.client.AddRoute(http.MethodPost, "/api/1/users/:user_id/households", api.createHouseholdHandler, api.auth)
+	api.client.AddRoute(http.MethodPost, "/api/1/users/:user_id/households/join", api.joinHouseholdHandler, api.auth)
+	api.client.AddRoute(http.MethodGet, "/api/1/users/:user_id/households/:household_id", api.getHouseholdHandler, api.auth)
+	api.client.AddRoute(http.MethodPut, "/api/1/users/:user_id/households/:household_id", api.updateHouseholdHandler, api.auth, api.requireHouseholdRole(householdRoleAdmin))
+	api.client.AddRoute(http.MethodDelete, "/api/1/users/:user_id/households/:household_id", api.deleteHouseholdHandler, api.auth, api.requireHouseholdRole(householdRoleOwner))
+
+	api.client.AddRoute(http.MethodGet, "/api/1/users/:user_id/households/:household_id/members", api.getHouseholdMembersHandler, api.auth)
+	api.client.AddRoute(http.MethodPut, "/api/1/users/:user_id/households/:household_id/members/:member_id", api.updateHouseholdMemberHandler, api.auth, api.requireHouseholdRole(householdRoleAdmin))
+	api.client.AddRoute(http.MethodDelete, "/api/1/users/:user_id/households/:household_id/members/:member_id", api.removeHouseholdMemberHandler, api.auth)
+
+	api.client.AddRoute(http.MethodGet, "/api/1/users/:user_id/households/:household_id/invitations", api.getHouseholdInvitationsHandler, api.auth, api.requireHouseholdRole(householdRoleAdmin))
+	api.client.AddRoute(http.MethodPost, "/api/1/users/:user_id/households/:household_id/invitations", api.inviteHouseholdMemberHandler, api.auth, api.requireHouseholdRole(householdRoleAdmin))
+	api.client.AddRoute(http.MethodDelete, "/api/1/users/:user_id/households/:household_id/invitations/:invitation_id", api.cancelHouseholdInvitationHandler, api.auth, api.requireHouseholdRole(householdRoleAdmin))
+
+	api.client.AddRoute(http.MethodGet, "/api/1/users/:user_id/households/:household_id/wallets", api.getHouseholdWalletsHandler, api.auth)
+	api.client.AddRoute(http.MethodPost, "/api/1/users/:user_id/households/:household_id/wallets", api.attachHouseholdWalletHandler, api.auth, api.requireHouseholdRole(householdRoleMember))
+	api.client.AddRoute(http.MethodDelete, "/api/1/users/:user_id/households/:household_id/wallets/:wallet_id", api.detachHouseholdWalletHandler, api.auth)
+
+	api.client.AddRoute(http.MethodGet, "/api/1/users/:user_id/households/:household_id/categories", api.getHouseholdCategoriesHandler, api.auth)
+	api.client.AddRoute(http.MethodPost, "/api/1/users/:user_id/households/:household_id/categories", api.createHouseholdCategoriesHandler, api.auth, api.requireHouseholdRole(householdRoleMember))
+	api.client.AddRoute(http.MethodPut, "/api/1/users/:user_id/households/:household_id/categories/:category_id", api.updateHouseholdCategoryHandler, api.auth, api.requireHouseholdRole(householdRoleMember))
+	api.client.AddRoute(http.MethodDelete, "/api/1/users/:user_id/households/:household_id/categories/:category_id", api.deleteHouseholdCategoryHandler, api.auth, api.requireHouseholdRole(householdRoleMember))
+
+	api.client.AddRoute(http.MethodGet, "/api/1/users/:user_id/households/:household_id/budgets", api.getBudgetsHandler, api.auth)
+	api.client.AddRoute(http.MethodPost, "/api/1/users/:user_id/households/:household_id/budgets", api.createBudgetHandler, api.auth, api.requireHouseholdRole(householdRoleAdmin))
+	api.client.AddRoute(http.MethodPut, "/api/1/users/:user_id/households/:household_id/budgets/:budget_id", api.updateBudgetHandler, api.auth, api.requireHouseholdRole(householdRoleAdmin))
+	api.client.AddRoute(http.MethodDelete, "/api/1/users/:user_id/households/:household_id/budgets/:budget_id", api.deleteBudgetHandler, api.auth, api.requireHouseholdRole(householdRoleAdmin))
+
+	api.client.AddRoute(http.MethodGet, "/api/1/users/:user_id/households/:household_id/reports", api.getHouseholdReportHandler, api.auth)
+
+	return nil
+}
+
+func (api *apiWeb) getHouseholdsHandler(ctx echo.Context) error {
+	request := getHouseholdsRequest{
+		UserID: ctx.Param("user_id"),
+	}
+
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	if households, err := api.interactor.getHouseholds(request.UserID); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else {
+		householdsResponse := make([]*householdResponse, 0)
+		for _, household := range households {
+			householdsResponse = append(householdsResponse, newHouseholdResponse(household))
+		}
+		return ctx.JSON(http.StatusOK, householdsResponse)
+	}
+}
+
+func (api *apiWeb) getHouseholdHandler(ctx echo.Context) error {
+	request := getHouseholdRequest{
+		UserID:      ctx.Param("user_id"),
+		HouseholdID: ctx.Param("household_id"),
+	}
+
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	if household, err := api.interactor.getHousehold(request.UserID, request.HouseholdID); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else if household == nil {
+		return ctx.NoContent(http.StatusNotFound)
+	} else {
+		return ctx.JSON(http.StatusOK, newHouseholdResponse(household))
+	}
+}
+
+func (api *apiWeb) createHouseholdHandler(ctx echo.Context) error {
+	request := createHouseholdRequest{
+		UserID: ctx.Param("user_id"),
+	}
+
+	if err := ctx.Bind(&request.Body); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
+	}
+
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	if household, err := api.interactor.createHousehold(request.UserID, request.Body.Name); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else {
+		return ctx.JSON(http.StatusCreated, newHouseholdResponse(household))
+	}
+}
+
+func (api *apiWeb) updateHouseholdHandler(ctx echo.Context) error {
+	request := updateHouseholdRequest{
+		UserID:      ctx.Param("user_id"),
+		HouseholdID: ctx.Param("household_id"),
+	}
+
+	if err := ctx.Bind(&request.Body); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
+	}
+
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	if household, err := api.interactor.updateHousehold(request.UserID, request.HouseholdID, request.Body.Name); err != nil {
+		return householdErrorResponse(ctx, err)
+	} else if household == nil {
+		return ctx.NoContent(http.StatusNotFound)
+	} else {
+		return ctx.JSON(http.StatusOK, newHouseholdResponse(household))
+	}
+}
+
+func (api *apiWeb) deleteHouseholdHandler(ctx echo.Context) error {
+	request := getHouseholdRequest{
+		UserID:      ctx.Param("user_id"),
+		HouseholdID: ctx.Param("household_id"),
+	}
+
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	if deleted, err := api.interactor.deleteHousehold(request.UserID, request.HouseholdID); err != nil {
+		return householdErrorResponse(ctx, err)
+	} else if !deleted {
+		return ctx.NoContent(http.StatusNotFound)
+	} else {
+		return ctx.NoContent(http.StatusOK)
+	}
+}
+
+func (api *apiWeb) joinHouseholdHandler(ctx echo.Context) error {
+	request := joinHouseholdRequest{
+		UserID: ctx.Param("user_id"),
+	}
+
+	if err := ctx.Bind(&request.Body); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
+	}
+
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	if household, err := api.interactor.joinHousehold(request.UserID, request.Body.Token); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else if household == nil {
+		return ctx.JSON(http.StatusNotFound, errorResponse{Code: http.StatusNotFound, Message: "invalid or expired invitation", Cause: ""})
+	} else {
+		return ctx.JSON(http.StatusOK, newHouseholdResponse(household))
+	}
+}
+
+func (api *apiWeb) getHouseholdMembersHandler(ctx echo.Context) error {
+	request := getHouseholdRequest{
+		UserID:      ctx.Param("user_id"),
+		HouseholdID: ctx.Param("household_id"),
+	}
+
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	if members, err := api.interactor.getHouseholdMembers(request.UserID, request.HouseholdID); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else if members == nil {
+		return ctx.NoContent(http.StatusNotFound)
+	} else {
+		membersResponse := make([]*householdMemberResponse, 0)
+		for _, member := range members {
+			membersResponse = append(membersResponse, newHouseholdMemberResponse(member))
+		}
+		return ctx.JSON(http.StatusOK, membersResponse)
+	}
+}
+
+func (api *apiWeb) updateHouseholdMemberHandler(ctx echo.Context) error {
+	request := updateHouseholdMemberRequest{
+		UserID:      ctx.Param("user_id"),
+		HouseholdID: ctx.Param("household_id"),
+		MemberID:    ctx.Param("member_id"),
+	}
+
+	if err := ctx.Bind(&request.Body); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
+	}
+
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	if member, err := api.interactor.updateHouseholdMember(request.UserID, request.HouseholdID, request.MemberID, request.Body.Role); err != nil {
+		return householdErrorResponse(ctx, err)
+	} else if member == nil {
+		return ctx.NoContent(http.StatusNotFound)
+	} else {
+		return ctx.JSON(http.StatusOK, newHouseholdMemberResponse(member))
+	}
+}
+
+func (api *apiWeb) removeHouseholdMemberHandler(ctx echo.Context) error {
+	request := removeHouseholdMemberRequest{
+		UserID:      ctx.Param("user_id"),
+		HouseholdID: ctx.Param("household_id"),
+		MemberID:    ctx.Param("member_id"),
+	}
+
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	if deleted, err := api.interactor.removeHouseholdMember(request.UserID, request.HouseholdID, request.MemberID); err != nil {
+		return householdErrorResponse(ctx, err)
+	} else if !deleted {
+		return ctx.NoContent(http.StatusNotFound)
+	} else {
+		return ctx.NoContent(http.StatusOK)
+	}
+}
+
+func (api *apiWeb) getHouseholdInvitationsHandler(ctx echo.Context) error {
+	request := getHouseholdRequest{
+		UserID:      ctx.Param("user_id"),
+		HouseholdID: ctx.Param("household_id"),
+	}
+
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	if invitations, err := api.interactor.getHouseholdInvitations(request.UserID, request.HouseholdID); err != nil {
+		return householdErrorResponse(ctx, err)
+	} else if invitations == nil {
+		return ctx.NoContent(http.StatusNotFound)
+	} else {
+		invitationsResponse := make([]*householdInvitationResponse, 0)
+		for _, invitation := range invitations {
+			invitationsResponse = append(invitationsResponse, newHouseholdInvitationResponse(invitation))
+		}
+		return ctx.JSON(http.StatusOK, invitationsResponse)
+	}
+}
+
+func (api *apiWeb) inviteHouseholdMemberHandler(ctx echo.Context) error {
+	request := inviteHouseholdMemberRequest{
+		UserID:      ctx.Param("user_id"),
+		HouseholdID: ctx.Param("household_id"),
+	}
+
+	if err := ctx.Bind(&request.Body); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
+	}
+
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	if invitation, err := api.interactor.inviteHouseholdMember(request.UserID, request.HouseholdID, request.Body.Email, request.Body.Role); err != nil {
+		return householdErrorResponse(ctx, err)
+	} else {
+		return ctx.JSON(http.StatusCreated, newHouseholdInvitationResponse(invitation))
+	}
+}
+
+func (api *apiWeb) cancelHouseholdInvitationHandler(ctx echo.Context) error {
+	request := cancelHouseholdInvitationRequest{
+		UserID:       ctx.Param("user_id"),
+		HouseholdID:  ctx.Param("household_id"),
+		InvitationID: ctx.Param("invitation_id"),
+	}
+
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	if deleted, err := api.interactor.cancelHouseholdInvitation(request.UserID, request.HouseholdID, request.InvitationID); err != nil {
+		return householdErrorResponse(ctx, err)
+	} else if !deleted {
+		return ctx.NoContent(http.StatusNotFound)
+	} else {
+		return ctx.NoContent(http.StatusOK)
+	}
+}
+
+func (api *apiWeb) getHouseholdWalletsHandler(ctx echo.Context) error {
+	request := getHouseholdRequest{
+		UserID:      ctx.Param("user_id"),
+		HouseholdID: ctx.Param("household_id"),
+	}
+
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	if wallets, err := api.interactor.getHouseholdWallets(request.UserID, request.HouseholdID); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else if wallets == nil {
+		return ctx.NoContent(http.StatusNotFound)
+	} else {
+		walletsResponse := make([]*walletResponse, 0)
+		for _, wallet := range wallets {
+			walletsResponse = append(walletsResponse, newWalletResponse(wallet))
+		}
+		return ctx.JSON(http.StatusOK, walletsResponse)
+	}
+}
+
+func (api *apiWeb) attachHouseholdWalletHandler(ctx echo.Context) error {
+	request := attachHouseholdWalletRequest{
+		UserID:      ctx.Param("user_id"),
+		HouseholdID: ctx.Param("household_id"),
+	}
+
+	if err := ctx.Bind(&request.Body); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
+	}
+
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	if wallet, err := api.interactor.attachHouseholdWallet(request.UserID, request.HouseholdID, request.Body.WalletID); err != nil {
+		return householdErrorResponse(ctx, err)
+	} else {
+		return ctx.JSON(http.StatusCreated, newWalletResponse(wallet))
+	}
+}
+
+func (api *apiWeb) detachHouseholdWalletHandler(ctx echo.Context) error {
+	request := detachHouseholdWalletRequest{
+		UserID:      ctx.Param("user_id"),
+		HouseholdID: ctx.Param("household_id"),
+		WalletID:    ctx.Param("wallet_id"),
+	}
+
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	if detached, err := api.interactor.detachHouseholdWallet(request.UserID, request.HouseholdID, request.WalletID); err != nil {
+		return householdErrorResponse(ctx, err)
+	} else if !detached {
+		return ctx.NoContent(http.StatusNotFound)
+	} else {
+		return ctx.NoContent(http.StatusOK)
+	}
+}
+
+func (api *apiWeb) getHouseholdCategoriesHandler(ctx echo.Context) error {
+	request := getHouseholdRequest{
+		UserID:      ctx.Param("user_id"),
+		HouseholdID: ctx.Param("household_id"),
+	}
+
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	if categories, err := api.interactor.getHouseholdCategories(request.UserID, request.HouseholdID); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else if categories == nil {
+		return ctx.NoContent(http.StatusNotFound)
+	} else {
+		categoriesResponse := make([]*categoryResponse, 0)
+		for _, category := range categories {
+			categoriesResponse = append(categoriesResponse, newCategoryResponse(category))
+		}
+		return ctx.JSON(http.StatusOK, categoriesResponse)
+	}
+}
+
+func (api *apiWeb) createHouseholdCategoriesHandler(ctx echo.Context) error {
+	request := createHouseholdCategoriesRequest{
+		UserID:      ctx.Param("user_id"),
+		HouseholdID: ctx.Param("household_id"),
+	}
+	categories := make([]*category, 0)
+
+	if err := ctx.Bind(&request.Body); err != nil {
+		log.WithFields(map[string]interface{}{"error": err, "cause": ""}).
+			Error("error getting body")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
+	}
+
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	for _, item := range request.Body {
+		categories = append(categories, &category{
+			Name:        item.Name,
+			Description: item.Description,
+			ImageID:     item.ImageID,
+		})
+	}
+
+	if createdCategories, err := api.interactor.createHouseholdCategories(request.UserID, request.HouseholdID, categories); err != nil {
+		return householdErrorResponse(ctx, err)
+	} else {
+		categoriesResponse := make([]*categoryResponse, 0)
+		for _, createdCategory := range createdCategories {
+			categoriesResponse = append(categoriesResponse, newCategoryResponse(createdCategory))
+		}
+		return ctx.JSON(http.StatusCreated, categoriesResponse)
+	}
+}
+
+func (api *apiWeb) updateHouseholdCategoryHandler(ctx echo.Context) error {
+	request := updateHouseholdCategoryRequest{
+		UserID:      ctx.Param("user_id"),
+		HouseholdID: ctx.Param("household_id"),
+		CategoryID:  ctx.Param("category_id"),
+	}
+
+	if err := ctx.Bind(&request.Body); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
+	}
+
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	if updatedCategory, err := api.interactor.updateHouseholdCategory(
+		&category{
+			UserID:      request.UserID,
+			HouseholdID: request.HouseholdID,
+			CategoryID:  request.CategoryID,
+			Name:        request.Body.Name,
+			Description: request.Body.Description,
+			ImageID:     request.Body.ImageID,
+		}); err != nil {
+		return householdErrorResponse(ctx, err)
+	} else if updatedCategory == nil {
+		return ctx.NoContent(http.StatusNotFound)
+	} else {
+		return ctx.JSON(http.StatusOK, newCategoryResponse(updatedCategory))
+	}
+}
+
+func (api *apiWeb) deleteHouseholdCategoryHandler(ctx echo.Context) error {
+	request := deleteHouseholdCategoryRequest{
+		UserID:      ctx.Param("user_id"),
+		HouseholdID: ctx.Param("household_id"),
+		CategoryID:  ctx.Param("category_id"),
+	}
+
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	if deleted, err := api.interactor.deleteHouseholdCategory(request.UserID, request.HouseholdID, request.CategoryID); err != nil {
+		return householdErrorResponse(ctx, err)
+	} else if !deleted {
+		return ctx.NoContent(http.StatusNotFound)
+	} else {
+		return ctx.NoContent(http.StatusOK)
+	}
+}
+
+func (api *apiWeb) getBudgetsHandler(ctx echo.Context) error {
+	request := getHouseholdRequest{
+		UserID:      ctx.Param("user_id"),
+		HouseholdID: ctx.Param("household_id"),
+	}
+
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	locked, err := api.lockedWallets(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	}
+
+	if budgets, err := api.interactor.getBudgets(request.UserID, request.HouseholdID, locked); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else if budgets == nil {
+		return ctx.NoContent(http.StatusNotFound)
+	} else {
+		budgetsResponse := make([]*budgetResponse, 0)
+		for _, status := range budgets {
+			budgetResponse := newBudgetResponse(status.budget)
+			budgetResponse.CurrentPeriod = status.CurrentPeriod
+			budgetResponse.Spent = status.Spent.String()
+			budgetResponse.Remaining = status.Remaining.String()
+			budgetsResponse = append(budgetsResponse, budgetResponse)
+		}
+		return ctx.JSON(http.StatusOK, budgetsResponse)
+	}
+}
+
+func (api *apiWeb) createBudgetHandler(ctx echo.Context) error {
+	request := createBudgetRequest{
+		UserID:      ctx.Param("user_id"),
+		HouseholdID: ctx.Param("household_id"),
+	}
+
+	if err := ctx.Bind(&request.Body); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
+	}
+
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	if budget, err := api.interactor.createBudget(request.UserID, &budget{
+		HouseholdID: request.HouseholdID,
+		CategoryID:  request.Body.CategoryID,
+		Amount:      request.Body.Amount,
+		Period:      request.Body.Period,
+	}); err != nil {
+		return householdErrorResponse(ctx, err)
+	} else {
+		return ctx.JSON(http.StatusCreated, newBudgetResponse(budget))
+	}
+}
+
+func (api *apiWeb) updateBudgetHandler(ctx echo.Context) error {
+	request := updateBudgetRequest{
+		UserID:      ctx.Param("user_id"),
+		HouseholdID: ctx.Param("household_id"),
+		BudgetID:    ctx.Param("budget_id"),
+	}
+
+	if err := ctx.Bind(&request.Body); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
+	}
+
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	if budget, err := api.interactor.updateBudget(request.UserID, &budget{
+		BudgetID:    request.BudgetID,
+		HouseholdID: request.HouseholdID,
+		CategoryID:  request.Body.CategoryID,
+		Amount:      request.Body.Amount,
+		Period:      request.Body.Period,
+	}); err != nil {
+		return householdErrorResponse(ctx, err)
+	} else if budget == nil {
+		return ctx.NoContent(http.StatusNotFound)
+	} else {
+		return ctx.JSON(http.StatusOK, newBudgetResponse(budget))
+	}
+}
+
+func (api *apiWeb) deleteBudgetHandler(ctx echo.Context) error {
+	request := deleteBudgetRequest{
+		UserID:      ctx.Param("user_id"),
+		HouseholdID: ctx.Param("household_id"),
+		BudgetID:    ctx.Param("budget_id"),
+	}
+
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	if deleted, err := api.interactor.deleteBudget(request.UserID, request.HouseholdID, request.BudgetID); err != nil {
+		return householdErrorResponse(ctx, err)
+	} else if !deleted {
+		return ctx.NoContent(http.StatusNotFound)
+	} else {
+		return ctx.NoContent(http.StatusOK)
+	}
+}
+
+func (api *apiWeb) getHouseholdReportHandler(ctx echo.Context) error {
+	request := getReportRequest{
+		transactionFilterRequest: newTransactionFilterRequest(ctx),
+		Period:                   ctx.QueryParam("period"),
+	}
+
+	if request.Period == "" {
+		request.Period = reportPeriodMonth
+	}
+
+	filter, err := request.filter()
+	if err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
+	}
+
+	if !isReportPeriod(request.Period) {
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: fmt.Sprintf("invalid period %s", request.Period), Cause: ""})
+	}
+
+	if filter.LockedWallets, err = api.lockedWallets(ctx); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	}
+
+	if report, err := api.interactor.getHouseholdReport(request.UserID, ctx.Param("household_id"), filter, request.Period); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else if report == nil {
+		return ctx.NoContent(http.StatusNotFound)
+	} else {
+		reportResponse := make([]*reportItemResponse, 0)
+		for _, item := range report {
+			reportResponse = append(reportResponse, &reportItemResponse{
+				Period:     item.Period,
+				CategoryID: item.CategoryID,
+				Category:   item.Category,
+				Count:      item.Count,
+				Expenses:   item.Expenses.String(),
+				Income:     item.Income.String(),
+				Total:      item.Total.String(),
+			})
+		}
+		return ctx.JSON(http.StatusOK, reportResponse)
+	}
+}
+
+type getCategoriesRequest struct {
+	UserID string `json:"user_id" validate:"ui"`
+}
+
+type getCategoryRequest struct {
+	UserID     string `json:"user_id" validate:"ui"`
+	CategoryID string `json:"category_id" validate:"ui"`
+}
+
+type createCategoriesRequest struct {
+	UserID string                `json:"user_id" validate:"ui"`
+	Body   []categoryItemRequest `json:"categories" validate:"min=1"`
+}
+
+type updateCategoryRequest struct {
+	UserID     string `json:"user_id" validate:"ui"`
+	CategoryID string `json:"category_id" validate:"ui"`
+	Body       categoryItemRequest
+}
+
+type categoryItemRequest struct {
+	Name        string `json:"name" validate:"nonzero"`
+	Description string `json:"description"`
+	ImageID     string `json:"image_id" validate:"ui"`
+}
+
+type deleteCategoryRequest struct {
+	UserID     string `json:"user_id" validate:"ui"`
+	CategoryID string `json:"category_id" validate:"ui"`
+}
+
+type categoryResponse struct {
+	CategoryID  string `json:"category_id"`
+	UserID      string `json:"user_id"`
+	HouseholdID string `json:"household_id,omitempty"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	ImageID     string `json:"image_id"`
+	UpdatedAt   string `json:"updated_at"`
+	CreatedAt   string `json:"created_at"`
+}
+
+func (api *apiWeb) registerRoutesForCategories() error {
+	api.client.AddRoute(http.MethodGet, "/api/1/users/:user_id/categories", api.getCategoriesHandler, api.auth)
+	api.client.AddRoute(http.MethodGet, "/api/1/users/:user_id/categories/:category_id", api.getCategoryHandler, api.auth)
+	api.client.AddRoute(http.MethodPost, "/api/1/users/:user_id/categories", api.createCategoriesHandler, api.auth)
+	api.client.AddRoute(http.MethodPut, "/api/1/users/:user_id/categories/:category_id", api.updateCategoryHandler, api.auth)
+	api.client.AddRoute(http.MethodDelete, "/api/1/users/:user_id/categories/:category_id", api.deleteCategoryHandler, api.auth)
+
+	return nil
+}
+
+func (api *apiWeb) getCategoriesHandler(ctx echo.Context) error {
+	request := getCategoriesRequest{
+		UserID: ctx.Param("user_id"),
+	}
+
+	if categories, err := api.interactor.getCategories(request.UserID); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else if categories == nil {
+		return ctx.NoContent(http.StatusNotFound)
+	} else {
+		categoriesResponse := make([]*categoryResponse, 0)
+
+		for _, category := range categories {
+			categoryResponse := &categoryResponse{
+				CategoryID:  category.CategoryID,
+				UserID:      category.UserID,
+				HouseholdID: category.HouseholdID,
+				Name:        category.Name,
+				Description: category.Description,
+				ImageID:     category.ImageID,
+				CreatedAt:   category.CreatedAt.String(),
+				UpdatedAt:   category.UpdatedAt.String(),
+			}
+			categoriesResponse = append(categoriesResponse, categoryResponse)
+		}
+		return ctx.JSON(http.StatusOK, categoriesResponse)
+	}
+}
+
+func (api *apiWeb) getCategoryHandler(ctx echo.Context) error {
+	request := getCategoryRequest{
+		UserID:     ctx.Param("user_id"),
+		CategoryID: ctx.Param("category_id"),
+	}
+
+	if category, err := api.interactor.getCategory(request.UserID, request.CategoryID); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else if category == nil {
+		return ctx.NoContent(http.StatusNotFound)
+	} else {
+		return ctx.JSON(http.StatusOK,
+			categoryResponse{
+				CategoryID:  category.CategoryID,
+				UserID:      category.UserID,
+				HouseholdID: category.HouseholdID,
+				Name:        category.Name,
+				Description: category.Description,
+				ImageID:     category.ImageID,
+				CreatedAt:   category.CreatedAt.String(),
+				UpdatedAt:   category.UpdatedAt.String(),
+			})
+	}
+}
+
+func (api *apiWeb) createCategoriesHandler(ctx echo.Context) error {
+	request := createCategoriesRequest{
+		UserID: ctx.Param("user_id"),
+	}
+	categories := make([]*category, 0)
+
+	if err := ctx.Bind(&request.Body); err != nil {
+		log.WithFields(map[string]interface{}{"error": err, "cause": ""}).
+			Error("error getting body")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
+	}
+
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	for _, item := range request.Body {
+		categories = append(categories, &category{
+			UserID:      request.UserID,
+			Name:        item.Name,
+			Description: item.Description,
+			ImageID:     item.ImageID,
+		})
+	}
+
+	if createdCategories, err := api.interactor.createCategories(categories); err != nil {
+		if authErr, ok := err.(*authorizationError); ok {
+			return ctx.JSON(http.StatusForbidden, errorResponse{Code: http.StatusForbidden, Message: authErr.Error(), Cause: ""})
+		}
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else {
+		categoriesResponse := make([]*categoryResponse, 0)
+
+		for _, createdCategory := range createdCategories {
+			categoryResponse := &categoryResponse{
+				CategoryID:  createdCategory.CategoryID,
+				UserID:      createdCategory.UserID,
+				HouseholdID: createdCategory.HouseholdID,
+				Name:        createdCategory.Name,
+				Description: createdCategory.Description,
+				ImageID:     createdCategory.ImageID,
+				CreatedAt:   createdCategory.CreatedAt.String(),
+				UpdatedAt:   createdCategory.UpdatedAt.String(),
+			}
+			categoriesResponse = append(categoriesResponse, categoryResponse)
+		}
+		return ctx.JSON(http.StatusCreated, categoriesResponse)
+	}
+}
+
+func (api *apiWeb) updateCategoryHandler(ctx echo.Context) error {
+	request := updateCategoryRequest{
+		UserID:     ctx.Param("user_id"),
+		CategoryID: ctx.Param("category_id"),
+	}
+	if err := ctx.Bind(&request.Body); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
+	}
+
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	if updatedCategory, err := api.interactor.updateCategory(
+		&category{
+			UserID:      request.UserID,
+			CategoryID:  request.CategoryID,
+			Name:        request.Body.Name,
+			Description: request.Body.Description,
+		}); err != nil {
+		if authErr, ok := err.(*authorizationError); ok {
+			return ctx.JSON(http.StatusForbidden, errorResponse{Code: http.StatusForbidden, Message: authErr.Error(), Cause: ""})
+		}
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else if updatedCategory == nil {
+		return ctx.NoContent(http.StatusNotFound)
+	} else {
+		return ctx.JSON(http.StatusCreated, categoryResponse{
+			CategoryID:  updatedCategory.CategoryID,
+			UserID:      updatedCategory.UserID,
+			HouseholdID: updatedCategory.HouseholdID,
+			Name:        updatedCategory.Name,
+			Description: updatedCategory.Description,
+			ImageID:     updatedCategory.ImageID,
+			CreatedAt:   updatedCategory.CreatedAt.String(),
+			UpdatedAt:   updatedCategory.UpdatedAt.String(),
+		})
+	}
+}
+
+func (api *apiWeb) deleteCategoryHandler(ctx echo.Context) error {
+	request := deleteCategoryRequest{
+		UserID:     ctx.Param("user_id"),
+		CategoryID: ctx.Param("category_id"),
+	}
+
+	if err := api.interactor.deleteCategory(request.UserID, request.CategoryID); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else {
+		return ctx.NoContent(http.StatusOK)
+	}
+}
+
+type getImagesRequest struct {
+	UserID string `json:"user_id" validate:"ui"`
+}
+
+type getImageRequest struct {
+	UserID  string `json:"user_id" validate:"ui"`
+	ImageID string `json:"image_id" validate:"ui"`
+}
+
+type getImageRawRequest struct {
+	UserID  string `json:"user_id" validate:"ui"`
+	ImageID string `json:"image_id" validate:"ui"`
+}
+
+type createImagesRequest struct {
+	UserID string `json:"user_id" validate:"ui"`
+	Body   struct {
+		Name        string `json:"name" validate:"nonzero"`
+		Description string `json:"description" validate:"nonzero"`
+		Url         string `json:"url"`
+		ImageKey    string `json:"image_key"`
+	} `json:"images" validate:"min=1"`
+}
+
+type updateImageRequest struct {
+	ImageID string `json:"image_id" validate:"ui"`
+	UserID  string `json:"user_id" validate:"ui"`
+	Body    struct {
+		Name        string `json:"name" validate:"nonzero"`
+		Description string `json:"description"`
+		Url         string `json:"url"`
+		ImageKey    string `json:"image_key"`
+	} `json:"body"`
+}
+
+type getThumbnailRequest struct {
+	UserID  string `json:"user_id" validate:"ui"`
+	ImageID string `json:"image_id" validate:"ui"`
+	Name    string `json:"name" validate:"nonzero"`
+}
+
+type getResizedImageRequest struct {
+	UserID  string `json:"user_id" validate:"ui"`
+	ImageID string `json:"image_id" validate:"ui"`
+	Width   int    `json:"width"`
+	Height  int    `json:"height"`
+	Fit     string `json:"fit"`
+}
+
+type deleteImageRawRequest struct {
+	UserID  string `json:"user_id" validate:"ui"`
+	ImageID string `json:"image_id" validate:"ui"`
+}
+
+type imageResponse struct {
+	ImageID     string            `json:"image_id"`
+	UserID      string            `json:"user_id"`
+	Name        string            `json:"name,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Url         string            `json:"url,omitempty"`
+	FileName    string            `json:"file_name,omitempty"`
+	Format      string            `json:"format,omitempty"`
+	RawUrl      string            `json:"raw_url,omitempty"`
+	Thumbnails  map[string]string `json:"thumbnails,omitempty"`
+	UpdatedAt   string            `json:"updated_at,omitempty"`
+	CreatedAt   string            `json:"created_at,omitempty"`
+}
+
+func (api *apiWeb) registerRoutesForImages() error {
+	api.client.AddRoute(http.MethodGet, "/api/1/users/:user_id/images", api.getImagesHandler, api.auth)
+	api.client.AddRoute(http.MethodGet, "/api/1/users/:user_id/images/:image_id", api.getImageHandler, api.auth)
+	api.client.AddRoute(http.MethodGet, "/api/1/users/:user_id/images/:image_id/raw", api.getImageRawHandler, api.auth)
+	api.client.AddRoute(http.MethodGet, "/api/1/users/:user_id/images/:image_id/thumbnails/:name", api.getThumbnailHandler, api.auth)
+	api.client.AddRoute(http.MethodGet, "/api/1/users/:user_id/images/:image_id/resize", api.getResizedImageHandler, api.auth)
+	api.client.AddRoute(http.MethodPost, "/api/1/users/:user_id/images", api.createImageHandler, api.auth)
+	api.client.AddRoute(http.MethodPut, "/api/1/users/:user_id/images/:image_id", api.updateImageHandler, api.auth)
+	api.client.AddRoute(http.MethodDelete, "/api/1/users/:user_id/images/:image_id", api.deleteImageHandler, api.auth)
+
+	return nil
+}
+
+func (api *apiWeb) getImagesHandler(ctx echo.Context) error {
+	request := getImagesRequest{
+		UserID: ctx.Param("user_id"),
+	}
+
+	if images, err := api.interactor.getImages(request.UserID); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else if images == nil {
+		return ctx.NoContent(http.StatusNotFound)
+	} else {
+		imagesResponse := make([]*imageResponse, 0)
+
+		for _, image := range images {
+			imageResponse := &imageResponse{
+				UserID:      image.UserID,
+				ImageID:     image.ImageID,
+				Name:        image.Name,
+				Description: image.Description,
+				Url:         image.Url,
+				FileName:    image.FileName,
+				Format:      image.Format,
+				RawUrl:      imageRawUrl(image.UserID, image.ImageID),
+				Thumbnails:  api.imageThumbnailUrls(image.UserID, image.ImageID),
+				CreatedAt:   image.CreatedAt.String(),
+				UpdatedAt:   image.UpdatedAt.String(),
+			}
+			imagesResponse = append(imagesResponse, imageResponse)
+		}
+		return ctx.JSON(http.StatusOK, imagesResponse)
+	}
+}
+
+func (api *apiWeb) getImageHandler(ctx echo.Context) error {
+	request := getImageRawRequest{
+		UserID:  ctx.Param("user_id"),
+		ImageID: ctx.Param("image_id"),
+	}
+
+	if image, err := api.interactor.getImage(request.UserID, request.ImageID); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else if image == nil {
+		return ctx.NoContent(http.StatusNotFound)
+	} else {
+		return ctx.JSON(http.StatusOK,
+			imageResponse{
+				UserID:      image.UserID,
+				ImageID:     image.ImageID,
+				Name:        image.Name,
+				Description: image.Description,
+				Url:         image.Url,
+				FileName:    image.FileName,
+				Format:      image.Format,
+				RawUrl:      imageRawUrl(image.UserID, image.ImageID),
+				Thumbnails:  api.imageThumbnailUrls(image.UserID, image.ImageID),
+				CreatedAt:   image.CreatedAt.String(),
+				UpdatedAt:   image.UpdatedAt.String(),
+			})
+	}
+}
+
+func (api *apiWeb) getImageRawHandler(ctx echo.Context) error {
+	request := getImageRawRequest{
+		UserID:  ctx.Param("user_id"),
+		ImageID: ctx.Param("image_id"),
+	}
+
+	if image, err := api.interactor.getImageRaw(request.UserID, request.ImageID); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else if image == nil || image.RawImage == nil {
+		return ctx.NoContent(http.StatusNotFound)
+	} else {
+		return serveImage(ctx, image)
+	}
+}
+
+func (api *apiWeb) getThumbnailHandler(ctx echo.Context) error {
+	request := getThumbnailRequest{
+		UserID:  ctx.Param("user_id"),
+		ImageID: ctx.Param("image_id"),
+		Name:    ctx.Param("name"),
+	}
+
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	if _, ok := api.interactor.thumbnailConfig(request.Name); !ok {
+		return ctx.NoContent(http.StatusNotFound)
+	}
+
+	if image, err := api.interactor.getThumbnail(request.UserID, request.ImageID, request.Name); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else if image == nil || image.RawImage == nil {
+		return ctx.NoContent(http.StatusNotFound)
+	} else {
+		return serveImage(ctx, image)
+	}
+}
+
+func (api *apiWeb) getResizedImageHandler(ctx echo.Context) error {
+	request := getResizedImageRequest{
+		UserID:  ctx.Param("user_id"),
+		ImageID: ctx.Param("image_id"),
+		Fit:     ctx.QueryParam("fit"),
+	}
+
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	maxResize := api.interactor.maxResize()
+
+	for _, item := range []struct {
+		name  string
+		value *int
+	}{
+		{"width", &request.Width},
+		{"height", &request.Height},
+	} {
+		if param := ctx.QueryParam(item.name); param != "" {
+			value, err := strconv.Atoi(param)
+			if err != nil || value < 0 || value > maxResize {
+				return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: fmt.Sprintf("invalid %s %s, it must be between 0 and %d", item.name, param, maxResize), Cause: ""})
+			}
+			*item.value = value
+		}
+	}
+
+	if request.Width == 0 && request.Height == 0 {
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: "the width or the height is required", Cause: ""})
+	}
+
+	if request.Fit == "" {
+		request.Fit = imageFitContain
+	}
+	if !isImageFit(request.Fit) {
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: fmt.Sprintf("invalid fit %s", request.Fit), Cause: ""})
+	}
+
+	if image, err := api.interactor.getResizedImage(request.UserID, request.ImageID, request.Width, request.Height, request.Fit); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else if image == nil || image.RawImage == nil {
+		return ctx.NoContent(http.StatusNotFound)
+	} else {
+		return serveImage(ctx, image)
+	}
+}
+
+// serveImage writes the image content, serve content handles the conditional and range requests
+func serveImage(ctx echo.Context, image *image) error {
+	header := ctx.Response().Header()
+	header.Set(echo.HeaderContentType, imageContentType(image.Format, image.RawImage))
+	header.Set("ETag", imageETag(image.RawImage))
+	header.Set("Cache-Control", imageCacheControl)
+	header.Set(echo.HeaderContentDisposition, fmt.Sprintf("inline; filename=%q", image.FileName))
+
+	http.ServeContent(ctx.Response(), ctx.Request(), image.FileName, image.UpdatedAt, bytes.NewReader(image.RawImage))
+	return nil
+}
+
+// imageRawUrl ...
+func imageRawUrl(userID string, imageID string) string {
+	return fmt.Sprintf("/api/1/users/%s/images/%s/raw", userID, imageID)
+}
+
+// imageThumbnailUrls returns the url of each configured thumbnail
+func (api *apiWeb) imageThumbnailUrls(userID string, imageID string) map[string]string {
+	if len(api.interactor.config.Image.Thumbnails) == 0 {
+		return nil
+	}
+
+	urls := make(map[string]string)
+	for _, thumbnail := range api.interactor.config.Image.Thumbnails {
+		urls[thumbnail.Name] = fmt.Sprintf("/api/1/users/%s/images/%s/thumbnails/%s", userID, imageID, thumbnail.Name)
+	}
+	return urls
+}
+
+func (api *apiWeb) createImageHandler(ctx echo.Context) error {
+	request := createImagesRequest{
+		UserID: ctx.Param("user_id"),
+	}
+
+	// form values
+	request.Body.Name = ctx.FormValue("name")
+	request.Body.Description = ctx.FormValue("description")
+	request.Body.Url = ctx.FormValue("url")
+	request.Body.ImageKey = "image"
+
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	if downloads, err := download(request.Body.ImageKey, ctx); err != nil {
+		log.WithFields(map[string]interface{}{"error": err, "cause": ""}).
+			Error("error uploading images")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
+	} else {
+		if len(downloads) == 0 {
+			log.Errorf("missing image %s", request.Body.ImageKey)
+			return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: fmt.Sprintf("missing image %s", request.Body.ImageKey), Cause: ""})
+		}
+
+		image := &image{
+			UserID:      request.UserID,
+			Name:        request.Body.Name,
+			Description: request.Body.Description,
+			Url:         request.Body.Url,
+			FileName:    downloads[0].FileName,
+			RawImage:    downloads[0].Data.Bytes(),
+		}
+
+		if createdImage, err := api.interactor.createImage(image); err != nil {
+			if imageErr, ok := err.(*imageError); ok {
+				return ctx.JSON(imageErr.status, errorResponse{Code: imageErr.status, Message: imageErr.Error(), Cause: ""})
+			}
+			return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+		} else {
+			return ctx.JSON(http.StatusCreated, &imageResponse{
+				ImageID:     createdImage.ImageID,
+				UserID:      createdImage.UserID,
+				Name:        createdImage.Name,
+				Description: createdImage.Description,
+				Url:         createdImage.Url,
+				FileName:    createdImage.FileName,
+				Format:      createdImage.Format,
+				RawUrl:      imageRawUrl(createdImage.UserID, createdImage.ImageID),
+				Thumbnails:  api.imageThumbnailUrls(createdImage.UserID, createdImage.ImageID),
+				CreatedAt:   createdImage.CreatedAt.String(),
+				UpdatedAt:   createdImage.UpdatedAt.String(),
+			})
+		}
+	}
+}
+
+func (api *apiWeb) updateImageHandler(ctx echo.Context) error {
+	request := updateImageRequest{
+		UserID:  ctx.Param("user_id"),
+		ImageID: ctx.Param("image_id"),
+	}
+
+	// form values
+	request.Body.Name = ctx.FormValue("name")
+	request.Body.Description = ctx.FormValue("description")
+	request.Body.Url = ctx.FormValue("url")
+	request.Body.ImageKey = ctx.FormValue("image_key")
+
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	if downloads, err := download(request.Body.ImageKey, ctx); err != nil {
+		log.WithFields(map[string]interface{}{"error": err, "cause": ""}).
+			Error("error uploading images")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
+	} else {
+		if len(downloads) == 0 {
+			log.Errorf("missing image %s", request.Body.ImageKey)
+			return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: fmt.Sprintf("missing image %s", request.Body.ImageKey), Cause: ""})
+		}
+
+		image := &image{
+			ImageID:     request.ImageID,
+			UserID:      request.UserID,
+			Name:        request.Body.Name,
+			Description: request.Body.Description,
+			Url:         request.Body.Url,
+			FileName:    downloads[0].FileName,
+			RawImage:    downloads[0].Data.Bytes(),
+		}
+
+		if updatedImage, err := api.interactor.updateImage(image); err != nil {
+			if imageErr, ok := err.(*imageError); ok {
+				return ctx.JSON(imageErr.status, errorResponse{Code: imageErr.status, Message: imageErr.Error(), Cause: ""})
+			}
+			return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+		} else if updatedImage == nil {
+			return ctx.NoContent(http.StatusNotFound)
+		} else {
+			return ctx.JSON(http.StatusCreated, imageResponse{
+				ImageID:     updatedImage.ImageID,
+				UserID:      updatedImage.UserID,
+				Name:        updatedImage.Name,
+				Description: updatedImage.Description,
+				Url:         updatedImage.Url,
+				FileName:    updatedImage.FileName,
+				Format:      updatedImage.Format,
+				RawUrl:      imageRawUrl(updatedImage.UserID, updatedImage.ImageID),
+				Thumbnails:  api.imageThumbnailUrls(updatedImage.UserID, updatedImage.ImageID),
+				CreatedAt:   updatedImage.CreatedAt.String(),
+				UpdatedAt:   updatedImage.UpdatedAt.String(),
+			})
+		}
+	}
+}
+
+func (api *apiWeb) deleteImageHandler(ctx echo.Context) error {
+	request := deleteImageRawRequest{
+		UserID:  ctx.Param("user_id"),
+		ImageID: ctx.Param("image_id"),
+	}
+
+	if err := api.interactor.deleteImage(request.UserID, request.ImageID); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else {
+		return ctx.NoContent(http.StatusOK)
+	}
+}
+
+type getTransactionsRequest struct {
+	UserID   string `json:"user_id" validate:"ui"`
+	WalletID string `json:"wallet_id" validate:"ui"`
+}
+
+type getTransactionRequest struct {
+	UserID        string `json:"user_id" validate:"ui"`
+	WalletID      string `json:"wallet_id" validate:"ui"`
+	TransactionID string `json:"transaction_id" validate:"ui"`
+}
+
+type createTransactionsRequest struct {
+	UserID   string                   `json:"user_id" validate:"ui"`
+	WalletID string                   `json:"wallet_id" validate:"ui"`
+	Body     []transactionItemRequest `json:"transactions" validate:"min=1"`
+}
+
+type updateTransactionRequest struct {
+	UserID        string `json:"user_id" validate:"ui"`
+	WalletID      string `json:"wallet_id" validate:"ui"`
+	TransactionID string `json:"transaction_id" validate:"ui"`
+	Body          transactionItemRequest
+}
+
+type transactionItemRequest struct {
+	CategoryID  string   `json:"category_id" validate:"ui"`
+	Price       string   `json:"price"`
+	Description string   `json:"description"`
+	Date        string   `json:"date" validate:"nonzero"`
+	Latitude    *float64 `json:"latitude"`
+	Longitude   *float64 `json:"longitude"`
+	GroupID     string   `json:"group_id"`
+}
+
+type deleteTransactionRequest struct {
+	UserID        string `json:"user_id" validate:"ui"`
+	WalletID      string `json:"wallet_id" validate:"ui"`
+	TransactionID string `json:"transaction_id" validate:"ui"`
+}
+
+type transactionResponse struct {
+	UserID        string   `json:"user_id"`
+	WalletID      string   `json:"wallet_id"`
+	TransactionID string   `json:"transaction_id"`
+	CategoryID    string   `json:"category_id"`
+	Price         string   `json:"price"`
+	Description   string   `json:"description,omitempty"`
+	Date          string   `json:"date"`
+	Latitude      *float64 `json:"latitude,omitempty"`
+	Longitude     *float64 `json:"longitude,omitempty"`
+	GroupID       string   `json:"group_id,omitempty"`
+	CreatedBy     string   `json:"created_by"`
+	UpdatedBy     string   `json:"updated_by"`
+	UpdatedAt     string   `json:"updated_at"`
+	CreatedAt     string   `json:"created_at"`
+}
+
+func (api *apiWeb) registerRoutesForTransactions() error {
+	api.client.AddRoute(http.MethodGet, "/api/1/users/:user_id/wallets/:wallet_id/transactions", api.getTransactionsHandler, api.auth, api.grant)
+	api.client.AddRoute(http.MethodGet, "/api/1/users/:user_id/wallets/:wallet_id/transactions/:transaction_id", api.getTransactionHandler, api.auth, api.grant)
+	api.client.AddRoute(http.MethodPost, "/api/1/users/:user_id/wallets/:wallet_id/transactions", api.createTransactionsHandler, api.auth, api.requireWalletRole(walletRoleEditor), api.grant)
+	api.client.AddRoute(http.MethodPut, "/api/1/users/:user_id/wallets/:wallet_id/transactions/:transaction_id", api.updateTransactionHandler, api.auth, api.requireWalletRole(walletRoleEditor), api.grant)
+	api.client.AddRoute(http.MethodDelete, "/api/1/users/:user_id/wallets/:wallet_id/transactions/:transaction_id", api.deleteTransactionHandler, api.auth, api.requireWalletRole(walletRoleEditor), api.grant)
+
+	return nil
+}
+
+func (api *apiWeb) getTransactionsHandler(ctx echo.Context) error {
+	request := getTransactionsRequest{
+		UserID:   ctx.Param("user_id"),
+		WalletID: ctx.Param("wallet_id"),
+	}
+
+	if transactions, err := api.interactor.getFilteredTransactions(request.UserID, &transactionFilter{WalletID: request.WalletID}); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else if transactions == nil {
+		return ctx.NoContent(http.StatusNotFound)
+	} else {
+		transactionsResponse := make([]*transactionResponse, 0)
+
+		for _, transaction := range transactions {
+			transactionResponse := &transactionResponse{
+				TransactionID: transaction.TransactionID,
+				UserID:        transaction.UserID,
+				WalletID:      transaction.WalletID,
+				CategoryID:    transaction.CategoryID,
+				Price:         transaction.Price.String(),
+				Description:   transaction.Description,
+				Date:          transaction.Date.String(),
+				Latitude:      transaction.Latitude,
+				Longitude:     transaction.Longitude,
+				GroupID:       transaction.GroupID,
+				CreatedBy:     transaction.CreatedBy,
+				UpdatedBy:     transaction.UpdatedBy,
+				CreatedAt:     transaction.CreatedAt.String(),
+				UpdatedAt:     transaction.UpdatedAt.String(),
+			}
+			transactionsResponse = append(transactionsResponse, transactionResponse)
+		}
+		return ctx.JSON(http.StatusOK, transactionsResponse)
+	}
+}
+
+func (api *apiWeb) getTransactionHandler(ctx echo.Context) error {
+	request := getTransactionRequest{
+		UserID:        ctx.Param("user_id"),
+		WalletID:      ctx.Param("wallet_id"),
+		TransactionID: ctx.Param("transaction_id"),
+	}
+
+	if transaction, err := api.interactor.getTransaction(request.UserID, request.WalletID, request.TransactionID); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else if transaction == nil {
+		return ctx.NoContent(http.StatusNotFound)
+	} else {
+		return ctx.JSON(http.StatusOK,
+			transactionResponse{
+				TransactionID: transaction.TransactionID,
+				UserID:        transaction.UserID,
+				WalletID:      transaction.WalletID,
+				CategoryID:    transaction.CategoryID,
+				Price:         transaction.Price.String(),
+				Description:   transaction.Description,
+				Date:          transaction.Date.String(),
+				Latitude:      transaction.Latitude,
+				Longitude:     transaction.Longitude,
+				GroupID:       transaction.GroupID,
+				CreatedBy:     transaction.CreatedBy,
+				UpdatedBy:     transaction.UpdatedBy,
+				CreatedAt:     transaction.CreatedAt.String(),
+				UpdatedAt:     transaction.UpdatedAt.String(),
+			})
+	}
+}
+
+func (api *apiWeb) createTransactionsHandler(ctx echo.Context) error {
+	request := createTransactionsRequest{
+		UserID:   ctx.Param("user_id"),
+		WalletID: ctx.Param("wallet_id"),
+	}
+	transactions := make([]*transaction, 0)
+
+	if err := ctx.Bind(&request.Body); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
+	}
+
+	for _, item := range request.Body {
+		if err := validator.Validate(item); err != nil {
+			log.WithFields(map[string]interface{}{"error": err, "cause": ""}).
+				Error("error when validating body request")
+			return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+		}
+	}
+
+	for _, item := range request.Body {
+		date, err := time.Parse(time.RFC3339, item.Date)
+		if err != nil {
+			log.WithFields(map[string]interface{}{"error": err, "cause": ""}).
+				Error("error getting date")
+			return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
+		}
+
+		if !validLocation(item.Latitude, item.Longitude) {
+			return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: "invalid location, the latitude and the longitude are required together", Cause: ""})
+		}
+
+		price, err := decimal.NewFromString(item.Price)
+
+		transactions = append(transactions, &transaction{
+			UserID:      request.UserID,
+			WalletID:    request.WalletID,
+			CategoryID:  item.CategoryID,
+			Price:       price,
+			Description: item.Description,
+			Date:        date,
+			Latitude:    item.Latitude,
+			Longitude:   item.Longitude,
+			GroupID:     item.GroupID,
+		})
+	}
+
+	if createdTransactions, err := api.interactor.createTransactions(transactions); err != nil {
+		if authErr, ok := err.(*authorizationError); ok {
+			return ctx.JSON(http.StatusForbidden, errorResponse{Code: http.StatusForbidden, Message: authErr.Error(), Cause: ""})
+		}
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else {
+		transactionsResponse := make([]*transactionResponse, 0)
+
+		for _, createdTransaction := range createdTransactions {
+			transactionResponse := &transactionResponse{
+				TransactionID: createdTransaction.TransactionID,
+				UserID:        createdTransaction.UserID,
+				WalletID:      createdTransaction.WalletID,
+				CategoryID:    createdTransaction.CategoryID,
+				Price:         createdTransaction.Price.String(),
+				Description:   createdTransaction.Description,
+				Date:          createdTransaction.Date.String(),
+				Latitude:      createdTransaction.Latitude,
+				Longitude:     createdTransaction.Longitude,
+				GroupID:       createdTransaction.GroupID,
+				CreatedBy:     createdTransaction.CreatedBy,
+				UpdatedBy:     createdTransaction.UpdatedBy,
+				CreatedAt:     createdTransaction.CreatedAt.String(),
+				UpdatedAt:     createdTransaction.UpdatedAt.String(),
+			}
+			transactionsResponse = append(transactionsResponse, transactionResponse)
+		}
+		return ctx.JSON(http.StatusCreated, transactionsResponse)
+	}
+}
+
+func (api *apiWeb) updateTransactionHandler(ctx echo.Context) error {
+	request := updateTransactionRequest{
+		UserID:        ctx.Param("user_id"),
+		WalletID:      ctx.Param("wallet_id"),
+		TransactionID: ctx.Param("transaction_id"),
+	}
+	if err := ctx.Bind(&request.Body); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
+	}
+
+	if err := validator.Validate(request.Body); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	price, err := decimal.NewFromString(request.Body.Price)
+	if err != nil {
+		log.WithFields(map[string]interface{}{"error": err, "cause": ""}).
+			Error("error getting price")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
+	}
+
+	date, err := time.Parse(time.RFC3339, request.Body.Date)
+	if err != nil {
+		log.WithFields(map[string]interface{}{"error": err, "cause": ""}).
+			Error("error getting date")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
+	}
+
+	if !validLocation(request.Body.Latitude, request.Body.Longitude) {
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: "invalid location, the latitude and the longitude are required together", Cause: ""})
+	}
+
+	if updatedTransaction, err := api.interactor.updateTransaction(
+		&transaction{
+			UserID:        request.UserID,
+			WalletID:      request.WalletID,
+			TransactionID: request.TransactionID,
+			CategoryID:    request.Body.CategoryID,
+			Price:         price,
+			Description:   request.Body.Description,
+			Date:          date,
+			Latitude:      request.Body.Latitude,
+			Longitude:     request.Body.Longitude,
+			GroupID:       request.Body.GroupID,
+		}); err != nil {
+		if authErr, ok := err.(*authorizationError); ok {
+			return ctx.JSON(http.StatusForbidden, errorResponse{Code: http.StatusForbidden, Message: authErr.Error(), Cause: ""})
+		}
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else if updatedTransaction == nil {
+		return ctx.NoContent(http.StatusNotFound)
+	} else {
+		return ctx.JSON(http.StatusCreated, transactionResponse{
+			TransactionID: updatedTransaction.TransactionID,
+			UserID:        updatedTransaction.UserID,
+			WalletID:      updatedTransaction.WalletID,
+			CategoryID:    updatedTransaction.CategoryID,
+			Price:         updatedTransaction.Price.String(),
+			Description:   updatedTransaction.Description,
+			Date:          updatedTransaction.Date.String(),
+			Latitude:      updatedTransaction.Latitude,
+			Longitude:     updatedTransaction.Longitude,
+			GroupID:       updatedTransaction.GroupID,
+			CreatedBy:     updatedTransaction.CreatedBy,
+			UpdatedBy:     updatedTransaction.UpdatedBy,
+			CreatedAt:     updatedTransaction.CreatedAt.String(),
+			UpdatedAt:     updatedTransaction.UpdatedAt.String(),
+		})
+	}
+}
+
+func (api *apiWeb) deleteTransactionHandler(ctx echo.Context) error {
+	request := deleteTransactionRequest{
+		UserID:        ctx.Param("user_id"),
+		WalletID:      ctx.Param("wallet_id"),
+		TransactionID: ctx.Param("transaction_id"),
+	}
+
+	if err := api.interactor.deleteTransaction(request.UserID, request.WalletID, request.TransactionID); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else {
+		return ctx.NoContent(http.StatusOK)
+	}
+}
+
+type getAttachmentsRequest struct {
+	UserID        string `json:"user_id" validate:"ui"`
+	WalletID      string `json:"wallet_id" validate:"ui"`
+	TransactionID string `json:"transaction_id" validate:"ui"`
+}
+
+type getAttachmentRequest struct {
+	UserID        string `json:"user_id" validate:"ui"`
+	WalletID      string `json:"wallet_id" validate:"ui"`
+	TransactionID string `json:"transaction_id" validate:"ui"`
+	AttachmentID  string `json:"attachment_id" validate:"ui"`
+}
+
+type createAttachmentsRequest struct {
+	UserID        string `json:"user_id" validate:"ui"`
+	WalletID      string `json:"wallet_id" validate:"ui"`
+	TransactionID string `json:"transaction_id" validate:"ui"`
+	AttachmentKey string `json:"attachment_key"`
+}
+
+type deleteAttachmentRequest struct {
+	UserID        string `json:"user_id" validate:"ui"`
+	WalletID      string `json:"wallet_id" validate:"ui"`
+	TransactionID string `json:"transaction_id" validate:"ui"`
+	AttachmentID  string `json:"attachment_id" validate:"ui"`
+}
+
+type attachmentResponse struct {
+	AttachmentID  string `json:"attachment_id"`
+	TransactionID string `json:"transaction_id"`
+	UserID        string `json:"user_id"`
+	FileName      string `json:"file_name"`
+	ContentType   string `json:"content_type"`
+	Size          int    `json:"size"`
+	Url           string `json:"url"`
+	UpdatedAt     string `json:"updated_at"`
+	CreatedAt     string `json:"created_at"`
+}
+
+func (api *apiWeb) registerRoutesForAttachments() error {
+	api.client.AddRoute(http.MethodGet, "/api/1/users/:user_id/wallets/:wallet_id/transactions/:transaction_id/attachments", api.getAttachmentsHandler, api.auth, api.grant)
+	api.client.AddRoute(http.MethodGet, "/api/1/users/:user_id/wallets/:wallet_id/transactions/:transaction_id/attachments/:attachment_id", api.getAttachmentHandler, api.auth, api.grant)
+	api.client.AddRoute(http.MethodPost, "/api/1/users/:user_id/wallets/:wallet_id/transactions/:transaction_id/attachments", api.createAttachmentsHandler, api.auth, api.requireWalletRole(walletRoleEditor), api.grant)
+	api.client.AddRoute(http.MethodDelete, "/api/1/users/:user_id/wallets/:wallet_id/transactions/:transaction_id/attachments/:attachment_id", api.deleteAttachmentHandler, api.auth, api.requireWalletRole(walletRoleEditor), api.grant)
+
+	return nil
+}
+
+func (api *apiWeb) getAttachmentsHandler(ctx echo.Context) error {
+	request := getAttachmentsRequest{
+		UserID:        ctx.Param("user_id"),
+		WalletID:      ctx.Param("wallet_id"),
+		TransactionID: ctx.Param("transaction_id"),
+	}
+
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	if attachments, err := api.interactor.getAttachments(request.UserID, request.WalletID, request.TransactionID); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else if attachments == nil {
+		return ctx.NoContent(http.StatusNotFound)
+	} else {
+		attachmentsResponse := make([]*attachmentResponse, 0)
+		for _, attachment := range attachments {
+			attachmentsResponse = append(attachmentsResponse, newAttachmentResponse(request.WalletID, attachment))
+		}
+		return ctx.JSON(http.StatusOK, attachmentsResponse)
+	}
+}
+
+func (api *apiWeb) getAttachmentHandler(ctx echo.Context) error {
+	request := getAttachmentRequest{
+		UserID:        ctx.Param("user_id"),
+		WalletID:      ctx.Param("wallet_id"),
+		TransactionID: ctx.Param("transaction_id"),
+		AttachmentID:  ctx.Param("attachment_id"),
+	}
+
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	if attachment, err := api.interactor.getAttachment(request.UserID, request.WalletID, request.TransactionID, request.AttachmentID); err != nil {
 		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
-	} else if rawImage == nil {
+	} else if attachment == nil || attachment.Data == nil {
 		return ctx.NoContent(http.StatusNotFound)
 	} else {
-		return ctx.JSON(http.StatusCreated, &imageResponse{
-			ImageID:  request.ImageID,
-			UserID:   request.UserID,
-			RawImage: rawImage,
-		})
+		header := ctx.Response().Header()
+		header.Set(echo.HeaderContentType, attachment.ContentType)
+		header.Set("ETag", imageETag(attachment.Data))
+		header.Set("Cache-Control", imageCacheControl)
+		header.Set(echo.HeaderContentDisposition, fmt.Sprintf("inline; filename=%q", attachment.FileName))
+
+		http.ServeContent(ctx.Response(), ctx.Request(), attachment.FileName, attachment.UpdatedAt, bytes.NewReader(attachment.Data))
+		return nil
 	}
 }
 
-func (api *apiWeb) createImageHandler(ctx echo.Context) error {
-	request := createImagesRequest{
-		UserID: ctx.Param("user_id"),
+func (api *apiWeb) createAttachmentsHandler(ctx echo.Context) error {
+	request := createAttachmentsRequest{
+		UserID:        ctx.Param("user_id"),
+		WalletID:      ctx.Param("wallet_id"),
+		TransactionID: ctx.Param("transaction_id"),
+		AttachmentKey: "attachment",
 	}
 
-	// form values
-	request.Body.Name = ctx.FormValue("name")
-	request.Body.Description = ctx.FormValue("description")
-	request.Body.Url = ctx.FormValue("url")
-	request.Body.ImageKey = "image"
-
 	if err := validator.Validate(request); err != nil {
 		log.WithFields(map[string]interface{}{"error": err}).
 			Error("error when validating body request")
 		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
 	}
 
-	if downloads, err := download(request.Body.ImageKey, ctx); err != nil {
+	downloads, err := download(request.AttachmentKey, ctx)
+	if err != nil {
 		log.WithFields(map[string]interface{}{"error": err, "cause": ""}).
-			Error("error uploading images")
+			Error("error uploading attachments")
 		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
+	}
+	if len(downloads) == 0 {
+		log.Errorf("missing attachment %s", request.AttachmentKey)
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: fmt.Sprintf("missing attachment %s", request.AttachmentKey), Cause: ""})
+	}
+
+	attachments := make([]*attachment, 0)
+	for _, download := range downloads {
+		attachments = append(attachments, &attachment{
+			FileName: download.FileName,
+			Data:     download.Data.Bytes(),
+		})
+	}
+
+	if createdAttachments, err := api.interactor.createAttachments(request.UserID, request.WalletID, request.TransactionID, attachments); err != nil {
+		if imageErr, ok := err.(*imageError); ok {
+			return ctx.JSON(imageErr.status, errorResponse{Code: imageErr.status, Message: imageErr.Error(), Cause: ""})
+		}
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else if createdAttachments == nil {
+		return ctx.NoContent(http.StatusNotFound)
 	} else {
-		if len(downloads) == 0 {
-			log.Error(err)
-			return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
+		attachmentsResponse := make([]*attachmentResponse, 0)
+		for _, attachment := range createdAttachments {
+			attachmentsResponse = append(attachmentsResponse, newAttachmentResponse(request.WalletID, attachment))
 		}
+		return ctx.JSON(http.StatusCreated, attachmentsResponse)
+	}
+}
 
-		format := strings.Split(downloads[0].FileName, ".")[1]
+func (api *apiWeb) deleteAttachmentHandler(ctx echo.Context) error {
+	request := deleteAttachmentRequest{
+		UserID:        ctx.Param("user_id"),
+		WalletID:      ctx.Param("wallet_id"),
+		TransactionID: ctx.Param("transaction_id"),
+		AttachmentID:  ctx.Param("attachment_id"),
+	}
 
-		image := &image{
-			UserID:      request.UserID,
-			Name:        request.Body.Name,
-			Description: request.Body.Description,
-			Url:         request.Body.Url,
-			FileName:    downloads[0].FileName,
-			Format:      format,
-			RawImage:    downloads[0].Data.Bytes(),
-		}
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
 
-		if createdImage, err := api.interactor.createImage(image); err != nil {
-			return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
-		} else {
-			return ctx.JSON(http.StatusCreated, &imageResponse{
-				ImageID:     createdImage.ImageID,
-				UserID:      createdImage.UserID,
-				Name:        createdImage.Name,
-				Description: createdImage.Description,
-				Url:         createdImage.Url,
-				FileName:    createdImage.FileName,
-				Format:      createdImage.Format,
-				RawImage:    createdImage.RawImage,
-				CreatedAt:   createdImage.CreatedAt.String(),
-				UpdatedAt:   createdImage.UpdatedAt.String(),
-			})
-		}
+	if err := api.interactor.deleteAttachment(request.UserID, request.WalletID, request.TransactionID, request.AttachmentID); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else {
+		return ctx.NoContent(http.StatusOK)
 	}
 }
 
-func (api *apiWeb) updateImageHandler(ctx echo.Context) error {
-	request := updateImageRequest{
-		UserID:  ctx.Param("user_id"),
-		ImageID: ctx.Param("image_id"),
+// newAttachmentResponse ...
+func newAttachmentResponse(walletID string, attachment *attachment) *attachmentResponse {
+	return &attachmentResponse{
+		AttachmentID:  attachment.AttachmentID,
+		TransactionID: attachment.TransactionID,
+		UserID:        attachment.UserID,
+		FileName:      attachment.FileName,
+		ContentType:   attachment.ContentType,
+		Size:          attachment.Size,
+		Url: fmt.Sprintf("/api/1/users/%s/wallets/%s/transactions/%s/attachments/%s",
+			attachment.UserID, walletID, attachment.TransactionID, attachment.AttachmentID),
+		UpdatedAt: attachment.UpdatedAt.String(),
+		CreatedAt: attachment.CreatedAt.String(),
 	}
+}
 
-	// form values
-	request.Body.Name = ctx.FormValue("name")
-	request.Body.Description = ctx.FormValue("description")
-	request.Body.Url = ctx.FormValue("url")
-	request.Body.ImageKey = ctx.FormValue("image_key")
+type prefillReceiptRequest struct {
+	UserID     string `json:"user_id" validate:"ui"`
+	ReceiptKey string `json:"receipt_key"`
+}
+
+type receiptPrefillResponse struct {
+	Date       string   `json:"date,omitempty"`
+	Latitude   *float64 `json:"latitude,omitempty"`
+	Longitude  *float64 `json:"longitude,omitempty"`
+	WalletID   string   `json:"wallet_id,omitempty"`
+	CategoryID string   `json:"category_id,omitempty"`
+	Matches    int      `json:"matches"`
+}
+
+func (api *apiWeb) registerRoutesForReceipts() error {
+	api.client.AddRoute(http.MethodPost, "/api/1/users/:user_id/receipts/prefill", api.prefillReceiptHandler, api.auth)
+
+	return nil
+}
+
+func (api *apiWeb) prefillReceiptHandler(ctx echo.Context) error {
+	request := prefillReceiptRequest{
+		UserID:     ctx.Param("user_id"),
+		ReceiptKey: "receipt",
+	}
 
 	if err := validator.Validate(request); err != nil {
 		log.WithFields(map[string]interface{}{"error": err}).
@@ -1194,314 +3801,555 @@ func (api *apiWeb) updateImageHandler(ctx echo.Context) error {
 		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
 	}
 
-	if downloads, err := download(request.Body.ImageKey, ctx); err != nil {
+	downloads, err := download(request.ReceiptKey, ctx)
+	if err != nil {
 		log.WithFields(map[string]interface{}{"error": err, "cause": ""}).
-			Error("error uploading images")
+			Error("error uploading receipt")
 		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
+	}
+	if len(downloads) == 0 {
+		log.Errorf("missing receipt %s", request.ReceiptKey)
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: fmt.Sprintf("missing receipt %s", request.ReceiptKey), Cause: ""})
+	}
+
+	if prefill, err := api.interactor.prefillFromReceipt(request.UserID, downloads[0].Data.Bytes()); err != nil {
+		if imageErr, ok := err.(*imageError); ok {
+			return ctx.JSON(imageErr.status, errorResponse{Code: imageErr.status, Message: imageErr.Error(), Cause: ""})
+		}
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
 	} else {
-		if len(downloads) == 0 {
-			log.Error(err)
-			return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
+		response := receiptPrefillResponse{
+			Latitude:   prefill.Latitude,
+			Longitude:  prefill.Longitude,
+			WalletID:   prefill.WalletID,
+			CategoryID: prefill.CategoryID,
+			Matches:    prefill.Matches,
 		}
+		if !prefill.Date.IsZero() {
+			response.Date = prefill.Date.Format(time.RFC3339)
+		}
+		return ctx.JSON(http.StatusOK, response)
+	}
+}
 
-		format := strings.Split(downloads[0].FileName, ".")[1]
+type exportAccountRequest struct {
+	UserID string `json:"user_id" validate:"ui"`
+}
 
-		image := &image{
-			ImageID:     request.ImageID,
-			UserID:      request.UserID,
-			Name:        request.Body.Name,
-			Description: request.Body.Description,
-			Url:         request.Body.Url,
-			Format:      format,
-			FileName:    downloads[0].FileName,
-			RawImage:    downloads[0].Data.Bytes(),
-		}
+type getExportRequest struct {
+	UserID   string `json:"user_id" validate:"ui"`
+	ExportID string `json:"export_id" validate:"ui"`
+}
 
-		if updatedImage, err := api.interactor.updateImage(image); err != nil {
-			return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
-		} else if updatedImage == nil {
-			return ctx.NoContent(http.StatusNotFound)
-		} else {
-			return ctx.JSON(http.StatusCreated, imageResponse{
-				ImageID:     updatedImage.ImageID,
-				UserID:      updatedImage.UserID,
-				Name:        updatedImage.Name,
-				Description: updatedImage.Description,
-				Url:         updatedImage.Url,
-				FileName:    updatedImage.FileName,
-				Format:      updatedImage.Format,
-				RawImage:    updatedImage.RawImage,
-				CreatedAt:   updatedImage.CreatedAt.String(),
-				UpdatedAt:   updatedImage.UpdatedAt.String(),
-			})
+type importAccountRequest struct {
+	UserID     string `json:"user_id" validate:"ui"`
+	ArchiveKey string `json:"archive_key"`
+}
+
+type exportLedgerRequest struct {
+	UserID string `json:"user_id" validate:"ui"`
+	Format string `json:"format" validate:"nonzero"`
+}
+
+type exportResponse struct {
+	ExportID  string `json:"export_id"`
+	UserID    string `json:"user_id"`
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+	Size      int    `json:"size"`
+	UpdatedAt string `json:"updated_at"`
+	CreatedAt string `json:"created_at"`
+}
+
+func (api *apiWeb) registerRoutesForExports() error {
+	api.client.AddRoute(http.MethodPost, "/api/1/users/:user_id/export", api.exportAccountHandler, api.auth)
+	api.client.AddRoute(http.MethodPost, "/api/1/users/:user_id/exports", api.createExportHandler, api.auth)
+	api.client.AddRoute(http.MethodGet, "/api/1/users/:user_id/exports/:export_id", api.getExportHandler, api.auth)
+	api.client.AddRoute(http.MethodGet, "/api/1/users/:user_id/exports/:export_id/archive", api.getExportArchiveHandler, api.auth)
+	api.client.AddRoute(http.MethodPost, "/api/1/users/:user_id/import", api.importAccountHandler, api.auth)
+	api.client.AddRoute(http.MethodGet, "/api/1/users/:user_id/ledger", api.exportLedgerHandler, api.auth)
+
+	return nil
+}
+
+// exportAccountHandler returns the archive of the account, or creates an export generated in background for the large accounts
+func (api *apiWeb) exportAccountHandler(ctx echo.Context) error {
+	request := exportAccountRequest{
+		UserID: ctx.Param("user_id"),
+	}
+
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	isLarge, err := api.interactor.isLargeAccount(request.UserID)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	}
+
+	if isLarge {
+		return api.createExportHandler(ctx)
+	}
+
+	locked, err := api.lockedWallets(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	}
+
+	var buffer bytes.Buffer
+	if err := api.interactor.exportAccount(request.UserID, locked, &buffer); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	}
+
+	ctx.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("go-money-%s.zip", request.UserID)))
+	return ctx.Blob(http.StatusOK, "application/zip", buffer.Bytes())
+}
+
+func (api *apiWeb) createExportHandler(ctx echo.Context) error {
+	request := exportAccountRequest{
+		UserID: ctx.Param("user_id"),
+	}
+
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	locked, err := api.lockedWallets(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	}
+
+	if createdExport, err := api.interactor.createExport(request.UserID, locked); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else if createdExport == nil {
+		return ctx.NoContent(http.StatusInternalServerError)
+	} else {
+		ctx.Response().Header().Set(echo.HeaderLocation, fmt.Sprintf("/api/1/users/%s/exports/%s", createdExport.UserID, createdExport.ExportID))
+		return ctx.JSON(http.StatusAccepted, exportResponse{
+			ExportID:  createdExport.ExportID,
+			UserID:    createdExport.UserID,
+			Status:    createdExport.Status,
+			Error:     createdExport.Error,
+			Size:      createdExport.Size,
+			CreatedAt: createdExport.CreatedAt.String(),
+			UpdatedAt: createdExport.UpdatedAt.String(),
+		})
+	}
+}
+
+func (api *apiWeb) getExportHandler(ctx echo.Context) error {
+	request := getExportRequest{
+		UserID:   ctx.Param("user_id"),
+		ExportID: ctx.Param("export_id"),
+	}
+
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	if export, err := api.interactor.getExport(request.UserID, request.ExportID); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else if export == nil {
+		return ctx.NoContent(http.StatusNotFound)
+	} else {
+		return ctx.JSON(http.StatusOK, exportResponse{
+			ExportID:  export.ExportID,
+			UserID:    export.UserID,
+			Status:    export.Status,
+			Error:     export.Error,
+			Size:      export.Size,
+			CreatedAt: export.CreatedAt.String(),
+			UpdatedAt: export.UpdatedAt.String(),
+		})
+	}
+}
+
+func (api *apiWeb) getExportArchiveHandler(ctx echo.Context) error {
+	request := getExportRequest{
+		UserID:   ctx.Param("user_id"),
+		ExportID: ctx.Param("export_id"),
+	}
+
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	export, err := api.interactor.getExport(request.UserID, request.ExportID)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else if export == nil {
+		return ctx.NoContent(http.StatusNotFound)
+	} else if export.Status != exportStatusDone {
+		return ctx.JSON(http.StatusConflict, errorResponse{Code: http.StatusConflict, Message: fmt.Sprintf("export is %s", export.Status), Cause: export.Error})
+	}
+
+	// the archive is streamed from the blob storage, the status is only sent with its first bytes
+	ctx.Response().Header().Set(echo.HeaderContentType, "application/zip")
+	ctx.Response().Header().Set(echo.HeaderContentLength, strconv.Itoa(export.Size))
+	ctx.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("go-money-%s.zip", export.ExportID)))
+	writer := &exportArchiveWriter{response: ctx.Response()}
+
+	if found, err := api.interactor.writeExportArchive(export, writer); writer.started {
+		return nil
+	} else if err != nil {
+		ctx.Response().Header().Del(echo.HeaderContentLength)
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else if !found {
+		ctx.Response().Header().Del(echo.HeaderContentLength)
+		return ctx.NoContent(http.StatusNotFound)
+	}
+
+	ctx.Response().WriteHeader(http.StatusOK)
+	return nil
+}
+
+// exportArchiveWriter writes the status of the response with the first bytes of the archive,
+// so a missing archive still gets a not found
+type exportArchiveWriter struct {
+	response *echo.Response
+	started  bool
+}
+
+func (writer *exportArchiveWriter) Write(data []byte) (int, error) {
+	if !writer.started {
+		writer.started = true
+		writer.response.WriteHeader(http.StatusOK)
+	}
+	return writer.response.Write(data)
+}
+
+func (api *apiWeb) importAccountHandler(ctx echo.Context) error {
+	request := importAccountRequest{
+		UserID:     ctx.Param("user_id"),
+		ArchiveKey: "archive",
+	}
+
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	downloads, err := download(request.ArchiveKey, ctx)
+	if err != nil {
+		log.WithFields(map[string]interface{}{"error": err, "cause": ""}).
+			Error("error uploading archive")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
+	} else if len(downloads) == 0 {
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: "missing archive", Cause: ""})
+	}
+
+	data := downloads[0].Data.Bytes()
+	archive, err := readAccountArchive(bytes.NewReader(data), int64(len(data)), api.interactor.archiveLimits())
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
+	}
+
+	if err := api.interactor.importAccount(request.UserID, archive); err != nil {
+		if err == errAccountNotEmpty {
+			return ctx.JSON(http.StatusConflict, errorResponse{Code: http.StatusConflict, Message: err.Error(), Cause: ""})
+		} else if _, ok := err.(*archiveError); ok {
+			return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
 		}
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	}
+
+	return ctx.NoContent(http.StatusCreated)
+}
+
+func (api *apiWeb) exportLedgerHandler(ctx echo.Context) error {
+	request := exportLedgerRequest{
+		UserID: ctx.Param("user_id"),
+		Format: ctx.QueryParam("format"),
 	}
-}
 
-func (api *apiWeb) deleteImageHandler(ctx echo.Context) error {
-	request := deleteImageRawRequest{
-		UserID:  ctx.Param("user_id"),
-		ImageID: ctx.Param("image_id"),
+	if request.Format == "" {
+		request.Format = ledgerFormatLedger
 	}
 
-	if err := api.interactor.deleteImage(request.UserID, request.ImageID); err != nil {
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	if !isLedgerFormat(request.Format) {
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: fmt.Sprintf("invalid format %s", request.Format), Cause: ""})
+	}
+
+	locked, err := api.lockedWallets(ctx)
+	if err != nil {
 		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
-	} else {
-		return ctx.NoContent(http.StatusOK)
 	}
-}
 
-type getTransactionsRequest struct {
-	UserID   string `json:"user_id" validate:"ui"`
-	WalletID string `json:"wallet_id" validate:"ui"`
+	// the data is loaded before the status is written, so a failure is still answered with an error
+	journal, err := api.interactor.getLedgerJournal(request.UserID, request.Format, locked)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	}
+
+	ctx.Response().Header().Set(echo.HeaderContentType, echo.MIMETextPlainCharsetUTF8)
+	ctx.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("go-money-%s.%s", request.UserID, request.Format)))
+	ctx.Response().WriteHeader(http.StatusOK)
+
+	if err := api.interactor.writeLedgerJournal(request.UserID, journal, ctx.Response()); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error streaming %s journal", request.Format)
+		return err
+	}
+
+	return nil
 }
 
-type getTransactionRequest struct {
-	UserID        string `json:"user_id" validate:"ui"`
-	WalletID      string `json:"wallet_id" validate:"ui"`
-	TransactionID string `json:"transaction_id" validate:"ui"`
+type transactionFilterRequest struct {
+	UserID     string `json:"user_id" validate:"ui"`
+	WalletID   string `json:"wallet_id"`
+	CategoryID string `json:"category_id"`
+	From       string `json:"from"`
+	To         string `json:"to"`
 }
 
-type createTransactionsRequest struct {
-	UserID   string                   `json:"user_id" validate:"ui"`
-	WalletID string                   `json:"wallet_id" validate:"ui"`
-	Body     []transactionItemRequest `json:"transactions" validate:"min=1"`
+type getReportRequest struct {
+	transactionFilterRequest
+	Period string `json:"period"`
 }
 
-type updateTransactionRequest struct {
-	UserID        string `json:"user_id" validate:"ui"`
-	WalletID      string `json:"wallet_id" validate:"ui"`
-	TransactionID string `json:"transaction_id" validate:"ui"`
-	Body          transactionItemRequest
+type exportTransactionsSpreadsheetRequest struct {
+	transactionFilterRequest
+	Group string `json:"group"`
 }
 
-type transactionItemRequest struct {
-	CategoryID  string `json:"category_id" validate:"ui"`
-	Price       string `json:"price"`
-	Description string `json:"description"`
-	Date        string `json:"date" validate:"nonzero"`
+type exportStatementRequest struct {
+	UserID   string `json:"user_id" validate:"ui"`
+	WalletID string `json:"wallet_id" validate:"ui"`
+	Year     int    `json:"year" validate:"nonzero"`
+	Month    int    `json:"month" validate:"nonzero"`
 }
 
-type deleteTransactionRequest struct {
-	UserID        string `json:"user_id" validate:"ui"`
-	WalletID      string `json:"wallet_id" validate:"ui"`
-	TransactionID string `json:"transaction_id" validate:"ui"`
+type reportItemResponse struct {
+	Period     string `json:"period"`
+	CategoryID string `json:"category_id"`
+	Category   string `json:"category"`
+	Count      int    `json:"count"`
+	Expenses   string `json:"expenses"`
+	Income     string `json:"income"`
+	Total      string `json:"total"`
 }
 
-type transactionResponse struct {
-	UserID        string `json:"user_id"`
-	WalletID      string `json:"wallet_id"`
-	TransactionID string `json:"transaction_id"`
-	CategoryID    string `json:"category_id"`
-	Price         string `json:"price"`
-	Description   string `json:"description,omitempty"`
-	Date          string `json:"date"`
-	UpdatedAt     string `json:"updated_at"`
-	CreatedAt     string `json:"created_at"`
+func newTransactionFilterRequest(ctx echo.Context) transactionFilterRequest {
+	return transactionFilterRequest{
+		UserID:     ctx.Param("user_id"),
+		WalletID:   ctx.QueryParam("wallet_id"),
+		CategoryID: ctx.QueryParam("category_id"),
+		From:       ctx.QueryParam("from"),
+		To:         ctx.QueryParam("to"),
+	}
 }
 
-func (api *apiWeb) registerRoutesForTransactions() error {
-	api.client.AddRoute(http.MethodGet, "/api/1/users/:user_id/wallets/:wallet_id/transactions", api.getTransactionsHandler, api.auth)
-	api.client.AddRoute(http.MethodGet, "/api/1/users/:user_id/wallets/:wallet_id/transactions/:transaction_id", api.getTransactionHandler, api.auth)
-	api.client.AddRoute(http.MethodPost, "/api/1/users/:user_id/wallets/:wallet_id/transactions", api.createTransactionsHandler, api.auth)
-	api.client.AddRoute(http.MethodPut, "/api/1/users/:user_id/wallets/:wallet_id/transactions/:transaction_id", api.updateTransactionHandler, api.auth)
-	api.client.AddRoute(http.MethodDelete, "/api/1/users/:user_id/wallets/:wallet_id/transactions/:transaction_id", api.deleteTransactionHandler, api.auth)
+// filter validates the request and converts it to a transaction filter, the dates are RFC3339 or yyyy-mm-dd
+func (request *transactionFilterRequest) filter() (*transactionFilter, error) {
+	if err := validator.Validate(*request); err != nil {
+		return nil, err[0]
+	}
 
-	return nil
-}
+	filter := &transactionFilter{
+		WalletID:   request.WalletID,
+		CategoryID: request.CategoryID,
+	}
 
-func (api *apiWeb) getTransactionsHandler(ctx echo.Context) error {
-	request := getTransactionsRequest{
-		UserID:   ctx.Param("user_id"),
-		WalletID: ctx.Param("wallet_id"),
+	if filter.WalletID != "" {
+		if err := valUI(filter.WalletID); err != nil {
+			return nil, fmt.Errorf("%s is not a valid unique identifier", filter.WalletID)
+		}
 	}
 
-	if transactions, err := api.interactor.getTransactions(request.UserID); err != nil {
-		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
-	} else if transactions == nil {
-		return ctx.NoContent(http.StatusNotFound)
-	} else {
-		transactionsResponse := make([]*transactionResponse, 0)
+	if filter.CategoryID != "" {
+		if err := valUI(filter.CategoryID); err != nil {
+			return nil, fmt.Errorf("%s is not a valid unique identifier", filter.CategoryID)
+		}
+	}
 
-		for _, transaction := range transactions {
-			transactionResponse := &transactionResponse{
-				TransactionID: transaction.TransactionID,
-				UserID:        transaction.UserID,
-				WalletID:      transaction.WalletID,
-				CategoryID:    transaction.CategoryID,
-				Price:         transaction.Price.String(),
-				Description:   transaction.Description,
-				Date:          transaction.Date.String(),
-				CreatedAt:     transaction.CreatedAt.String(),
-				UpdatedAt:     transaction.UpdatedAt.String(),
+	for _, item := range []struct {
+		value string
+		date  *time.Time
+	}{
+		{request.From, &filter.From},
+		{request.To, &filter.To},
+	} {
+		if item.value == "" {
+			continue
+		}
+
+		date, err := time.Parse(time.RFC3339, item.value)
+		if err != nil {
+			if date, err = time.Parse("2006-01-02", item.value); err != nil {
+				return nil, fmt.Errorf("%s is not a valid date", item.value)
 			}
-			transactionsResponse = append(transactionsResponse, transactionResponse)
 		}
-		return ctx.JSON(http.StatusOK, transactionsResponse)
+		*item.date = date
 	}
+
+	return filter, nil
 }
 
-func (api *apiWeb) getTransactionHandler(ctx echo.Context) error {
-	request := getTransactionRequest{
-		UserID:        ctx.Param("user_id"),
-		WalletID:      ctx.Param("wallet_id"),
-		TransactionID: ctx.Param("transaction_id"),
-	}
+func (api *apiWeb) registerRoutesForReports() error {
+	api.client.AddRoute(http.MethodGet, "/api/1/users/:user_id/reports", api.getReportHandler, api.auth)
+	api.client.AddRoute(http.MethodGet, "/api/1/users/:user_id/spreadsheets/transactions", api.exportTransactionsSpreadsheetHandler, api.auth)
+	api.client.AddRoute(http.MethodGet, "/api/1/users/:user_id/spreadsheets/reports", api.exportReportSpreadsheetHandler, api.auth)
+	api.client.AddRoute(http.MethodGet, "/api/1/users/:user_id/wallets/:wallet_id/statements/:year/:month", api.exportStatementHandler, api.auth, api.grant)
 
-	if transaction, err := api.interactor.getTransaction(request.UserID, request.WalletID, request.TransactionID); err != nil {
-		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
-	} else if transaction == nil {
-		return ctx.NoContent(http.StatusNotFound)
-	} else {
-		return ctx.JSON(http.StatusOK,
-			transactionResponse{
-				TransactionID: transaction.TransactionID,
-				UserID:        transaction.UserID,
-				WalletID:      transaction.WalletID,
-				CategoryID:    transaction.CategoryID,
-				Price:         transaction.Price.String(),
-				Description:   transaction.Description,
-				Date:          transaction.Date.String(),
-				CreatedAt:     transaction.CreatedAt.String(),
-				UpdatedAt:     transaction.UpdatedAt.String(),
-			})
-	}
+	return nil
 }
 
-func (api *apiWeb) createTransactionsHandler(ctx echo.Context) error {
-	request := createTransactionsRequest{
-		UserID:   ctx.Param("user_id"),
-		WalletID: ctx.Param("wallet_id"),
+func (api *apiWeb) getReportHandler(ctx echo.Context) error {
+	request := getReportRequest{
+		transactionFilterRequest: newTransactionFilterRequest(ctx),
+		Period:                   ctx.QueryParam("period"),
 	}
-	transactions := make([]*transaction, 0)
 
-	if err := ctx.Bind(&request.Body); err != nil {
+	if request.Period == "" {
+		request.Period = reportPeriodMonth
+	}
+
+	filter, err := request.filter()
+	if err != nil {
 		log.WithFields(map[string]interface{}{"error": err}).
 			Error("error when validating body request")
 		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
 	}
 
-	for _, item := range request.Body {
-		if err := validator.Validate(item); err != nil {
-			log.WithFields(map[string]interface{}{"error": err, "cause": ""}).
-				Error("error when validating body request")
-			return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
-		}
+	if !isReportPeriod(request.Period) {
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: fmt.Sprintf("invalid period %s", request.Period), Cause: ""})
 	}
 
-	for _, item := range request.Body {
-		date, err := time.Parse(time.RFC3339, item.Date)
-		if err != nil {
-			log.WithFields(map[string]interface{}{"error": err, "cause": ""}).
-				Error("error getting date")
-			return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
-		}
-
-		price, err := decimal.NewFromString(item.Price)
-
-		transactions = append(transactions, &transaction{
-			UserID:      request.UserID,
-			WalletID:    request.WalletID,
-			CategoryID:  item.CategoryID,
-			Price:       price,
-			Description: item.Description,
-			Date:        date,
-		})
+	if filter.LockedWallets, err = api.lockedWallets(ctx); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
 	}
 
-	if createdTransactions, err := api.interactor.createTransactions(transactions); err != nil {
+	if report, err := api.interactor.getReport(request.UserID, filter, request.Period); err != nil {
 		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
 	} else {
-		transactionsResponse := make([]*transactionResponse, 0)
-
-		for _, createdTransaction := range createdTransactions {
-			transactionResponse := &transactionResponse{
-				TransactionID: createdTransaction.TransactionID,
-				UserID:        createdTransaction.UserID,
-				WalletID:      createdTransaction.WalletID,
-				CategoryID:    createdTransaction.CategoryID,
-				Price:         createdTransaction.Price.String(),
-				Description:   createdTransaction.Description,
-				Date:          createdTransaction.Date.String(),
-				CreatedAt:     createdTransaction.CreatedAt.String(),
-				UpdatedAt:     createdTransaction.UpdatedAt.String(),
-			}
-			transactionsResponse = append(transactionsResponse, transactionResponse)
+		reportResponse := make([]*reportItemResponse, 0)
+		for _, item := range report {
+			reportResponse = append(reportResponse, &reportItemResponse{
+				Period:     item.Period,
+				CategoryID: item.CategoryID,
+				Category:   item.Category,
+				Count:      item.Count,
+				Expenses:   item.Expenses.String(),
+				Income:     item.Income.String(),
+				Total:      item.Total.String(),
+			})
 		}
-		return ctx.JSON(http.StatusCreated, transactionsResponse)
+		return ctx.JSON(http.StatusOK, reportResponse)
 	}
 }
 
-func (api *apiWeb) updateTransactionHandler(ctx echo.Context) error {
-	request := updateTransactionRequest{
-		UserID:        ctx.Param("user_id"),
-		WalletID:      ctx.Param("wallet_id"),
-		TransactionID: ctx.Param("transaction_id"),
+func (api *apiWeb) exportTransactionsSpreadsheetHandler(ctx echo.Context) error {
+	request := exportTransactionsSpreadsheetRequest{
+		transactionFilterRequest: newTransactionFilterRequest(ctx),
+		Group:                    ctx.QueryParam("group"),
 	}
-	if err := ctx.Bind(&request.Body); err != nil {
+
+	if request.Group == "" {
+		request.Group = spreadsheetGroupWallet
+	}
+
+	filter, err := request.filter()
+	if err != nil {
 		log.WithFields(map[string]interface{}{"error": err}).
 			Error("error when validating body request")
 		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
 	}
 
-	if err := validator.Validate(request.Body); err != nil {
-		log.WithFields(map[string]interface{}{"error": err}).
-			Error("error when validating body request")
-		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	if request.Group != spreadsheetGroupWallet && request.Group != spreadsheetGroupMonth {
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: fmt.Sprintf("invalid group %s", request.Group), Cause: ""})
 	}
 
-	price, err := decimal.NewFromString(request.Body.Price)
-	if err != nil {
-		log.WithFields(map[string]interface{}{"error": err, "cause": ""}).
-			Error("error getting price")
-		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
+	if filter.LockedWallets, err = api.lockedWallets(ctx); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
 	}
 
-	date, err := time.Parse(time.RFC3339, request.Body.Date)
+	var buffer bytes.Buffer
+	if err := api.interactor.exportTransactionsSpreadsheet(request.UserID, filter, request.Group, &buffer); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	}
+
+	ctx.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", "transactions.xlsx"))
+	return ctx.Blob(http.StatusOK, mimeXlsx, buffer.Bytes())
+}
+
+func (api *apiWeb) exportReportSpreadsheetHandler(ctx echo.Context) error {
+	request := getReportRequest{
+		transactionFilterRequest: newTransactionFilterRequest(ctx),
+		Period:                   ctx.QueryParam("period"),
+	}
+
+	if request.Period == "" {
+		request.Period = reportPeriodMonth
+	}
+
+	filter, err := request.filter()
 	if err != nil {
-		log.WithFields(map[string]interface{}{"error": err, "cause": ""}).
-			Error("error getting date")
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
 		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err.Error(), Cause: ""})
 	}
 
-	if updatedTransaction, err := api.interactor.updateTransaction(
-		&transaction{
-			UserID:        request.UserID,
-			WalletID:      request.WalletID,
-			TransactionID: request.TransactionID,
-			CategoryID:    request.Body.CategoryID,
-			Price:         price,
-			Description:   request.Body.Description,
-			Date:          date,
-		}); err != nil {
+	if !isReportPeriod(request.Period) {
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: fmt.Sprintf("invalid period %s", request.Period), Cause: ""})
+	}
+
+	if filter.LockedWallets, err = api.lockedWallets(ctx); err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	}
+
+	var buffer bytes.Buffer
+	if err := api.interactor.exportReportSpreadsheet(request.UserID, filter, request.Period, &buffer); err != nil {
 		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
-	} else if updatedTransaction == nil {
-		return ctx.NoContent(http.StatusNotFound)
-	} else {
-		return ctx.JSON(http.StatusCreated, transactionResponse{
-			TransactionID: updatedTransaction.TransactionID,
-			UserID:        updatedTransaction.UserID,
-			WalletID:      updatedTransaction.WalletID,
-			CategoryID:    updatedTransaction.CategoryID,
-			Price:         updatedTransaction.Price.String(),
-			Description:   updatedTransaction.Description,
-			Date:          updatedTransaction.Date.String(),
-			CreatedAt:     updatedTransaction.CreatedAt.String(),
-			UpdatedAt:     updatedTransaction.UpdatedAt.String(),
-		})
 	}
+
+	ctx.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", "report.xlsx"))
+	return ctx.Blob(http.StatusOK, mimeXlsx, buffer.Bytes())
 }
 
-func (api *apiWeb) deleteTransactionHandler(ctx echo.Context) error {
-	request := deleteTransactionRequest{
-		UserID:        ctx.Param("user_id"),
-		WalletID:      ctx.Param("wallet_id"),
-		TransactionID: ctx.Param("transaction_id"),
+func (api *apiWeb) exportStatementHandler(ctx echo.Context) error {
+	year, _ := strconv.Atoi(ctx.Param("year"))
+	month, _ := strconv.Atoi(ctx.Param("month"))
+
+	request := exportStatementRequest{
+		UserID:   ctx.Param("user_id"),
+		WalletID: ctx.Param("wallet_id"),
+		Year:     year,
+		Month:    month,
 	}
 
-	if err := api.interactor.deleteTransaction(request.UserID, request.WalletID, request.TransactionID); err != nil {
+	if err := validator.Validate(request); err != nil {
+		log.WithFields(map[string]interface{}{"error": err}).
+			Error("error when validating body request")
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: err[0].Error(), Cause: ""})
+	}
+
+	if request.Month < 1 || request.Month > 12 {
+		return ctx.JSON(http.StatusBadRequest, errorResponse{Code: http.StatusBadRequest, Message: fmt.Sprintf("invalid month %s", ctx.Param("month")), Cause: ""})
+	}
+
+	var buffer bytes.Buffer
+	if err := api.interactor.exportStatement(request.UserID, request.WalletID, request.Year, time.Month(request.Month), &buffer); err != nil {
+		if err == errWalletNotFound {
+			return ctx.NoContent(http.StatusNotFound)
+		}
 		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
-	} else {
-		return ctx.NoContent(http.StatusOK)
 	}
+
+	ctx.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("inline; filename=%q", fmt.Sprintf("statement-%04d-%02d.pdf", request.Year, request.Month)))
+	return ctx.Blob(http.StatusOK, mimePdf, buffer.Bytes())
 }
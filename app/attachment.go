@@ -0,0 +1,89 @@
+package gomoney
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	defaultAttachmentMaxSize           = 10 << 20
+	defaultAttachmentMaxPerTransaction = 10
+)
+
+// normalizeAttachment validates an uploaded attachment, that must be an image or a pdf.
+// The images are validated and normalized like the other images, so the metadata of the receipts is removed.
+func (interactor *interactor) normalizeAttachment(newAttachment *attachment) error {
+	maxSize := interactor.config.Attachment.MaxSize
+	if maxSize <= 0 {
+		maxSize = defaultAttachmentMaxSize
+	}
+
+	if len(newAttachment.Data) == 0 {
+		return newImageError(http.StatusBadRequest, "the attachment is empty")
+	}
+	if len(newAttachment.Data) > maxSize {
+		return newImageError(http.StatusRequestEntityTooLarge, "the attachment has %d bytes, the maximum is %d bytes", len(newAttachment.Data), maxSize)
+	}
+
+	switch {
+	case sniffImageFormat(newAttachment.Data) != "":
+		image := &image{
+			FileName: newAttachment.FileName,
+			RawImage: newAttachment.Data,
+		}
+		if err := interactor.normalizeImage(image); err != nil {
+			return err
+		}
+
+		newAttachment.FileName = image.FileName
+		newAttachment.Data = image.RawImage
+		newAttachment.ContentType = imageContentType(image.Format, image.RawImage)
+
+	case bytes.HasPrefix(newAttachment.Data, []byte("%PDF-")):
+		newAttachment.ContentType = mimePdf
+		if !strings.EqualFold(filepath.Ext(newAttachment.FileName), ".pdf") {
+			name := strings.TrimSuffix(newAttachment.FileName, filepath.Ext(newAttachment.FileName))
+			if name == "" {
+				name = "attachment"
+			}
+			newAttachment.FileName = fmt.Sprintf("%s.pdf", name)
+		}
+
+	default:
+		return newImageError(http.StatusUnsupportedMediaType, "the attachment must be an image or a pdf")
+	}
+
+	newAttachment.Size = len(newAttachment.Data)
+
+	return nil
+}
+
+// validateAttachmentCount checks the number of attachments that a transaction would have
+func (interactor *interactor) validateAttachmentCount(count int) error {
+	maxAttachments := interactor.config.Attachment.MaxPerTransaction
+	if maxAttachments <= 0 {
+		maxAttachments = defaultAttachmentMaxPerTransaction
+	}
+
+	if count > maxAttachments {
+		return newImageError(http.StatusBadRequest, "a transaction can have at most %d attachments", maxAttachments)
+	}
+	return nil
+}
+
+// attachmentStorage returns the blob storage of an attachment
+func (interactor *interactor) attachmentStorage(attachment *attachment) (iStorageBlob, error) {
+	driver := attachment.Storage
+	if driver == "" {
+		driver = blobDriver(interactor.config)
+	}
+
+	storage, ok := interactor.storageBlobs[driver]
+	if !ok {
+		return nil, fmt.Errorf("the blob storage %s of attachment %s is not configured", driver, attachment.AttachmentID)
+	}
+	return storage, nil
+}
@@ -0,0 +1,257 @@
+package gomoney
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo"
+)
+
+const (
+	roleUser  = "user"
+	roleAdmin = "admin"
+
+	principalKey = "principal"
+)
+
+// principal is the authenticated user of a request
+type principal struct {
+	UserID    string
+	Role      string
+	SessionID string
+	Token     string
+	// Scopes are the scopes of the personal access token, the sessions have every scope
+	Scopes []string
+}
+
+// isAdmin ...
+func (p *principal) isAdmin() bool {
+	return p.Role == roleAdmin
+}
+
+// canAccess checks if the principal can access the resources of the user, that must be the principal or any user for the admins
+func (p *principal) canAccess(userID string) bool {
+	return p.UserID == userID || p.isAdmin()
+}
+
+// isRole ...
+func isRole(role string) bool {
+	return role == roleUser || role == roleAdmin
+}
+
+// principalFromContext returns the principal set by the authenticate middleware
+func principalFromContext(ctx echo.Context) *principal {
+	if principal, ok := ctx.Get(principalKey).(*principal); ok {
+		return principal
+	}
+	return nil
+}
+
+// authorizationError is returned when a resource doesn't belong to the user
+type authorizationError struct {
+	message string
+}
+
+func (e *authorizationError) Error() string {
+	return e.message
+}
+
+func newAuthorizationError(format string, arguments ...interface{}) *authorizationError {
+	return &authorizationError{message: fmt.Sprintf(format, arguments...)}
+}
+
+// cookieRoutes are the routes that accept the access token on the cookie, the images and the attachments
+// loaded by the browser without the header. The other routes require the header, that a cross site request can't send.
+var cookieRoutes = map[string]bool{
+	"/api/1/users/:user_id/images/:image_id/raw":                                                       true,
+	"/api/1/users/:user_id/images/:image_id/thumbnails/:name":                                          true,
+	"/api/1/users/:user_id/images/:image_id/resize":                                                    true,
+	"/api/1/users/:user_id/wallets/:wallet_id/transactions/:transaction_id/attachments/:attachment_id": true,
+}
+
+// sessionToken returns the access token of the request, from the header, or from the cookie on the get of the cookie routes
+func sessionToken(ctx echo.Context) string {
+	value := ctx.Request().Header.Get(session_key)
+	if value == "" && ctx.Request().Method == http.MethodGet && cookieRoutes[ctx.Path()] {
+		if cookie, err := ctx.Cookie(session_key); err == nil {
+			value = cookie.Value
+		}
+	}
+	return strings.TrimSpace(strings.TrimPrefix(value, fmt.Sprintf("%s ", authentication)))
+}
+
+// authenticate validates the access token and puts the principal on the context.
+// On the routes with a user the principal must be that user or an admin.
+func (api *apiWeb) authenticate() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			sessionKeyValue := sessionToken(ctx)
+			if sessionKeyValue == "" {
+				return ctx.JSON(http.StatusUnauthorized, errorResponse{Code: http.StatusUnauthorized, Message: "missing access token", Cause: ""})
+			}
+
+			if isAccessToken(sessionKeyValue) {
+				return api.authenticateAccessToken(ctx, next, sessionKeyValue)
+			}
+
+			var session *session
+			token, err := jwt.Parse(sessionKeyValue, func(token *jwt.Token) (interface{}, error) {
+				if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+					return nil, fmt.Errorf("unexpected signing method %s", token.Header["alg"])
+				}
+
+				var err error
+				if session, err = api.interactor.useSession(sessionKeyValue); err != nil {
+					return nil, err
+				} else if session == nil {
+					return nil, fmt.Errorf("the session is expired or doesn't exist")
+				}
+				return []byte(session.Original), nil
+			})
+
+			if err != nil || !token.Valid {
+				log.Infof("invalid token with error %s", err)
+				return ctx.JSON(http.StatusUnauthorized, errorResponse{Code: http.StatusUnauthorized, Message: "invalid or expired access token", Cause: ""})
+			}
+
+			user, err := api.interactor.getUser(session.UserID)
+			if err != nil {
+				return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+			} else if user == nil {
+				return ctx.JSON(http.StatusUnauthorized, errorResponse{Code: http.StatusUnauthorized, Message: "invalid or expired access token", Cause: ""})
+			} else if status, message := loginDenied(user); status != 0 {
+				return ctx.JSON(status, errorResponse{Code: status, Message: message, Cause: ""})
+			}
+
+			principal := &principal{
+				UserID:    user.UserID,
+				Role:      user.Role,
+				SessionID: session.SessionID,
+				Token:     sessionKeyValue,
+			}
+			return api.authorize(ctx, next, principal)
+		}
+	}
+}
+
+// authenticateAccessToken validates a personal access token, that can only be used on the routes of its scopes
+func (api *apiWeb) authenticateAccessToken(ctx echo.Context, next echo.HandlerFunc, token string) error {
+	accessToken, err := api.interactor.useAccessToken(token)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else if accessToken == nil {
+		return ctx.JSON(http.StatusUnauthorized, errorResponse{Code: http.StatusUnauthorized, Message: "invalid or expired access token", Cause: ""})
+	}
+
+	user, err := api.interactor.getUser(accessToken.UserID)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+	} else if user == nil {
+		return ctx.JSON(http.StatusUnauthorized, errorResponse{Code: http.StatusUnauthorized, Message: "invalid or expired access token", Cause: ""})
+	} else if status, message := loginDenied(user); status != 0 {
+		return ctx.JSON(status, errorResponse{Code: status, Message: message, Cause: ""})
+	}
+
+	if scope := routeScope(ctx); scope == "" || !hasScope(accessToken.Scopes, scope) {
+		log.Infof("access token %s of user %s doesn't have the scope of %s %s", accessToken.AccessTokenID, user.UserID, ctx.Request().Method, ctx.Path())
+		return ctx.JSON(http.StatusForbidden, errorResponse{Code: http.StatusForbidden, Message: "the access token doesn't have the scope of the route", Cause: ""})
+	}
+
+	return api.authorize(ctx, next, &principal{
+		UserID: user.UserID,
+		Role:   user.Role,
+		Token:  token,
+		Scopes: accessToken.Scopes,
+	})
+}
+
+// authorize puts the principal on the context, checking that it can access the user of the route
+func (api *apiWeb) authorize(ctx echo.Context, next echo.HandlerFunc, principal *principal) error {
+	ctx.Set(principalKey, principal)
+
+	if userID := ctx.Param("user_id"); userID != "" && !principal.canAccess(userID) {
+		log.Infof("user %s can't access the resources of user %s", principal.UserID, userID)
+		return ctx.JSON(http.StatusForbidden, errorResponse{Code: http.StatusForbidden, Message: "forbidden", Cause: ""})
+	}
+
+	return next(ctx)
+}
+
+// requireAdmin only allows the admins, it must be used after the authenticate middleware
+func (api *apiWeb) requireAdmin() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			if principal := principalFromContext(ctx); principal == nil || !principal.isAdmin() {
+				return ctx.JSON(http.StatusForbidden, errorResponse{Code: http.StatusForbidden, Message: "forbidden", Cause: ""})
+			}
+			return next(ctx)
+		}
+	}
+}
+
+// validateTransactionOwnership checks that the user can edit the transactions of the wallet and that the category
+// belongs to the user, on a shared wallet to the owner of the wallet, or to the household of the wallet.
+// It returns the wallet of the transaction.
+func (interactor *interactor) validateTransactionOwnership(transaction *transaction) (*wallet, error) {
+	wallet, err := interactor.getWallet(transaction.UserID, transaction.WalletID)
+	if err != nil {
+		return nil, err
+	}
+	if wallet == nil {
+		return nil, newAuthorizationError("the wallet %s doesn't belong to the user", transaction.WalletID)
+	}
+	if !walletRoleAllows(wallet.Role, walletRoleEditor) {
+		return nil, newAuthorizationError("the user can't change the transactions of the wallet %s", transaction.WalletID)
+	}
+
+	if wallet.HouseholdID != "" && transaction.CategoryID != "" {
+		category, err := interactor.storageDB.getCategory(transaction.UserID, transaction.CategoryID)
+		if err != nil {
+			return nil, err
+		}
+		if category != nil && category.HouseholdID == wallet.HouseholdID {
+			return wallet, nil
+		}
+	}
+
+	if err := interactor.validateOwnership(transaction.UserID, "categories", "category_id", transaction.CategoryID); err != nil {
+		if _, ok := err.(*authorizationError); !ok || wallet.UserID == transaction.UserID {
+			return nil, err
+		}
+		if err := interactor.validateOwnership(wallet.UserID, "categories", "category_id", transaction.CategoryID); err != nil {
+			return nil, err
+		}
+	}
+
+	return wallet, nil
+}
+
+// validateOwnership checks that the resources referenced by a request belong to the user
+func (interactor *interactor) validateOwnership(userID string, table string, column string, ids ...string) error {
+	references := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if id != "" {
+			references = append(references, id)
+		}
+	}
+	if len(references) == 0 {
+		return nil
+	}
+
+	owned, err := interactor.storageDB.getOwnedIDs(userID, table, column, references)
+	if err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error getting %s of user %s on storage database %s", table, userID, err)
+		return err
+	}
+
+	for _, id := range references {
+		if !owned[id] {
+			return newAuthorizationError("the %s %s doesn't belong to the user", strings.TrimSuffix(column, "_id"), id)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,410 @@
+package gomoney
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo"
+)
+
+// fakeStorageDB is a storage database on memory with only the methods used by the tests,
+// the other methods panic on the nil interface
+type fakeStorageDB struct {
+	iStorageDB
+	users      map[string]*user
+	sessions   map[string]*session
+	wallets    map[string]*wallet
+	categories map[string]*category
+	// owners are the users of the resources of each id
+	owners map[string]string
+}
+
+func newFakeStorageDB() *fakeStorageDB {
+	return &fakeStorageDB{
+		users:      make(map[string]*user),
+		sessions:   make(map[string]*session),
+		wallets:    make(map[string]*wallet),
+		categories: make(map[string]*category),
+		owners:     make(map[string]string),
+	}
+}
+
+func (storage *fakeStorageDB) getUsers() ([]*user, error) {
+	users := make([]*user, 0)
+	for _, user := range storage.users {
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+func (storage *fakeStorageDB) getUser(userID string) (*user, error) {
+	return storage.users[userID], nil
+}
+
+func (storage *fakeStorageDB) useSession(token string, idleTimeout int) (*session, error) {
+	return storage.sessions[token], nil
+}
+
+func (storage *fakeStorageDB) getWallet(userID string, walletID string) (*wallet, error) {
+	return storage.wallets[userID+":"+walletID], nil
+}
+
+func (storage *fakeStorageDB) getCategory(userID string, categoryID string) (*category, error) {
+	if category, ok := storage.categories[categoryID]; ok && storage.owners[categoryID] == userID {
+		return category, nil
+	}
+	return nil, nil
+}
+
+func (storage *fakeStorageDB) getOwnedIDs(userID string, table string, column string, ids []string) (map[string]bool, error) {
+	owned := make(map[string]bool)
+	for _, id := range ids {
+		if storage.owners[id] == userID {
+			owned[id] = true
+		}
+	}
+	return owned, nil
+}
+
+// addSession creates an active user with a session, returning its token
+func (storage *fakeStorageDB) addSession(userID string, role string) string {
+	storage.users[userID] = &user{UserID: userID, Role: role, Status: userStatusActive}
+
+	token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": userID}).SignedString([]byte("secret " + userID))
+	storage.sessions[token] = &session{SessionID: genUI(), UserID: userID, Original: "secret " + userID, Token: token}
+	return token
+}
+
+// echoClient is a web client that adds the routes to an echo router, recording them
+type echoClient struct {
+	echo   *echo.Echo
+	routes []string
+}
+
+func (client *echoClient) AddRoute(method, path string, handler interface{}, middleware ...interface{}) error {
+	client.routes = append(client.routes, method+" "+path)
+
+	middlewares := make([]echo.MiddlewareFunc, 0, len(middleware))
+	for _, item := range middleware {
+		middlewares = append(middlewares, item.(echo.MiddlewareFunc))
+	}
+	client.echo.Add(method, path, handler.(func(echo.Context) error), middlewares...)
+	return nil
+}
+
+func (client *echoClient) Start(waitGroup ...*sync.WaitGroup) error { return nil }
+func (client *echoClient) Stop(waitGroup ...*sync.WaitGroup) error  { return nil }
+func (client *echoClient) Started() bool                            { return false }
+func (client *echoClient) GetClient() interface{}                   { return client.echo }
+
+// newTestApi registers every route of the api on an echo router, with an interactor on the storage
+func newTestApi(storage iStorageDB) (*apiWeb, *echoClient) {
+	client := &echoClient{echo: echo.New()}
+	api := &apiWeb{
+		client:     client,
+		interactor: newInteractor(storage, nil, newMailerLog(), newLimiterMemory(), &MoneyConfig{}),
+	}
+	api.auth = api.authenticate()
+	api.admin = api.requireAdmin()
+	api.grant = api.requireWalletGrant()
+
+	api.registerRoutesForUsers()
+	api.registerRoutesForSessions()
+	api.registerRoutesForPasswords()
+	api.registerRoutesForTOTP()
+	api.registerRoutesForAccessTokens()
+	api.registerRoutesForWallets()
+	api.registerRoutesForWalletMembers()
+	api.registerRoutesForHouseholds()
+	api.registerRoutesForCategories()
+	api.registerRoutesForImages()
+	api.registerRoutesForTransactions()
+	api.registerRoutesForAttachments()
+	api.registerRoutesForReceipts()
+	api.registerRoutesForExports()
+	api.registerRoutesForReports()
+
+	return api, client
+}
+
+func TestSessionToken(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		route    string
+		header   string
+		cookie   string
+		expected string
+	}{
+		{name: "header", method: http.MethodPost, route: "/api/1/users/:user_id/wallets", header: "Bearer header", expected: "header"},
+		{name: "header before the cookie", method: http.MethodGet, route: "/api/1/users/:user_id/images/:image_id/raw", header: "Bearer header", cookie: "Bearer cookie", expected: "header"},
+		{name: "cookie on the raw image", method: http.MethodGet, route: "/api/1/users/:user_id/images/:image_id/raw", cookie: "Bearer cookie", expected: "cookie"},
+		{name: "cookie on the thumbnail", method: http.MethodGet, route: "/api/1/users/:user_id/images/:image_id/thumbnails/:name", cookie: "Bearer cookie", expected: "cookie"},
+		{name: "cookie on the resized image", method: http.MethodGet, route: "/api/1/users/:user_id/images/:image_id/resize", cookie: "Bearer cookie", expected: "cookie"},
+		{name: "cookie on the attachment", method: http.MethodGet, route: "/api/1/users/:user_id/wallets/:wallet_id/transactions/:transaction_id/attachments/:attachment_id", cookie: "Bearer cookie", expected: "cookie"},
+		{name: "cookie on the image metadata", method: http.MethodGet, route: "/api/1/users/:user_id/images/:image_id", cookie: "Bearer cookie"},
+		{name: "cookie on the wallets", method: http.MethodGet, route: "/api/1/users/:user_id/wallets", cookie: "Bearer cookie"},
+		{name: "cookie on a change of the wallets", method: http.MethodPost, route: "/api/1/users/:user_id/wallets", cookie: "Bearer cookie"},
+		{name: "cookie on the deletion of an image", method: http.MethodDelete, route: "/api/1/users/:user_id/images/:image_id", cookie: "Bearer cookie"},
+		{name: "cookie on the deletion of an attachment", method: http.MethodDelete, route: "/api/1/users/:user_id/wallets/:wallet_id/transactions/:transaction_id/attachments/:attachment_id", cookie: "Bearer cookie"},
+		{name: "cookie on the export", method: http.MethodPost, route: "/api/1/users/:user_id/exports", cookie: "Bearer cookie"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := httptest.NewRequest(test.method, "/", nil)
+			if test.header != "" {
+				request.Header.Set(session_key, test.header)
+			}
+			if test.cookie != "" {
+				request.AddCookie(&http.Cookie{Name: session_key, Value: test.cookie})
+			}
+			ctx := echo.New().NewContext(request, httptest.NewRecorder())
+			ctx.SetPath(test.route)
+
+			if token := sessionToken(ctx); token != test.expected {
+				t.Errorf("expected the token %q, got %q", test.expected, token)
+			}
+		})
+	}
+}
+
+func TestSessionCreatedCookie(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	ctx := echo.New().NewContext(httptest.NewRequest(http.MethodPost, "/api/1/sessions", nil), recorder)
+
+	api := &apiWeb{}
+	if err := api.sessionCreated(ctx, http.StatusCreated, &user{}, &session{Token: "token", ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+
+	cookie := recorder.Header().Get("Set-Cookie")
+	for _, attribute := range []string{"HttpOnly", "Secure", "SameSite=Strict"} {
+		if !strings.Contains(cookie, attribute) {
+			t.Errorf("expected the attribute %s on the cookie %s", attribute, cookie)
+		}
+	}
+}
+
+func TestAuthorizationRoutes(t *testing.T) {
+	storage := newFakeStorageDB()
+	userID, otherID, adminID := genUI(), genUI(), genUI()
+	userToken := storage.addSession(userID, roleUser)
+	otherToken := storage.addSession(otherID, roleUser)
+	adminToken := storage.addSession(adminID, roleAdmin)
+
+	_, client := newTestApi(storage)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		// expected is the status of the request, or zero when the request reaches the handler
+		expected int
+	}{
+		{name: "users without principal", method: http.MethodGet, path: "/api/1/users", expected: http.StatusUnauthorized},
+		{name: "users with an invalid token", method: http.MethodGet, path: "/api/1/users", token: "invalid", expected: http.StatusUnauthorized},
+		{name: "users by a user", method: http.MethodGet, path: "/api/1/users", token: userToken, expected: http.StatusForbidden},
+		{name: "users by an admin", method: http.MethodGet, path: "/api/1/users", token: adminToken, expected: http.StatusOK},
+		{name: "user without principal", method: http.MethodGet, path: "/api/1/users/" + userID, expected: http.StatusUnauthorized},
+		{name: "user by itself", method: http.MethodGet, path: "/api/1/users/" + userID, token: userToken},
+		{name: "user by another user", method: http.MethodGet, path: "/api/1/users/" + userID, token: otherToken, expected: http.StatusForbidden},
+		{name: "user by an admin", method: http.MethodGet, path: "/api/1/users/" + userID, token: adminToken},
+		{name: "role by a user", method: http.MethodPut, path: "/api/1/users/" + userID + "/role", token: userToken, expected: http.StatusForbidden},
+		{name: "status by a user", method: http.MethodPut, path: "/api/1/users/" + userID + "/status", token: userToken, expected: http.StatusForbidden},
+		{name: "wallets without principal", method: http.MethodGet, path: "/api/1/users/" + userID + "/wallets", expected: http.StatusUnauthorized},
+		{name: "wallets by another user", method: http.MethodGet, path: "/api/1/users/" + userID + "/wallets", token: otherToken, expected: http.StatusForbidden},
+		{name: "transactions by another user", method: http.MethodGet, path: "/api/1/users/" + userID + "/wallets/" + genUI() + "/transactions", token: otherToken, expected: http.StatusForbidden},
+		{name: "images by another user", method: http.MethodGet, path: "/api/1/users/" + userID + "/images", token: otherToken, expected: http.StatusForbidden},
+		{name: "deletion by another user", method: http.MethodDelete, path: "/api/1/users/" + userID, token: otherToken, expected: http.StatusForbidden},
+		{name: "sessions by another user", method: http.MethodGet, path: "/api/1/users/" + userID + "/sessions", token: otherToken, expected: http.StatusForbidden},
+		{name: "tokens by another user", method: http.MethodGet, path: "/api/1/users/" + userID + "/tokens", token: otherToken, expected: http.StatusForbidden},
+		{name: "export by another user", method: http.MethodPost, path: "/api/1/users/" + userID + "/exports", token: otherToken, expected: http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := httptest.NewRequest(test.method, test.path, nil)
+			if test.token != "" {
+				request.Header.Set(session_key, authentication+" "+test.token)
+			}
+			recorder := httptest.NewRecorder()
+			client.echo.ServeHTTP(recorder, request)
+
+			if test.expected == 0 {
+				if recorder.Code == http.StatusUnauthorized || recorder.Code == http.StatusForbidden {
+					t.Errorf("expected the request to be authorized, got %d with %s", recorder.Code, recorder.Body.String())
+				}
+			} else if recorder.Code != test.expected {
+				t.Errorf("expected the status %d, got %d with %s", test.expected, recorder.Code, recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestPrincipalCanAccess(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal *principal
+		userID    string
+		expected  bool
+	}{
+		{name: "itself", principal: &principal{UserID: "user", Role: roleUser}, userID: "user", expected: true},
+		{name: "another user", principal: &principal{UserID: "user", Role: roleUser}, userID: "other", expected: false},
+		{name: "admin", principal: &principal{UserID: "admin", Role: roleAdmin}, userID: "other", expected: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if access := test.principal.canAccess(test.userID); access != test.expected {
+				t.Errorf("expected %t, got %t", test.expected, access)
+			}
+		})
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal *principal
+		userID    string
+		expected  int
+	}{
+		{name: "route without user", principal: &principal{UserID: "user", Role: roleUser}, expected: http.StatusOK},
+		{name: "itself", principal: &principal{UserID: "user", Role: roleUser}, userID: "user", expected: http.StatusOK},
+		{name: "another user", principal: &principal{UserID: "user", Role: roleUser}, userID: "other", expected: http.StatusForbidden},
+		{name: "admin", principal: &principal{UserID: "admin", Role: roleAdmin}, userID: "other", expected: http.StatusOK},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			ctx := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), recorder)
+			if test.userID != "" {
+				ctx.SetParamNames("user_id")
+				ctx.SetParamValues(test.userID)
+			}
+
+			api := &apiWeb{}
+			err := api.authorize(ctx, func(ctx echo.Context) error { return ctx.NoContent(http.StatusOK) }, test.principal)
+			if err != nil {
+				t.Fatalf("unexpected error %s", err)
+			}
+			if recorder.Code != test.expected {
+				t.Errorf("expected the status %d, got %d", test.expected, recorder.Code)
+			}
+			if principalFromContext(ctx) != test.principal {
+				t.Errorf("expected the principal on the context")
+			}
+		})
+	}
+}
+
+func TestRequireAdmin(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal *principal
+		expected  int
+	}{
+		{name: "without principal", expected: http.StatusForbidden},
+		{name: "user", principal: &principal{UserID: "user", Role: roleUser}, expected: http.StatusForbidden},
+		{name: "admin", principal: &principal{UserID: "admin", Role: roleAdmin}, expected: http.StatusOK},
+	}
+
+	api := &apiWeb{}
+	handler := api.requireAdmin()(func(ctx echo.Context) error { return ctx.NoContent(http.StatusOK) })
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			ctx := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/api/1/users", nil), recorder)
+			if test.principal != nil {
+				ctx.Set(principalKey, test.principal)
+			}
+
+			if err := handler(ctx); err != nil {
+				t.Fatalf("unexpected error %s", err)
+			}
+			if recorder.Code != test.expected {
+				t.Errorf("expected the status %d, got %d", test.expected, recorder.Code)
+			}
+		})
+	}
+}
+
+func TestValidateOwnership(t *testing.T) {
+	storage := newFakeStorageDB()
+	storage.owners["image"] = "user"
+	storage.owners["other image"] = "other"
+	interactor := newInteractor(storage, nil, nil, nil, &MoneyConfig{})
+
+	tests := []struct {
+		name       string
+		ids        []string
+		authorized bool
+	}{
+		{name: "without references", ids: []string{"", ""}, authorized: true},
+		{name: "owned", ids: []string{"image"}, authorized: true},
+		{name: "of another user", ids: []string{"image", "other image"}, authorized: false},
+		{name: "missing", ids: []string{"missing"}, authorized: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := interactor.validateOwnership("user", "images", "image_id", test.ids...)
+			if test.authorized && err != nil {
+				t.Errorf("unexpected error %s", err)
+			}
+			if _, ok := err.(*authorizationError); !test.authorized && !ok {
+				t.Errorf("expected an authorization error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateTransactionOwnership(t *testing.T) {
+	storage := newFakeStorageDB()
+	storage.wallets["user:wallet"] = &wallet{WalletID: "wallet", UserID: "user", Role: walletRoleOwner}
+	storage.wallets["member:wallet"] = &wallet{WalletID: "wallet", UserID: "user", Role: walletRoleEditor}
+	storage.wallets["viewer:wallet"] = &wallet{WalletID: "wallet", UserID: "user", Role: walletRoleViewer}
+	storage.wallets["user:household wallet"] = &wallet{WalletID: "household wallet", UserID: "user", HouseholdID: "household", Role: walletRoleOwner}
+	storage.owners["category"] = "user"
+	storage.owners["member category"] = "member"
+	storage.owners["other category"] = "other"
+	storage.categories["household category"] = &category{CategoryID: "household category", HouseholdID: "household"}
+	storage.owners["household category"] = "user"
+	interactor := newInteractor(storage, nil, nil, nil, &MoneyConfig{})
+
+	tests := []struct {
+		name        string
+		transaction *transaction
+		authorized  bool
+	}{
+		{name: "owner with its category", transaction: &transaction{UserID: "user", WalletID: "wallet", CategoryID: "category"}, authorized: true},
+		{name: "owner with a category of another user", transaction: &transaction{UserID: "user", WalletID: "wallet", CategoryID: "other category"}},
+		{name: "wallet of another user", transaction: &transaction{UserID: "other", WalletID: "wallet", CategoryID: "other category"}},
+		{name: "editor with its category", transaction: &transaction{UserID: "member", WalletID: "wallet", CategoryID: "member category"}, authorized: true},
+		{name: "editor with a category of the owner", transaction: &transaction{UserID: "member", WalletID: "wallet", CategoryID: "category"}, authorized: true},
+		{name: "editor with a category of another user", transaction: &transaction{UserID: "member", WalletID: "wallet", CategoryID: "other category"}},
+		{name: "viewer", transaction: &transaction{UserID: "viewer", WalletID: "wallet", CategoryID: "category"}},
+		{name: "category of the household", transaction: &transaction{UserID: "user", WalletID: "household wallet", CategoryID: "household category"}, authorized: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := interactor.validateTransactionOwnership(test.transaction)
+			if test.authorized && err != nil {
+				t.Errorf("unexpected error %s", err)
+			}
+			if _, ok := err.(*authorizationError); !test.authorized && !ok {
+				t.Errorf("expected an authorization error, got %v", err)
+			}
+		})
+	}
+}
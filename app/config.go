@@ -19,4 +19,98 @@ type MoneyConfig struct {
 	Dropbox struct {
 		Enabled bool `json:"enabled"`
 	} `json:"dropbox"`
+	Blob struct {
+		Driver string `json:"driver"`
+		Local  struct {
+			Path string `json:"path"`
+		} `json:"local"`
+		S3 struct {
+			Endpoint  string `json:"endpoint"`
+			Region    string `json:"region"`
+			Bucket    string `json:"bucket"`
+			AccessKey string `json:"access_key"`
+			SecretKey string `json:"secret_key"`
+			PathStyle bool   `json:"path_style"`
+		} `json:"s3"`
+	} `json:"blob"`
+	Image struct {
+		Thumbnails      []thumbnailConfig `json:"thumbnails"`
+		MaxResize       int               `json:"max_resize"`
+		MaxSize         int               `json:"max_size"`
+		MaxPixels       int               `json:"max_pixels"`
+		Formats         []string          `json:"formats"`
+		CanonicalFormat string            `json:"canonical_format"`
+	} `json:"image"`
+	Attachment struct {
+		MaxSize           int `json:"max_size"`
+		MaxPerTransaction int `json:"max_per_transaction"`
+	} `json:"attachment"`
+	Receipt struct {
+		Radius float64 `json:"radius"`
+	} `json:"receipt"`
+	Security struct {
+		PasswordCost int    `json:"password_cost"`
+		Secret       string `json:"secret"`
+	} `json:"security"`
+	TOTP struct {
+		Issuer            string `json:"issuer"`
+		ChallengeLifetime int    `json:"challenge_lifetime"`
+		ChallengeCodes    int    `json:"challenge_codes"`
+	} `json:"totp"`
+	Wallet struct {
+		GrantLifetime int `json:"grant_lifetime"`
+	} `json:"wallet"`
+	Household struct {
+		InvitationLifetime int    `json:"invitation_lifetime"`
+		InvitationLink     string `json:"invitation_link"`
+	} `json:"household"`
+	Verification struct {
+		Lifetime int    `json:"lifetime"`
+		Link     string `json:"link"`
+	} `json:"verification"`
+	Mail struct {
+		Driver string `json:"driver"`
+		From   string `json:"from"`
+		SMTP   struct {
+			Host     string `json:"host"`
+			Port     int    `json:"port"`
+			Username string `json:"username"`
+			Password string `json:"password"`
+		} `json:"smtp"`
+	} `json:"mail"`
+	PasswordReset struct {
+		Lifetime int    `json:"lifetime"`
+		Link     string `json:"link"`
+	} `json:"password_reset"`
+	Login struct {
+		Limiter         string `json:"limiter"`
+		Window          int    `json:"window"`
+		MaxAttemptsIP   int    `json:"max_attempts_ip"`
+		DelayAfter      int    `json:"delay_after"`
+		MaxDelay        int    `json:"max_delay"`
+		LockoutAfter    int    `json:"lockout_after"`
+		LockoutDuration int    `json:"lockout_duration"`
+	} `json:"login"`
+	Session struct {
+		AccessLifetime  int `json:"access_lifetime"`
+		RefreshLifetime int `json:"refresh_lifetime"`
+		IdleTimeout     int `json:"idle_timeout"`
+		PurgeInterval   int `json:"purge_interval"`
+	} `json:"session"`
+	Export struct {
+		AsyncThreshold    int    `json:"async_threshold"`
+		Lifetime          int    `json:"lifetime"`
+		Currency          string `json:"currency"`
+		ImportMaxFiles    int    `json:"import_max_files"`
+		ImportMaxFileSize int64  `json:"import_max_file_size"`
+		ImportMaxSize     int64  `json:"import_max_size"`
+	} `json:"export"`
+}
+
+// thumbnailConfig ...
+type thumbnailConfig struct {
+	Name   string `json:"name"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Fit    string `json:"fit"`
 }
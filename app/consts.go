@@ -3,4 +3,7 @@ package gomoney
 const (
 	defaultPath = "."
 	path_key    = "path"
+
+	// the images can be replaced, so the clients revalidate them with the etag after a few minutes
+	imageCacheControl = "private, max-age=300"
 )
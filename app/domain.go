@@ -13,6 +13,10 @@ type user struct {
 	Email       string
 	Password    string
 	Token       string
+	Role        string
+	Status      int
+	TOTPSecret  string
+	TOTPEnabled bool
 	Description string
 	UpdatedAt   time.Time
 	CreatedAt   time.Time
@@ -25,8 +29,41 @@ type session struct {
 	Original    string
 	Token       string
 	Description string
-	UpdatedAt   time.Time
-	CreatedAt   time.Time
+	UserAgent   string
+	IP          string
+	// RefreshToken is only known when the session is created or refreshed, the database has its hash
+	RefreshToken     string
+	ExpiresAt        time.Time
+	RefreshExpiresAt time.Time
+	LastUsedAt       time.Time
+	UpdatedAt        time.Time
+	CreatedAt        time.Time
+}
+
+// accessToken ...
+type accessToken struct {
+	AccessTokenID string
+	UserID        string
+	Name          string
+	// Token is only known when the access token is created, the database has its hash
+	Token      string
+	Prefix     string
+	Scopes     []string
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+}
+
+// loginAttempt ...
+type loginAttempt struct {
+	LoginAttemptID string
+	Email          string
+	UserID         string
+	IP             string
+	UserAgent      string
+	Success        bool
+	Reason         string
+	CreatedAt      time.Time
 }
 
 // wallet ...
@@ -36,10 +73,81 @@ type wallet struct {
 	Name        string
 	Description string
 	Password    string
+	HouseholdID string
+	// Role is the role of the user that got the wallet, the owner or the role as a member
+	Role      string
+	UpdatedAt time.Time
+	CreatedAt time.Time
+}
+
+// walletMember ...
+type walletMember struct {
+	WalletID  string
+	UserID    string
+	Name      string
+	Email     string
+	Role      string
+	InvitedBy string
+	UpdatedAt time.Time
+	CreatedAt time.Time
+}
+
+// household ...
+type household struct {
+	HouseholdID string
+	Name        string
+	CreatedBy   string
+	// Role is the role of the user that got the household
+	Role      string
+	UpdatedAt time.Time
+	CreatedAt time.Time
+}
+
+// householdMember ...
+type householdMember struct {
+	HouseholdID string
+	UserID      string
+	Name        string
+	Email       string
+	Role        string
+	InvitedBy   string
+	UpdatedAt   time.Time
+	CreatedAt   time.Time
+}
+
+// householdInvitation ...
+type householdInvitation struct {
+	InvitationID string
+	HouseholdID  string
+	Email        string
+	Role         string
+	InvitedBy    string
+	// Token is only known when the invitation is created, the database has its hash
+	Token     string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+// budget is the limit of the expenses of a household on a period, on a category or on every expense
+type budget struct {
+	BudgetID    string
+	HouseholdID string
+	CategoryID  string
+	Amount      decimal.Decimal
+	Period      string
+	CreatedBy   string
 	UpdatedAt   time.Time
 	CreatedAt   time.Time
 }
 
+// budgetStatus is the budget with the expenses of its current period
+type budgetStatus struct {
+	*budget
+	CurrentPeriod string
+	Spent         decimal.Decimal
+	Remaining     decimal.Decimal
+}
+
 // image ...
 type image struct {
 	ImageID     string
@@ -49,11 +157,37 @@ type image struct {
 	Url         string
 	FileName    string
 	Format      string
+	Storage     string
+	Hash        string
 	RawImage    []byte
 	UpdatedAt   time.Time
 	CreatedAt   time.Time
 }
 
+// imageBlob is the content of the images, shared by every image with the same sha256 hash
+type imageBlob struct {
+	Hash       string
+	Storage    string
+	Size       int
+	References int
+	// Uploaded is set when the content is on the blob storage
+	Uploaded bool
+}
+
+// attachment is a file attached to a transaction, like the receipt
+type attachment struct {
+	AttachmentID  string
+	TransactionID string
+	UserID        string
+	FileName      string
+	ContentType   string
+	Size          int
+	Storage       string
+	Data          []byte
+	UpdatedAt     time.Time
+	CreatedAt     time.Time
+}
+
 // transaction ...
 type transaction struct {
 	TransactionID string
@@ -63,6 +197,11 @@ type transaction struct {
 	Price         decimal.Decimal
 	Description   string
 	Date          time.Time
+	Latitude      *float64
+	Longitude     *float64
+	GroupID       string
+	CreatedBy     string
+	UpdatedBy     string
 	UpdatedAt     time.Time
 	CreatedAt     time.Time
 }
@@ -71,9 +210,44 @@ type transaction struct {
 type category struct {
 	CategoryID  string
 	UserID      string
+	HouseholdID string
 	ImageID     string
 	Name        string
 	Description string
 	UpdatedAt   time.Time
 	CreatedAt   time.Time
 }
+
+// export ...
+type export struct {
+	ExportID string
+	UserID   string
+	Status   string
+	Error    string
+	Size     int
+	// Storage is the blob storage with the archive of the export
+	Storage   string
+	UpdatedAt time.Time
+	CreatedAt time.Time
+}
+
+// transactionFilter ...
+type transactionFilter struct {
+	WalletID   string
+	CategoryID string
+	From       time.Time
+	To         time.Time
+	// LockedWallets are the wallets with a password without a grant, their transactions are left out
+	LockedWallets map[string]bool
+}
+
+// reportItem ...
+type reportItem struct {
+	Period     string
+	CategoryID string
+	Category   string
+	Count      int
+	Expenses   decimal.Decimal
+	Income     decimal.Decimal
+	Total      decimal.Decimal
+}
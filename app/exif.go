@@ -0,0 +1,277 @@
+package gomoney
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"time"
+)
+
+const (
+	exifTagOrientation        = 0x0112
+	exifTagDateTime           = 0x0132
+	exifTagExifDirectory      = 0x8769
+	exifTagGPSDirectory       = 0x8825
+	exifTagDateTimeOriginal   = 0x9003
+	exifTagOffsetTimeOriginal = 0x9011
+	exifTagGPSLatitudeRef     = 0x0001
+	exifTagGPSLatitude        = 0x0002
+	exifTagGPSLongitudeRef    = 0x0003
+	exifTagGPSLongitude       = 0x0004
+
+	exifDateFormat = "2006:01:02 15:04:05"
+)
+
+// exifData is the metadata read from the exif of an image.
+// The date is zero when the image doesn't have it, and the location is only valid with HasLocation.
+type exifData struct {
+	Orientation int
+	Date        time.Time
+	HasLocation bool
+	Latitude    float64
+	Longitude   float64
+}
+
+// exifEntry is an entry of an exif directory
+type exifEntry struct {
+	tag    uint16
+	kind   uint16
+	count  uint32
+	value  []byte
+	offset uint32
+}
+
+// readExif reads the exif of a jpeg, png or webp image, it returns nil when the image doesn't have exif
+func readExif(format string, data []byte) *exifData {
+	payload := exifPayload(format, data)
+	if payload == nil {
+		return nil
+	}
+
+	exif := &exifData{}
+	entries, order := readExifDirectory(payload, -1)
+
+	var dateTime string
+	for _, entry := range entries {
+		switch entry.tag {
+		case exifTagOrientation:
+			exif.Orientation = int(exifUint(order, entry))
+		case exifTagDateTime:
+			dateTime = exifString(entry)
+		case exifTagExifDirectory:
+			readExifDate(exif, payload, int64(exifUint(order, entry)))
+		case exifTagGPSDirectory:
+			readExifLocation(exif, payload, int64(exifUint(order, entry)))
+		}
+	}
+
+	// the modification date is used when the photo doesn't have the original date
+	if exif.Date.IsZero() && dateTime != "" {
+		if date, err := time.Parse(exifDateFormat, dateTime); err == nil {
+			exif.Date = date
+		}
+	}
+
+	return exif
+}
+
+// readExifDate reads the date when the photo was taken from the exif directory,
+// with the offset when it is known or as utc otherwise
+func readExifDate(exif *exifData, payload []byte, offset int64) {
+	entries, _ := readExifDirectory(payload, offset)
+
+	var dateTime, offsetTime string
+	for _, entry := range entries {
+		switch entry.tag {
+		case exifTagDateTimeOriginal:
+			dateTime = exifString(entry)
+		case exifTagOffsetTimeOriginal:
+			offsetTime = exifString(entry)
+		}
+	}
+
+	if dateTime == "" {
+		return
+	}
+
+	if offsetTime != "" {
+		if date, err := time.Parse(exifDateFormat+"-07:00", dateTime+offsetTime); err == nil {
+			exif.Date = date
+			return
+		}
+	}
+
+	if date, err := time.Parse(exifDateFormat, dateTime); err == nil {
+		exif.Date = date
+	}
+}
+
+// readExifLocation reads the gps coordinates from the gps directory, as degrees, minutes and seconds
+func readExifLocation(exif *exifData, payload []byte, offset int64) {
+	entries, order := readExifDirectory(payload, offset)
+
+	var latitudeRef, longitudeRef string
+	var latitude, longitude []float64
+	for _, entry := range entries {
+		switch entry.tag {
+		case exifTagGPSLatitudeRef:
+			latitudeRef = exifString(entry)
+		case exifTagGPSLatitude:
+			latitude = exifRationals(order, entry)
+		case exifTagGPSLongitudeRef:
+			longitudeRef = exifString(entry)
+		case exifTagGPSLongitude:
+			longitude = exifRationals(order, entry)
+		}
+	}
+
+	if len(latitude) != 3 || len(longitude) != 3 {
+		return
+	}
+
+	exif.Latitude = latitude[0] + latitude[1]/60 + latitude[2]/3600
+	exif.Longitude = longitude[0] + longitude[1]/60 + longitude[2]/3600
+	if latitudeRef == "S" {
+		exif.Latitude = -exif.Latitude
+	}
+	if longitudeRef == "W" {
+		exif.Longitude = -exif.Longitude
+	}
+
+	exif.HasLocation = exif.Latitude >= -90 && exif.Latitude <= 90 && exif.Longitude >= -180 && exif.Longitude <= 180 &&
+		(exif.Latitude != 0 || exif.Longitude != 0)
+}
+
+// exifPayload returns the tiff structure with the exif of the image
+func exifPayload(format string, data []byte) []byte {
+	switch format {
+	case "jpeg", "jpg":
+		for _, segment := range jpegSegments(data) {
+			if segment.marker == 0xe1 && bytes.HasPrefix(segment.data, []byte("Exif\x00\x00")) {
+				return segment.data[6:]
+			}
+		}
+	case "png":
+		for _, chunk := range pngChunks(data) {
+			if chunk.kind == "eXIf" {
+				return chunk.data
+			}
+		}
+	case "webp":
+		for _, chunk := range webpChunks(data) {
+			if chunk.kind == "EXIF" {
+				return bytes.TrimPrefix(chunk.data, []byte("Exif\x00\x00"))
+			}
+		}
+	}
+	return nil
+}
+
+// readExifDirectory reads the entries of the directory on the offset, or of the first directory when the offset is negative
+func readExifDirectory(payload []byte, offset int64) ([]*exifEntry, binary.ByteOrder) {
+	if len(payload) < 8 {
+		return nil, nil
+	}
+
+	var order binary.ByteOrder
+	switch string(payload[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil, nil
+	}
+
+	if order.Uint16(payload[2:4]) != 42 {
+		return nil, nil
+	}
+
+	if offset < 0 {
+		offset = int64(order.Uint32(payload[4:8]))
+	}
+	if offset < 8 || offset+2 > int64(len(payload)) {
+		return nil, order
+	}
+
+	count := int64(order.Uint16(payload[offset:]))
+	entries := make([]*exifEntry, 0, count)
+	for i := int64(0); i < count; i++ {
+		start := offset + 2 + i*12
+		if start+12 > int64(len(payload)) {
+			break
+		}
+
+		entry := &exifEntry{
+			tag:    order.Uint16(payload[start:]),
+			kind:   order.Uint16(payload[start+2:]),
+			count:  order.Uint32(payload[start+4:]),
+			offset: order.Uint32(payload[start+8:]),
+		}
+
+		size := int64(exifTypeSize(entry.kind)) * int64(entry.count)
+		if size <= 4 {
+			entry.value = payload[start+8 : start+8+size]
+		} else if int64(entry.offset)+size <= int64(len(payload)) {
+			entry.value = payload[entry.offset : int64(entry.offset)+size]
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, order
+}
+
+// exifTypeSize returns the size of a value of the type
+func exifTypeSize(kind uint16) int {
+	switch kind {
+	case 1, 2, 6, 7: // byte, ascii, signed byte, undefined
+		return 1
+	case 3, 8: // short, signed short
+		return 2
+	case 4, 9, 11: // long, signed long, float
+		return 4
+	case 5, 10, 12: // rational, signed rational, double
+		return 8
+	default:
+		return 0
+	}
+}
+
+// exifUint reads the first value of a short or long entry
+func exifUint(order binary.ByteOrder, entry *exifEntry) uint32 {
+	switch {
+	case entry.kind == 3 && len(entry.value) >= 2:
+		return uint32(order.Uint16(entry.value))
+	case entry.kind == 4 && len(entry.value) >= 4:
+		return order.Uint32(entry.value)
+	default:
+		return 0
+	}
+}
+
+// exifString reads an ascii entry
+func exifString(entry *exifEntry) string {
+	if entry.kind != 2 {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimRight(string(entry.value), "\x00"))
+}
+
+// exifRationals reads the values of an unsigned rational entry
+func exifRationals(order binary.ByteOrder, entry *exifEntry) []float64 {
+	if entry.kind != 5 {
+		return nil
+	}
+
+	values := make([]float64, 0, entry.count)
+	for i := 0; i+8 <= len(entry.value); i += 8 {
+		numerator := order.Uint32(entry.value[i:])
+		denominator := order.Uint32(entry.value[i+4:])
+		if denominator == 0 {
+			return nil
+		}
+		values = append(values, float64(numerator)/float64(denominator))
+	}
+	return values
+}
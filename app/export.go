@@ -0,0 +1,531 @@
+package gomoney
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	archiveVersion = 1
+
+	exportStatusPending = "pending"
+	exportStatusRunning = "running"
+	exportStatusDone    = "done"
+	exportStatusFailed  = "failed"
+
+	defaultExportAsyncThreshold = 1000
+	defaultExportLifetime       = 7 * 24 * 60 * 60
+
+	defaultImportMaxFiles    = 100000
+	defaultImportMaxFileSize = 100 * 1024 * 1024
+	defaultImportMaxSize     = 1024 * 1024 * 1024
+)
+
+// accountArchive is the content of a data portability archive
+type accountArchive struct {
+	Manifest     archiveManifest
+	User         archiveUser
+	Sessions     []*archiveSession
+	Wallets      []*archiveWallet
+	Categories   []*archiveCategory
+	Images       []*archiveImage
+	Transactions []*archiveTransaction
+	Files        map[string][]byte
+	// loadFile loads the content of the images without a file, while the archive is written
+	loadFile func(imageID string) ([]byte, error)
+}
+
+// archiveManifest ...
+type archiveManifest struct {
+	Version       int       `json:"version"`
+	UserID        string    `json:"user_id"`
+	CreatedAt     time.Time `json:"created_at"`
+	MissingImages []string  `json:"missing_images,omitempty"`
+	// LockedWallets are the wallets with a password exported without their transactions, because they weren't unlocked
+	LockedWallets []string `json:"locked_wallets,omitempty"`
+}
+
+// archiveUser ...
+type archiveUser struct {
+	UserID      string    `json:"user_id"`
+	Name        string    `json:"name"`
+	Email       string    `json:"email"`
+	Description string    `json:"description,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// archiveSession ...
+type archiveSession struct {
+	SessionID   string    `json:"session_id"`
+	Description string    `json:"description,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// archiveWallet ...
+type archiveWallet struct {
+	WalletID    string    `json:"wallet_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// archiveCategory ...
+type archiveCategory struct {
+	CategoryID  string    `json:"category_id"`
+	ImageID     string    `json:"image_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// archiveImage ...
+type archiveImage struct {
+	ImageID     string    `json:"image_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Url         string    `json:"url,omitempty"`
+	FileName    string    `json:"file_name,omitempty"`
+	Format      string    `json:"format,omitempty"`
+	File        string    `json:"file,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// archiveTransaction ...
+type archiveTransaction struct {
+	TransactionID string    `json:"transaction_id"`
+	WalletID      string    `json:"wallet_id"`
+	CategoryID    string    `json:"category_id"`
+	Price         string    `json:"price"`
+	Description   string    `json:"description,omitempty"`
+	Date          time.Time `json:"date"`
+	Latitude      *float64  `json:"latitude,omitempty"`
+	Longitude     *float64  `json:"longitude,omitempty"`
+	GroupID       string    `json:"group_id,omitempty"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func archiveImageFile(image *archiveImage) string {
+	if image.Format == "" {
+		return fmt.Sprintf("images/%s", image.ImageID)
+	}
+	return fmt.Sprintf("images/%s.%s", image.ImageID, image.Format)
+}
+
+// writeAccountArchive writes the archive as a zip with a json and a csv file for each entity.
+// The images are written first, loading each of them only when it is written, and the images that
+// can't be loaded are left out and added to the missing images of the manifest.
+func writeAccountArchive(writer io.Writer, archive *accountArchive) error {
+	zipWriter := zip.NewWriter(writer)
+
+	for _, image := range archive.Images {
+		if image.File == "" {
+			continue
+		}
+
+		data, ok := archive.Files[image.ImageID]
+		if !ok && archive.loadFile != nil {
+			if loaded, err := archive.loadFile(image.ImageID); err == nil && loaded != nil {
+				data, ok = loaded, true
+			}
+		}
+		if !ok {
+			archive.Manifest.MissingImages = append(archive.Manifest.MissingImages, image.ImageID)
+			image.File = ""
+			continue
+		}
+
+		fileWriter, err := zipWriter.Create(image.File)
+		if err != nil {
+			return err
+		}
+		if _, err := fileWriter.Write(data); err != nil {
+			return err
+		}
+	}
+
+	jsonFiles := []struct {
+		name  string
+		value interface{}
+	}{
+		{"manifest.json", archive.Manifest},
+		{"user.json", archive.User},
+		{"sessions.json", archive.Sessions},
+		{"wallets.json", archive.Wallets},
+		{"categories.json", archive.Categories},
+		{"images.json", archive.Images},
+		{"transactions.json", archive.Transactions},
+	}
+
+	for _, file := range jsonFiles {
+		if err := writeArchiveJSON(zipWriter, file.name, file.value); err != nil {
+			return err
+		}
+	}
+
+	if err := writeArchiveCSV(zipWriter, "user.csv", archiveUserRecords(archive)); err != nil {
+		return err
+	}
+	if err := writeArchiveCSV(zipWriter, "sessions.csv", archiveSessionRecords(archive)); err != nil {
+		return err
+	}
+	if err := writeArchiveCSV(zipWriter, "wallets.csv", archiveWalletRecords(archive)); err != nil {
+		return err
+	}
+	if err := writeArchiveCSV(zipWriter, "categories.csv", archiveCategoryRecords(archive)); err != nil {
+		return err
+	}
+	if err := writeArchiveCSV(zipWriter, "images.csv", archiveImageRecords(archive)); err != nil {
+		return err
+	}
+	if err := writeArchiveCSV(zipWriter, "transactions.csv", archiveTransactionRecords(archive)); err != nil {
+		return err
+	}
+
+	return zipWriter.Close()
+}
+
+func writeArchiveJSON(zipWriter *zip.Writer, name string, value interface{}) error {
+	fileWriter, err := zipWriter.Create(name)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(fileWriter)
+	encoder.SetIndent("", "    ")
+	return encoder.Encode(value)
+}
+
+func writeArchiveCSV(zipWriter *zip.Writer, name string, records [][]string) error {
+	fileWriter, err := zipWriter.Create(name)
+	if err != nil {
+		return err
+	}
+
+	csvWriter := csv.NewWriter(fileWriter)
+	if err := csvWriter.WriteAll(records); err != nil {
+		return err
+	}
+
+	return csvWriter.Error()
+}
+
+func archiveUserRecords(archive *accountArchive) [][]string {
+	return [][]string{
+		{"user_id", "name", "email", "description", "updated_at", "created_at"},
+		{
+			archive.User.UserID,
+			archive.User.Name,
+			archive.User.Email,
+			archive.User.Description,
+			archive.User.UpdatedAt.Format(time.RFC3339),
+			archive.User.CreatedAt.Format(time.RFC3339),
+		},
+	}
+}
+
+func archiveSessionRecords(archive *accountArchive) [][]string {
+	records := [][]string{{"session_id", "description", "updated_at", "created_at"}}
+	for _, session := range archive.Sessions {
+		records = append(records, []string{
+			session.SessionID,
+			session.Description,
+			session.UpdatedAt.Format(time.RFC3339),
+			session.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	return records
+}
+
+func archiveWalletRecords(archive *accountArchive) [][]string {
+	records := [][]string{{"wallet_id", "name", "description", "updated_at", "created_at"}}
+	for _, wallet := range archive.Wallets {
+		records = append(records, []string{
+			wallet.WalletID,
+			wallet.Name,
+			wallet.Description,
+			wallet.UpdatedAt.Format(time.RFC3339),
+			wallet.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	return records
+}
+
+func archiveCategoryRecords(archive *accountArchive) [][]string {
+	records := [][]string{{"category_id", "image_id", "name", "description", "updated_at", "created_at"}}
+	for _, category := range archive.Categories {
+		records = append(records, []string{
+			category.CategoryID,
+			category.ImageID,
+			category.Name,
+			category.Description,
+			category.UpdatedAt.Format(time.RFC3339),
+			category.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	return records
+}
+
+func archiveImageRecords(archive *accountArchive) [][]string {
+	records := [][]string{{"image_id", "name", "description", "url", "file_name", "format", "file", "updated_at", "created_at"}}
+	for _, image := range archive.Images {
+		records = append(records, []string{
+			image.ImageID,
+			image.Name,
+			image.Description,
+			image.Url,
+			image.FileName,
+			image.Format,
+			image.File,
+			image.UpdatedAt.Format(time.RFC3339),
+			image.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	return records
+}
+
+func archiveTransactionRecords(archive *accountArchive) [][]string {
+	records := [][]string{{"transaction_id", "wallet_id", "category_id", "price", "description", "date", "group_id", "updated_at", "created_at"}}
+	for _, transaction := range archive.Transactions {
+		records = append(records, []string{
+			transaction.TransactionID,
+			transaction.WalletID,
+			transaction.CategoryID,
+			transaction.Price,
+			transaction.Description,
+			transaction.Date.Format(time.RFC3339),
+			transaction.GroupID,
+			transaction.UpdatedAt.Format(time.RFC3339),
+			transaction.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	return records
+}
+
+// archiveError is returned when an archive can not be imported
+type archiveError struct {
+	message string
+}
+
+func (e *archiveError) Error() string {
+	return e.message
+}
+
+func newArchiveError(format string, arguments ...interface{}) *archiveError {
+	return &archiveError{message: fmt.Sprintf(format, arguments...)}
+}
+
+// archiveLimits are the limits of an archive imported, that is read to memory
+type archiveLimits struct {
+	maxFiles    int
+	maxFileSize int64
+	maxSize     int64
+}
+
+// archiveLimits returns the configured limits of the imported archives
+func (interactor *interactor) archiveLimits() archiveLimits {
+	limits := archiveLimits{
+		maxFiles:    interactor.config.Export.ImportMaxFiles,
+		maxFileSize: interactor.config.Export.ImportMaxFileSize,
+		maxSize:     interactor.config.Export.ImportMaxSize,
+	}
+	if limits.maxFiles <= 0 {
+		limits.maxFiles = defaultImportMaxFiles
+	}
+	if limits.maxFileSize <= 0 {
+		limits.maxFileSize = defaultImportMaxFileSize
+	}
+	if limits.maxSize <= 0 {
+		limits.maxSize = defaultImportMaxSize
+	}
+	return limits
+}
+
+// readAccountArchive reads an archive written by writeAccountArchive. The number of files and their size
+// once decompressed can't exceed the limits, that are checked before and while reading each file.
+func readAccountArchive(reader io.ReaderAt, size int64, limits archiveLimits) (*accountArchive, error) {
+	zipReader, err := zip.NewReader(reader, size)
+	if err != nil {
+		return nil, newArchiveError("invalid archive: %s", err)
+	}
+	if len(zipReader.File) > limits.maxFiles {
+		return nil, newArchiveError("the archive has more than %d files", limits.maxFiles)
+	}
+	remaining := limits.maxSize
+
+	files := make(map[string]*zip.File)
+	for _, file := range zipReader.File {
+		files[file.Name] = file
+	}
+
+	archive := &accountArchive{
+		Files: make(map[string][]byte),
+	}
+
+	jsonFiles := []struct {
+		name  string
+		value interface{}
+	}{
+		{"manifest.json", &archive.Manifest},
+		{"user.json", &archive.User},
+		{"sessions.json", &archive.Sessions},
+		{"wallets.json", &archive.Wallets},
+		{"categories.json", &archive.Categories},
+		{"images.json", &archive.Images},
+		{"transactions.json", &archive.Transactions},
+	}
+
+	for _, jsonFile := range jsonFiles {
+		file, ok := files[jsonFile.name]
+		if !ok {
+			return nil, newArchiveError("missing %s on archive", jsonFile.name)
+		}
+
+		data, err := readArchiveFile(file, limits.maxFileSize, &remaining)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(data, jsonFile.value); err != nil {
+			return nil, newArchiveError("invalid %s on archive: %s", jsonFile.name, err)
+		}
+	}
+
+	for _, image := range archive.Images {
+		if image.File == "" {
+			continue
+		}
+
+		file, ok := files[image.File]
+		if !ok {
+			return nil, newArchiveError("missing file %s of image %s on archive", image.File, image.ImageID)
+		}
+
+		data, err := readArchiveFile(file, limits.maxFileSize, &remaining)
+		if err != nil {
+			return nil, err
+		}
+		archive.Files[image.ImageID] = data
+	}
+
+	return archive, nil
+}
+
+// readArchiveFile reads a file of an archive up to the max size and to the remaining size of the archive,
+// that is decreased by the size of the file. The size on the header is checked before reading,
+// and the reading is limited in case the header doesn't have the real size.
+func readArchiveFile(file *zip.File, maxSize int64, remaining *int64) ([]byte, error) {
+	limit := maxSize
+	if *remaining < limit {
+		limit = *remaining
+	}
+	if file.UncompressedSize64 > uint64(limit) {
+		return nil, newArchiveError("the file %s on archive exceeds the size of the imports", file.Name)
+	}
+
+	reader, err := file.Open()
+	if err != nil {
+		return nil, newArchiveError("invalid file %s on archive: %s", file.Name, err)
+	}
+	defer reader.Close()
+
+	data, err := ioutil.ReadAll(io.LimitReader(reader, limit+1))
+	if err != nil {
+		return nil, newArchiveError("invalid file %s on archive: %s", file.Name, err)
+	}
+	if int64(len(data)) > limit {
+		return nil, newArchiveError("the file %s on archive exceeds the size of the imports", file.Name)
+	}
+	*remaining -= int64(len(data))
+
+	return data, nil
+}
+
+// validateAccountArchive checks the version and the referential integrity of the archive
+func validateAccountArchive(archive *accountArchive) error {
+	if archive.Manifest.Version != archiveVersion {
+		return newArchiveError("unsupported archive version %d", archive.Manifest.Version)
+	}
+
+	wallets := make(map[string]bool)
+	for _, wallet := range archive.Wallets {
+		if err := valUI(wallet.WalletID); err != nil {
+			return newArchiveError("invalid wallet id %s", wallet.WalletID)
+		}
+		if wallets[wallet.WalletID] {
+			return newArchiveError("duplicated wallet %s", wallet.WalletID)
+		}
+		if wallet.Name == "" {
+			return newArchiveError("wallet %s without name", wallet.WalletID)
+		}
+		wallets[wallet.WalletID] = true
+	}
+
+	images := make(map[string]bool)
+	for _, image := range archive.Images {
+		if err := valUI(image.ImageID); err != nil {
+			return newArchiveError("invalid image id %s", image.ImageID)
+		}
+		if images[image.ImageID] {
+			return newArchiveError("duplicated image %s", image.ImageID)
+		}
+		if image.Name == "" {
+			return newArchiveError("image %s without name", image.ImageID)
+		}
+		images[image.ImageID] = true
+	}
+
+	categories := make(map[string]bool)
+	for _, category := range archive.Categories {
+		if err := valUI(category.CategoryID); err != nil {
+			return newArchiveError("invalid category id %s", category.CategoryID)
+		}
+		if categories[category.CategoryID] {
+			return newArchiveError("duplicated category %s", category.CategoryID)
+		}
+		if category.Name == "" {
+			return newArchiveError("category %s without name", category.CategoryID)
+		}
+		if !images[category.ImageID] {
+			return newArchiveError("category %s references unknown image %s", category.CategoryID, category.ImageID)
+		}
+		categories[category.CategoryID] = true
+	}
+
+	transactions := make(map[string]bool)
+	for _, transaction := range archive.Transactions {
+		if err := valUI(transaction.TransactionID); err != nil {
+			return newArchiveError("invalid transaction id %s", transaction.TransactionID)
+		}
+		if transactions[transaction.TransactionID] {
+			return newArchiveError("duplicated transaction %s", transaction.TransactionID)
+		}
+		if !wallets[transaction.WalletID] {
+			return newArchiveError("transaction %s references unknown wallet %s", transaction.TransactionID, transaction.WalletID)
+		}
+		if !categories[transaction.CategoryID] {
+			return newArchiveError("transaction %s references unknown category %s", transaction.TransactionID, transaction.CategoryID)
+		}
+		if _, err := decimal.NewFromString(transaction.Price); err != nil {
+			return newArchiveError("transaction %s with invalid price %s", transaction.TransactionID, transaction.Price)
+		}
+		if !validLocation(transaction.Latitude, transaction.Longitude) {
+			return newArchiveError("transaction %s with invalid location", transaction.TransactionID)
+		}
+		transactions[transaction.TransactionID] = true
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package gomoney
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"testing"
+	"time"
+)
+
+func newTestArchive(t *testing.T) []byte {
+	archive := &accountArchive{
+		Manifest: archiveManifest{Version: archiveVersion, UserID: "user", CreatedAt: time.Now()},
+		User:     archiveUser{UserID: "user", Name: "user", Email: "user@example.com"},
+		Images:   []*archiveImage{{ImageID: "image", Name: "image", Format: "png", File: "images/image.png"}},
+		Files:    map[string][]byte{"image": bytes.Repeat([]byte{1}, 1000)},
+	}
+
+	var buffer bytes.Buffer
+	if err := writeAccountArchive(&buffer, archive); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	return buffer.Bytes()
+}
+
+func TestReadAccountArchiveLimits(t *testing.T) {
+	data := newTestArchive(t)
+
+	tests := []struct {
+		name   string
+		limits archiveLimits
+		err    bool
+	}{
+		{name: "inside of the limits", limits: archiveLimits{maxFiles: 100, maxFileSize: 10000, maxSize: 100000}},
+		{name: "too many files", limits: archiveLimits{maxFiles: 2, maxFileSize: 10000, maxSize: 100000}, err: true},
+		{name: "file too large", limits: archiveLimits{maxFiles: 100, maxFileSize: 500, maxSize: 100000}, err: true},
+		{name: "archive too large", limits: archiveLimits{maxFiles: 100, maxFileSize: 10000, maxSize: 1200}, err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			archive, err := readAccountArchive(bytes.NewReader(data), int64(len(data)), test.limits)
+			if test.err {
+				if _, ok := err.(*archiveError); !ok {
+					t.Errorf("expected an archive error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error %s", err)
+			}
+			if len(archive.Files["image"]) != 1000 {
+				t.Errorf("expected the image with 1000 bytes, got %d", len(archive.Files["image"]))
+			}
+		})
+	}
+}
+
+// the size on the header of a file can be changed, the reading must stop on the limit anyway
+func TestReadAccountArchiveFileSizeHeader(t *testing.T) {
+	var compressed bytes.Buffer
+	compressor, _ := flate.NewWriter(&compressed, flate.BestCompression)
+	compressor.Write(make([]byte, 10*1024*1024))
+	compressor.Close()
+
+	var buffer bytes.Buffer
+	writer := zip.NewWriter(&buffer)
+	file, err := writer.CreateRaw(&zip.FileHeader{
+		Name:               "manifest.json",
+		Method:             zip.Deflate,
+		CompressedSize64:   uint64(compressed.Len()),
+		UncompressedSize64: 10,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	file.Write(compressed.Bytes())
+	writer.Close()
+
+	data := buffer.Bytes()
+	_, err = readAccountArchive(bytes.NewReader(data), int64(len(data)), archiveLimits{maxFiles: 10, maxFileSize: 1024, maxSize: 1024})
+	if _, ok := err.(*archiveError); !ok {
+		t.Errorf("expected an archive error, got %v", err)
+	}
+}
+
+func TestWriteAccountArchiveLoadFile(t *testing.T) {
+	archive := &accountArchive{
+		Manifest: archiveManifest{Version: archiveVersion, UserID: "user", CreatedAt: time.Now()},
+		User:     archiveUser{UserID: "user", Name: "user", Email: "user@example.com"},
+		Images: []*archiveImage{
+			{ImageID: "image", Name: "image", Format: "png", File: "images/image.png"},
+			{ImageID: "missing", Name: "missing", Format: "png", File: "images/missing.png"},
+		},
+		Files: make(map[string][]byte),
+		loadFile: func(imageID string) ([]byte, error) {
+			if imageID == "image" {
+				return []byte("image"), nil
+			}
+			return nil, nil
+		},
+	}
+
+	var buffer bytes.Buffer
+	if err := writeAccountArchive(&buffer, archive); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+
+	data := buffer.Bytes()
+	read, err := readAccountArchive(bytes.NewReader(data), int64(len(data)), archiveLimits{maxFiles: 100, maxFileSize: 10000, maxSize: 100000})
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+
+	if string(read.Files["image"]) != "image" {
+		t.Errorf("expected the loaded image, got %q", read.Files["image"])
+	}
+	if len(read.Manifest.MissingImages) != 1 || read.Manifest.MissingImages[0] != "missing" {
+		t.Errorf("expected the missing image on the manifest, got %v", read.Manifest.MissingImages)
+	}
+	if read.Images[1].File != "" {
+		t.Errorf("expected the missing image without file, got %s", read.Images[1].File)
+	}
+}
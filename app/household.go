@@ -0,0 +1,731 @@
+package gomoney
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/joaosoft/errors"
+	"github.com/labstack/echo"
+	"github.com/shopspring/decimal"
+)
+
+// the roles on a household, the owner created the household and the others joined it with an invitation
+const (
+	householdRoleViewer = "viewer"
+	householdRoleMember = "member"
+	householdRoleAdmin  = "admin"
+	householdRoleOwner  = "owner"
+
+	defaultInvitationLifetime = 7 * 24 * 60 * 60
+)
+
+var householdRoleRanks = map[string]int{
+	householdRoleViewer: 1,
+	householdRoleMember: 2,
+	householdRoleAdmin:  3,
+	householdRoleOwner:  4,
+}
+
+var errInvalidHouseholdRole = errors.New(errors.LevelError, 1, "invalid role, the roles are viewer, member and admin")
+var errHouseholdNotFound = errors.New(errors.LevelError, 1, "the household was not found")
+var errHouseholdMemberExists = errors.New(errors.LevelError, 1, "the user is already a member of the household")
+var errHouseholdMemberIsOwner = errors.New(errors.LevelError, 1, "the user is the owner of the household")
+var errWalletOnOtherHousehold = errors.New(errors.LevelError, 1, "the wallet is shared with another household")
+var errInvalidBudget = errors.New(errors.LevelError, 1, "invalid budget, the amount must be positive and the period month or year")
+
+// isHouseholdMemberRole checks if the role can be given to a member
+func isHouseholdMemberRole(role string) bool {
+	return role == householdRoleViewer || role == householdRoleMember || role == householdRoleAdmin
+}
+
+// householdRoleAllows checks if the role has at least the permissions of the required role
+func householdRoleAllows(role string, required string) bool {
+	return householdRoleRanks[role] >= householdRoleRanks[required]
+}
+
+// householdWithRole returns the household when the user is a member with at least the role,
+// nil when the user isn't a member and an authorization error when the role isn't enough
+func (interactor *interactor) householdWithRole(userID string, householdID string, role string) (*household, error) {
+	household, err := interactor.getHousehold(userID, householdID)
+	if err != nil || household == nil {
+		return nil, err
+	}
+	if !householdRoleAllows(household.Role, role) {
+		return nil, newAuthorizationError("the %s role of the household %s is required", role, householdID)
+	}
+	return household, nil
+}
+
+// getHouseholds returns the households of the user
+func (interactor *interactor) getHouseholds(userID string) ([]*household, error) {
+	log.WithFields(map[string]interface{}{"method": "getHouseholds"})
+	log.Infof("getting households of user %s", userID)
+
+	if households, err := interactor.storageDB.getHouseholds(userID); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error getting households on storage database %s", err)
+		return nil, err
+	} else {
+		return households, nil
+	}
+}
+
+// getHousehold returns the household, or nil when the user isn't a member
+func (interactor *interactor) getHousehold(userID string, householdID string) (*household, error) {
+	log.WithFields(map[string]interface{}{"method": "getHousehold"})
+	log.Infof("getting household %s of user %s", householdID, userID)
+
+	if household, err := interactor.storageDB.getHousehold(userID, householdID); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error getting household on storage database %s", err)
+		return nil, err
+	} else {
+		return household, nil
+	}
+}
+
+// createHousehold creates a household with the user as the owner
+func (interactor *interactor) createHousehold(userID string, name string) (*household, error) {
+	log.WithFields(map[string]interface{}{"method": "createHousehold"})
+	log.Infof("creating household %s of user %s", name, userID)
+
+	if household, err := interactor.storageDB.createHousehold(&household{
+		HouseholdID: genUI(),
+		Name:        name,
+		CreatedBy:   userID,
+	}); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error creating household on storage database %s", err)
+		return nil, err
+	} else {
+		return household, nil
+	}
+}
+
+// updateHousehold renames the household, returning nil when the user isn't a member
+func (interactor *interactor) updateHousehold(userID string, householdID string, name string) (*household, error) {
+	log.WithFields(map[string]interface{}{"method": "updateHousehold"})
+	log.Infof("updating household %s of user %s", householdID, userID)
+
+	if household, err := interactor.householdWithRole(userID, householdID, householdRoleAdmin); err != nil || household == nil {
+		return nil, err
+	}
+
+	if household, err := interactor.storageDB.updateHousehold(userID, householdID, name); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error updating household on storage database %s", err)
+		return nil, err
+	} else {
+		return household, nil
+	}
+}
+
+// deleteHousehold deletes the household, only the owner can delete it. The wallets go back to their owners and
+// the shared categories to the users that created them. It returns false when the user isn't a member.
+func (interactor *interactor) deleteHousehold(userID string, householdID string) (bool, error) {
+	log.WithFields(map[string]interface{}{"method": "deleteHousehold"})
+	log.Infof("deleting household %s of user %s", householdID, userID)
+
+	if household, err := interactor.householdWithRole(userID, householdID, householdRoleOwner); err != nil || household == nil {
+		return false, err
+	}
+
+	if err := interactor.storageDB.deleteHousehold(householdID); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error deleting household on storage database %s", err)
+		return false, err
+	}
+
+	return true, nil
+}
+
+// getHouseholdMembers returns the members of the household, or nil when the user isn't a member
+func (interactor *interactor) getHouseholdMembers(userID string, householdID string) ([]*householdMember, error) {
+	log.WithFields(map[string]interface{}{"method": "getHouseholdMembers"})
+	log.Infof("getting members of household %s of user %s", householdID, userID)
+
+	if household, err := interactor.getHousehold(userID, householdID); err != nil || household == nil {
+		return nil, err
+	}
+
+	if members, err := interactor.storageDB.getHouseholdMembers(householdID); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error getting members of household on storage database %s", err)
+		return nil, err
+	} else {
+		return members, nil
+	}
+}
+
+// updateHouseholdMember changes the role of a member of the household, returning nil when the member doesn't exist
+func (interactor *interactor) updateHouseholdMember(userID string, householdID string, memberID string, role string) (*householdMember, error) {
+	log.WithFields(map[string]interface{}{"method": "updateHouseholdMember"})
+	log.Infof("updating member %s of household %s of user %s to %s", memberID, householdID, userID, role)
+
+	if !isHouseholdMemberRole(role) {
+		return nil, errInvalidHouseholdRole
+	}
+
+	if household, err := interactor.householdWithRole(userID, householdID, householdRoleAdmin); err != nil || household == nil {
+		return nil, err
+	}
+
+	member, err := interactor.storageDB.getHouseholdMember(householdID, memberID)
+	if err != nil || member == nil {
+		return nil, err
+	}
+	if member.Role == householdRoleOwner {
+		return nil, errHouseholdMemberIsOwner
+	}
+
+	if member, err := interactor.storageDB.updateHouseholdMember(householdID, memberID, role); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error updating member of household on storage database %s", err)
+		return nil, err
+	} else {
+		return member, nil
+	}
+}
+
+// removeHouseholdMember removes a member of the household and detaches the wallets of the member,
+// the admins remove any member and the others can only leave. The owner can't leave the household.
+// It returns false when the member doesn't exist.
+func (interactor *interactor) removeHouseholdMember(userID string, householdID string, memberID string) (bool, error) {
+	log.WithFields(map[string]interface{}{"method": "removeHouseholdMember"})
+	log.Infof("removing member %s of household %s of user %s", memberID, householdID, userID)
+
+	household, err := interactor.getHousehold(userID, householdID)
+	if err != nil || household == nil {
+		return false, err
+	}
+	if memberID != userID && !householdRoleAllows(household.Role, householdRoleAdmin) {
+		return false, newAuthorizationError("the user can't manage the members of the household %s", householdID)
+	}
+
+	member, err := interactor.storageDB.getHouseholdMember(householdID, memberID)
+	if err != nil || member == nil {
+		return false, err
+	}
+	if member.Role == householdRoleOwner {
+		return false, errHouseholdMemberIsOwner
+	}
+
+	if deleted, err := interactor.storageDB.deleteHouseholdMember(householdID, memberID); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error removing member of household on storage database %s", err)
+		return false, err
+	} else {
+		return deleted, nil
+	}
+}
+
+// getHouseholdInvitations returns the pending invitations of the household, or nil when the user isn't a member
+func (interactor *interactor) getHouseholdInvitations(userID string, householdID string) ([]*householdInvitation, error) {
+	log.WithFields(map[string]interface{}{"method": "getHouseholdInvitations"})
+	log.Infof("getting invitations of household %s of user %s", householdID, userID)
+
+	if household, err := interactor.householdWithRole(userID, householdID, householdRoleAdmin); err != nil || household == nil {
+		return nil, err
+	}
+
+	if invitations, err := interactor.storageDB.getHouseholdInvitations(householdID); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error getting invitations of household on storage database %s", err)
+		return nil, err
+	} else {
+		return invitations, nil
+	}
+}
+
+// inviteHouseholdMember mails an invitation to join the household with the role to the email,
+// the invitation can only be accepted by the user with the email
+func (interactor *interactor) inviteHouseholdMember(userID string, householdID string, email string, role string) (*householdInvitation, error) {
+	log.WithFields(map[string]interface{}{"method": "inviteHouseholdMember"})
+	log.Infof("inviting %s to household %s of user %s as %s", email, householdID, userID, role)
+
+	if !isHouseholdMemberRole(role) {
+		return nil, errInvalidHouseholdRole
+	}
+
+	household, err := interactor.householdWithRole(userID, householdID, householdRoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if household == nil {
+		return nil, errHouseholdNotFound
+	}
+
+	if invited, err := interactor.getUserByEmail(email); err != nil {
+		return nil, err
+	} else if invited != nil {
+		if member, err := interactor.storageDB.getHouseholdMember(householdID, invited.UserID); err != nil {
+			return nil, err
+		} else if member != nil {
+			return nil, errHouseholdMemberExists
+		}
+	}
+
+	lifetime := interactor.config.Household.InvitationLifetime
+	if lifetime <= 0 {
+		lifetime = defaultInvitationLifetime
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	invitation, err := interactor.storageDB.createHouseholdInvitation(&householdInvitation{
+		InvitationID: genUI(),
+		HouseholdID:  householdID,
+		Email:        email,
+		Role:         role,
+		InvitedBy:    userID,
+	}, hashToken(token), lifetime)
+	if err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error creating invitation of household on storage database %s", err)
+		return nil, err
+	}
+	invitation.Token = token
+
+	body := fmt.Sprintf("Hello,\n\n"+
+		"you were invited to join the household %s on Go Money as %s. "+
+		"To accept the invitation open the link below, it expires in %d days.\n\n"+
+		"%s\n\n"+
+		"If you don't know this household, ignore this email.\n",
+		household.Name, role, lifetime/(24*60*60), passwordResetLink(interactor.config.Household.InvitationLink, token))
+
+	if err := interactor.mailer.send(email, "You were invited to a Go Money household", body); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error sending household invitation to %s %s", email, err)
+	}
+
+	return invitation, nil
+}
+
+// cancelHouseholdInvitation deletes a pending invitation, returning false when it doesn't exist
+func (interactor *interactor) cancelHouseholdInvitation(userID string, householdID string, invitationID string) (bool, error) {
+	log.WithFields(map[string]interface{}{"method": "cancelHouseholdInvitation"})
+	log.Infof("canceling invitation %s of household %s of user %s", invitationID, householdID, userID)
+
+	if household, err := interactor.householdWithRole(userID, householdID, householdRoleAdmin); err != nil || household == nil {
+		return false, err
+	}
+
+	if deleted, err := interactor.storageDB.deleteHouseholdInvitation(householdID, invitationID); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error canceling invitation of household on storage database %s", err)
+		return false, err
+	} else {
+		return deleted, nil
+	}
+}
+
+// joinHousehold accepts the invitation of the token for the user, returning nil when the token is invalid,
+// expired or of another email
+func (interactor *interactor) joinHousehold(userID string, token string) (*household, error) {
+	log.WithFields(map[string]interface{}{"method": "joinHousehold"})
+	log.Infof("joining household with user %s", userID)
+
+	user, err := interactor.getUser(userID)
+	if err != nil || user == nil {
+		return nil, err
+	}
+
+	householdID, err := interactor.storageDB.acceptHouseholdInvitation(hashToken(token), userID, user.Email)
+	if err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error accepting invitation of household on storage database %s", err)
+		return nil, err
+	}
+	if householdID == "" {
+		return nil, nil
+	}
+
+	return interactor.getHousehold(userID, householdID)
+}
+
+// getHouseholdWallets returns the wallets shared with the household, or nil when the user isn't a member
+func (interactor *interactor) getHouseholdWallets(userID string, householdID string) ([]*wallet, error) {
+	log.WithFields(map[string]interface{}{"method": "getHouseholdWallets"})
+	log.Infof("getting wallets of household %s of user %s", householdID, userID)
+
+	if household, err := interactor.getHousehold(userID, householdID); err != nil || household == nil {
+		return nil, err
+	}
+
+	wallets, err := interactor.getWallets(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	householdWallets := make([]*wallet, 0)
+	for _, wallet := range wallets {
+		if wallet.HouseholdID == householdID {
+			householdWallets = append(householdWallets, wallet)
+		}
+	}
+
+	return householdWallets, nil
+}
+
+// attachHouseholdWallet shares a wallet of the user with the household, only the owner of the wallet can attach it
+func (interactor *interactor) attachHouseholdWallet(userID string, householdID string, walletID string) (*wallet, error) {
+	log.WithFields(map[string]interface{}{"method": "attachHouseholdWallet"})
+	log.Infof("attaching wallet %s to household %s of user %s", walletID, householdID, userID)
+
+	household, err := interactor.householdWithRole(userID, householdID, householdRoleMember)
+	if err != nil {
+		return nil, err
+	}
+	if household == nil {
+		return nil, errHouseholdNotFound
+	}
+
+	wallet, err := interactor.getWallet(userID, walletID)
+	if err != nil {
+		return nil, err
+	}
+	if wallet == nil {
+		return nil, errWalletNotFound
+	}
+	if wallet.UserID != userID {
+		return nil, newAuthorizationError("only the owner can share the wallet %s with a household", walletID)
+	}
+	if wallet.HouseholdID != "" && wallet.HouseholdID != householdID {
+		return nil, errWalletOnOtherHousehold
+	}
+
+	if _, err := interactor.storageDB.attachWallet(userID, walletID, householdID); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error attaching wallet to household on storage database %s", err)
+		return nil, err
+	}
+
+	return interactor.getWallet(userID, walletID)
+}
+
+// detachHouseholdWallet stops sharing a wallet with the household, the owner of the wallet and the admins can detach it.
+// It returns false when the wallet isn't on the household.
+func (interactor *interactor) detachHouseholdWallet(userID string, householdID string, walletID string) (bool, error) {
+	log.WithFields(map[string]interface{}{"method": "detachHouseholdWallet"})
+	log.Infof("detaching wallet %s of household %s of user %s", walletID, householdID, userID)
+
+	household, err := interactor.getHousehold(userID, householdID)
+	if err != nil || household == nil {
+		return false, err
+	}
+
+	wallet, err := interactor.getWallet(userID, walletID)
+	if err != nil || wallet == nil || wallet.HouseholdID != householdID {
+		return false, err
+	}
+	if wallet.UserID != userID && !householdRoleAllows(household.Role, householdRoleAdmin) {
+		return false, newAuthorizationError("the user can't detach the wallet %s of the household", walletID)
+	}
+
+	if detached, err := interactor.storageDB.detachWallet(householdID, walletID); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error detaching wallet of household on storage database %s", err)
+		return false, err
+	} else {
+		return detached, nil
+	}
+}
+
+// getHouseholdCategories returns the shared categories of the household, or nil when the user isn't a member
+func (interactor *interactor) getHouseholdCategories(userID string, householdID string) ([]*category, error) {
+	log.WithFields(map[string]interface{}{"method": "getHouseholdCategories"})
+	log.Infof("getting categories of household %s of user %s", householdID, userID)
+
+	if household, err := interactor.getHousehold(userID, householdID); err != nil || household == nil {
+		return nil, err
+	}
+
+	categories, err := interactor.getCategories(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	householdCategories := make([]*category, 0)
+	for _, category := range categories {
+		if category.HouseholdID == householdID {
+			householdCategories = append(householdCategories, category)
+		}
+	}
+
+	return householdCategories, nil
+}
+
+// createHouseholdCategories creates shared categories of the household with the images of the user
+func (interactor *interactor) createHouseholdCategories(userID string, householdID string, newCategories []*category) ([]*category, error) {
+	log.WithFields(map[string]interface{}{"method": "createHouseholdCategories"})
+	log.Infof("creating categories of household %s of user %s", householdID, userID)
+
+	household, err := interactor.householdWithRole(userID, householdID, householdRoleMember)
+	if err != nil {
+		return nil, err
+	}
+	if household == nil {
+		return nil, errHouseholdNotFound
+	}
+
+	for _, category := range newCategories {
+		category.UserID = userID
+		category.HouseholdID = householdID
+	}
+
+	return interactor.createCategories(newCategories)
+}
+
+// updateHouseholdCategory updates a shared category of the household, returning nil when it doesn't exist
+func (interactor *interactor) updateHouseholdCategory(updCategory *category) (*category, error) {
+	log.WithFields(map[string]interface{}{"method": "updateHouseholdCategory"})
+	log.Infof("updating category %s of household %s of user %s", updCategory.CategoryID, updCategory.HouseholdID, updCategory.UserID)
+
+	if household, err := interactor.householdWithRole(updCategory.UserID, updCategory.HouseholdID, householdRoleMember); err != nil || household == nil {
+		return nil, err
+	}
+
+	if err := interactor.validateOwnership(updCategory.UserID, "images", "image_id", updCategory.ImageID); err != nil {
+		return nil, err
+	}
+
+	if category, err := interactor.storageDB.updateHouseholdCategory(updCategory); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error updating category of household on storage database %s", err)
+		return nil, err
+	} else {
+		return category, nil
+	}
+}
+
+// deleteHouseholdCategory deletes a shared category of the household, returning false when it doesn't exist
+func (interactor *interactor) deleteHouseholdCategory(userID string, householdID string, categoryID string) (bool, error) {
+	log.WithFields(map[string]interface{}{"method": "deleteHouseholdCategory"})
+	log.Infof("deleting category %s of household %s of user %s", categoryID, householdID, userID)
+
+	if household, err := interactor.householdWithRole(userID, householdID, householdRoleMember); err != nil || household == nil {
+		return false, err
+	}
+
+	if deleted, err := interactor.storageDB.deleteHouseholdCategory(householdID, categoryID); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error deleting category of household on storage database %s", err)
+		return false, err
+	} else {
+		return deleted, nil
+	}
+}
+
+// getHouseholdReport aggregates the filtered transactions of the wallets of the household by period and category,
+// or returns nil when the user isn't a member
+func (interactor *interactor) getHouseholdReport(userID string, householdID string, filter *transactionFilter, period string) ([]*reportItem, error) {
+	log.WithFields(map[string]interface{}{"method": "getHouseholdReport"})
+	log.Infof("getting %s report of household %s of user %s", period, householdID, userID)
+
+	if household, err := interactor.getHousehold(userID, householdID); err != nil || household == nil {
+		return nil, err
+	}
+
+	transactions, err := interactor.getHouseholdTransactions(householdID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := interactor.getCategories(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildReport(transactions, categories, period), nil
+}
+
+// getHouseholdTransactions returns the transactions of the wallets of the household that match the filter
+func (interactor *interactor) getHouseholdTransactions(householdID string, filter *transactionFilter) ([]*transaction, error) {
+	transactions, err := interactor.storageDB.getHouseholdTransactions(householdID)
+	if err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error getting transactions of household on storage database %s", err)
+		return nil, err
+	}
+
+	filtered := make([]*transaction, 0)
+	for _, transaction := range transactions {
+		if filter.match(transaction) {
+			filtered = append(filtered, transaction)
+		}
+	}
+
+	return filtered, nil
+}
+
+// budgetPeriod returns the first day of the month or of the year of the date and the first day of the next one
+func budgetPeriod(date time.Time, period string) (time.Time, time.Time) {
+	if period == reportPeriodYear {
+		from := time.Date(date.Year(), time.January, 1, 0, 0, 0, 0, date.Location())
+		return from, from.AddDate(1, 0, 0)
+	}
+	from := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, date.Location())
+	return from, from.AddDate(0, 1, 0)
+}
+
+// getBudgets returns the budgets of the household with the expenses of their current period,
+// or nil when the user isn't a member. The expenses of the locked wallets are left out.
+func (interactor *interactor) getBudgets(userID string, householdID string, locked map[string]bool) ([]*budgetStatus, error) {
+	log.WithFields(map[string]interface{}{"method": "getBudgets"})
+	log.Infof("getting budgets of household %s of user %s", householdID, userID)
+
+	if household, err := interactor.getHousehold(userID, householdID); err != nil || household == nil {
+		return nil, err
+	}
+
+	budgets, err := interactor.storageDB.getBudgets(householdID)
+	if err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error getting budgets on storage database %s", err)
+		return nil, err
+	}
+
+	transactions, err := interactor.getHouseholdTransactions(householdID, &transactionFilter{LockedWallets: locked})
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]*budgetStatus, 0)
+	for _, budget := range budgets {
+		statuses = append(statuses, newBudgetStatus(budget, transactions, time.Now()))
+	}
+
+	return statuses, nil
+}
+
+// newBudgetStatus sums the expenses of the transactions on the category of the budget in the period of the date
+func newBudgetStatus(budget *budget, transactions []*transaction, date time.Time) *budgetStatus {
+	from, to := budgetPeriod(date, budget.Period)
+	filter := &transactionFilter{
+		CategoryID: budget.CategoryID,
+		From:       from,
+		To:         to,
+	}
+
+	spent := decimal.Zero
+	for _, transaction := range transactions {
+		if filter.match(transaction) && transaction.Price.IsPositive() {
+			spent = spent.Add(transaction.Price)
+		}
+	}
+
+	return &budgetStatus{
+		budget:        budget,
+		CurrentPeriod: reportPeriod(from, budget.Period),
+		Spent:         spent,
+		Remaining:     budget.Amount.Sub(spent),
+	}
+}
+
+// validateBudget checks the amount and the period of the budget and that its category is shared with the household
+func (interactor *interactor) validateBudget(userID string, budget *budget) error {
+	if !budget.Amount.IsPositive() || !isReportPeriod(budget.Period) {
+		return errInvalidBudget
+	}
+
+	if budget.CategoryID != "" {
+		category, err := interactor.storageDB.getCategory(userID, budget.CategoryID)
+		if err != nil {
+			return err
+		}
+		if category == nil || category.HouseholdID != budget.HouseholdID {
+			return newAuthorizationError("the category %s isn't a category of the household", budget.CategoryID)
+		}
+	}
+
+	return nil
+}
+
+// createBudget creates a budget of the household
+func (interactor *interactor) createBudget(userID string, newBudget *budget) (*budget, error) {
+	log.WithFields(map[string]interface{}{"method": "createBudget"})
+	log.Infof("creating budget of household %s of user %s", newBudget.HouseholdID, userID)
+
+	household, err := interactor.householdWithRole(userID, newBudget.HouseholdID, householdRoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if household == nil {
+		return nil, errHouseholdNotFound
+	}
+
+	if err := interactor.validateBudget(userID, newBudget); err != nil {
+		return nil, err
+	}
+	newBudget.BudgetID = genUI()
+	newBudget.CreatedBy = userID
+
+	if budget, err := interactor.storageDB.createBudget(newBudget); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error creating budget on storage database %s", err)
+		return nil, err
+	} else {
+		return budget, nil
+	}
+}
+
+// updateBudget updates a budget of the household, returning nil when it doesn't exist
+func (interactor *interactor) updateBudget(userID string, updBudget *budget) (*budget, error) {
+	log.WithFields(map[string]interface{}{"method": "updateBudget"})
+	log.Infof("updating budget %s of household %s of user %s", updBudget.BudgetID, updBudget.HouseholdID, userID)
+
+	if household, err := interactor.householdWithRole(userID, updBudget.HouseholdID, householdRoleAdmin); err != nil || household == nil {
+		return nil, err
+	}
+
+	if err := interactor.validateBudget(userID, updBudget); err != nil {
+		return nil, err
+	}
+
+	if budget, err := interactor.storageDB.updateBudget(updBudget); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error updating budget on storage database %s", err)
+		return nil, err
+	} else {
+		return budget, nil
+	}
+}
+
+// deleteBudget deletes a budget of the household, returning false when it doesn't exist
+func (interactor *interactor) deleteBudget(userID string, householdID string, budgetID string) (bool, error) {
+	log.WithFields(map[string]interface{}{"method": "deleteBudget"})
+	log.Infof("deleting budget %s of household %s of user %s", budgetID, householdID, userID)
+
+	if household, err := interactor.householdWithRole(userID, householdID, householdRoleAdmin); err != nil || household == nil {
+		return false, err
+	}
+
+	if deleted, err := interactor.storageDB.deleteBudget(householdID, budgetID); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error deleting budget on storage database %s", err)
+		return false, err
+	} else {
+		return deleted, nil
+	}
+}
+
+// requireHouseholdRole only allows the users with at least the role on the household of the route,
+// it must be used after the authenticate middleware. The households of other users are left to the handlers.
+func (api *apiWeb) requireHouseholdRole(role string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			household, err := api.interactor.getHousehold(ctx.Param("user_id"), ctx.Param("household_id"))
+			if err != nil {
+				return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+			} else if household != nil && !householdRoleAllows(household.Role, role) {
+				log.Infof("user %s is %s of household %s and requires %s", ctx.Param("user_id"), household.Role, household.HouseholdID, role)
+				return ctx.JSON(http.StatusForbidden, errorResponse{Code: http.StatusForbidden, Message: fmt.Sprintf("the %s role of the household is required", role), Cause: ""})
+			}
+			return next(ctx)
+		}
+	}
+}
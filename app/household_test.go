@@ -0,0 +1,164 @@
+package gomoney
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/shopspring/decimal"
+)
+
+// fakeHouseholdStorage keeps the households of the users on memory by "user:household"
+type fakeHouseholdStorage struct {
+	*fakeStorageDB
+	households map[string]*household
+}
+
+func (storage *fakeHouseholdStorage) getHousehold(userID string, householdID string) (*household, error) {
+	return storage.households[userID+":"+householdID], nil
+}
+
+func TestHouseholdRoleAllows(t *testing.T) {
+	tests := []struct {
+		role     string
+		required string
+		expected bool
+	}{
+		{role: householdRoleViewer, required: householdRoleViewer, expected: true},
+		{role: householdRoleViewer, required: householdRoleMember, expected: false},
+		{role: householdRoleMember, required: householdRoleViewer, expected: true},
+		{role: householdRoleMember, required: householdRoleAdmin, expected: false},
+		{role: householdRoleAdmin, required: householdRoleMember, expected: true},
+		{role: householdRoleAdmin, required: householdRoleOwner, expected: false},
+		{role: householdRoleOwner, required: householdRoleAdmin, expected: true},
+		{role: "", required: householdRoleViewer, expected: false},
+	}
+
+	for _, test := range tests {
+		if got := householdRoleAllows(test.role, test.required); got != test.expected {
+			t.Errorf("%q requiring %q: expected %t, got %t", test.role, test.required, test.expected, got)
+		}
+	}
+}
+
+func TestRequireHouseholdRole(t *testing.T) {
+	storage := &fakeHouseholdStorage{fakeStorageDB: newFakeStorageDB(), households: make(map[string]*household)}
+	storage.households["user:household"] = &household{HouseholdID: "household", Role: householdRoleMember}
+	api, _ := newTestApi(storage)
+
+	tests := []struct {
+		name        string
+		householdID string
+		required    string
+		expected    int
+	}{
+		{name: "lower role", householdID: "household", required: householdRoleViewer, expected: http.StatusNoContent},
+		{name: "same role", householdID: "household", required: householdRoleMember, expected: http.StatusNoContent},
+		{name: "higher role", householdID: "household", required: householdRoleAdmin, expected: http.StatusForbidden},
+		{name: "household left to the handler", householdID: "other", required: householdRoleOwner, expected: http.StatusNoContent},
+	}
+
+	for _, test := range tests {
+		router := echo.New()
+		router.Add(http.MethodPut, "/api/1/users/:user_id/households/:household_id", func(ctx echo.Context) error {
+			return ctx.NoContent(http.StatusNoContent)
+		}, api.requireHouseholdRole(test.required))
+
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPut, "/api/1/users/user/households/"+test.householdID, nil))
+
+		if recorder.Code != test.expected {
+			t.Errorf("%s: expected the status %d, got %d with %s", test.name, test.expected, recorder.Code, recorder.Body.String())
+		}
+	}
+}
+
+func TestHouseholdWithRole(t *testing.T) {
+	storage := &fakeHouseholdStorage{fakeStorageDB: newFakeStorageDB(), households: make(map[string]*household)}
+	storage.households["user:household"] = &household{HouseholdID: "household", Role: householdRoleViewer}
+	interactor := newInteractor(storage, nil, newMailerLog(), newLimiterMemory(), &MoneyConfig{})
+
+	if household, err := interactor.householdWithRole("user", "household", householdRoleViewer); err != nil || household == nil {
+		t.Errorf("expected the household, got %v with error %v", household, err)
+	}
+	if _, err := interactor.householdWithRole("user", "household", householdRoleMember); err == nil {
+		t.Error("expected an authorization error")
+	} else if _, ok := err.(*authorizationError); !ok {
+		t.Errorf("expected an authorization error, got %v", err)
+	}
+	if household, err := interactor.householdWithRole("other", "household", householdRoleViewer); err != nil || household != nil {
+		t.Errorf("expected no household of another user, got %v with error %v", household, err)
+	}
+}
+
+func TestNewBudgetStatus(t *testing.T) {
+	date := time.Date(2026, time.March, 15, 12, 0, 0, 0, time.UTC)
+	transactions := []*transaction{
+		{CategoryID: "food", Price: decimal.NewFromInt(30), Date: time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)},
+		{CategoryID: "food", Price: decimal.NewFromInt(20), Date: time.Date(2026, time.March, 31, 23, 59, 0, 0, time.UTC)},
+		{CategoryID: "food", Price: decimal.NewFromInt(-100), Date: date},
+		{CategoryID: "food", Price: decimal.NewFromInt(40), Date: time.Date(2026, time.April, 1, 0, 0, 0, 0, time.UTC)},
+		{CategoryID: "food", Price: decimal.NewFromInt(50), Date: time.Date(2026, time.January, 10, 0, 0, 0, 0, time.UTC)},
+		{CategoryID: "house", Price: decimal.NewFromInt(500), Date: date},
+	}
+
+	tests := []struct {
+		name      string
+		budget    *budget
+		period    string
+		spent     int64
+		remaining int64
+	}{
+		{name: "month of a category", budget: &budget{CategoryID: "food", Amount: decimal.NewFromInt(100), Period: reportPeriodMonth}, period: "2026-03", spent: 50, remaining: 50},
+		{name: "year of a category", budget: &budget{CategoryID: "food", Amount: decimal.NewFromInt(100), Period: reportPeriodYear}, period: "2026", spent: 140, remaining: -40},
+		{name: "month of every category", budget: &budget{Amount: decimal.NewFromInt(1000), Period: reportPeriodMonth}, period: "2026-03", spent: 550, remaining: 450},
+	}
+
+	for _, test := range tests {
+		status := newBudgetStatus(test.budget, transactions, date)
+		if status.CurrentPeriod != test.period {
+			t.Errorf("%s: expected the period %s, got %s", test.name, test.period, status.CurrentPeriod)
+		}
+		if !status.Spent.Equal(decimal.NewFromInt(test.spent)) || !status.Remaining.Equal(decimal.NewFromInt(test.remaining)) {
+			t.Errorf("%s: expected %d spent and %d remaining, got %s and %s", test.name, test.spent, test.remaining, status.Spent, status.Remaining)
+		}
+	}
+}
+
+func TestValidateBudget(t *testing.T) {
+	storage := newFakeStorageDB()
+	storage.categories["shared"] = &category{CategoryID: "shared", HouseholdID: "household"}
+	storage.categories["personal"] = &category{CategoryID: "personal"}
+	storage.owners["shared"], storage.owners["personal"] = "user", "user"
+	interactor := newInteractor(storage, nil, newMailerLog(), newLimiterMemory(), &MoneyConfig{})
+
+	tests := []struct {
+		name     string
+		budget   *budget
+		expected error
+		// authorization is true when the category of the budget isn't allowed
+		authorization bool
+	}{
+		{name: "budget of the household", budget: &budget{HouseholdID: "household", Amount: decimal.NewFromInt(100), Period: reportPeriodMonth}},
+		{name: "budget of a shared category", budget: &budget{HouseholdID: "household", CategoryID: "shared", Amount: decimal.NewFromInt(100), Period: reportPeriodYear}},
+		{name: "zero amount", budget: &budget{HouseholdID: "household", Amount: decimal.Zero, Period: reportPeriodMonth}, expected: errInvalidBudget},
+		{name: "negative amount", budget: &budget{HouseholdID: "household", Amount: decimal.NewFromInt(-1), Period: reportPeriodMonth}, expected: errInvalidBudget},
+		{name: "invalid period", budget: &budget{HouseholdID: "household", Amount: decimal.NewFromInt(100), Period: "week"}, expected: errInvalidBudget},
+		{name: "personal category", budget: &budget{HouseholdID: "household", CategoryID: "personal", Amount: decimal.NewFromInt(100), Period: reportPeriodMonth}, authorization: true},
+		{name: "category of another household", budget: &budget{HouseholdID: "other", CategoryID: "shared", Amount: decimal.NewFromInt(100), Period: reportPeriodMonth}, authorization: true},
+		{name: "unknown category", budget: &budget{HouseholdID: "household", CategoryID: "unknown", Amount: decimal.NewFromInt(100), Period: reportPeriodMonth}, authorization: true},
+	}
+
+	for _, test := range tests {
+		err := interactor.validateBudget("user", test.budget)
+		if test.authorization {
+			if _, ok := err.(*authorizationError); !ok {
+				t.Errorf("%s: expected an authorization error, got %v", test.name, err)
+			}
+		} else if err != test.expected {
+			t.Errorf("%s: expected the error %v, got %v", test.name, test.expected, err)
+		}
+	}
+}
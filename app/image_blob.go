@@ -0,0 +1,173 @@
+package gomoney
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// imageHash is the sha256 of the content of an image, the images with the same hash share the blob
+func imageHash(data []byte) string {
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+// blobFolderPath is the folder of a shared blob on the blob storage, with the image and the thumbnails
+func blobFolderPath(hash string) string {
+	return fmt.Sprintf("/blobs/%s/%s", hash[:2], hash)
+}
+
+// imageBlobPath is the path of the content of an image on the blob storage.
+// The images from before the deduplication are still on the path of the user.
+func imageBlobPath(image *image) string {
+	if image.Hash == "" {
+		return imagePath(image.UserID, image.ImageID)
+	}
+	return blobFolderPath(image.Hash) + "/image"
+}
+
+// imageThumbnailsPath is the folder with the thumbnails of an image on the blob storage
+func imageThumbnailsPath(image *image) string {
+	if image.Hash == "" {
+		return thumbnailsPath(image.UserID, image.ImageID)
+	}
+	return blobFolderPath(image.Hash) + "/thumbnails"
+}
+
+// imageThumbnailPath is the path of a thumbnail of an image on the blob storage
+func imageThumbnailPath(image *image, name string) string {
+	return fmt.Sprintf("%s/%s", imageThumbnailsPath(image), name)
+}
+
+// acquireImageBlob adds a reference of the image to the blob with its content, uploading the content until
+// it is marked as uploaded. The images that share a blob still being uploaded upload the same content too,
+// so an image is only returned with its content on the blob storage, even when the first upload fails.
+// It returns true when the blob was created.
+func (interactor *interactor) acquireImageBlob(image *image) (bool, error) {
+	image.Hash = imageHash(image.RawImage)
+
+	blob, err := interactor.storageDB.acquireImageBlob(image.Hash, blobDriver(interactor.config), len(image.RawImage))
+	if err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error acquiring blob %s on storage database %s", image.Hash, err)
+		return false, err
+	}
+	image.Storage = blob.Storage
+	created := blob.References == 1
+
+	if !created {
+		log.Infof("image %s of user %s shares the blob %s", image.ImageID, image.UserID, image.Hash)
+		if blob.Uploaded {
+			return false, nil
+		}
+		log.Infof("blob %s isn't uploaded yet, uploading it with image %s", image.Hash, image.ImageID)
+	}
+
+	storage, err := interactor.imageStorage(image)
+	if err == nil {
+		err = storage.upload(imageBlobPath(image), image.RawImage)
+	}
+	if err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error uploading blob %s on blob storage %s", image.Hash, err)
+		interactor.releaseImageBlob(image)
+		return false, err
+	}
+
+	if err := interactor.storageDB.setImageBlobUploaded(image.Hash); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error marking blob %s as uploaded on storage database %s", image.Hash, err)
+		interactor.releaseImageBlob(image)
+		return false, err
+	}
+
+	return created, nil
+}
+
+// releaseImageBlob removes the reference of the image to its blob, deleting the blob when it was the last reference
+func (interactor *interactor) releaseImageBlob(image *image) error {
+	_, err := interactor.storageDB.releaseImageBlob(image.Hash, func(blob *imageBlob) error {
+		log.Infof("deleting blob %s without references", image.Hash)
+		released := *image
+		released.Storage = blob.Storage
+		return interactor.deleteImageBlob(&released)
+	})
+	if err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error releasing blob %s %s", image.Hash, err)
+		return err
+	}
+
+	return nil
+}
+
+// ImageDeduplication is the result of a deduplication of the images
+type ImageDeduplication struct {
+	Deduplicated int
+	Shared       int
+	Saved        int64
+	Missing      int
+	Failed       int
+}
+
+// deduplicateImages moves the images from before the deduplication to the shared blobs, so the identical images
+// keep a single copy. Each image is updated after the move, so the deduplication can be stopped and resumed.
+func (interactor *interactor) deduplicateImages() (*ImageDeduplication, error) {
+	log.WithFields(map[string]interface{}{"method": "deduplicateImages"})
+	log.Info("deduplicating images")
+
+	images, err := interactor.storageDB.getImagesWithoutHash()
+	if err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error getting images on storage database %s", err)
+		return nil, err
+	}
+
+	deduplication := &ImageDeduplication{}
+	for _, legacy := range images {
+		rawImage, err := interactor.downloadImage(legacy)
+		if err != nil {
+			deduplication.Failed++
+			continue
+		}
+		if rawImage == nil {
+			log.Warnf("image %s of user %s was not found on blob storage %s", legacy.ImageID, legacy.UserID, legacy.Storage)
+			deduplication.Missing++
+			continue
+		}
+
+		shared := &image{
+			ImageID:  legacy.ImageID,
+			UserID:   legacy.UserID,
+			RawImage: rawImage,
+		}
+		created, err := interactor.acquireImageBlob(shared)
+		if err != nil {
+			deduplication.Failed++
+			continue
+		}
+
+		if err := interactor.storageDB.updateImageHash(shared.UserID, shared.ImageID, shared.Hash, shared.Storage); err != nil {
+			log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+				Errorf("error updating hash of image %s on storage database %s", shared.ImageID, err)
+			interactor.releaseImageBlob(shared)
+			deduplication.Failed++
+			continue
+		}
+
+		if legacy.Storage = interactor.imageStorageDriver(legacy); legacy.Storage != "" {
+			interactor.deleteImageBlob(legacy)
+		}
+
+		deduplication.Deduplicated++
+		if !created {
+			deduplication.Shared++
+			deduplication.Saved += int64(len(rawImage))
+		}
+	}
+
+	log.Infof("deduplicated %d images, %d shared with other images saving %d bytes, %d missing and %d failed",
+		deduplication.Deduplicated, deduplication.Shared, deduplication.Saved, deduplication.Missing, deduplication.Failed)
+
+	return deduplication, nil
+}
@@ -0,0 +1,379 @@
+package gomoney
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	img "image"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	defaultImageMaxSize   = 10 << 20
+	defaultImageMaxPixels = 40000000
+)
+
+// imageFormats are the formats that can be decoded
+var imageFormats = []string{"jpeg", "png", "gif", "webp", "bmp"}
+
+// imageError is returned when an uploaded image or attachment is not accepted, with the http status of the reason
+type imageError struct {
+	status  int
+	message string
+}
+
+func (e *imageError) Error() string {
+	return e.message
+}
+
+func newImageError(status int, format string, arguments ...interface{}) *imageError {
+	return &imageError{status: status, message: fmt.Sprintf(format, arguments...)}
+}
+
+// sniffImageFormat detects the format of the image from the content
+func sniffImageFormat(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xff, 0xd8, 0xff}):
+		return "jpeg"
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return "png"
+	case bytes.HasPrefix(data, []byte("GIF87a")), bytes.HasPrefix(data, []byte("GIF89a")):
+		return "gif"
+	case len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return "webp"
+	case bytes.HasPrefix(data, []byte("BM")):
+		return "bmp"
+	default:
+		return ""
+	}
+}
+
+// normalizeImage validates the uploaded image against the configured limits, corrects the exif orientation,
+// strips the metadata and converts it to the canonical format when configured
+func (interactor *interactor) normalizeImage(newImage *image) error {
+	config := interactor.config.Image
+
+	maxSize := config.MaxSize
+	if maxSize <= 0 {
+		maxSize = defaultImageMaxSize
+	}
+	maxPixels := config.MaxPixels
+	if maxPixels <= 0 {
+		maxPixels = defaultImageMaxPixels
+	}
+	formats := config.Formats
+	if len(formats) == 0 {
+		formats = imageFormats
+	}
+
+	if len(newImage.RawImage) == 0 {
+		return newImageError(http.StatusBadRequest, "the image is empty")
+	}
+	if len(newImage.RawImage) > maxSize {
+		return newImageError(http.StatusRequestEntityTooLarge, "the image has %d bytes, the maximum is %d bytes", len(newImage.RawImage), maxSize)
+	}
+
+	format := sniffImageFormat(newImage.RawImage)
+	if format == "" {
+		return newImageError(http.StatusUnsupportedMediaType, "the image format is not supported")
+	}
+
+	allowed := false
+	for _, item := range formats {
+		if item == format || (item == "jpg" && format == "jpeg") {
+			allowed = true
+		}
+	}
+	if !allowed {
+		return newImageError(http.StatusUnsupportedMediaType, "the image format %s is not allowed, the allowed formats are %s", format, strings.Join(formats, ", "))
+	}
+
+	imageConfig, _, err := img.DecodeConfig(bytes.NewReader(newImage.RawImage))
+	if err != nil {
+		return newImageError(http.StatusBadRequest, "the image is not a valid %s: %s", format, err)
+	}
+	if imageConfig.Width <= 0 || imageConfig.Height <= 0 {
+		return newImageError(http.StatusBadRequest, "the image has no pixels")
+	}
+	if imageConfig.Width*imageConfig.Height > maxPixels {
+		return newImageError(http.StatusRequestEntityTooLarge, "the image has %dx%d pixels, the maximum is %d pixels", imageConfig.Width, imageConfig.Height, maxPixels)
+	}
+
+	orientation := 1
+	if exif := readExif(format, newImage.RawImage); exif != nil && exif.Orientation > 1 && exif.Orientation <= 8 {
+		orientation = exif.Orientation
+	}
+
+	target := format
+	if config.CanonicalFormat != "" {
+		target = variantFormat(config.CanonicalFormat)
+	}
+
+	if target != format || orientation != 1 {
+		// encoding again drops the metadata
+		decoded, err := decodeImage(bytes.NewReader(newImage.RawImage), format)
+		if err != nil {
+			return newImageError(http.StatusBadRequest, "the image is not a valid %s: %s", format, err)
+		}
+
+		target = variantFormat(target)
+		if newImage.RawImage, err = encodeVariant(orientImage(decoded, orientation), target); err != nil {
+			return err
+		}
+	} else {
+		newImage.RawImage = stripImageMetadata(format, newImage.RawImage)
+	}
+
+	// the file name keeps the extension when it matches the format
+	newImage.Format = target
+	extension := strings.ToLower(strings.TrimPrefix(filepath.Ext(newImage.FileName), "."))
+	if extension != target && !(imageContentType(extension, nil) == "image/jpeg" && imageContentType(target, nil) == "image/jpeg") {
+		name := strings.TrimSuffix(newImage.FileName, filepath.Ext(newImage.FileName))
+		if name == "" {
+			name = "image"
+		}
+		newImage.FileName = fmt.Sprintf("%s.%s", name, target)
+	}
+
+	return nil
+}
+
+// orientImage transforms the image with the exif orientation, so it is shown upright without the exif
+func orientImage(image img.Image, orientation int) img.Image {
+	if orientation <= 1 || orientation > 8 {
+		return image
+	}
+
+	bounds := image.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	var oriented *img.RGBA
+	if orientation >= 5 {
+		oriented = img.NewRGBA(img.Rect(0, 0, height, width))
+	} else {
+		oriented = img.NewRGBA(img.Rect(0, 0, width, height))
+	}
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // flip horizontal
+				dx, dy = width-1-x, y
+			case 3: // rotate 180
+				dx, dy = width-1-x, height-1-y
+			case 4: // flip vertical
+				dx, dy = x, height-1-y
+			case 5: // transpose
+				dx, dy = y, x
+			case 6: // rotate 90 clockwise
+				dx, dy = height-1-y, x
+			case 7: // transverse
+				dx, dy = height-1-y, width-1-x
+			case 8: // rotate 90 counter clockwise
+				dx, dy = y, width-1-x
+			}
+			oriented.Set(dx, dy, image.At(bounds.Min.X+x, bounds.Min.Y+y))
+		}
+	}
+
+	return oriented
+}
+
+// stripImageMetadata removes the exif, xmp, iptc and text metadata without encoding the image again
+func stripImageMetadata(format string, data []byte) []byte {
+	switch format {
+	case "jpeg", "jpg":
+		return stripJpegMetadata(data)
+	case "png":
+		return stripPngMetadata(data)
+	case "webp":
+		return stripWebpMetadata(data)
+	default:
+		return data
+	}
+}
+
+// jpegSegment ...
+type jpegSegment struct {
+	marker byte
+	start  int
+	end    int
+	data   []byte
+}
+
+// jpegSegments returns the segments before the image data
+func jpegSegments(data []byte) []*jpegSegment {
+	segments := make([]*jpegSegment, 0)
+	if len(data) < 4 || data[0] != 0xff || data[1] != 0xd8 {
+		return segments
+	}
+
+	for position := 2; position+4 <= len(data); {
+		if data[position] != 0xff {
+			break
+		}
+
+		marker := data[position+1]
+		if marker == 0xff {
+			position++
+			continue
+		}
+		if marker == 0xda || marker == 0xd9 {
+			break
+		}
+
+		length := int(binary.BigEndian.Uint16(data[position+2:]))
+		end := position + 2 + length
+		if length < 2 || end > len(data) {
+			break
+		}
+
+		segments = append(segments, &jpegSegment{
+			marker: marker,
+			start:  position,
+			end:    end,
+			data:   data[position+4 : end],
+		})
+		position = end
+	}
+
+	return segments
+}
+
+// stripJpegMetadata removes the exif and xmp (app1), the iptc (app13) and the comments, keeping the color profile
+func stripJpegMetadata(data []byte) []byte {
+	segments := jpegSegments(data)
+	if len(segments) == 0 {
+		return data
+	}
+
+	var buffer bytes.Buffer
+	buffer.Write(data[:2])
+	for _, segment := range segments {
+		if segment.marker == 0xe1 || segment.marker == 0xed || segment.marker == 0xfe {
+			continue
+		}
+		buffer.Write(data[segment.start:segment.end])
+	}
+	buffer.Write(data[segments[len(segments)-1].end:])
+
+	return buffer.Bytes()
+}
+
+// imageChunk is a chunk of a png or a webp image
+type imageChunk struct {
+	kind  string
+	start int
+	end   int
+	data  []byte
+}
+
+// pngChunks ...
+func pngChunks(data []byte) []*imageChunk {
+	chunks := make([]*imageChunk, 0)
+	if sniffImageFormat(data) != "png" {
+		return chunks
+	}
+
+	for position := 8; position+12 <= len(data); {
+		length := int(binary.BigEndian.Uint32(data[position:]))
+		end := position + 12 + length
+		if length < 0 || end > len(data) {
+			break
+		}
+
+		chunks = append(chunks, &imageChunk{
+			kind:  string(data[position+4 : position+8]),
+			start: position,
+			end:   end,
+			data:  data[position+8 : position+8+length],
+		})
+		position = end
+	}
+
+	return chunks
+}
+
+// stripPngMetadata removes the text, exif and time chunks
+func stripPngMetadata(data []byte) []byte {
+	chunks := pngChunks(data)
+	if len(chunks) == 0 {
+		return data
+	}
+
+	var buffer bytes.Buffer
+	buffer.Write(data[:8])
+	for _, chunk := range chunks {
+		switch chunk.kind {
+		case "tEXt", "zTXt", "iTXt", "eXIf", "tIME":
+			continue
+		}
+		buffer.Write(data[chunk.start:chunk.end])
+	}
+
+	return buffer.Bytes()
+}
+
+// webpChunks ...
+func webpChunks(data []byte) []*imageChunk {
+	chunks := make([]*imageChunk, 0)
+	if sniffImageFormat(data) != "webp" {
+		return chunks
+	}
+
+	for position := 12; position+8 <= len(data); {
+		length := int(binary.LittleEndian.Uint32(data[position+4:]))
+		end := position + 8 + length + length%2
+		if length < 0 || position+8+length > len(data) {
+			break
+		}
+		if end > len(data) {
+			end = len(data)
+		}
+
+		chunks = append(chunks, &imageChunk{
+			kind:  string(data[position : position+4]),
+			start: position,
+			end:   end,
+			data:  data[position+8 : position+8+length],
+		})
+		position = end
+	}
+
+	return chunks
+}
+
+// stripWebpMetadata removes the exif and xmp chunks and their flags
+func stripWebpMetadata(data []byte) []byte {
+	chunks := webpChunks(data)
+	if len(chunks) == 0 {
+		return data
+	}
+
+	var buffer bytes.Buffer
+	buffer.Write(data[:12])
+	for _, chunk := range chunks {
+		switch chunk.kind {
+		case "EXIF", "XMP ":
+			continue
+		case "VP8X":
+			vp8x := make([]byte, chunk.end-chunk.start)
+			copy(vp8x, data[chunk.start:chunk.end])
+			if len(vp8x) > 8 {
+				// clear the exif (0x08) and xmp (0x04) flags
+				vp8x[8] &^= 0x0c
+			}
+			buffer.Write(vp8x)
+			continue
+		}
+		buffer.Write(data[chunk.start:chunk.end])
+	}
+
+	stripped := buffer.Bytes()
+	binary.LittleEndian.PutUint32(stripped[4:], uint32(len(stripped)-8))
+
+	return stripped
+}
@@ -1,24 +1,59 @@
 package gomoney
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"fmt"
+	img "image"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/joaosoft/errors"
+	"github.com/shopspring/decimal"
 )
 
 // iStorageDB ...
 type iStorageDB interface {
 	getSession(userID string, token string) (*session, error)
 	getSessions(userID string) ([]*session, error)
-	createSession(newSession *session) (*session, error)
+	createSession(newSession *session, refreshToken string, accessLifetime int, refreshLifetime int) (*session, error)
+	useSession(token string, idleTimeout int) (*session, error)
+	rotateSession(refreshToken string, newSession *session, accessLifetime int, refreshLifetime int, idleTimeout int) (*session, bool, error)
 	deleteSession(userID string, token string) error
 	deleteSessions(userID string) error
+	deleteExpiredSessions(idleTimeout int) (int64, error)
+	getActiveSessions(userID string, idleTimeout int) ([]*session, error)
+	deleteSessionByID(userID string, sessionID string) (bool, error)
+	deleteOtherSessions(userID string, sessionID string) (int64, error)
+
+	createPasswordReset(token string, userID string, lifetime int) error
+	resetPassword(token string, password string) (string, error)
+	deleteExpiredPasswordResets() (int64, error)
 
 	getUsers() ([]*user, error)
 	getUser(userID string) (*user, error)
 	getUserByEmail(email string) (*user, error)
 	createUser(newUser *user) (*user, error)
 	updateUser(updUser *user) (*user, error)
+	updateUserPassword(userID string, password string) error
+	updateUserRole(userID string, role string) (*user, error)
+	updateUserStatus(userID string, status int) (*user, error)
+	updateUserTOTP(userID string, secret string, enabled bool) error
+	useTOTPCounter(userID string, counter int64) (bool, error)
+	replaceRecoveryCodes(userID string, codes []string) error
+	useRecoveryCode(userID string, code string) (bool, error)
+	createAccessToken(newAccessToken *accessToken, tokenHash string, expiresIn int) (*accessToken, error)
+	getAccessTokens(userID string) ([]*accessToken, error)
+	deleteAccessToken(userID string, accessTokenID string) (bool, error)
+	useAccessToken(tokenHash string) (*accessToken, error)
+	getUserLockout(email string) (int, error)
+	lockUserLogin(email string, duration int) error
+	createLoginAttempt(attempt *loginAttempt) error
+	getOwnedIDs(userID string, table string, column string, ids []string) (map[string]bool, error)
 	deleteUser(userID string) error
 
 	getWallets(userID string) ([]*wallet, error)
@@ -26,12 +61,49 @@ type iStorageDB interface {
 	createWallets(newWallets []*wallet) ([]*wallet, error)
 	updateWallet(updCategory *wallet) (*wallet, error)
 	deleteWallet(userID string, walletID string) error
+	getWalletMembers(walletID string) ([]*walletMember, error)
+	getWalletMember(walletID string, userID string) (*walletMember, error)
+	createWalletMember(newMember *walletMember) (*walletMember, error)
+	updateWalletMember(walletID string, userID string, role string) (*walletMember, error)
+	deleteWalletMember(walletID string, userID string) (bool, error)
+
+	getHouseholds(userID string) ([]*household, error)
+	getHousehold(userID string, householdID string) (*household, error)
+	createHousehold(newHousehold *household) (*household, error)
+	updateHousehold(userID string, householdID string, name string) (*household, error)
+	deleteHousehold(householdID string) error
+	getHouseholdMembers(householdID string) ([]*householdMember, error)
+	getHouseholdMember(householdID string, userID string) (*householdMember, error)
+	updateHouseholdMember(householdID string, userID string, role string) (*householdMember, error)
+	deleteHouseholdMember(householdID string, userID string) (bool, error)
+	getHouseholdInvitations(householdID string) ([]*householdInvitation, error)
+	createHouseholdInvitation(newInvitation *householdInvitation, tokenHash string, lifetime int) (*householdInvitation, error)
+	deleteHouseholdInvitation(householdID string, invitationID string) (bool, error)
+	acceptHouseholdInvitation(tokenHash string, userID string, email string) (string, error)
+	attachWallet(userID string, walletID string, householdID string) (bool, error)
+	detachWallet(householdID string, walletID string) (bool, error)
+	getHouseholdTransactions(householdID string) ([]*transaction, error)
+	updateHouseholdCategory(updCategory *category) (*category, error)
+	deleteHouseholdCategory(householdID string, categoryID string) (bool, error)
+	getBudgets(householdID string) ([]*budget, error)
+	getBudget(householdID string, budgetID string) (*budget, error)
+	createBudget(newBudget *budget) (*budget, error)
+	updateBudget(updBudget *budget) (*budget, error)
+	deleteBudget(householdID string, budgetID string) (bool, error)
 
 	getImages(userID string) ([]*image, error)
 	getImage(userID string, imageID string) (*image, error)
 	createImage(newImage *image) (*image, error)
 	updateImage(updImage *image) (*image, error)
 	deleteImage(userID string, imageID string) error
+	getImagesNotOnStorage(imageStorage string) ([]*image, error)
+	updateImageStorage(userID string, imageID string, imageStorage string) error
+	getImagesWithoutHash() ([]*image, error)
+	updateImageHash(userID string, imageID string, hash string, imageStorage string) error
+	acquireImageBlob(hash string, imageStorage string, size int) (*imageBlob, error)
+	releaseImageBlob(hash string, deleteContent func(blob *imageBlob) error) (*imageBlob, error)
+	updateImageBlobStorage(hash string, imageStorage string) error
+	setImageBlobUploaded(hash string) error
 
 	getCategories(userID string) ([]*category, error)
 	getCategory(userID string, categoryID string) (*category, error)
@@ -44,28 +116,69 @@ type iStorageDB interface {
 	createTransactions(newTransaction []*transaction) ([]*transaction, error)
 	updateTransaction(updTransaction *transaction) (*transaction, error)
 	deleteTransaction(userID string, walletID string, transactionID string) error
+	getTransactionsInBox(userID string, minLatitude float64, maxLatitude float64, minLongitude float64, maxLongitude float64) ([]*transaction, error)
+	getAttachments(userID string, transactionID string) ([]*attachment, error)
+	getAttachment(userID string, transactionID string, attachmentID string) (*attachment, error)
+	createAttachment(newAttachment *attachment) (*attachment, error)
+	deleteAttachment(userID string, transactionID string, attachmentID string) error
+
+	countTransactions(userID string) (int, error)
+	countImages(userID string) (int, error)
+
+	getExport(userID string, exportID string) (*export, error)
+	createExport(newExport *export) (*export, error)
+	updateExport(updExport *export) (*export, error)
+	deleteExpiredExports(lifetime int) ([]*export, error)
+
+	getExistingIDs(table string, column string, ids []string) (map[string]bool, error)
+	importAccount(userID string, newUser *user, wallets []*wallet, categories []*category, images []*image, transactions []*transaction) (bool, error)
 }
 
-// iStorageDropbox ...
-type iStorageDropbox interface {
+var errAccountNotEmpty = errors.New(errors.LevelError, 1, "the account is not empty")
+var errWalletNotFound = errors.New(errors.LevelError, 1, "the wallet was not found")
+
+// iStorageBlob ...
+type iStorageBlob interface {
 	upload(path string, data []byte) error
 	download(path string) ([]byte, error)
 	delete(path string) error
+	// uploadFrom and downloadTo stream the large blobs, like the archives of the exports
+	uploadFrom(path string, reader io.ReadSeeker, size int64) error
+	downloadTo(path string, writer io.Writer) (bool, error)
+}
+
+// iMailer ...
+type iMailer interface {
+	send(to string, subject string, body string) error
+}
+
+// iLimiterStore counts the failed logins of a key inside of a window
+type iLimiterStore interface {
+	addFailure(key string, window int) (int, error)
+	getFailures(key string, window int) (*loginFailures, error)
+	resetFailures(key string) error
+	purgeFailures(window int) (int64, error)
 }
 
 // interactor ...
 type interactor struct {
-	storageDB      iStorageDB
-	storageDropbox iStorageDropbox
-	config         *MoneyConfig
+	storageDB    iStorageDB
+	storageBlob  iStorageBlob
+	storageBlobs map[string]iStorageBlob
+	mailer       iMailer
+	limiter      iLimiterStore
+	config       *MoneyConfig
 }
 
 // newInteractor ...
-func newInteractor(storageDB iStorageDB, storageDropbox iStorageDropbox, config *MoneyConfig) *interactor {
+func newInteractor(storageDB iStorageDB, storageBlobs map[string]iStorageBlob, mailer iMailer, limiter iLimiterStore, config *MoneyConfig) *interactor {
 	return &interactor{
-		storageDB:      storageDB,
-		storageDropbox: storageDropbox,
-		config:         config,
+		storageDB:    storageDB,
+		storageBlob:  storageBlobs[blobDriver(config)],
+		storageBlobs: storageBlobs,
+		mailer:       mailer,
+		limiter:      limiter,
+		config:       config,
 	}
 }
 
@@ -108,19 +221,18 @@ func (interactor *interactor) getUserByEmail(email string) (*user, error) {
 	}
 }
 
-// createUser ...
+// createUser creates the unverified user with the hash of the password and mails the verification link
 func (interactor *interactor) createUser(newUser *user) (*user, error) {
 	log.WithFields(map[string]interface{}{"method": "createUser"})
 
 	newUser.UserID = genUI()
-	passwordToken, err := generateToken(authentication, []byte(newUser.Password))
+	hash, err := interactor.hashPassword(newUser.Password)
 	if err != nil {
-		newErr := errors.New(errors.LevelError, 1, err)
-		log.WithFields(map[string]interface{}{"error": newErr.Error(), "cause": newErr}).
-			Error("error when generating password token")
-		return nil, newErr
+		return nil, err
 	}
-	newUser.Token = passwordToken
+	newUser.Password = hash
+	newUser.Token = ""
+	newUser.Status = userStatusUnverified
 
 	log.Infof("creating user %s", newUser.UserID)
 
@@ -129,28 +241,85 @@ func (interactor *interactor) createUser(newUser *user) (*user, error) {
 			Errorf("error creating user on storage database %s", err)
 		return nil, err
 	} else {
+		if user != nil {
+			interactor.sendVerification(user)
+		}
 		return user, nil
 	}
 }
 
-// updateUser ...
-func (interactor *interactor) updateUser(updUser *user) (*user, error) {
+// updateUser updates the user, keeping the password when no new password is given.
+// A new password requires the current one and revokes the sessions of the user except the given one.
+// A new email of an active user is unverified until the user opens the link mailed to it.
+func (interactor *interactor) updateUser(updUser *user, currentPassword string, sessionID string) (*user, error) {
 	log.WithFields(map[string]interface{}{"method": "updateUser"})
 	log.Infof("updating user %s", updUser.UserID)
 
-	passwordToken, err := generateToken(authentication, []byte(updUser.Password))
-	if err != nil {
-		newErr := errors.New(errors.LevelError, 1, err)
-		log.WithFields(map[string]interface{}{"error": newErr.Error(), "cause": newErr}).
-			Error("error when generating password token")
-		return nil, newErr
+	current, err := interactor.getUser(updUser.UserID)
+	if err != nil || current == nil {
+		return nil, err
+	}
+	emailChanged := !strings.EqualFold(strings.TrimSpace(current.Email), strings.TrimSpace(updUser.Email))
+	passwordChanged := updUser.Password != ""
+
+	if passwordChanged {
+		if matches, _ := checkPassword(current, currentPassword); !matches {
+			log.Infof("invalid current password of user %s", current.UserID)
+			return nil, errInvalidCurrentPassword
+		}
+
+		hash, err := interactor.hashPassword(updUser.Password)
+		if err != nil {
+			return nil, err
+		}
+		updUser.Password = hash
+		updUser.Token = ""
+	} else {
+		updUser.Password = current.Password
+		updUser.Token = current.Token
 	}
-	updUser.Token = passwordToken
 
-	if user, err := interactor.storageDB.updateUser(updUser); err != nil {
+	user, err := interactor.storageDB.updateUser(updUser)
+	if err != nil {
 		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
 			Errorf("error updating user on storage database %s", err)
 		return nil, err
+	}
+	if user == nil {
+		return nil, nil
+	}
+
+	if passwordChanged {
+		if _, err := interactor.revokeOtherSessions(user.UserID, sessionID); err != nil {
+			return nil, err
+		}
+	}
+	if !emailChanged {
+		return user, nil
+	}
+
+	log.Infof("email of user %s changed", user.UserID)
+	if user.Status == userStatusActive {
+		if user, err = interactor.updateUserStatus(user.UserID, userStatusUnverified); err != nil || user == nil {
+			return nil, err
+		}
+	}
+	if user.Status == userStatusUnverified {
+		interactor.sendVerification(user)
+	}
+
+	return user, nil
+}
+
+// updateUserRole ...
+func (interactor *interactor) updateUserRole(userID string, role string) (*user, error) {
+	log.WithFields(map[string]interface{}{"method": "updateUserRole"})
+	log.Infof("updating role of user %s to %s", userID, role)
+
+	if user, err := interactor.storageDB.updateUserRole(userID, role); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error updating role of user on storage database %s", err)
+		return nil, err
 	} else {
 		return user, nil
 	}
@@ -194,30 +363,29 @@ func (interactor *interactor) getSession(userID string, token string) (*session,
 	}
 }
 
-// createSession ...
+// createSession creates a session with an access token that expires and a refresh token to renew it
 func (interactor *interactor) createSession(newSession *session) (*session, error) {
 	log.WithFields(map[string]interface{}{"method": "createSession"})
 
-	random := genUI()
 	newSession.SessionID = genUI()
-	token, err := generateToken(authentication, []byte(random))
-	if err != nil {
-		newErr := errors.New(errors.LevelError, 1, err)
-		log.WithFields(map[string]interface{}{"error": err.Error()}).
-			Errorf("error getting sessions on storage database %s", err)
-		return nil, newErr
+	if err := interactor.newSessionTokens(newSession); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error generating session tokens %s", err)
+		return nil, err
 	}
-	newSession.Original = random
-	newSession.Token = token
 
 	log.Infof("creating session for user %s", newSession.UserID)
 
-	if user, err := interactor.storageDB.createSession(newSession); err != nil {
+	access, refresh, _ := interactor.sessionLifetimes()
+	if session, err := interactor.storageDB.createSession(newSession, hashToken(newSession.RefreshToken), access, refresh); err != nil {
 		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
 			Errorf("error creating session on storage database %s", err)
 		return nil, err
+	} else if session == nil {
+		return nil, nil
 	} else {
-		return user, nil
+		session.RefreshToken = newSession.RefreshToken
+		return session, nil
 	}
 }
 
@@ -278,6 +446,9 @@ func (interactor *interactor) createWallets(newWallets []*wallet) ([]*wallet, er
 	log.Info("creating wallets")
 	for _, wallet := range newWallets {
 		wallet.WalletID = genUI()
+		if err := interactor.hashWalletPassword(wallet); err != nil {
+			return nil, err
+		}
 	}
 
 	if wallets, err := interactor.storageDB.createWallets(newWallets); err != nil {
@@ -289,10 +460,27 @@ func (interactor *interactor) createWallets(newWallets []*wallet) ([]*wallet, er
 	}
 }
 
-// updateWallet ...
-func (interactor *interactor) updateWallet(updWallet *wallet) (*wallet, error) {
+// updateWallet updates the wallet, the password is only changed when it is given and an empty password removes it
+func (interactor *interactor) updateWallet(updWallet *wallet, password *string) (*wallet, error) {
 	log.WithFields(map[string]interface{}{"method": "updateWallet"})
 	log.Infof("updating wallet %s of user %s", updWallet.UserID, updWallet.UserID)
+
+	current, err := interactor.getWallet(updWallet.UserID, updWallet.WalletID)
+	if err != nil || current == nil {
+		return nil, err
+	}
+
+	if password == nil {
+		updWallet.Password = current.Password
+	} else if current.Role != walletRoleOwner {
+		return nil, newAuthorizationError("only the owner can change the password of the wallet %s", updWallet.WalletID)
+	} else {
+		updWallet.Password = *password
+		if err := interactor.hashWalletPassword(updWallet); err != nil {
+			return nil, err
+		}
+	}
+
 	if wallet, err := interactor.storageDB.updateWallet(updWallet); err != nil {
 		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
 			Errorf("error updating wallet on storage database %s", err)
@@ -341,29 +529,93 @@ func (interactor *interactor) getImage(userID string, imageID string) (*image, e
 	}
 }
 
-// getImageRaw ...
-func (interactor *interactor) getImageRaw(userID string, imageID string) ([]byte, error) {
-	log.WithFields(map[string]interface{}{"method": "getImage"})
+// getImageRaw returns the image with the raw content
+func (interactor *interactor) getImageRaw(userID string, imageID string) (*image, error) {
+	log.WithFields(map[string]interface{}{"method": "getImageRaw"})
 	log.Infof("getting rawImage %s of user %s", imageID, userID)
 
-	if interactor.config.Dropbox.Enabled {
-		path := fmt.Sprintf("/users/%s/images/%s", userID, imageID)
-		if rawImage, err := interactor.storageDropbox.download(path); err != nil {
-			log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
-				Errorf("error getting rawImage on storage dropbox %s", err)
-			return nil, err
-		} else {
-			return rawImage, nil
-		}
+	if image, err := interactor.storageDB.getImage(userID, imageID); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error getting image on storage database %s", err)
+		return nil, err
+	} else if image == nil {
+		return nil, nil
 	} else {
-		if image, err := interactor.storageDB.getImage(userID, imageID); err != nil {
-			log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
-				Errorf("error getting image on storage database %s", err)
+		if image.RawImage, err = interactor.downloadImage(image); err != nil {
 			return nil, err
-		} else {
-			return image.RawImage, nil
 		}
+		return image, nil
+	}
+}
+
+// downloadImage downloads an image from the blob storage where it is
+func (interactor *interactor) downloadImage(image *image) ([]byte, error) {
+	return interactor.downloadImageBlob(image, imageBlobPath(image))
+}
+
+// downloadImageBlob downloads a blob of an image from the blob storage of the image.
+// The images without storage are from before the storage was tracked, so every storage is tried.
+func (interactor *interactor) downloadImageBlob(image *image, path string) ([]byte, error) {
+	drivers := []string{image.Storage}
+	if image.Storage == "" {
+		drivers = blobDrivers(interactor.config, interactor.storageBlobs)
+	}
+
+	var lastErr error
+	for _, driver := range drivers {
+		storage, ok := interactor.storageBlobs[driver]
+		if !ok {
+			lastErr = fmt.Errorf("the blob storage %s of image %s is not configured", driver, image.ImageID)
+			continue
+		}
+
+		if data, err := storage.download(path); err != nil {
+			lastErr = err
+		} else if data != nil {
+			return data, nil
+		}
+	}
+
+	if lastErr != nil {
+		log.WithFields(map[string]interface{}{"error": lastErr.Error(), "cause": lastErr}).
+			Errorf("error getting %s of image %s on blob storage %s", path, image.ImageID, lastErr)
+	}
+	return nil, lastErr
+}
+
+// imageStorageDriver returns the driver of the blob storage with the content of the image. The images without storage
+// are from before the storage was tracked, so the driver is the first storage where the content is found,
+// or empty when it isn't on any storage.
+func (interactor *interactor) imageStorageDriver(image *image) string {
+	if image.Storage != "" {
+		return image.Storage
+	}
+
+	for _, driver := range blobDrivers(interactor.config, interactor.storageBlobs) {
+		storage, ok := interactor.storageBlobs[driver]
+		if !ok {
+			continue
+		}
+		if data, err := storage.download(imageBlobPath(image)); err == nil && data != nil {
+			return driver
+		}
+	}
+
+	return ""
+}
+
+// imageStorage returns the blob storage of an image
+func (interactor *interactor) imageStorage(image *image) (iStorageBlob, error) {
+	driver := image.Storage
+	if driver == "" {
+		driver = blobDriver(interactor.config)
+	}
+
+	storage, ok := interactor.storageBlobs[driver]
+	if !ok {
+		return nil, fmt.Errorf("the blob storage %s of image %s is not configured", driver, image.ImageID)
 	}
+	return storage, nil
 }
 
 // createImage ...
@@ -371,20 +623,28 @@ func (interactor *interactor) createImage(newImage *image) (*image, error) {
 	log.WithFields(map[string]interface{}{"method": "createImage"})
 
 	log.Info("creating image")
+	if err := interactor.normalizeImage(newImage); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error validating image %s", err)
+		return nil, err
+	}
+
 	newImage.ImageID = genUI()
+	created, err := interactor.acquireImageBlob(newImage)
+	if err != nil {
+		return nil, err
+	}
 
 	if image, err := interactor.storageDB.createImage(newImage); err != nil {
 		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
 			Errorf("error creating image on storage database %s", err)
+		interactor.releaseImageBlob(newImage)
 		return nil, err
 	} else {
-		if interactor.config.Dropbox.Enabled {
-			path := fmt.Sprintf("/users/%s/images/%s", newImage.UserID, newImage.ImageID)
-			if err := interactor.storageDropbox.upload(path, newImage.RawImage); err != nil {
-				log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
-					Errorf("error creating image on storage dropbox %s", err)
-				return nil, err
-			}
+		// a shared blob already has the thumbnails
+		if created {
+			image.RawImage = newImage.RawImage
+			interactor.createThumbnails(image)
 		}
 
 		return image, nil
@@ -394,46 +654,247 @@ func (interactor *interactor) createImage(newImage *image) (*image, error) {
 // updateImage ...
 func (interactor *interactor) updateImage(updImage *image) (*image, error) {
 	log.WithFields(map[string]interface{}{"method": "updateImage"})
-	log.Infof("updating image %s of user %s", updImage.UserID, updImage.UserID)
-	if image, err := interactor.storageDB.updateImage(updImage); err != nil {
+	log.Infof("updating image %s of user %s", updImage.ImageID, updImage.UserID)
+
+	if err := interactor.normalizeImage(updImage); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error validating image %s", err)
+		return nil, err
+	}
+
+	oldImage, err := interactor.storageDB.getImage(updImage.UserID, updImage.ImageID)
+	if err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error getting image on storage database %s", err)
+		return nil, err
+	} else if oldImage == nil {
+		return nil, nil
+	}
+
+	created, err := interactor.acquireImageBlob(updImage)
+	if err != nil {
+		return nil, err
+	}
+
+	image, err := interactor.storageDB.updateImage(updImage)
+	if err != nil {
 		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
 			Errorf("error updating image on storage database %s", err)
+		interactor.releaseImageBlob(updImage)
 		return nil, err
-	} else {
-		if interactor.config.Dropbox.Enabled {
-			path := fmt.Sprintf("/users/%s/images/%s", updImage.UserID, updImage.ImageID)
-			if err := interactor.storageDropbox.upload(path, updImage.RawImage); err != nil {
-				log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
-					Errorf("error updating image on storage dropbox %s", err)
-				return nil, err
-			}
-			return image, nil
-		}
+	} else if image == nil {
+		interactor.releaseImageBlob(updImage)
+		return nil, nil
 	}
-	return nil, nil
+
+	interactor.deleteImageContent(oldImage)
+
+	// a shared blob already has the thumbnails
+	if created {
+		image.RawImage = updImage.RawImage
+		interactor.createThumbnails(image)
+	}
+
+	return image, nil
 }
 
 // deleteImage ...
 func (interactor *interactor) deleteImage(userID string, imageID string) error {
 	log.WithFields(map[string]interface{}{"method": "deleteImage"})
 	log.Infof("deleting image %s of user %s", imageID, userID)
+
+	image, err := interactor.storageDB.getImage(userID, imageID)
+	if err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error getting image on storage database %s", err)
+		return err
+	}
+
 	if err := interactor.storageDB.deleteImage(userID, imageID); err != nil {
 		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
 			Errorf("error deleting image on storage database %s", err)
 		return err
-	} else {
-		if interactor.config.Dropbox.Enabled {
-			path := fmt.Sprintf("/users/%s/images/%s", userID, imageID)
-			if err := interactor.storageDropbox.delete(path); err != nil {
-				log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
-					Errorf("error deleting image on storage dropbox %s", err)
-				return err
-			}
+	}
+
+	if image != nil {
+		if err := interactor.deleteImageContent(image); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteImageContent removes the content of an image that no longer exists or that has a new content.
+// The shared blobs are only deleted with the last reference.
+func (interactor *interactor) deleteImageContent(image *image) error {
+	if image.Hash != "" {
+		return interactor.releaseImageBlob(image)
+	}
+
+	if image.Storage = interactor.imageStorageDriver(image); image.Storage == "" {
+		log.Warnf("image %s of user %s was not found on any blob storage", image.ImageID, image.UserID)
+		return nil
+	}
+	return interactor.deleteImageBlob(image)
+}
+
+// deleteImageBlob deletes the image and the thumbnails from the blob storage of the image
+func (interactor *interactor) deleteImageBlob(image *image) error {
+	storage, err := interactor.imageStorage(image)
+	if err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error deleting image on blob storage %s", err)
+		return err
+	}
+
+	paths := []string{imagePath(image.UserID, image.ImageID), thumbnailsPath(image.UserID, image.ImageID)}
+	if image.Hash != "" {
+		paths = []string{blobFolderPath(image.Hash)}
+	}
+
+	for _, path := range paths {
+		if err := storage.delete(path); err != nil {
+			log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+				Errorf("error deleting %s on blob storage %s", path, err)
+			return err
 		}
 	}
+
 	return nil
 }
 
+// createThumbnails generates and uploads the configured thumbnails of an image.
+// A thumbnail that fails is generated again when it is requested, so the errors don't fail the upload.
+func (interactor *interactor) createThumbnails(image *image) {
+	if len(interactor.config.Image.Thumbnails) == 0 {
+		return
+	}
+
+	decoded, err := decodeImage(bytes.NewReader(image.RawImage), image.Format)
+	if err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error decoding image %s to create the thumbnails %s", image.ImageID, err)
+		return
+	}
+
+	for _, thumbnail := range interactor.config.Image.Thumbnails {
+		if _, err := interactor.createThumbnail(image, decoded, thumbnail.Name); err != nil {
+			log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+				Errorf("error creating thumbnail %s of image %s %s", thumbnail.Name, image.ImageID, err)
+		}
+	}
+}
+
+// createThumbnail ...
+func (interactor *interactor) createThumbnail(image *image, decoded img.Image, name string) ([]byte, error) {
+	thumbnail, ok := interactor.thumbnailConfig(name)
+	if !ok {
+		return nil, fmt.Errorf("invalid thumbnail %s", name)
+	}
+
+	data, err := encodeVariant(resizeImage(decoded, thumbnail.Width, thumbnail.Height, thumbnail.Fit, interactor.maxResize()), image.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	storage, err := interactor.imageStorage(image)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := storage.upload(imageThumbnailPath(image, name), data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// thumbnailConfig ...
+func (interactor *interactor) thumbnailConfig(name string) (thumbnailConfig, bool) {
+	for _, thumbnail := range interactor.config.Image.Thumbnails {
+		if thumbnail.Name == name {
+			return thumbnail, true
+		}
+	}
+	return thumbnailConfig{}, false
+}
+
+// getThumbnail returns the image with the content of the thumbnail, generating it when it is missing
+func (interactor *interactor) getThumbnail(userID string, imageID string, name string) (*image, error) {
+	log.WithFields(map[string]interface{}{"method": "getThumbnail"})
+	log.Infof("getting thumbnail %s of image %s of user %s", name, imageID, userID)
+
+	image, err := interactor.getImage(userID, imageID)
+	if err != nil || image == nil {
+		return nil, err
+	}
+
+	if data, err := interactor.downloadImageBlob(image, imageThumbnailPath(image, name)); err == nil && data != nil {
+		image.RawImage = data
+		image.Format = variantFormat(image.Format)
+		return image, nil
+	}
+
+	if image.RawImage, err = interactor.downloadImage(image); err != nil || image.RawImage == nil {
+		return nil, err
+	}
+
+	decoded, err := decodeImage(bytes.NewReader(image.RawImage), image.Format)
+	if err != nil {
+		newErr := errors.New(errors.LevelError, 1, err)
+		log.WithFields(map[string]interface{}{"error": newErr.Error(), "cause": newErr}).
+			Errorf("error decoding image %s %s", imageID, newErr)
+		return nil, newErr
+	}
+
+	if image.RawImage, err = interactor.createThumbnail(image, decoded, name); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error creating thumbnail %s of image %s %s", name, imageID, err)
+		return nil, err
+	}
+	image.Format = variantFormat(image.Format)
+
+	return image, nil
+}
+
+// maxResize is the largest width and height of the resized images
+func (interactor *interactor) maxResize() int {
+	if interactor.config.Image.MaxResize > 0 {
+		return interactor.config.Image.MaxResize
+	}
+	return defaultMaxResize
+}
+
+// getResizedImage returns the image with the content resized on demand
+func (interactor *interactor) getResizedImage(userID string, imageID string, width int, height int, fit string) (*image, error) {
+	log.WithFields(map[string]interface{}{"method": "getResizedImage"})
+	log.Infof("resizing image %s of user %s to %dx%d (%s)", imageID, userID, width, height, fit)
+
+	image, err := interactor.getImageRaw(userID, imageID)
+	if err != nil || image == nil || image.RawImage == nil {
+		return nil, err
+	}
+
+	decoded, err := decodeImage(bytes.NewReader(image.RawImage), image.Format)
+	if err != nil {
+		newErr := errors.New(errors.LevelError, 1, err)
+		log.WithFields(map[string]interface{}{"error": newErr.Error(), "cause": newErr}).
+			Errorf("error decoding image %s %s", imageID, newErr)
+		return nil, newErr
+	}
+
+	if image.RawImage, err = encodeVariant(resizeImage(decoded, width, height, fit, interactor.maxResize()), image.Format); err != nil {
+		newErr := errors.New(errors.LevelError, 1, err)
+		log.WithFields(map[string]interface{}{"error": newErr.Error(), "cause": newErr}).
+			Errorf("error encoding image %s %s", imageID, newErr)
+		return nil, newErr
+	}
+	image.Format = variantFormat(image.Format)
+
+	return image, nil
+}
+
 // getCategories ...
 func (interactor *interactor) getCategories(userID string) ([]*category, error) {
 	log.WithFields(map[string]interface{}{"method": "getCategories"})
@@ -466,6 +927,9 @@ func (interactor *interactor) createCategories(newCategories []*category) ([]*ca
 
 	log.Info("creating categories")
 	for _, category := range newCategories {
+		if err := interactor.validateOwnership(category.UserID, "images", "image_id", category.ImageID); err != nil {
+			return nil, err
+		}
 		category.CategoryID = genUI()
 	}
 
@@ -481,7 +945,12 @@ func (interactor *interactor) createCategories(newCategories []*category) ([]*ca
 // updateCategory ...
 func (interactor *interactor) updateCategory(updCategory *category) (*category, error) {
 	log.WithFields(map[string]interface{}{"method": "updateCategory"})
-	log.Infof("updating category %s of user %s", updCategory.UserID, updCategory.UserID)
+	log.Infof("updating category %s of user %s", updCategory.CategoryID, updCategory.UserID)
+
+	if err := interactor.validateOwnership(updCategory.UserID, "images", "image_id", updCategory.ImageID); err != nil {
+		return nil, err
+	}
+
 	if category, err := interactor.storageDB.updateCategory(updCategory); err != nil {
 		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
 			Errorf("error updating category on storage database %s", err)
@@ -535,7 +1004,15 @@ func (interactor *interactor) createTransactions(newTransactions []*transaction)
 
 	log.Info("creating transactions")
 	for _, transaction := range newTransactions {
+		wallet, err := interactor.validateTransactionOwnership(transaction)
+		if err != nil {
+			return nil, err
+		}
+		// the transactions of a shared wallet belong to the owner of the wallet
 		transaction.TransactionID = genUI()
+		transaction.CreatedBy = transaction.UserID
+		transaction.UpdatedBy = transaction.UserID
+		transaction.UserID = wallet.UserID
 	}
 
 	if transactions, err := interactor.storageDB.createTransactions(newTransactions); err != nil {
@@ -550,7 +1027,15 @@ func (interactor *interactor) createTransactions(newTransactions []*transaction)
 // updateTransaction ...
 func (interactor *interactor) updateTransaction(updTransaction *transaction) (*transaction, error) {
 	log.WithFields(map[string]interface{}{"method": "updateTransaction"})
-	log.Infof("updating transaction %s of user %s", updTransaction.UserID, updTransaction.UserID)
+	log.Infof("updating transaction %s of user %s", updTransaction.TransactionID, updTransaction.UserID)
+
+	wallet, err := interactor.validateTransactionOwnership(updTransaction)
+	if err != nil {
+		return nil, err
+	}
+	updTransaction.UpdatedBy = updTransaction.UserID
+	updTransaction.UserID = wallet.UserID
+
 	if transaction, err := interactor.storageDB.updateTransaction(updTransaction); err != nil {
 		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
 			Errorf("error updating transaction on storage database %s", err)
@@ -560,14 +1045,1151 @@ func (interactor *interactor) updateTransaction(updTransaction *transaction) (*t
 	}
 }
 
-// deleteTransaction ...
+// deleteTransaction deletes the transaction with the attachments
 func (interactor *interactor) deleteTransaction(userID string, walletID string, transactionID string) error {
 	log.WithFields(map[string]interface{}{"method": "deleteTransaction"})
 	log.Infof("deleting transaction %s of user %s", transactionID, userID)
-	if err := interactor.storageDB.deleteTransaction(userID, walletID, transactionID); err != nil {
-		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
-			Errorf("error deleting transaction on storage database %s", err)
+
+	transaction, err := interactor.getTransaction(userID, walletID, transactionID)
+	if err != nil || transaction == nil {
 		return err
 	}
+
+	attachments, err := interactor.getAttachments(userID, walletID, transactionID)
+	if err != nil {
+		return err
+	}
+
+	// the attachments are deleted in cascade with the transaction
+	if err := interactor.storageDB.deleteTransaction(transaction.UserID, walletID, transactionID); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error deleting transaction on storage database %s", err)
+		return err
+	}
+
+	for _, attachment := range attachments {
+		interactor.deleteAttachmentBlob(attachment)
+	}
+
+	return nil
+}
+
+// getAttachments returns the attachments of a transaction, or nil when the transaction doesn't exist
+func (interactor *interactor) getAttachments(userID string, walletID string, transactionID string) ([]*attachment, error) {
+	log.WithFields(map[string]interface{}{"method": "getAttachments"})
+	log.Infof("getting attachments of transaction %s of user %s", transactionID, userID)
+
+	transaction, err := interactor.getTransaction(userID, walletID, transactionID)
+	if err != nil || transaction == nil {
+		return nil, err
+	}
+
+	// the attachments of a shared wallet belong to the owner of the transaction
+	if attachments, err := interactor.storageDB.getAttachments(transaction.UserID, transactionID); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error getting attachments on storage database %s", err)
+		return nil, err
+	} else {
+		return attachments, nil
+	}
+}
+
+// getAttachment returns the attachment with the content
+func (interactor *interactor) getAttachment(userID string, walletID string, transactionID string, attachmentID string) (*attachment, error) {
+	log.WithFields(map[string]interface{}{"method": "getAttachment"})
+	log.Infof("getting attachment %s of transaction %s of user %s", attachmentID, transactionID, userID)
+
+	transaction, err := interactor.getTransaction(userID, walletID, transactionID)
+	if err != nil || transaction == nil {
+		return nil, err
+	}
+
+	attachment, err := interactor.storageDB.getAttachment(transaction.UserID, transactionID, attachmentID)
+	if err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error getting attachment on storage database %s", err)
+		return nil, err
+	} else if attachment == nil {
+		return nil, nil
+	}
+
+	storage, err := interactor.attachmentStorage(attachment)
+	if err == nil {
+		attachment.Data, err = storage.download(attachmentPath(attachment.UserID, attachmentID))
+	}
+	if err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error getting attachment on blob storage %s", err)
+		return nil, err
+	}
+
+	return attachment, nil
+}
+
+// createAttachments validates and uploads the attachments of a transaction.
+// It returns nil when the transaction doesn't exist.
+func (interactor *interactor) createAttachments(userID string, walletID string, transactionID string, newAttachments []*attachment) ([]*attachment, error) {
+	log.WithFields(map[string]interface{}{"method": "createAttachments"})
+	log.Infof("creating %d attachments on transaction %s of user %s", len(newAttachments), transactionID, userID)
+
+	transaction, err := interactor.getTransaction(userID, walletID, transactionID)
+	if err != nil || transaction == nil {
+		return nil, err
+	}
+
+	attachments, err := interactor.getAttachments(userID, walletID, transactionID)
+	if err != nil || attachments == nil {
+		return nil, err
+	}
+
+	if err := interactor.validateAttachmentCount(len(attachments) + len(newAttachments)); err != nil {
+		return nil, err
+	}
+
+	for _, newAttachment := range newAttachments {
+		if err := interactor.normalizeAttachment(newAttachment); err != nil {
+			log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+				Errorf("error validating attachment %s", err)
+			return nil, err
+		}
+	}
+
+	createdAttachments := make([]*attachment, 0)
+	for _, newAttachment := range newAttachments {
+		newAttachment.AttachmentID = genUI()
+		newAttachment.UserID = transaction.UserID
+		newAttachment.TransactionID = transactionID
+		newAttachment.Storage = blobDriver(interactor.config)
+
+		if err := interactor.storageBlob.upload(attachmentPath(newAttachment.UserID, newAttachment.AttachmentID), newAttachment.Data); err != nil {
+			log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+				Errorf("error creating attachment on blob storage %s", err)
+			return nil, err
+		}
+
+		createdAttachment, err := interactor.storageDB.createAttachment(newAttachment)
+		if err != nil {
+			log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+				Errorf("error creating attachment on storage database %s", err)
+			interactor.deleteAttachmentBlob(newAttachment)
+			return nil, err
+		}
+		createdAttachments = append(createdAttachments, createdAttachment)
+	}
+
+	return createdAttachments, nil
+}
+
+// deleteAttachment ...
+func (interactor *interactor) deleteAttachment(userID string, walletID string, transactionID string, attachmentID string) error {
+	log.WithFields(map[string]interface{}{"method": "deleteAttachment"})
+	log.Infof("deleting attachment %s of transaction %s of user %s", attachmentID, transactionID, userID)
+
+	transaction, err := interactor.getTransaction(userID, walletID, transactionID)
+	if err != nil || transaction == nil {
+		return err
+	}
+
+	attachment, err := interactor.storageDB.getAttachment(transaction.UserID, transactionID, attachmentID)
+	if err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error getting attachment on storage database %s", err)
+		return err
+	} else if attachment == nil {
+		return nil
+	}
+
+	if err := interactor.storageDB.deleteAttachment(transaction.UserID, transactionID, attachmentID); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error deleting attachment on storage database %s", err)
+		return err
+	}
+
+	return interactor.deleteAttachmentBlob(attachment)
+}
+
+// deleteAttachmentBlob deletes the content of an attachment from the blob storage
+func (interactor *interactor) deleteAttachmentBlob(attachment *attachment) error {
+	storage, err := interactor.attachmentStorage(attachment)
+	if err == nil {
+		err = storage.delete(attachmentPath(attachment.UserID, attachment.AttachmentID))
+	}
+	if err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error deleting attachment %s on blob storage %s", attachment.AttachmentID, err)
+		return err
+	}
+
+	return nil
+}
+
+// isLargeAccount ...
+func (interactor *interactor) isLargeAccount(userID string) (bool, error) {
+	log.WithFields(map[string]interface{}{"method": "isLargeAccount"})
+	log.Infof("checking account size of user %s", userID)
+
+	threshold := interactor.config.Export.AsyncThreshold
+	if threshold <= 0 {
+		threshold = defaultExportAsyncThreshold
+	}
+
+	transactions, err := interactor.storageDB.countTransactions(userID)
+	if err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error counting transactions on storage database %s", err)
+		return false, err
+	}
+
+	images, err := interactor.storageDB.countImages(userID)
+	if err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error counting images on storage database %s", err)
+		return false, err
+	}
+
+	return transactions+images > threshold, nil
+}
+
+// getAccountArchive returns the archive of the account, the transactions of the locked wallets are left out
+func (interactor *interactor) getAccountArchive(userID string, locked map[string]bool) (*accountArchive, error) {
+	log.WithFields(map[string]interface{}{"method": "getAccountArchive"})
+	log.Infof("getting account archive of user %s", userID)
+
+	user, err := interactor.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.New(errors.LevelError, 1, fmt.Sprintf("user %s not found", userID))
+	}
+
+	archive := &accountArchive{
+		Manifest: archiveManifest{
+			Version:   archiveVersion,
+			UserID:    user.UserID,
+			CreatedAt: time.Now().UTC(),
+		},
+		User: archiveUser{
+			UserID:      user.UserID,
+			Name:        user.Name,
+			Email:       user.Email,
+			Description: user.Description,
+			UpdatedAt:   user.UpdatedAt,
+			CreatedAt:   user.CreatedAt,
+		},
+		Sessions:     make([]*archiveSession, 0),
+		Wallets:      make([]*archiveWallet, 0),
+		Categories:   make([]*archiveCategory, 0),
+		Images:       make([]*archiveImage, 0),
+		Transactions: make([]*archiveTransaction, 0),
+		Files:        make(map[string][]byte),
+	}
+
+	sessions, err := interactor.getSessions(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, session := range sessions {
+		archive.Sessions = append(archive.Sessions, &archiveSession{
+			SessionID:   session.SessionID,
+			Description: session.Description,
+			UpdatedAt:   session.UpdatedAt,
+			CreatedAt:   session.CreatedAt,
+		})
+	}
+
+	// the wallets shared with the user belong to the archive of their owners
+	wallets, err := interactor.getWallets(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, wallet := range wallets {
+		if wallet.UserID != userID {
+			continue
+		}
+		if locked[wallet.WalletID] {
+			archive.Manifest.LockedWallets = append(archive.Manifest.LockedWallets, wallet.WalletID)
+		}
+		archive.Wallets = append(archive.Wallets, &archiveWallet{
+			WalletID:    wallet.WalletID,
+			Name:        wallet.Name,
+			Description: wallet.Description,
+			UpdatedAt:   wallet.UpdatedAt,
+			CreatedAt:   wallet.CreatedAt,
+		})
+	}
+
+	categories, err := interactor.getCategories(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, category := range categories {
+		archive.Categories = append(archive.Categories, &archiveCategory{
+			CategoryID:  category.CategoryID,
+			ImageID:     category.ImageID,
+			Name:        category.Name,
+			Description: category.Description,
+			UpdatedAt:   category.UpdatedAt,
+			CreatedAt:   category.CreatedAt,
+		})
+	}
+
+	images, err := interactor.getImages(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, image := range images {
+		archiveImage := &archiveImage{
+			ImageID:     image.ImageID,
+			Name:        image.Name,
+			Description: image.Description,
+			Url:         image.Url,
+			FileName:    image.FileName,
+			Format:      image.Format,
+			UpdatedAt:   image.UpdatedAt,
+			CreatedAt:   image.CreatedAt,
+		}
+
+		archiveImage.File = archiveImageFile(archiveImage)
+		archive.Images = append(archive.Images, archiveImage)
+	}
+
+	// the images are downloaded while the archive is written, so only one of them is on memory at a time
+	imagesByID := make(map[string]*image, len(images))
+	for _, image := range images {
+		imagesByID[image.ImageID] = image
+	}
+	archive.loadFile = func(imageID string) ([]byte, error) {
+		rawImage, err := interactor.downloadImage(imagesByID[imageID])
+		if err != nil || rawImage == nil {
+			log.Warnf("image %s of user %s could not be added to the archive", imageID, userID)
+		}
+		return rawImage, err
+	}
+
+	transactions, err := interactor.getTransactions(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, transaction := range transactions {
+		if transaction.UserID != userID || locked[transaction.WalletID] {
+			continue
+		}
+		archive.Transactions = append(archive.Transactions, &archiveTransaction{
+			TransactionID: transaction.TransactionID,
+			WalletID:      transaction.WalletID,
+			CategoryID:    transaction.CategoryID,
+			Price:         transaction.Price.String(),
+			Description:   transaction.Description,
+			Date:          transaction.Date,
+			Latitude:      transaction.Latitude,
+			Longitude:     transaction.Longitude,
+			GroupID:       transaction.GroupID,
+			UpdatedAt:     transaction.UpdatedAt,
+			CreatedAt:     transaction.CreatedAt,
+		})
+	}
+
+	return archive, nil
+}
+
+// exportAccount writes the archive of the account, the transactions of the locked wallets are left out
+func (interactor *interactor) exportAccount(userID string, locked map[string]bool, writer io.Writer) error {
+	log.WithFields(map[string]interface{}{"method": "exportAccount"})
+	log.Infof("exporting account of user %s", userID)
+
+	archive, err := interactor.getAccountArchive(userID, locked)
+	if err != nil {
+		return err
+	}
+
+	if err := writeAccountArchive(writer, archive); err != nil {
+		newErr := errors.New(errors.LevelError, 1, err)
+		log.WithFields(map[string]interface{}{"error": newErr.Error(), "cause": newErr}).
+			Errorf("error writing archive of user %s", userID)
+		return newErr
+	}
+
+	return nil
+}
+
+// getExport ...
+func (interactor *interactor) getExport(userID string, exportID string) (*export, error) {
+	log.WithFields(map[string]interface{}{"method": "getExport"})
+	log.Infof("getting export %s of user %s", exportID, userID)
+	if export, err := interactor.storageDB.getExport(userID, exportID); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error getting export on storage database %s", err)
+		return nil, err
+	} else {
+		return export, nil
+	}
+}
+
+// writeExportArchive streams the archive of a done export from the blob storage, returning false when it doesn't exist
+func (interactor *interactor) writeExportArchive(export *export, writer io.Writer) (bool, error) {
+	log.WithFields(map[string]interface{}{"method": "writeExportArchive"})
+	log.Infof("writing archive of export %s of user %s", export.ExportID, export.UserID)
+
+	storage, ok := interactor.storageBlobs[export.Storage]
+	if !ok {
+		err := fmt.Errorf("the blob storage %s of export %s is not configured", export.Storage, export.ExportID)
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error getting export archive %s", err)
+		return false, err
+	}
+
+	found, err := storage.downloadTo(exportArchivePath(export.UserID, export.ExportID), writer)
+	if err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error getting export archive on blob storage %s", err)
+	}
+	return found, err
+}
+
+// createExport creates a pending export and generates its archive in background,
+// the transactions of the locked wallets are left out
+func (interactor *interactor) createExport(userID string, locked map[string]bool) (*export, error) {
+	log.WithFields(map[string]interface{}{"method": "createExport"})
+
+	newExport := &export{
+		ExportID: genUI(),
+		UserID:   userID,
+		Status:   exportStatusPending,
+	}
+
+	log.Infof("creating export %s of user %s", newExport.ExportID, userID)
+
+	createdExport, err := interactor.storageDB.createExport(newExport)
+	if err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error creating export on storage database %s", err)
+		return nil, err
+	}
+
+	if createdExport != nil {
+		// the export returned to the caller isn't shared with the background
+		runningExport := *createdExport
+		go interactor.runExport(&runningExport, locked)
+	}
+
+	return createdExport, nil
+}
+
+// runExport generates the archive of the export, a panic fails the export instead of stopping the service
+func (interactor *interactor) runExport(runExport *export, locked map[string]bool) {
+	log.WithFields(map[string]interface{}{"method": "runExport"})
+	log.Infof("running export %s of user %s", runExport.ExportID, runExport.UserID)
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			log.Errorf("panic running export %s of user %s: %v", runExport.ExportID, runExport.UserID, recovered)
+
+			runExport.Status = exportStatusFailed
+			runExport.Error = fmt.Sprintf("%v", recovered)
+			runExport.Storage = ""
+			runExport.Size = 0
+			if _, err := interactor.storageDB.updateExport(runExport); err != nil {
+				log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+					Errorf("error updating export on storage database %s", err)
+			}
+		}
+	}()
+
+	runExport.Status = exportStatusRunning
+	if _, err := interactor.storageDB.updateExport(runExport); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error updating export on storage database %s", err)
+		return
+	}
+
+	if size, err := interactor.storeExportArchive(runExport, locked); err != nil {
+		runExport.Status = exportStatusFailed
+		runExport.Error = err.Error()
+	} else {
+		runExport.Status = exportStatusDone
+		runExport.Storage = blobDriver(interactor.config)
+		runExport.Size = int(size)
+	}
+
+	if _, err := interactor.storageDB.updateExport(runExport); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error updating export on storage database %s", err)
+	}
+}
+
+// storeExportArchive writes the archive of the export to a temporary file and uploads it to the blob storage,
+// so the archive is never on memory. It returns the size of the archive.
+func (interactor *interactor) storeExportArchive(storeExport *export, locked map[string]bool) (int64, error) {
+	file, err := ioutil.TempFile("", "go-money-export-")
+	if err != nil {
+		return 0, errors.New(errors.LevelError, 1, err)
+	}
+	defer func() {
+		file.Close()
+		os.Remove(file.Name())
+	}()
+
+	if err := interactor.exportAccount(storeExport.UserID, locked, file); err != nil {
+		return 0, err
+	}
+
+	size, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, errors.New(errors.LevelError, 1, err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return 0, errors.New(errors.LevelError, 1, err)
+	}
+
+	if err := interactor.storageBlob.uploadFrom(exportArchivePath(storeExport.UserID, storeExport.ExportID), file, size); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error uploading archive of export %s on blob storage %s", storeExport.ExportID, err)
+		return 0, err
+	}
+
+	return size, nil
+}
+
+// purgeExports deletes the exports and their archives after the lifetime of the exports
+func (interactor *interactor) purgeExports() (int64, error) {
+	log.WithFields(map[string]interface{}{"method": "purgeExports"})
+
+	lifetime := interactor.config.Export.Lifetime
+	if lifetime <= 0 {
+		lifetime = defaultExportLifetime
+	}
+
+	exports, err := interactor.storageDB.deleteExpiredExports(lifetime)
+	if err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error purging exports on storage database %s", err)
+		return 0, err
+	}
+
+	for _, expired := range exports {
+		if expired.Storage == "" {
+			continue
+		}
+		storage, ok := interactor.storageBlobs[expired.Storage]
+		if !ok {
+			log.Warnf("the blob storage %s of export %s is not configured", expired.Storage, expired.ExportID)
+			continue
+		}
+		if err := storage.delete(exportArchivePath(expired.UserID, expired.ExportID)); err != nil {
+			log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+				Errorf("error deleting archive of export %s on blob storage %s", expired.ExportID, err)
+		}
+	}
+
+	if len(exports) > 0 {
+		log.Infof("purged %d expired exports", len(exports))
+	}
+
+	return int64(len(exports)), nil
+}
+
+// isEmptyAccount ...
+func (interactor *interactor) isEmptyAccount(userID string) (bool, error) {
+	log.WithFields(map[string]interface{}{"method": "isEmptyAccount"})
+	log.Infof("checking if account of user %s is empty", userID)
+
+	if wallets, err := interactor.getWallets(userID); err != nil {
+		return false, err
+	} else {
+		for _, wallet := range wallets {
+			if wallet.UserID == userID {
+				return false, nil
+			}
+		}
+	}
+
+	if categories, err := interactor.getCategories(userID); err != nil {
+		return false, err
+	} else {
+		for _, category := range categories {
+			if category.UserID == userID {
+				return false, nil
+			}
+		}
+	}
+
+	if images, err := interactor.storageDB.countImages(userID); err != nil {
+		return false, err
+	} else if images > 0 {
+		return false, nil
+	}
+
+	if transactions, err := interactor.storageDB.countTransactions(userID); err != nil {
+		return false, err
+	} else if transactions > 0 {
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// importAccount restores an archive into an existing and empty account
+func (interactor *interactor) importAccount(userID string, archive *accountArchive) error {
+	log.WithFields(map[string]interface{}{"method": "importAccount"})
+	log.Infof("importing archive into account of user %s", userID)
+
+	if user, err := interactor.getUser(userID); err != nil {
+		return err
+	} else if user == nil {
+		return errors.New(errors.LevelError, 1, fmt.Sprintf("user %s not found", userID))
+	}
+
+	// checked again with the user locked on the import, this check only avoids the uploads of the images
+	if empty, err := interactor.isEmptyAccount(userID); err != nil {
+		return err
+	} else if !empty {
+		return errAccountNotEmpty
+	}
+
+	return interactor.restoreAccount(nil, userID, archive)
+}
+
+// importNewAccount restores an archive into a new account
+func (interactor *interactor) importNewAccount(email string, password string, archive *accountArchive) (*user, error) {
+	log.WithFields(map[string]interface{}{"method": "importNewAccount"})
+
+	if email == "" {
+		email = archive.User.Email
+	}
+
+	newUser := &user{
+		UserID:      genUI(),
+		Name:        archive.User.Name,
+		Email:       email,
+		Password:    password,
+		Description: archive.User.Description,
+	}
+
+	hash, err := interactor.hashPassword(newUser.Password)
+	if err != nil {
+		return nil, err
+	}
+	newUser.Password = hash
+
+	log.Infof("importing archive into new account of user %s", newUser.UserID)
+
+	if err := interactor.restoreAccount(newUser, newUser.UserID, archive); err != nil {
+		return nil, err
+	}
+
+	return interactor.getUser(newUser.UserID)
+}
+
+// remapIDs maps each id to a new one when it already exists on the database
+func (interactor *interactor) remapIDs(table string, column string, ids []string) (map[string]string, error) {
+	existing, err := interactor.storageDB.getExistingIDs(table, column, ids)
+	if err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error getting existing ids of %s on storage database %s", table, err)
+		return nil, err
+	}
+
+	mapping := make(map[string]string)
+	for _, id := range ids {
+		if existing[id] {
+			mapping[id] = genUI()
+			log.Infof("remapping %s %s to %s", column, id, mapping[id])
+		} else {
+			mapping[id] = id
+		}
+	}
+
+	return mapping, nil
+}
+
+// restoreAccount validates the archive, uploads the images and inserts everything on a single database transaction
+func (interactor *interactor) restoreAccount(newUser *user, userID string, archive *accountArchive) error {
+	if err := validateAccountArchive(archive); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error validating archive %s", err)
+		return err
+	}
+
+	ids := make([]string, 0)
+	for _, wallet := range archive.Wallets {
+		ids = append(ids, wallet.WalletID)
+	}
+	walletIDs, err := interactor.remapIDs("wallets", "wallet_id", ids)
+	if err != nil {
+		return err
+	}
+
+	ids = make([]string, 0)
+	for _, image := range archive.Images {
+		ids = append(ids, image.ImageID)
+	}
+	imageIDs, err := interactor.remapIDs("images", "image_id", ids)
+	if err != nil {
+		return err
+	}
+
+	ids = make([]string, 0)
+	for _, category := range archive.Categories {
+		ids = append(ids, category.CategoryID)
+	}
+	categoryIDs, err := interactor.remapIDs("categories", "category_id", ids)
+	if err != nil {
+		return err
+	}
+
+	ids = make([]string, 0)
+	for _, transaction := range archive.Transactions {
+		ids = append(ids, transaction.TransactionID)
+	}
+	transactionIDs, err := interactor.remapIDs("transactions", "transaction_id", ids)
+	if err != nil {
+		return err
+	}
+
+	wallets := make([]*wallet, 0)
+	for _, item := range archive.Wallets {
+		wallets = append(wallets, &wallet{
+			WalletID:    walletIDs[item.WalletID],
+			UserID:      userID,
+			Name:        item.Name,
+			Description: item.Description,
+			UpdatedAt:   item.UpdatedAt,
+			CreatedAt:   item.CreatedAt,
+		})
+	}
+
+	images := make([]*image, 0)
+	for _, item := range archive.Images {
+		images = append(images, &image{
+			ImageID:     imageIDs[item.ImageID],
+			UserID:      userID,
+			Name:        item.Name,
+			Description: item.Description,
+			Url:         item.Url,
+			FileName:    item.FileName,
+			Format:      item.Format,
+			Storage:     blobDriver(interactor.config),
+			RawImage:    archive.Files[item.ImageID],
+			UpdatedAt:   item.UpdatedAt,
+			CreatedAt:   item.CreatedAt,
+		})
+	}
+
+	categories := make([]*category, 0)
+	for _, item := range archive.Categories {
+		categories = append(categories, &category{
+			CategoryID:  categoryIDs[item.CategoryID],
+			UserID:      userID,
+			ImageID:     imageIDs[item.ImageID],
+			Name:        item.Name,
+			Description: item.Description,
+			UpdatedAt:   item.UpdatedAt,
+			CreatedAt:   item.CreatedAt,
+		})
+	}
+
+	transactions := make([]*transaction, 0)
+	for _, item := range archive.Transactions {
+		price, _ := decimal.NewFromString(item.Price)
+		transactions = append(transactions, &transaction{
+			TransactionID: transactionIDs[item.TransactionID],
+			UserID:        userID,
+			WalletID:      walletIDs[item.WalletID],
+			CategoryID:    categoryIDs[item.CategoryID],
+			Price:         price,
+			Description:   item.Description,
+			Date:          item.Date,
+			Latitude:      item.Latitude,
+			Longitude:     item.Longitude,
+			GroupID:       item.GroupID,
+			UpdatedAt:     item.UpdatedAt,
+			CreatedAt:     item.CreatedAt,
+		})
+	}
+
+	acquired := make([]*image, 0)
+	for _, image := range images {
+		if image.RawImage == nil {
+			continue
+		}
+
+		if _, err := interactor.acquireImageBlob(image); err != nil {
+			interactor.releaseImageBlobs(acquired)
+			return err
+		}
+		acquired = append(acquired, image)
+	}
+
+	if imported, err := interactor.storageDB.importAccount(userID, newUser, wallets, categories, images, transactions); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error importing account on storage database %s", err)
+		interactor.releaseImageBlobs(acquired)
+		return err
+	} else if !imported {
+		log.Infof("account of user %s is no longer empty", userID)
+		interactor.releaseImageBlobs(acquired)
+		return errAccountNotEmpty
+	}
+
+	log.Infof("imported %d wallets, %d categories, %d images and %d transactions into account of user %s",
+		len(wallets), len(categories), len(images), len(transactions), userID)
+
+	return nil
+}
+
+// releaseImageBlobs removes the references of the images of a failed import
+func (interactor *interactor) releaseImageBlobs(images []*image) {
+	for _, image := range images {
+		interactor.releaseImageBlob(image)
+	}
+}
+
+// exportLedger streams the wallets, categories and transactions of a user as a plain text accounting journal,
+// the transactions of the locked wallets are left out
+func (interactor *interactor) exportLedger(userID string, format string, locked map[string]bool, writer io.Writer) error {
+	journal, err := interactor.getLedgerJournal(userID, format, locked)
+	if err != nil {
+		return err
+	}
+
+	return interactor.writeLedgerJournal(userID, journal, writer)
+}
+
+// getLedgerJournal loads the journal of the user, to be written after the data is loaded
+func (interactor *interactor) getLedgerJournal(userID string, format string, locked map[string]bool) (*ledgerJournal, error) {
+	log.WithFields(map[string]interface{}{"method": "getLedgerJournal"})
+	log.Infof("getting %s journal of user %s", format, userID)
+
+	wallets, err := interactor.getWallets(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := interactor.getCategories(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions, err := interactor.getFilteredTransactions(userID, &transactionFilter{LockedWallets: locked})
+	if err != nil {
+		return nil, err
+	}
+
+	return newLedgerJournal(format, interactor.config.Export.Currency, wallets, categories, transactions), nil
+}
+
+// writeLedgerJournal streams the journal of the user
+func (interactor *interactor) writeLedgerJournal(userID string, journal *ledgerJournal, writer io.Writer) error {
+	log.WithFields(map[string]interface{}{"method": "writeLedgerJournal"})
+	log.Infof("writing %s journal of user %s", journal.format, userID)
+
+	if err := journal.write(writer); err != nil {
+		newErr := errors.New(errors.LevelError, 1, err)
+		log.WithFields(map[string]interface{}{"error": newErr.Error(), "cause": newErr}).
+			Errorf("error writing %s journal of user %s", journal.format, userID)
+		return newErr
+	}
+
+	return nil
+}
+
+// getFilteredTransactions ...
+func (interactor *interactor) getFilteredTransactions(userID string, filter *transactionFilter) ([]*transaction, error) {
+	log.WithFields(map[string]interface{}{"method": "getFilteredTransactions"})
+	log.Infof("getting filtered transactions of user %s", userID)
+
+	transactions, err := interactor.getTransactions(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := make([]*transaction, 0)
+	for _, transaction := range transactions {
+		if filter.match(transaction) {
+			filtered = append(filtered, transaction)
+		}
+	}
+
+	sort.SliceStable(filtered, func(i, j int) bool {
+		return filtered[i].Date.Before(filtered[j].Date)
+	})
+
+	return filtered, nil
+}
+
+// getReport ...
+func (interactor *interactor) getReport(userID string, filter *transactionFilter, period string) ([]*reportItem, error) {
+	log.WithFields(map[string]interface{}{"method": "getReport"})
+	log.Infof("getting %s report of user %s", period, userID)
+
+	transactions, err := interactor.getFilteredTransactions(userID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := interactor.getCategories(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildReport(transactions, categories, period), nil
+}
+
+// exportTransactionsSpreadsheet writes the filtered transactions with a sheet for each wallet or month
+func (interactor *interactor) exportTransactionsSpreadsheet(userID string, filter *transactionFilter, group string, writer io.Writer) error {
+	log.WithFields(map[string]interface{}{"method": "exportTransactionsSpreadsheet"})
+	log.Infof("exporting transactions spreadsheet of user %s", userID)
+
+	transactions, err := interactor.getFilteredTransactions(userID, filter)
+	if err != nil {
+		return err
+	}
+
+	wallets, err := interactor.getWallets(userID)
+	if err != nil {
+		return err
+	}
+	walletNames := make(map[string]string)
+	for _, wallet := range wallets {
+		walletNames[wallet.WalletID] = wallet.Name
+	}
+
+	categories, err := interactor.getCategories(userID)
+	if err != nil {
+		return err
+	}
+	categoryNames := make(map[string]string)
+	for _, category := range categories {
+		categoryNames[category.CategoryID] = category.Name
+	}
+
+	workbook := newXlsxWorkbook()
+	sheets := make(map[string]*xlsxSheet)
+	for _, transaction := range transactions {
+		key := reportPeriod(transaction.Date, reportPeriodMonth)
+		name := key
+		if group == spreadsheetGroupWallet {
+			key = transaction.WalletID
+			name = walletNames[transaction.WalletID]
+		}
+
+		sheet, ok := sheets[key]
+		if !ok {
+			sheet = workbook.addSheet(name)
+			sheet.addHeader("Date", "Wallet", "Category", "Description", "Price", "Transaction")
+			sheets[key] = sheet
+		}
+
+		sheet.addRow(
+			transaction.Date,
+			walletNames[transaction.WalletID],
+			categoryNames[transaction.CategoryID],
+			transaction.Description,
+			transaction.Price,
+			transaction.TransactionID)
+	}
+
+	if err := workbook.write(writer); err != nil {
+		newErr := errors.New(errors.LevelError, 1, err)
+		log.WithFields(map[string]interface{}{"error": newErr.Error(), "cause": newErr}).
+			Errorf("error writing transactions spreadsheet of user %s", userID)
+		return newErr
+	}
+
+	return nil
+}
+
+// exportReportSpreadsheet writes the report with a sheet for each period
+func (interactor *interactor) exportReportSpreadsheet(userID string, filter *transactionFilter, period string, writer io.Writer) error {
+	log.WithFields(map[string]interface{}{"method": "exportReportSpreadsheet"})
+	log.Infof("exporting %s report spreadsheet of user %s", period, userID)
+
+	report, err := interactor.getReport(userID, filter, period)
+	if err != nil {
+		return err
+	}
+
+	workbook := newXlsxWorkbook()
+	sheets := make(map[string]*xlsxSheet)
+	for _, item := range report {
+		sheet, ok := sheets[item.Period]
+		if !ok {
+			sheet = workbook.addSheet(item.Period)
+			sheet.addHeader("Category", "Transactions", "Expenses", "Income", "Total")
+			sheets[item.Period] = sheet
+		}
+
+		sheet.addRow(item.Category, item.Count, item.Expenses, item.Income, item.Total)
+	}
+
+	if err := workbook.write(writer); err != nil {
+		newErr := errors.New(errors.LevelError, 1, err)
+		log.WithFields(map[string]interface{}{"error": newErr.Error(), "cause": newErr}).
+			Errorf("error writing report spreadsheet of user %s", userID)
+		return newErr
+	}
+
+	return nil
+}
+
+// exportStatement writes the monthly statement of a wallet as a pdf, with the icons of the categories
+func (interactor *interactor) exportStatement(userID string, walletID string, year int, month time.Month, writer io.Writer) error {
+	log.WithFields(map[string]interface{}{"method": "exportStatement"})
+	log.Infof("exporting statement %d-%02d of wallet %s of user %s", year, month, walletID, userID)
+
+	wallet, err := interactor.getWallet(userID, walletID)
+	if err != nil {
+		return err
+	}
+	if wallet == nil {
+		return errWalletNotFound
+	}
+
+	to := time.Date(year, month+1, 1, 0, 0, 0, 0, time.UTC)
+	transactions, err := interactor.getFilteredTransactions(userID, &transactionFilter{WalletID: walletID, To: to})
+	if err != nil {
+		return err
+	}
+
+	categories, err := interactor.getCategories(userID)
+	if err != nil {
+		return err
+	}
+
+	statement := newStatement(wallet, interactor.config.Export.Currency, year, month, transactions, categories)
+
+	for _, item := range statement.breakdown {
+		category, ok := statement.categories[item.CategoryID]
+		if !ok || category.ImageID == "" {
+			continue
+		}
+
+		image, err := interactor.getImageRaw(userID, category.ImageID)
+		if err != nil || image == nil || image.RawImage == nil {
+			continue
+		}
+
+		icon, err := decodeImage(bytes.NewReader(image.RawImage), image.Format)
+		if err != nil {
+			log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+				Warnf("error decoding image %s of category %s, the statement will not have the icon", category.ImageID, category.CategoryID)
+			continue
+		}
+
+		statement.addIcon(category.CategoryID, icon)
+	}
+
+	if err := statement.write(writer); err != nil {
+		newErr := errors.New(errors.LevelError, 1, err)
+		log.WithFields(map[string]interface{}{"error": newErr.Error(), "cause": newErr}).
+			Errorf("error writing statement of wallet %s of user %s", walletID, userID)
+		return newErr
+	}
+
+	return nil
+}
+
+// ImageMigration is the result of a migration of the images to a blob storage
+type ImageMigration struct {
+	Migrated int
+	Missing  int
+	Failed   int
+}
+
+// migrateImages copies the images that are not on the target storage, verifying the copy with a sha256 checksum.
+// The storage of each image is updated after the copy, so the migration can be stopped and resumed.
+func (interactor *interactor) migrateImages(target string, deleteSource bool) (*ImageMigration, error) {
+	log.WithFields(map[string]interface{}{"method": "migrateImages"})
+	log.Infof("migrating images to blob storage %s", target)
+
+	targetStorage, ok := interactor.storageBlobs[target]
+	if !ok {
+		return nil, fmt.Errorf("the blob storage %s is not configured", target)
+	}
+
+	images, err := interactor.storageDB.getImagesNotOnStorage(target)
+	if err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error getting images on storage database %s", err)
+		return nil, err
+	}
+
+	migration := &ImageMigration{}
+	migrated := make(map[string]bool)
+	for _, image := range images {
+		// the images that share a blob are moved together with the first of them
+		if image.Hash != "" && migrated[image.Hash] {
+			migration.Migrated++
+			continue
+		}
+
+		path := imageBlobPath(image)
+
+		rawImage, err := interactor.downloadImage(image)
+		if err != nil {
+			migration.Failed++
+			continue
+		}
+		if rawImage == nil {
+			log.Warnf("image %s of user %s was not found on blob storage %s", image.ImageID, image.UserID, image.Storage)
+			migration.Missing++
+			continue
+		}
+
+		// the storage of the source is found before the copy, that can be on a storage tried first
+		source := *image
+		source.Storage = interactor.imageStorageDriver(image)
+
+		if err := interactor.copyImage(targetStorage, path, rawImage); err != nil {
+			log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+				Errorf("error copying image %s to blob storage %s %s", image.ImageID, target, err)
+			migration.Failed++
+			continue
+		}
+
+		if image.Hash != "" {
+			err = interactor.storageDB.updateImageBlobStorage(image.Hash, target)
+		} else {
+			err = interactor.storageDB.updateImageStorage(image.UserID, image.ImageID, target)
+		}
+		if err != nil {
+			log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+				Errorf("error updating storage of image %s on storage database %s", image.ImageID, err)
+			migration.Failed++
+			continue
+		}
+		if image.Hash != "" {
+			migrated[image.Hash] = true
+		}
+
+		if deleteSource && source.Storage != "" && source.Storage != target {
+			interactor.deleteImageBlob(&source)
+		}
+
+		migration.Migrated++
+		log.Infof("migrated image %s of user %s from %q to %s", image.ImageID, image.UserID, image.Storage, target)
+	}
+
+	log.Infof("migrated %d images to blob storage %s, %d missing and %d failed", migration.Migrated, target, migration.Missing, migration.Failed)
+
+	return migration, nil
+}
+
+// copyImage uploads the image and reads it back to compare the checksums
+func (interactor *interactor) copyImage(storage iStorageBlob, path string, rawImage []byte) error {
+	if err := storage.upload(path, rawImage); err != nil {
+		return err
+	}
+
+	copied, err := storage.download(path)
+	if err != nil {
+		return err
+	}
+
+	if sha256.Sum256(copied) != sha256.Sum256(rawImage) {
+		return fmt.Errorf("the checksum of the copy of %s doesn't match", path)
+	}
+
 	return nil
 }
@@ -0,0 +1,304 @@
+package gomoney
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	ledgerFormatLedger    = "ledger"
+	ledgerFormatHLedger   = "hledger"
+	ledgerFormatBeancount = "beancount"
+
+	defaultExportCurrency = "EUR"
+)
+
+// ledgerJournal renders wallets as asset accounts and categories as expense or income accounts.
+// A positive price leaves the wallet (expense), a negative price enters it (income).
+// Each transaction renders as a balanced entry, and the transactions of a group as a single entry with the net
+// amount of each wallet and category, so a transfer moves the price between the wallets and a split divides it
+// between the categories.
+type ledgerJournal struct {
+	format           string
+	currency         string
+	wallets          map[string]string
+	categories       map[string]*category
+	categoryAccounts map[string]string
+	used             map[string]bool
+	entries          []*ledgerEntry
+	accounts         []string
+	openDate         time.Time
+}
+
+// ledgerEntry is a single transaction, or the transactions of a group
+type ledgerEntry struct {
+	key          string
+	id           string
+	date         time.Time
+	description  string
+	transactions []*transaction
+	postings     []*ledgerPosting
+}
+
+type ledgerPosting struct {
+	account string
+	amount  decimal.Decimal
+}
+
+func isLedgerFormat(format string) bool {
+	switch format {
+	case ledgerFormatLedger, ledgerFormatHLedger, ledgerFormatBeancount:
+		return true
+	}
+	return false
+}
+
+func newLedgerJournal(format string, currency string, wallets []*wallet, categories []*category, transactions []*transaction) *ledgerJournal {
+	if currency == "" {
+		currency = defaultExportCurrency
+	}
+
+	journal := &ledgerJournal{
+		format:           format,
+		currency:         strings.ToUpper(currency),
+		wallets:          make(map[string]string),
+		categories:       make(map[string]*category),
+		categoryAccounts: make(map[string]string),
+		used:             make(map[string]bool),
+		openDate:         time.Now().UTC(),
+	}
+
+	for _, wallet := range wallets {
+		journal.wallets[wallet.WalletID] = uniqueLedgerAccount(journal.used, "Assets:Wallets", wallet.Name, wallet.WalletID)
+		journal.accounts = append(journal.accounts, journal.wallets[wallet.WalletID])
+		if wallet.CreatedAt.Before(journal.openDate) {
+			journal.openDate = wallet.CreatedAt
+		}
+	}
+
+	for _, category := range categories {
+		journal.categories[category.CategoryID] = category
+	}
+
+	sorted := make([]*transaction, len(transactions))
+	copy(sorted, transactions)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Date.Before(sorted[j].Date)
+	})
+
+	groups := make(map[string]*ledgerEntry)
+	for _, item := range sorted {
+		if item.Date.Before(journal.openDate) {
+			journal.openDate = item.Date
+		}
+
+		if _, ok := journal.wallets[item.WalletID]; !ok {
+			journal.wallets[item.WalletID] = uniqueLedgerAccount(journal.used, "Assets:Wallets", "", item.WalletID)
+			journal.accounts = append(journal.accounts, journal.wallets[item.WalletID])
+		}
+
+		if item.GroupID == "" {
+			journal.entries = append(journal.entries, &ledgerEntry{
+				key:          "transaction_id",
+				id:           item.TransactionID,
+				date:         item.Date,
+				transactions: []*transaction{item},
+			})
+			continue
+		}
+
+		// the entry of a group has the date of its first transaction
+		if entry, ok := groups[item.GroupID]; ok {
+			entry.transactions = append(entry.transactions, item)
+			continue
+		}
+		groups[item.GroupID] = &ledgerEntry{
+			key:          "group_id",
+			id:           item.GroupID,
+			date:         item.Date,
+			transactions: []*transaction{item},
+		}
+		journal.entries = append(journal.entries, groups[item.GroupID])
+	}
+
+	known := make(map[string]bool)
+	for _, account := range journal.accounts {
+		known[account] = true
+	}
+	for _, entry := range journal.entries {
+		entry.description = journal.description(entry.transactions)
+		entry.postings = journal.postings(entry.transactions)
+
+		for _, posting := range entry.postings {
+			if !known[posting.account] {
+				known[posting.account] = true
+				journal.accounts = append(journal.accounts, posting.account)
+			}
+		}
+	}
+
+	sort.Strings(journal.accounts)
+
+	return journal
+}
+
+// categoryAccount returns the expense or the income account of the category, unique like the accounts of the wallets
+func (journal *ledgerJournal) categoryAccount(categoryID string, income bool) string {
+	root := "Expenses"
+	if income {
+		root = "Income"
+	}
+
+	key := fmt.Sprintf("%s:%s", root, categoryID)
+	if account, ok := journal.categoryAccounts[key]; ok {
+		return account
+	}
+
+	name := categoryID
+	if category, ok := journal.categories[categoryID]; ok {
+		name = category.Name
+	}
+
+	journal.categoryAccounts[key] = uniqueLedgerAccount(journal.used, root, name, categoryID)
+	return journal.categoryAccounts[key]
+}
+
+// description returns the first description of the transactions, or the name of the category of the first one
+func (journal *ledgerJournal) description(transactions []*transaction) string {
+	for _, transaction := range transactions {
+		if transaction.Description != "" {
+			return transaction.Description
+		}
+	}
+
+	if category, ok := journal.categories[transactions[0].CategoryID]; ok {
+		return category.Name
+	}
+	return ""
+}
+
+// postings returns the net amount of each category and wallet of the transactions, in the order they appear.
+// The amounts that cancel out, like the category of a transfer, are left out.
+func (journal *ledgerJournal) postings(transactions []*transaction) []*ledgerPosting {
+	categoryIDs := make([]string, 0)
+	walletIDs := make([]string, 0)
+	categories := make(map[string]decimal.Decimal)
+	wallets := make(map[string]decimal.Decimal)
+
+	for _, transaction := range transactions {
+		if _, ok := categories[transaction.CategoryID]; !ok {
+			categoryIDs = append(categoryIDs, transaction.CategoryID)
+			categories[transaction.CategoryID] = decimal.Zero
+		}
+		categories[transaction.CategoryID] = categories[transaction.CategoryID].Add(transaction.Price)
+
+		if _, ok := wallets[transaction.WalletID]; !ok {
+			walletIDs = append(walletIDs, transaction.WalletID)
+			wallets[transaction.WalletID] = decimal.Zero
+		}
+		wallets[transaction.WalletID] = wallets[transaction.WalletID].Sub(transaction.Price)
+	}
+
+	postings := make([]*ledgerPosting, 0)
+	for _, categoryID := range categoryIDs {
+		if amount := categories[categoryID]; !amount.IsZero() {
+			postings = append(postings, &ledgerPosting{account: journal.categoryAccount(categoryID, amount.IsNegative()), amount: amount})
+		}
+	}
+	for _, walletID := range walletIDs {
+		if amount := wallets[walletID]; !amount.IsZero() {
+			postings = append(postings, &ledgerPosting{account: journal.wallets[walletID], amount: amount})
+		}
+	}
+
+	return postings
+}
+
+// write streams the journal in the syntax of the journal format
+func (journal *ledgerJournal) write(writer io.Writer) error {
+	buffered := bufio.NewWriter(writer)
+
+	switch journal.format {
+	case ledgerFormatBeancount:
+		fmt.Fprintf(buffered, "option \"operating_currency\" \"%s\"\n\n", journal.currency)
+		for _, account := range journal.accounts {
+			fmt.Fprintf(buffered, "%s open %s %s\n", journal.openDate.Format("2006-01-02"), account, journal.currency)
+		}
+	case ledgerFormatHLedger:
+		fmt.Fprintf(buffered, "commodity 1000.00 %s\n\n", journal.currency)
+		for _, account := range journal.accounts {
+			fmt.Fprintf(buffered, "account %s\n", account)
+		}
+	default:
+		fmt.Fprintf(buffered, "commodity %s\n\n", journal.currency)
+		for _, account := range journal.accounts {
+			fmt.Fprintf(buffered, "account %s\n", account)
+		}
+	}
+
+	for _, entry := range journal.entries {
+		// a transfer inside of the same wallet and category doesn't move anything
+		if len(entry.postings) == 0 {
+			continue
+		}
+		buffered.WriteString("\n")
+		journal.writeEntry(buffered, entry)
+	}
+
+	return buffered.Flush()
+}
+
+func (journal *ledgerJournal) writeEntry(writer *bufio.Writer, entry *ledgerEntry) {
+	switch journal.format {
+	case ledgerFormatBeancount:
+		fmt.Fprintf(writer, "%s * \"%s\"\n", entry.date.Format("2006-01-02"), strings.Replace(entry.description, "\"", "'", -1))
+		fmt.Fprintf(writer, "  %s: \"%s\"\n", entry.key, entry.id)
+		for _, posting := range entry.postings {
+			fmt.Fprintf(writer, "  %s  %s %s\n", posting.account, posting.amount.StringFixed(2), journal.currency)
+		}
+	default:
+		fmt.Fprintf(writer, "%s %s\n", entry.date.Format("2006-01-02"), strings.Replace(entry.description, "\n", " ", -1))
+		fmt.Fprintf(writer, "    ; %s: %s\n", entry.key, entry.id)
+		for _, posting := range entry.postings {
+			fmt.Fprintf(writer, "    %s  %s %s\n", posting.account, posting.amount.StringFixed(2), journal.currency)
+		}
+	}
+}
+
+// ledgerAccountName converts a name into an account component valid on every format
+func ledgerAccountName(name string, fallback string) string {
+	var builder strings.Builder
+	for _, word := range strings.FieldsFunc(name, func(r rune) bool {
+		return !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-')
+	}) {
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		builder.WriteString(string(runes))
+	}
+
+	account := builder.String()
+	if account == "" || !(account[0] >= 'A' && account[0] <= 'Z' || account[0] >= '0' && account[0] <= '9') {
+		account = "X" + account
+	}
+	if account == "X" {
+		account = "X" + fallback
+	}
+
+	return account
+}
+
+func uniqueLedgerAccount(used map[string]bool, root string, name string, id string) string {
+	account := fmt.Sprintf("%s:%s", root, ledgerAccountName(name, id))
+	if used[account] {
+		account = fmt.Sprintf("%s-%s", account, id)
+	}
+	used[account] = true
+	return account
+}
@@ -0,0 +1,95 @@
+package gomoney
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestLedgerJournal(t *testing.T) {
+	date := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	wallets := []*wallet{
+		{WalletID: "w1", Name: "Bank", CreatedAt: date},
+		{WalletID: "w2", Name: "Cash", CreatedAt: date},
+	}
+	categories := []*category{
+		{CategoryID: "c1", Name: "Food"},
+		{CategoryID: "c2", Name: "Food"},
+		{CategoryID: "c3", Name: "Transfers"},
+		{CategoryID: "c4", Name: "Home"},
+	}
+
+	tests := []struct {
+		name         string
+		transactions []*transaction
+		expected     []string
+		unexpected   []string
+	}{
+		{
+			name: "single transaction",
+			transactions: []*transaction{
+				{TransactionID: "t1", WalletID: "w1", CategoryID: "c1", Price: decimal.New(1050, -2), Description: "lunch", Date: date},
+			},
+			expected: []string{
+				"2026-10-01 lunch\n    ; transaction_id: t1\n    Expenses:Food  10.50 EUR\n    Assets:Wallets:Bank  -10.50 EUR\n",
+			},
+		},
+		{
+			name: "categories with the same name",
+			transactions: []*transaction{
+				{TransactionID: "t1", WalletID: "w1", CategoryID: "c1", Price: decimal.New(1, 0), Date: date},
+				{TransactionID: "t2", WalletID: "w1", CategoryID: "c2", Price: decimal.New(2, 0), Date: date},
+			},
+			expected: []string{
+				"account Expenses:Food\n",
+				"account Expenses:Food-c2\n",
+				"    Expenses:Food-c2  2.00 EUR\n",
+			},
+		},
+		{
+			name: "transfer",
+			transactions: []*transaction{
+				{TransactionID: "t1", WalletID: "w1", CategoryID: "c3", Price: decimal.New(100, 0), Description: "withdrawal", Date: date, GroupID: "g1"},
+				{TransactionID: "t2", WalletID: "w2", CategoryID: "c3", Price: decimal.New(-100, 0), Date: date, GroupID: "g1"},
+			},
+			expected: []string{
+				"2026-10-01 withdrawal\n    ; group_id: g1\n    Assets:Wallets:Bank  -100.00 EUR\n    Assets:Wallets:Cash  100.00 EUR\n",
+			},
+			unexpected: []string{"Transfers"},
+		},
+		{
+			name: "split",
+			transactions: []*transaction{
+				{TransactionID: "t1", WalletID: "w1", CategoryID: "c1", Price: decimal.New(30, 0), Description: "market", Date: date, GroupID: "g1"},
+				{TransactionID: "t2", WalletID: "w1", CategoryID: "c4", Price: decimal.New(20, 0), Date: date, GroupID: "g1"},
+			},
+			expected: []string{
+				"2026-10-01 market\n    ; group_id: g1\n    Expenses:Food  30.00 EUR\n    Expenses:Home  20.00 EUR\n    Assets:Wallets:Bank  -50.00 EUR\n",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buffer bytes.Buffer
+			if err := newLedgerJournal(ledgerFormatLedger, "", wallets, categories, test.transactions).write(&buffer); err != nil {
+				t.Fatalf("unexpected error %s", err)
+			}
+
+			journal := buffer.String()
+			for _, expected := range test.expected {
+				if !strings.Contains(journal, expected) {
+					t.Errorf("expected %q on the journal\n%s", expected, journal)
+				}
+			}
+			for _, unexpected := range test.unexpected {
+				if strings.Contains(journal, unexpected) {
+					t.Errorf("unexpected %q on the journal\n%s", unexpected, journal)
+				}
+			}
+		})
+	}
+}
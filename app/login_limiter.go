@@ -0,0 +1,313 @@
+package gomoney
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/joaosoft/manager"
+)
+
+const (
+	limiterDriverMemory   = "memory"
+	limiterDriverPostgres = "postgres"
+
+	defaultLoginWindow          = 15 * 60
+	defaultLoginMaxAttemptsIP   = 50
+	defaultLoginDelayAfter      = 3
+	defaultLoginMaxDelay        = 60
+	defaultLoginLockoutAfter    = 10
+	defaultLoginLockoutDuration = 15 * 60
+
+	loginReasonInvalid              = "invalid_credentials"
+	loginReasonInvalidCode          = "invalid_code"
+	loginReasonChallengeInvalidated = "challenge_invalidated"
+	loginReasonRateLimited          = "rate_limited"
+)
+
+// loginFailures are the failed logins of a key inside of the window
+type loginFailures struct {
+	Count int
+	// SinceFirst and SinceLast are the seconds since the first and the last failure
+	SinceFirst int
+	SinceLast  int
+}
+
+// newLimiterStore creates the limiter store of the configured driver, the failures are kept in memory when no driver is configured.
+// The instances behind a load balancer must share the postgres store.
+func newLimiterStore(config *MoneyConfig, connection manager.IDB) (iLimiterStore, error) {
+	switch config.Login.Limiter {
+	case limiterDriverPostgres:
+		return newLimiterPostgres(connection), nil
+	case limiterDriverMemory, "":
+		return newLimiterMemory(), nil
+	default:
+		return nil, fmt.Errorf("invalid login limiter %s", config.Login.Limiter)
+	}
+}
+
+// limiterMemory keeps the failures on the memory of the instance
+type limiterMemory struct {
+	mux      sync.Mutex
+	failures map[string]*limiterEntry
+}
+
+type limiterEntry struct {
+	count int
+	first time.Time
+	last  time.Time
+}
+
+func newLimiterMemory() *limiterMemory {
+	return &limiterMemory{
+		failures: make(map[string]*limiterEntry),
+	}
+}
+
+func (limiter *limiterMemory) addFailure(key string, window int) (int, error) {
+	limiter.mux.Lock()
+	defer limiter.mux.Unlock()
+
+	now := time.Now()
+	entry, ok := limiter.failures[key]
+	if !ok || now.Sub(entry.first) >= time.Duration(window)*time.Second {
+		entry = &limiterEntry{first: now}
+		limiter.failures[key] = entry
+	}
+	entry.count++
+	entry.last = now
+
+	return entry.count, nil
+}
+
+func (limiter *limiterMemory) getFailures(key string, window int) (*loginFailures, error) {
+	limiter.mux.Lock()
+	defer limiter.mux.Unlock()
+
+	now := time.Now()
+	entry, ok := limiter.failures[key]
+	if !ok || now.Sub(entry.first) >= time.Duration(window)*time.Second {
+		return &loginFailures{}, nil
+	}
+
+	return &loginFailures{
+		Count:      entry.count,
+		SinceFirst: int(now.Sub(entry.first) / time.Second),
+		SinceLast:  int(now.Sub(entry.last) / time.Second),
+	}, nil
+}
+
+func (limiter *limiterMemory) resetFailures(key string) error {
+	limiter.mux.Lock()
+	defer limiter.mux.Unlock()
+
+	delete(limiter.failures, key)
+	return nil
+}
+
+func (limiter *limiterMemory) purgeFailures(window int) (int64, error) {
+	limiter.mux.Lock()
+	defer limiter.mux.Unlock()
+
+	var count int64
+	now := time.Now()
+	for key, entry := range limiter.failures {
+		if now.Sub(entry.first) >= time.Duration(window)*time.Second {
+			delete(limiter.failures, key)
+			count++
+		}
+	}
+	return count, nil
+}
+
+// loginKeys returns the limiter keys of the email and of the ip
+func loginKeys(email string, ip string) (string, string) {
+	return "email:" + strings.ToLower(strings.TrimSpace(email)), "ip:" + ip
+}
+
+// challengeKeys returns the limiter keys of the second factor of the user and of the ip
+func challengeKeys(userID string, ip string) (string, string) {
+	return "totp:" + userID, "ip:" + ip
+}
+
+// walletKey returns the limiter key of the unlocks of a wallet by a user, so the failures of a member
+// don't delay the unlocks of the other users of the wallet
+func walletKey(userID string, walletID string) string {
+	return "wallet:" + userID + ":" + walletID
+}
+
+// loginDelay returns the seconds to wait before the next attempt, that double with each failure after the allowed ones
+func loginDelay(failures int, delayAfter int, maxDelay int) int {
+	if failures < delayAfter {
+		return 0
+	}
+
+	delay := 1
+	for i := delayAfter; i < failures && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay
+}
+
+// loginLimits returns the configured limits of the logins
+func (interactor *interactor) loginLimits() (window int, maxAttemptsIP int, delayAfter int, maxDelay int, lockoutAfter int, lockoutDuration int) {
+	login := interactor.config.Login
+
+	window, maxAttemptsIP, delayAfter, maxDelay, lockoutAfter, lockoutDuration =
+		login.Window, login.MaxAttemptsIP, login.DelayAfter, login.MaxDelay, login.LockoutAfter, login.LockoutDuration
+
+	if window <= 0 {
+		window = defaultLoginWindow
+	}
+	if maxAttemptsIP <= 0 {
+		maxAttemptsIP = defaultLoginMaxAttemptsIP
+	}
+	if delayAfter <= 0 {
+		delayAfter = defaultLoginDelayAfter
+	}
+	if maxDelay <= 0 {
+		maxDelay = defaultLoginMaxDelay
+	}
+	if lockoutAfter <= 0 {
+		lockoutAfter = defaultLoginLockoutAfter
+	}
+	if lockoutDuration <= 0 {
+		lockoutDuration = defaultLoginLockoutDuration
+	}
+
+	return
+}
+
+// loginRetryAfter returns the seconds until the email can try to login from the ip, or zero when it can try now
+func (interactor *interactor) loginRetryAfter(email string, ip string) (int, error) {
+	emailKey, ipKey := loginKeys(email, ip)
+	return interactor.limitRetryAfter(email, emailKey, ipKey)
+}
+
+// limitRetryAfter returns the seconds to wait with the lockout of the email and the failures of the keys,
+// or zero when the attempt is allowed now
+func (interactor *interactor) limitRetryAfter(email string, emailKey string, ipKey string) (int, error) {
+	window, maxAttemptsIP, delayAfter, maxDelay, _, _ := interactor.loginLimits()
+
+	locked, err := interactor.storageDB.getUserLockout(email)
+	if err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error getting lockout of user on storage database %s", err)
+		return 0, err
+	}
+	retryAfter := locked
+
+	emailFailures, err := interactor.limiter.getFailures(emailKey, window)
+	if err != nil {
+		return 0, err
+	}
+	if delay := loginDelay(emailFailures.Count, delayAfter, maxDelay) - emailFailures.SinceLast; delay > retryAfter {
+		retryAfter = delay
+	}
+
+	ipFailures, err := interactor.limiter.getFailures(ipKey, window)
+	if err != nil {
+		return 0, err
+	}
+	if ipFailures.Count >= maxAttemptsIP {
+		if wait := window - ipFailures.SinceFirst; wait > retryAfter {
+			retryAfter = wait
+		}
+	}
+
+	return retryAfter, nil
+}
+
+// login authenticates the user with the limits of the attempts by email and by ip, returning the seconds
+// to wait when the attempt isn't allowed. After too many failures the account is locked for a while.
+// Every attempt is recorded on the audit of the logins.
+func (interactor *interactor) login(email string, password string, ip string, userAgent string) (*user, int, error) {
+	log.WithFields(map[string]interface{}{"method": "login"})
+	log.Infof("login of %s from %s", email, ip)
+
+	window, _, _, _, lockoutAfter, lockoutDuration := interactor.loginLimits()
+	emailKey, ipKey := loginKeys(email, ip)
+	attempt := &loginAttempt{
+		LoginAttemptID: genUI(),
+		Email:          strings.ToLower(strings.TrimSpace(email)),
+		IP:             ip,
+		UserAgent:      userAgent,
+	}
+
+	retryAfter, err := interactor.loginRetryAfter(email, ip)
+	if err != nil {
+		return nil, 0, err
+	}
+	if retryAfter > 0 {
+		log.Infof("login of %s from %s is limited for %d seconds", email, ip, retryAfter)
+		attempt.Reason = loginReasonRateLimited
+		interactor.recordLoginAttempt(attempt)
+		return nil, retryAfter, nil
+	}
+
+	user, err := interactor.authenticateUser(email, password)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if user == nil {
+		attempt.Reason = loginReasonInvalid
+		interactor.recordLoginAttempt(attempt)
+
+		if _, err := interactor.limiter.addFailure(ipKey, window); err != nil {
+			return nil, 0, err
+		}
+		failures, err := interactor.limiter.addFailure(emailKey, window)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		if failures >= lockoutAfter {
+			log.Infof("locking the logins of %s for %d seconds after %d failures", email, lockoutDuration, failures)
+			if err := interactor.storageDB.lockUserLogin(email, lockoutDuration); err != nil {
+				log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+					Errorf("error locking user on storage database %s", err)
+				return nil, 0, err
+			}
+			interactor.limiter.resetFailures(emailKey)
+		}
+		return nil, 0, nil
+	}
+
+	attempt.UserID = user.UserID
+	attempt.Success = true
+	interactor.recordLoginAttempt(attempt)
+
+	if err := interactor.limiter.resetFailures(emailKey); err != nil {
+		return nil, 0, err
+	}
+
+	return user, 0, nil
+}
+
+// recordLoginAttempt adds the attempt to the audit of the logins, a failure is only logged
+func (interactor *interactor) recordLoginAttempt(attempt *loginAttempt) {
+	if err := interactor.storageDB.createLoginAttempt(attempt); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error recording login attempt on storage database %s", err)
+	}
+}
+
+// purgeLoginFailures deletes the failures outside of the window
+func (interactor *interactor) purgeLoginFailures() (int64, error) {
+	log.WithFields(map[string]interface{}{"method": "purgeLoginFailures"})
+
+	window, _, _, _, _, _ := interactor.loginLimits()
+	count, err := interactor.limiter.purgeFailures(window)
+	if err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error purging login failures %s", err)
+		return 0, err
+	}
+
+	return count, nil
+}
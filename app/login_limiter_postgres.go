@@ -0,0 +1,92 @@
+package gomoney
+
+import (
+	"database/sql"
+
+	"github.com/joaosoft/errors"
+	"github.com/joaosoft/manager"
+)
+
+// limiterPostgres keeps the failures on the database, so they are shared by every instance
+type limiterPostgres struct {
+	conn manager.IDB
+}
+
+// newLimiterPostgres ...
+func newLimiterPostgres(connection manager.IDB) *limiterPostgres {
+	return &limiterPostgres{
+		conn: connection,
+	}
+}
+
+func (limiter *limiterPostgres) addFailure(key string, window int) (int, error) {
+	row := limiter.conn.Get().QueryRow(`
+		INSERT INTO money.login_failures(key, failures, first_failure_at, last_failure_at)
+		VALUES($1, 1, NOW(), NOW())
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE WHEN money.login_failures.first_failure_at <= NOW() - $2::INTEGER * INTERVAL '1 second'
+				THEN 1 ELSE money.login_failures.failures + 1 END,
+			first_failure_at = CASE WHEN money.login_failures.first_failure_at <= NOW() - $2::INTEGER * INTERVAL '1 second'
+				THEN NOW() ELSE money.login_failures.first_failure_at END,
+			last_failure_at = NOW()
+		RETURNING failures
+	`, key, window)
+
+	var failures int
+	if err := row.Scan(&failures); err != nil {
+		return 0, errors.New(errors.LevelError, 1, err)
+	}
+
+	return failures, nil
+}
+
+func (limiter *limiterPostgres) getFailures(key string, window int) (*loginFailures, error) {
+	row := limiter.conn.Get().QueryRow(`
+		SELECT
+			failures,
+			EXTRACT(EPOCH FROM NOW() - first_failure_at)::INTEGER,
+			EXTRACT(EPOCH FROM NOW() - last_failure_at)::INTEGER
+		FROM money.login_failures
+		WHERE key = $1 AND first_failure_at > NOW() - $2::INTEGER * INTERVAL '1 second'
+	`, key, window)
+
+	failures := &loginFailures{}
+	if err := row.Scan(
+		&failures.Count,
+		&failures.SinceFirst,
+		&failures.SinceLast); err != nil {
+
+		if err != sql.ErrNoRows {
+			return nil, errors.New(errors.LevelError, 1, err)
+		}
+		return &loginFailures{}, nil
+	}
+
+	return failures, nil
+}
+
+func (limiter *limiterPostgres) resetFailures(key string) error {
+	if _, err := limiter.conn.Get().Exec(`
+	    DELETE
+		FROM money.login_failures
+		WHERE key = $1
+	`, key); err != nil {
+		return errors.New(errors.LevelError, 1, err)
+	}
+
+	return nil
+}
+
+func (limiter *limiterPostgres) purgeFailures(window int) (int64, error) {
+	result, err := limiter.conn.Get().Exec(`
+	    DELETE
+		FROM money.login_failures
+		WHERE first_failure_at <= NOW() - $1::INTEGER * INTERVAL '1 second'
+	`, window)
+	if err != nil {
+		return 0, errors.New(errors.LevelError, 1, err)
+	}
+
+	count, _ := result.RowsAffected()
+	return count, nil
+}
@@ -0,0 +1,167 @@
+package gomoney
+
+import (
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// fakeLoginStorage keeps the lockouts and the audit of the logins on memory
+type fakeLoginStorage struct {
+	*fakeStorageDB
+	lockouts map[string]time.Time
+	attempts []*loginAttempt
+}
+
+func newFakeLoginStorage() *fakeLoginStorage {
+	return &fakeLoginStorage{
+		fakeStorageDB: newFakeStorageDB(),
+		lockouts:      make(map[string]time.Time),
+	}
+}
+
+func (storage *fakeLoginStorage) getUserByEmail(email string) (*user, error) {
+	for _, user := range storage.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return nil, nil
+}
+
+func (storage *fakeLoginStorage) getUserLockout(email string) (int, error) {
+	if until, ok := storage.lockouts[email]; ok && until.After(time.Now()) {
+		return int(time.Until(until)/time.Second) + 1, nil
+	}
+	return 0, nil
+}
+
+func (storage *fakeLoginStorage) lockUserLogin(email string, duration int) error {
+	storage.lockouts[email] = time.Now().Add(time.Duration(duration) * time.Second)
+	return nil
+}
+
+func (storage *fakeLoginStorage) createLoginAttempt(attempt *loginAttempt) error {
+	storage.attempts = append(storage.attempts, attempt)
+	return nil
+}
+
+func TestLoginDelay(t *testing.T) {
+	tests := []struct {
+		failures   int
+		delayAfter int
+		maxDelay   int
+		expected   int
+	}{
+		{failures: 0, delayAfter: 3, maxDelay: 60, expected: 0},
+		{failures: 2, delayAfter: 3, maxDelay: 60, expected: 0},
+		{failures: 3, delayAfter: 3, maxDelay: 60, expected: 1},
+		{failures: 4, delayAfter: 3, maxDelay: 60, expected: 2},
+		{failures: 5, delayAfter: 3, maxDelay: 60, expected: 4},
+		{failures: 8, delayAfter: 3, maxDelay: 60, expected: 32},
+		{failures: 9, delayAfter: 3, maxDelay: 60, expected: 60},
+		{failures: 1000, delayAfter: 3, maxDelay: 60, expected: 60},
+		{failures: 1, delayAfter: 1, maxDelay: 1, expected: 1},
+	}
+
+	for _, test := range tests {
+		if got := loginDelay(test.failures, test.delayAfter, test.maxDelay); got != test.expected {
+			t.Errorf("failures %d after %d with max %d: expected %d, got %d", test.failures, test.delayAfter, test.maxDelay, test.expected, got)
+		}
+	}
+}
+
+func TestLimiterMemory(t *testing.T) {
+	limiter := newLimiterMemory()
+
+	for i := 1; i <= 3; i++ {
+		if count, _ := limiter.addFailure("email:user@example.com", 60); count != i {
+			t.Fatalf("expected %d failures, got %d", i, count)
+		}
+	}
+	limiter.addFailure("ip:127.0.0.1", 60)
+
+	if failures, _ := limiter.getFailures("email:user@example.com", 60); failures.Count != 3 || failures.SinceLast != 0 {
+		t.Errorf("expected 3 recent failures, got %+v", failures)
+	}
+	if failures, _ := limiter.getFailures("email:other@example.com", 60); failures.Count != 0 {
+		t.Errorf("expected no failures of another key, got %+v", failures)
+	}
+
+	// the failures outside of the window are ignored and restart the count
+	limiter.failures["email:user@example.com"].first = time.Now().Add(-time.Minute)
+	if failures, _ := limiter.getFailures("email:user@example.com", 60); failures.Count != 0 {
+		t.Errorf("expected no failures inside of the window, got %+v", failures)
+	}
+	if purged, _ := limiter.purgeFailures(60); purged != 1 {
+		t.Errorf("expected 1 purged key, got %d", purged)
+	}
+	if count, _ := limiter.addFailure("email:user@example.com", 60); count != 1 {
+		t.Errorf("expected the count restarted, got %d", count)
+	}
+
+	limiter.resetFailures("ip:127.0.0.1")
+	if failures, _ := limiter.getFailures("ip:127.0.0.1", 60); failures.Count != 0 {
+		t.Errorf("expected no failures after the reset, got %+v", failures)
+	}
+}
+
+func TestLoginLimits(t *testing.T) {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+
+	tests := []struct {
+		name         string
+		delayAfter   int
+		lockoutAfter int
+		maxIP        int
+		// failures are the logins with a wrong password before the login with the right one
+		failures   []string
+		email      string
+		ip         string
+		retryAfter bool
+		locked     bool
+	}{
+		{name: "no failures", email: "user@example.com", ip: "10.0.0.1"},
+		{name: "failures before the delay", delayAfter: 3, failures: []string{"user@example.com", "user@example.com"}, email: "user@example.com", ip: "10.0.0.1"},
+		{name: "delay after the failures", delayAfter: 2, failures: []string{"user@example.com", "user@example.com"}, email: "user@example.com", ip: "10.0.0.1", retryAfter: true},
+		{name: "delay of the email on another ip", delayAfter: 2, failures: []string{"user@example.com", "user@example.com"}, email: "user@example.com", ip: "10.0.0.2", retryAfter: true},
+		{name: "delay of another email", delayAfter: 2, failures: []string{"other@example.com", "other@example.com"}, email: "user@example.com", ip: "10.0.0.1"},
+		{name: "lockout", delayAfter: 100, lockoutAfter: 3, failures: []string{"user@example.com", "user@example.com", "user@example.com"}, email: "user@example.com", ip: "10.0.0.2", retryAfter: true, locked: true},
+		{name: "failures before the lockout", delayAfter: 100, lockoutAfter: 3, failures: []string{"user@example.com", "user@example.com"}, email: "user@example.com", ip: "10.0.0.1"},
+		{name: "attempts of the ip", delayAfter: 100, maxIP: 2, failures: []string{"a@example.com", "b@example.com"}, email: "user@example.com", ip: "10.0.0.1", retryAfter: true},
+		{name: "attempts of another ip", delayAfter: 100, maxIP: 2, failures: []string{"a@example.com", "b@example.com"}, email: "user@example.com", ip: "10.0.0.2"},
+	}
+
+	for _, test := range tests {
+		storage := newFakeLoginStorage()
+		storage.users["user"] = &user{UserID: "user", Email: "user@example.com", Password: string(hash), Status: userStatusActive}
+
+		config := &MoneyConfig{}
+		config.Security.PasswordCost = bcrypt.MinCost
+		config.Login.DelayAfter, config.Login.LockoutAfter, config.Login.MaxAttemptsIP = test.delayAfter, test.lockoutAfter, test.maxIP
+		interactor := newInteractor(storage, nil, newMailerLog(), newLimiterMemory(), config)
+
+		for _, email := range test.failures {
+			if user, retryAfter, err := interactor.login(email, "wrong", "10.0.0.1", "test"); err != nil || user != nil || retryAfter != 0 {
+				t.Fatalf("%s: expected a failure, got %v after %d with error %v", test.name, user, retryAfter, err)
+			}
+		}
+
+		user, retryAfter, err := interactor.login(test.email, "password", test.ip, "test")
+		if err != nil {
+			t.Fatalf("%s: unexpected error %s", test.name, err)
+		}
+		if (retryAfter > 0) != test.retryAfter || (user != nil) == test.retryAfter {
+			t.Errorf("%s: expected limited %t, got %v after %d", test.name, test.retryAfter, user, retryAfter)
+		}
+		if _, ok := storage.lockouts[test.email]; ok != test.locked {
+			t.Errorf("%s: expected locked %t", test.name, test.locked)
+		}
+
+		last := storage.attempts[len(storage.attempts)-1]
+		if test.retryAfter && last.Reason != loginReasonRateLimited || !test.retryAfter && !last.Success {
+			t.Errorf("%s: unexpected audit of the attempt %+v", test.name, last)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package gomoney
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	mailerDriverSMTP = "smtp"
+	mailerDriverLog  = "log"
+)
+
+// newMailer creates the mailer of the configured driver, the mails are only logged when no driver is configured
+func newMailer(config *MoneyConfig) (iMailer, error) {
+	switch config.Mail.Driver {
+	case mailerDriverSMTP:
+		if config.Mail.SMTP.Host == "" {
+			return nil, fmt.Errorf("the smtp mailer requires a host")
+		}
+		return newMailerSMTP(config.Mail.SMTP.Host, config.Mail.SMTP.Port, config.Mail.SMTP.Username,
+			config.Mail.SMTP.Password, config.Mail.From), nil
+	case mailerDriverLog, "":
+		return newMailerLog(), nil
+	default:
+		return nil, fmt.Errorf("invalid mail driver %s", config.Mail.Driver)
+	}
+}
+
+// mailerLog writes the mails to the log, to be used on development
+type mailerLog struct{}
+
+func newMailerLog() *mailerLog {
+	return &mailerLog{}
+}
+
+func (mailer *mailerLog) send(to string, subject string, body string) error {
+	log.Infof("mail to %s with subject %s:\n%s", to, subject, body)
+	return nil
+}
+
+// mailerSMTP sends the mails to a smtp server
+type mailerSMTP struct {
+	address  string
+	host     string
+	username string
+	password string
+	from     string
+}
+
+func newMailerSMTP(host string, port int, username string, password string, from string) *mailerSMTP {
+	if port == 0 {
+		port = 25
+	}
+
+	return &mailerSMTP{
+		address:  net.JoinHostPort(host, strconv.Itoa(port)),
+		host:     host,
+		username: username,
+		password: password,
+		from:     from,
+	}
+}
+
+func (mailer *mailerSMTP) send(to string, subject string, body string) error {
+	var auth smtp.Auth
+	if mailer.username != "" {
+		auth = smtp.PlainAuth("", mailer.username, mailer.password, mailer.host)
+	}
+
+	if err := smtp.SendMail(mailer.address, auth, mailer.from, []string{to}, newMailMessage(mailer.from, to, subject, body)); err != nil {
+		return fmt.Errorf("error sending mail to %s: %s", to, err)
+	}
+	return nil
+}
+
+// newMailMessage builds a plain text message, removing the line breaks of the headers
+func newMailMessage(from string, to string, subject string, body string) []byte {
+	header := strings.NewReplacer("\r", "", "\n", "")
+
+	var message bytes.Buffer
+	fmt.Fprintf(&message, "From: %s\r\n", header.Replace(from))
+	fmt.Fprintf(&message, "To: %s\r\n", header.Replace(to))
+	fmt.Fprintf(&message, "Subject: %s\r\n", header.Replace(subject))
+	fmt.Fprintf(&message, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	message.WriteString("MIME-Version: 1.0\r\n")
+	message.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	message.WriteString("\r\n")
+	message.WriteString(strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n"))
+
+	return message.Bytes()
+}
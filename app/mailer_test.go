@@ -0,0 +1,148 @@
+package gomoney
+
+import (
+	"bufio"
+	"encoding/base64"
+	"net"
+	"strings"
+	"testing"
+)
+
+// fakeSMTP is a smtp server that accepts one mail and records the dialogue
+type fakeSMTP struct {
+	listener net.Listener
+	auth     string
+	from     string
+	to       []string
+	data     string
+	done     chan struct{}
+}
+
+func newFakeSMTP(t *testing.T) *fakeSMTP {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+
+	server := &fakeSMTP{listener: listener, done: make(chan struct{})}
+	go server.serve()
+	return server
+}
+
+func (server *fakeSMTP) port() int {
+	return server.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (server *fakeSMTP) serve() {
+	defer close(server.done)
+
+	conn, err := server.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 localhost ready")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+
+		switch command {
+		case "EHLO":
+			reply("250-localhost")
+			reply("250 AUTH PLAIN")
+		case "AUTH":
+			credentials, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(line, "AUTH PLAIN "))
+			server.auth = string(credentials)
+			reply("235 authenticated")
+		case "MAIL":
+			server.from = line
+			reply("250 ok")
+		case "RCPT":
+			server.to = append(server.to, line)
+			reply("250 ok")
+		case "DATA":
+			reply("354 send the data")
+			var data strings.Builder
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(line)
+			}
+			server.data = data.String()
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func TestMailerSMTP(t *testing.T) {
+	tests := []struct {
+		name     string
+		username string
+		password string
+		auth     string
+	}{
+		{name: "without authentication"},
+		{name: "with authentication", username: "user", password: "secret", auth: "\x00user\x00secret"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := newFakeSMTP(t)
+			defer server.listener.Close()
+
+			mailer := newMailerSMTP("127.0.0.1", server.port(), test.username, test.password, "money@example.com")
+			if err := mailer.send("john@example.com", "Verify\r\nBcc: other@example.com", "line 1\nline 2"); err != nil {
+				t.Fatalf("unexpected error %s", err)
+			}
+			<-server.done
+
+			if server.auth != test.auth {
+				t.Errorf("expected the authentication %q, got %q", test.auth, server.auth)
+			}
+			if server.from != "MAIL FROM:<money@example.com>" && !strings.HasPrefix(server.from, "MAIL FROM:<money@example.com> ") {
+				t.Errorf("invalid sender %s", server.from)
+			}
+			if len(server.to) != 1 || server.to[0] != "RCPT TO:<john@example.com>" {
+				t.Errorf("invalid recipients %v", server.to)
+			}
+			for _, expected := range []string{"From: money@example.com\r\n", "To: john@example.com\r\n",
+				"Subject: VerifyBcc: other@example.com\r\n", "\r\n\r\nline 1\r\nline 2"} {
+				if !strings.Contains(server.data, expected) {
+					t.Errorf("expected the message to contain %q, got %q", expected, server.data)
+				}
+			}
+		})
+	}
+}
+
+func TestMailerSMTPError(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	mailer := newMailerSMTP("127.0.0.1", port, "", "", "money@example.com")
+	if err := mailer.send("john@example.com", "subject", "body"); err == nil {
+		t.Errorf("expected an error sending to the closed port %d", port)
+	}
+}
@@ -2,6 +2,7 @@ package gomoney
 
 import (
 	"fmt"
+	"io"
 
 	"github.com/joaosoft/logger"
 	"github.com/joaosoft/manager"
@@ -24,8 +25,10 @@ func init() {
 type Money struct {
 	interactor    *interactor
 	pm            *manager.Manager
+	db            manager.IDB
 	config        *MoneyConfig
 	isLogExternal bool
+	stopPurge     chan struct{}
 }
 
 // NewMoney ...
@@ -59,8 +62,27 @@ func NewMoney(options ...moneyOption) (*Money, error) {
 		return nil, err
 	}
 
+	storageBlobs, err := newStorageBlobs(&appConfig.GoMoney, simpleDB)
+	if err != nil {
+		log.Error(err.Error())
+		return nil, err
+	}
+
+	mailer, err := newMailer(&appConfig.GoMoney)
+	if err != nil {
+		log.Error(err.Error())
+		return nil, err
+	}
+
+	limiter, err := newLimiterStore(&appConfig.GoMoney, simpleDB)
+	if err != nil {
+		log.Error(err.Error())
+		return nil, err
+	}
+
+	money.db = simpleDB
 	money.config = &appConfig.GoMoney
-	money.interactor = newInteractor(newStoragePostgres(simpleDB), newStorageDropbox(nil), &appConfig.GoMoney)
+	money.interactor = newInteractor(newStoragePostgres(simpleDB), storageBlobs, mailer, limiter, &appConfig.GoMoney)
 
 	return money, nil
 }
@@ -70,10 +92,121 @@ func (m *Money) Start() error {
 	apiWeb := m.newApiWeb(m.config.Host, m.interactor)
 	m.pm.AddWeb("api_web", apiWeb.client)
 
+	// the expired sessions are purged on the background
+	m.stopPurge = make(chan struct{})
+	go m.interactor.runSessionPurge(m.stopPurge)
+
 	return m.pm.Start()
 }
 
 // Stop ...
 func (m *Money) Stop() error {
+	if m.stopPurge != nil {
+		close(m.stopPurge)
+		m.stopPurge = nil
+	}
 	return m.pm.Stop()
 }
+
+// startStorage starts the database connection when running without the web api
+func (m *Money) startStorage() error {
+	if m.db.Started() {
+		return nil
+	}
+	return m.db.Start()
+}
+
+// ExportAccount writes the data portability archive of a user, with the transactions of the locked wallets
+func (m *Money) ExportAccount(userID string, writer io.Writer) error {
+	if err := m.startStorage(); err != nil {
+		return err
+	}
+	return m.interactor.exportAccount(userID, nil, writer)
+}
+
+// ImportAccount restores an archive into an existing and empty account
+func (m *Money) ImportAccount(userID string, reader io.ReaderAt, size int64) error {
+	if err := m.startStorage(); err != nil {
+		return err
+	}
+
+	archive, err := readAccountArchive(reader, size, m.interactor.archiveLimits())
+	if err != nil {
+		return err
+	}
+
+	return m.interactor.importAccount(userID, archive)
+}
+
+// ImportNewAccount restores an archive into a new account, returning the new user id
+func (m *Money) ImportNewAccount(email string, password string, reader io.ReaderAt, size int64) (string, error) {
+	if err := m.startStorage(); err != nil {
+		return "", err
+	}
+
+	archive, err := readAccountArchive(reader, size, m.interactor.archiveLimits())
+	if err != nil {
+		return "", err
+	}
+
+	user, err := m.interactor.importNewAccount(email, password, archive)
+	if err != nil {
+		return "", err
+	}
+
+	return user.UserID, nil
+}
+
+// ExportLedger writes the journal of a user in the ledger, hledger or beancount format,
+// with the transactions of the locked wallets
+func (m *Money) ExportLedger(userID string, format string, writer io.Writer) error {
+	if !isLedgerFormat(format) {
+		return fmt.Errorf("invalid format %s", format)
+	}
+
+	if err := m.startStorage(); err != nil {
+		return err
+	}
+
+	return m.interactor.exportLedger(userID, format, nil, writer)
+}
+
+// SetUserRole sets the role of the user with the email
+func (m *Money) SetUserRole(email string, role string) error {
+	if !isRole(role) {
+		return fmt.Errorf("invalid role %s", role)
+	}
+
+	if err := m.startStorage(); err != nil {
+		return err
+	}
+
+	user, err := m.interactor.getUserByEmail(email)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return fmt.Errorf("the user %s doesn't exist", email)
+	}
+
+	_, err = m.interactor.updateUserRole(user.UserID, role)
+	return err
+}
+
+// MigrateImages copies the images to the blob storage of a driver, deleting them from the previous storage when asked
+func (m *Money) MigrateImages(driver string, deleteSource bool) (*ImageMigration, error) {
+	if err := m.startStorage(); err != nil {
+		return nil, err
+	}
+
+	return m.interactor.migrateImages(driver, deleteSource)
+}
+
+// DeduplicateImages moves the images stored from before the deduplication to the shared blobs
+func (m *Money) DeduplicateImages() (*ImageDeduplication, error) {
+	if err := m.startStorage(); err != nil {
+		return nil, err
+	}
+
+	return m.interactor.deduplicateImages()
+}
@@ -0,0 +1,117 @@
+package gomoney
+
+import (
+	"crypto/subtle"
+	"strings"
+	"sync"
+
+	"github.com/joaosoft/errors"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var errInvalidCurrentPassword = errors.New(errors.LevelError, 1, "the current password is invalid")
+
+var (
+	// dummyPasswordHash is compared when the user doesn't exist, so the response time doesn't reveal the accounts
+	dummyPasswordHash     []byte
+	dummyPasswordHashOnce sync.Once
+)
+
+// passwordCost returns the configured bcrypt cost inside of the limits of bcrypt
+func (interactor *interactor) passwordCost() int {
+	cost := interactor.config.Security.PasswordCost
+	switch {
+	case cost == 0:
+		return bcrypt.DefaultCost
+	case cost < bcrypt.MinCost:
+		return bcrypt.MinCost
+	case cost > bcrypt.MaxCost:
+		return bcrypt.MaxCost
+	default:
+		return cost
+	}
+}
+
+// hashPassword hashes the password with bcrypt, that generates a random salt for each password
+func (interactor *interactor) hashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), interactor.passwordCost())
+	if err != nil {
+		newErr := errors.New(errors.LevelError, 1, err)
+		log.WithFields(map[string]interface{}{"error": newErr.Error(), "cause": newErr}).
+			Error("error when hashing password")
+		return "", newErr
+	}
+	return string(hash), nil
+}
+
+// isPasswordHash checks if the stored password is a bcrypt hash.
+// The accounts from before the hashing only have the legacy token.
+func isPasswordHash(password string) bool {
+	return strings.HasPrefix(password, "$2")
+}
+
+// checkPassword compares the password with the bcrypt hash of the user, or with the legacy token
+// when the user was created before the hashing. It returns if the password matches and if it was a legacy token.
+func checkPassword(user *user, password string) (bool, bool) {
+	if isPasswordHash(user.Password) {
+		return bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) == nil, false
+	}
+
+	if user.Token == "" {
+		return false, false
+	}
+
+	token, err := generateToken(authentication, []byte(password))
+	if err != nil {
+		return false, false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(user.Token)) == 1, true
+}
+
+// authenticateUser checks the email and the password, returning nil when they don't match.
+// The legacy tokens and the hashes with a different cost are hashed again with the current cost.
+func (interactor *interactor) authenticateUser(email string, password string) (*user, error) {
+	log.WithFields(map[string]interface{}{"method": "authenticateUser"})
+	log.Infof("authenticating user %s", email)
+
+	user, err := interactor.getUserByEmail(email)
+	if err != nil {
+		return nil, err
+	}
+
+	if user == nil {
+		dummyPasswordHashOnce.Do(func() {
+			dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte(genUI()), interactor.passwordCost())
+		})
+		bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+		return nil, nil
+	}
+
+	matches, legacy := checkPassword(user, password)
+	if !matches {
+		log.Infof("invalid password of user %s", user.UserID)
+		return nil, nil
+	}
+
+	rehash := legacy
+	if !legacy {
+		if cost, err := bcrypt.Cost([]byte(user.Password)); err == nil && cost != interactor.passwordCost() {
+			rehash = true
+		}
+	}
+
+	if rehash {
+		if hash, err := interactor.hashPassword(password); err == nil {
+			if err := interactor.storageDB.updateUserPassword(user.UserID, hash); err != nil {
+				log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+					Errorf("error upgrading password of user %s on storage database %s", user.UserID, err)
+			} else {
+				log.Infof("upgraded password hash of user %s", user.UserID)
+				user.Password = hash
+				user.Token = ""
+			}
+		}
+	}
+
+	return user, nil
+}
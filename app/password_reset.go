@@ -0,0 +1,104 @@
+package gomoney
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const (
+	defaultPasswordResetLifetime = 60 * 60
+)
+
+// passwordResetLink returns the link of the reset password page with the token
+func passwordResetLink(link string, token string) string {
+	separator := "?"
+	if strings.Contains(link, "?") {
+		separator = "&"
+	}
+	return fmt.Sprintf("%s%stoken=%s", link, separator, url.QueryEscape(token))
+}
+
+// requestPasswordReset mails a reset token to the user with the email. Nothing is returned about the account,
+// so the email of a user can't be discovered.
+func (interactor *interactor) requestPasswordReset(email string) error {
+	log.WithFields(map[string]interface{}{"method": "requestPasswordReset"})
+	log.Infof("requesting password reset of %s", email)
+
+	user, err := interactor.getUserByEmail(email)
+	if err != nil {
+		return err
+	}
+	if user == nil || user.Status == userStatusDeleted {
+		log.Infof("password reset requested for the unknown email %s", email)
+		return nil
+	}
+
+	lifetime := interactor.config.PasswordReset.Lifetime
+	if lifetime <= 0 {
+		lifetime = defaultPasswordResetLifetime
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return err
+	}
+
+	if err := interactor.storageDB.createPasswordReset(hashToken(token), user.UserID, lifetime); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error creating password reset on storage database %s", err)
+		return err
+	}
+
+	body := fmt.Sprintf("Hello %s,\n\n"+
+		"to choose a new password for your Go Money account open the link below, it expires in %d minutes.\n\n"+
+		"%s\n\n"+
+		"If you didn't ask to reset your password, ignore this email.\n",
+		user.Name, lifetime/60, passwordResetLink(interactor.config.PasswordReset.Link, token))
+
+	if err := interactor.mailer.send(user.Email, "Reset your Go Money password", body); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error sending password reset to user %s %s", user.UserID, err)
+	}
+
+	return nil
+}
+
+// resetPassword sets the password of the user of the reset token, that can only be used once.
+// Every session of the user is revoked. It returns false when the token is invalid or expired.
+func (interactor *interactor) resetPassword(token string, password string) (bool, error) {
+	log.WithFields(map[string]interface{}{"method": "resetPassword"})
+	log.Info("resetting password")
+
+	hash, err := interactor.hashPassword(password)
+	if err != nil {
+		return false, err
+	}
+
+	userID, err := interactor.storageDB.resetPassword(hashToken(token), hash)
+	if err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error resetting password on storage database %s", err)
+		return false, err
+	}
+	if userID == "" {
+		return false, nil
+	}
+
+	log.Infof("reset password of user %s and revoked its sessions", userID)
+	return true, nil
+}
+
+// purgePasswordResets deletes the used and the expired reset tokens
+func (interactor *interactor) purgePasswordResets() (int64, error) {
+	log.WithFields(map[string]interface{}{"method": "purgePasswordResets"})
+
+	count, err := interactor.storageDB.deleteExpiredPasswordResets()
+	if err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error purging password resets on storage database %s", err)
+		return 0, err
+	}
+
+	return count, nil
+}
@@ -0,0 +1,99 @@
+package gomoney
+
+import (
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// fakePasswordStorage records the passwords updated on the users
+type fakePasswordStorage struct {
+	*fakeLoginStorage
+	updated map[string]string
+}
+
+func (storage *fakePasswordStorage) updateUserPassword(userID string, password string) error {
+	storage.updated[userID] = password
+	storage.users[userID].Password = password
+	return nil
+}
+
+func TestCheckPassword(t *testing.T) {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	token, _ := generateToken(authentication, []byte("password"))
+
+	tests := []struct {
+		name     string
+		user     *user
+		password string
+		matches  bool
+		legacy   bool
+	}{
+		{name: "hash", user: &user{Password: string(hash)}, password: "password", matches: true},
+		{name: "wrong password of a hash", user: &user{Password: string(hash)}, password: "wrong"},
+		{name: "hash with a legacy token", user: &user{Password: string(hash), Token: token}, password: "password", matches: true},
+		{name: "legacy token", user: &user{Token: token}, password: "password", matches: true, legacy: true},
+		{name: "wrong password of a legacy token", user: &user{Token: token}, password: "wrong", legacy: true},
+		{name: "no password", user: &user{}, password: ""},
+	}
+
+	for _, test := range tests {
+		matches, legacy := checkPassword(test.user, test.password)
+		if matches != test.matches || legacy != test.legacy {
+			t.Errorf("%s: expected %t and legacy %t, got %t and legacy %t", test.name, test.matches, test.legacy, matches, legacy)
+		}
+	}
+}
+
+func TestAuthenticateUser(t *testing.T) {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	costlyHash, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost+1)
+	token, _ := generateToken(authentication, []byte("password"))
+
+	tests := []struct {
+		name     string
+		user     *user
+		email    string
+		password string
+		found    bool
+		upgraded bool
+	}{
+		{name: "current hash", user: &user{Password: string(hash)}, email: "user@example.com", password: "password", found: true},
+		{name: "hash with another cost", user: &user{Password: string(costlyHash)}, email: "user@example.com", password: "password", found: true, upgraded: true},
+		{name: "legacy token", user: &user{Token: token}, email: "user@example.com", password: "password", found: true, upgraded: true},
+		{name: "wrong password", user: &user{Token: token}, email: "user@example.com", password: "wrong"},
+		{name: "unknown email", user: &user{Password: string(hash)}, email: "other@example.com", password: "password"},
+	}
+
+	for _, test := range tests {
+		storage := &fakePasswordStorage{fakeLoginStorage: newFakeLoginStorage(), updated: make(map[string]string)}
+		test.user.UserID, test.user.Email = "user", "user@example.com"
+		storage.users["user"] = test.user
+
+		config := &MoneyConfig{}
+		config.Security.PasswordCost = bcrypt.MinCost
+		interactor := newInteractor(storage, nil, newMailerLog(), newLimiterMemory(), config)
+
+		user, err := interactor.authenticateUser(test.email, test.password)
+		if err != nil {
+			t.Fatalf("%s: unexpected error %s", test.name, err)
+		}
+		if (user != nil) != test.found {
+			t.Fatalf("%s: expected found %t, got %v", test.name, test.found, user)
+		}
+
+		updated, ok := storage.updated["user"]
+		if ok != test.upgraded {
+			t.Fatalf("%s: expected upgraded %t", test.name, test.upgraded)
+		}
+		if !ok {
+			continue
+		}
+		if cost, err := bcrypt.Cost([]byte(updated)); err != nil || cost != bcrypt.MinCost {
+			t.Errorf("%s: expected a hash with the configured cost, got %d with error %v", test.name, cost, err)
+		}
+		if user.Token != "" {
+			t.Errorf("%s: expected the legacy token removed, got %s", test.name, user.Token)
+		}
+	}
+}
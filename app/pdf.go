@@ -0,0 +1,213 @@
+package gomoney
+
+import (
+	"bytes"
+	"fmt"
+	img "image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"io"
+	"strings"
+)
+
+const (
+	pdfPageWidth  = 595.0
+	pdfPageHeight = 842.0
+
+	pdfFontRegular = "F1"
+	pdfFontBold    = "F2"
+)
+
+// pdfDocument is a minimal pdf writer with the standard helvetica fonts, lines, rectangles and jpeg images.
+// The coordinates are in points with the origin on the top left corner of the page.
+type pdfDocument struct {
+	pages  []*pdfPage
+	images []*pdfImage
+}
+
+// pdfPage ...
+type pdfPage struct {
+	content bytes.Buffer
+}
+
+// pdfImage ...
+type pdfImage struct {
+	name   string
+	width  int
+	height int
+	data   []byte
+}
+
+func newPdfDocument() *pdfDocument {
+	return &pdfDocument{}
+}
+
+// addPage ...
+func (document *pdfDocument) addPage() *pdfPage {
+	page := &pdfPage{}
+	document.pages = append(document.pages, page)
+	return page
+}
+
+// addImage flattens the image over a white background and stores it as a jpeg
+func (document *pdfDocument) addImage(image img.Image) (*pdfImage, error) {
+	bounds := image.Bounds()
+	flat := img.NewRGBA(img.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(flat, flat.Bounds(), &img.Uniform{C: color.White}, img.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), image, bounds.Min, draw.Over)
+
+	var buffer bytes.Buffer
+	if err := jpeg.Encode(&buffer, flat, &jpeg.Options{Quality: 90}); err != nil {
+		return nil, err
+	}
+
+	pdfImage := &pdfImage{
+		name:   fmt.Sprintf("Im%d", len(document.images)+1),
+		width:  bounds.Dx(),
+		height: bounds.Dy(),
+		data:   buffer.Bytes(),
+	}
+	document.images = append(document.images, pdfImage)
+
+	return pdfImage, nil
+}
+
+// text ...
+func (page *pdfPage) text(x, y float64, size float64, font string, value string) {
+	fmt.Fprintf(&page.content, "BT /%s %.2f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, pdfPageHeight-y, pdfEscape(value))
+}
+
+// textRight writes the text aligned to the right of x, using an approximated helvetica width
+func (page *pdfPage) textRight(x, y float64, size float64, font string, value string) {
+	page.text(x-pdfTextWidth(value, size), y, size, font, value)
+}
+
+// line ...
+func (page *pdfPage) line(x1, y1, x2, y2 float64, gray float64) {
+	fmt.Fprintf(&page.content, "%.2f G 0.5 w %.2f %.2f m %.2f %.2f l S\n", gray, x1, pdfPageHeight-y1, x2, pdfPageHeight-y2)
+}
+
+// rect fills a rectangle with a rgb color with components between 0 and 1
+func (page *pdfPage) rect(x, y, width, height float64, r, g, b float64) {
+	fmt.Fprintf(&page.content, "%.3f %.3f %.3f rg %.2f %.2f %.2f %.2f re f 0 g\n", r, g, b, x, pdfPageHeight-y-height, width, height)
+}
+
+// image ...
+func (page *pdfPage) image(image *pdfImage, x, y, width, height float64) {
+	fmt.Fprintf(&page.content, "q %.2f 0 0 %.2f %.2f %.2f cm /%s Do Q\n", width, height, x, pdfPageHeight-y-height, image.name)
+}
+
+// write ...
+func (document *pdfDocument) write(writer io.Writer) error {
+	if len(document.pages) == 0 {
+		document.addPage()
+	}
+
+	var buffer bytes.Buffer
+	offsets := make([]int, 0)
+
+	object := func(content string) {
+		offsets = append(offsets, buffer.Len())
+		fmt.Fprintf(&buffer, "%d 0 obj\n%s\nendobj\n", len(offsets), content)
+	}
+
+	stream := func(dictionary string, data []byte) {
+		offsets = append(offsets, buffer.Len())
+		fmt.Fprintf(&buffer, "%d 0 obj\n<< %s /Length %d >>\nstream\n", len(offsets), dictionary, len(data))
+		buffer.Write(data)
+		buffer.WriteString("\nendstream\nendobj\n")
+	}
+
+	// 1 catalog, 2 pages, 3 and 4 fonts, then the images and a page and a content for each page
+	firstImage := 5
+	firstPage := firstImage + len(document.images)
+
+	kids := make([]string, 0)
+	for i := range document.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", firstPage+i*2))
+	}
+
+	xObjects := make([]string, 0)
+	for i, image := range document.images {
+		xObjects = append(xObjects, fmt.Sprintf("/%s %d 0 R", image.name, firstImage+i))
+	}
+
+	buffer.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(document.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	for _, image := range document.images {
+		stream(fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /DCTDecode", image.width, image.height), image.data)
+	}
+
+	for i, page := range document.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /%s 3 0 R /%s 4 0 R >> /XObject << %s >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, pdfFontRegular, pdfFontBold, strings.Join(xObjects, " "), firstPage+i*2+1))
+		stream("", page.content.Bytes())
+	}
+
+	xref := buffer.Len()
+	fmt.Fprintf(&buffer, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buffer, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buffer, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	_, err := buffer.WriteTo(writer)
+	return err
+}
+
+// pdfEscape encodes the text as WinAnsi and escapes the string delimiters
+func pdfEscape(value string) string {
+	var builder strings.Builder
+	for _, r := range value {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			builder.WriteByte('\\')
+			builder.WriteByte(byte(r))
+		case r == '€':
+			builder.WriteByte(0x80)
+		case r == '\n' || r == '\r' || r == '\t':
+			builder.WriteByte(' ')
+		case r >= 0x20 && r < 0x7f, r >= 0xa0 && r <= 0xff:
+			builder.WriteByte(byte(r))
+		default:
+			builder.WriteByte('?')
+		}
+	}
+	return builder.String()
+}
+
+// pdfTextWidth approximates the width of a text, helvetica glyphs average half of the font size
+func pdfTextWidth(value string, size float64) float64 {
+	width := 0.0
+	for _, r := range value {
+		switch {
+		case strings.ContainsRune("il.,:;'|!", r):
+			width += 0.28
+		case strings.ContainsRune("0123456789-+ ", r):
+			width += 0.556
+		case r >= 'A' && r <= 'Z', r == 'm', r == 'w':
+			width += 0.7
+		default:
+			width += 0.52
+		}
+	}
+	return width * size
+}
+
+// pdfTruncate cuts the text so it fits on the width
+func pdfTruncate(value string, size float64, width float64) string {
+	if pdfTextWidth(value, size) <= width {
+		return value
+	}
+
+	runes := []rune(value)
+	for len(runes) > 0 && pdfTextWidth(string(runes)+"...", size) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
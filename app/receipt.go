@@ -0,0 +1,160 @@
+package gomoney
+
+import (
+	"math"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultReceiptRadius = 250.0
+	earthRadius          = 6371000.0
+)
+
+// receiptPrefill is the transaction suggested from the photo of a receipt
+type receiptPrefill struct {
+	Date       time.Time
+	Latitude   *float64
+	Longitude  *float64
+	WalletID   string
+	CategoryID string
+	Matches    int
+}
+
+// validLocation checks that the location has both coordinates inside of the limits, or none of them
+func validLocation(latitude *float64, longitude *float64) bool {
+	if latitude == nil && longitude == nil {
+		return true
+	}
+	if latitude == nil || longitude == nil {
+		return false
+	}
+	return *latitude >= -90 && *latitude <= 90 && *longitude >= -180 && *longitude <= 180
+}
+
+// distance returns the distance in meters between two coordinates, with the haversine formula
+func distance(latitude1 float64, longitude1 float64, latitude2 float64, longitude2 float64) float64 {
+	radians := math.Pi / 180
+	deltaLatitude := (latitude2 - latitude1) * radians
+	deltaLongitude := (longitude2 - longitude1) * radians
+
+	a := math.Sin(deltaLatitude/2)*math.Sin(deltaLatitude/2) +
+		math.Cos(latitude1*radians)*math.Cos(latitude2*radians)*math.Sin(deltaLongitude/2)*math.Sin(deltaLongitude/2)
+
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// boundingBox returns the coordinates of a box around the location that contains the radius,
+// so the database can filter the transactions before the distance is calculated
+func boundingBox(latitude float64, longitude float64, radius float64) (float64, float64, float64, float64) {
+	deltaLatitude := radius / earthRadius * 180 / math.Pi
+	minLatitude, maxLatitude := latitude-deltaLatitude, latitude+deltaLatitude
+
+	// near the poles and the antimeridian every longitude is included
+	minLongitude, maxLongitude := -180.0, 180.0
+	if cos := math.Cos(latitude * math.Pi / 180); cos > 0.01 {
+		deltaLongitude := deltaLatitude / cos
+		if longitude-deltaLongitude >= -180 && longitude+deltaLongitude <= 180 {
+			minLongitude, maxLongitude = longitude-deltaLongitude, longitude+deltaLongitude
+		}
+	}
+
+	return minLatitude, maxLatitude, minLongitude, maxLongitude
+}
+
+// suggestFromNearby suggests the wallet and the category used more times by the transactions inside of the radius,
+// the nearest transaction wins the ties
+func suggestFromNearby(prefill *receiptPrefill, radius float64, transactions []*transaction) {
+	type suggestion struct {
+		walletID   string
+		categoryID string
+		count      int
+		nearest    float64
+	}
+
+	suggestions := make(map[string]*suggestion)
+	var best *suggestion
+
+	for _, transaction := range transactions {
+		if transaction.Latitude == nil || transaction.Longitude == nil {
+			continue
+		}
+
+		meters := distance(*prefill.Latitude, *prefill.Longitude, *transaction.Latitude, *transaction.Longitude)
+		if meters > radius {
+			continue
+		}
+
+		key := transaction.WalletID + "/" + transaction.CategoryID
+		item, ok := suggestions[key]
+		if !ok {
+			item = &suggestion{walletID: transaction.WalletID, categoryID: transaction.CategoryID, nearest: meters}
+			suggestions[key] = item
+		}
+		item.count++
+		if meters < item.nearest {
+			item.nearest = meters
+		}
+
+		if best == nil || item.count > best.count || (item.count == best.count && item.nearest < best.nearest) {
+			best = item
+		}
+	}
+
+	if best != nil {
+		prefill.WalletID = best.walletID
+		prefill.CategoryID = best.categoryID
+		prefill.Matches = best.count
+	}
+}
+
+// prefillFromReceipt reads the date and the location from the exif of the photo of a receipt and suggests
+// the wallet and the category from the previous transactions near that location.
+// The photo must be the original upload, because the metadata is removed when the images are stored.
+func (interactor *interactor) prefillFromReceipt(userID string, data []byte) (*receiptPrefill, error) {
+	log.WithFields(map[string]interface{}{"method": "prefillFromReceipt"})
+	log.Infof("prefilling transaction from receipt of user %s", userID)
+
+	maxSize := interactor.config.Image.MaxSize
+	if maxSize <= 0 {
+		maxSize = defaultImageMaxSize
+	}
+	if len(data) > maxSize {
+		return nil, newImageError(http.StatusRequestEntityTooLarge, "the receipt has %d bytes, the maximum is %d bytes", len(data), maxSize)
+	}
+
+	format := sniffImageFormat(data)
+	if format == "" {
+		return nil, newImageError(http.StatusUnsupportedMediaType, "the receipt must be an image")
+	}
+
+	prefill := &receiptPrefill{}
+	exif := readExif(format, data)
+	if exif == nil {
+		return prefill, nil
+	}
+
+	prefill.Date = exif.Date
+	if !exif.HasLocation {
+		return prefill, nil
+	}
+	prefill.Latitude = &exif.Latitude
+	prefill.Longitude = &exif.Longitude
+
+	radius := interactor.config.Receipt.Radius
+	if radius <= 0 {
+		radius = defaultReceiptRadius
+	}
+
+	minLatitude, maxLatitude, minLongitude, maxLongitude := boundingBox(exif.Latitude, exif.Longitude, radius)
+	transactions, err := interactor.storageDB.getTransactionsInBox(userID, minLatitude, maxLatitude, minLongitude, maxLongitude)
+	if err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error getting transactions near the receipt on storage database %s", err)
+		return nil, err
+	}
+
+	suggestFromNearby(prefill, radius, transactions)
+
+	return prefill, nil
+}
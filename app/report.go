@@ -0,0 +1,98 @@
+package gomoney
+
+import (
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	reportPeriodMonth = "month"
+	reportPeriodYear  = "year"
+
+	spreadsheetGroupWallet = "wallet"
+	spreadsheetGroupMonth  = "month"
+)
+
+func isReportPeriod(period string) bool {
+	return period == reportPeriodMonth || period == reportPeriodYear
+}
+
+// match checks if a transaction is on the wallet, category and dates [from, to) of the filter
+// and isn't on a locked wallet
+func (filter *transactionFilter) match(transaction *transaction) bool {
+	if filter == nil {
+		return true
+	}
+	if filter.LockedWallets[transaction.WalletID] {
+		return false
+	}
+	if filter.WalletID != "" && transaction.WalletID != filter.WalletID {
+		return false
+	}
+	if filter.CategoryID != "" && transaction.CategoryID != filter.CategoryID {
+		return false
+	}
+	if !filter.From.IsZero() && transaction.Date.Before(filter.From) {
+		return false
+	}
+	if !filter.To.IsZero() && !transaction.Date.Before(filter.To) {
+		return false
+	}
+	return true
+}
+
+func reportPeriod(date time.Time, period string) string {
+	if period == reportPeriodYear {
+		return date.Format("2006")
+	}
+	return date.Format("2006-01")
+}
+
+// buildReport aggregates the transactions by period and category.
+// A positive price is an expense and a negative price is an income, the total is the income minus the expenses.
+func buildReport(transactions []*transaction, categories []*category, period string) []*reportItem {
+	names := make(map[string]string)
+	for _, category := range categories {
+		names[category.CategoryID] = category.Name
+	}
+
+	items := make(map[string]*reportItem)
+	report := make([]*reportItem, 0)
+
+	for _, transaction := range transactions {
+		key := reportPeriod(transaction.Date, period) + "/" + transaction.CategoryID
+
+		item, ok := items[key]
+		if !ok {
+			item = &reportItem{
+				Period:     reportPeriod(transaction.Date, period),
+				CategoryID: transaction.CategoryID,
+				Category:   names[transaction.CategoryID],
+				Expenses:   decimal.Zero,
+				Income:     decimal.Zero,
+				Total:      decimal.Zero,
+			}
+			items[key] = item
+			report = append(report, item)
+		}
+
+		item.Count++
+		if transaction.Price.IsNegative() {
+			item.Income = item.Income.Add(transaction.Price.Neg())
+		} else {
+			item.Expenses = item.Expenses.Add(transaction.Price)
+		}
+		item.Total = item.Total.Sub(transaction.Price)
+	}
+
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Period != report[j].Period {
+			return report[i].Period < report[j].Period
+		}
+		return report[i].Category < report[j].Category
+	})
+
+	return report
+}
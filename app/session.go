@@ -0,0 +1,218 @@
+package gomoney
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/joaosoft/errors"
+)
+
+const (
+	defaultSessionAccessLifetime  = 15 * 60
+	defaultSessionRefreshLifetime = 30 * 24 * 60 * 60
+	defaultSessionIdleTimeout     = 7 * 24 * 60 * 60
+	defaultSessionPurgeInterval   = 60 * 60
+)
+
+// randomToken returns a random token with 256 bits of entropy
+func randomToken() (string, error) {
+	data := make([]byte, 32)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(data), nil
+}
+
+// hashToken is the sha256 of a token, so the refresh tokens aren't stored on the database
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// sessionLifetimes returns the lifetime of the access and the refresh tokens and the idle timeout, in seconds.
+// A negative idle timeout disables it.
+func (interactor *interactor) sessionLifetimes() (int, int, int) {
+	config := interactor.config.Session
+
+	access := config.AccessLifetime
+	if access <= 0 {
+		access = defaultSessionAccessLifetime
+	}
+	refresh := config.RefreshLifetime
+	if refresh <= 0 {
+		refresh = defaultSessionRefreshLifetime
+	}
+	idle := config.IdleTimeout
+	if idle == 0 {
+		idle = defaultSessionIdleTimeout
+	} else if idle < 0 {
+		idle = 0
+	}
+
+	return access, refresh, idle
+}
+
+// newSessionTokens generates the signing key, the access token and the refresh token of a session
+func (interactor *interactor) newSessionTokens(session *session) error {
+	access, _, _ := interactor.sessionLifetimes()
+
+	original, err := randomToken()
+	if err != nil {
+		return errors.New(errors.LevelError, 1, err)
+	}
+	refreshToken, err := randomToken()
+	if err != nil {
+		return errors.New(errors.LevelError, 1, err)
+	}
+	token, err := generateExpiringToken(authentication, []byte(original), time.Now().Add(time.Duration(access)*time.Second))
+	if err != nil {
+		return errors.New(errors.LevelError, 1, err)
+	}
+
+	session.Original = original
+	session.Token = token
+	session.RefreshToken = refreshToken
+
+	return nil
+}
+
+// useSession returns the session of the token when it isn't expired or idle, sliding the idle timeout
+func (interactor *interactor) useSession(token string) (*session, error) {
+	log.WithFields(map[string]interface{}{"method": "useSession"})
+
+	_, _, idle := interactor.sessionLifetimes()
+	if session, err := interactor.storageDB.useSession(token, idle); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error using session on storage database %s", err)
+		return nil, err
+	} else {
+		return session, nil
+	}
+}
+
+// refreshSession rotates the tokens of the session of the refresh token, returning nil when the refresh token
+// is invalid or expired. A refresh token can only be used once, when a used refresh token is presented again
+// it was stolen from the client, so the session is revoked.
+func (interactor *interactor) refreshSession(refreshToken string, userAgent string, ip string) (*session, error) {
+	log.WithFields(map[string]interface{}{"method": "refreshSession"})
+	log.Info("refreshing session")
+
+	access, refresh, idle := interactor.sessionLifetimes()
+
+	newSession := &session{
+		UserAgent: userAgent,
+		IP:        ip,
+	}
+	if err := interactor.newSessionTokens(newSession); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error generating session tokens %s", err)
+		return nil, err
+	}
+
+	refreshedSession, reused, err := interactor.storageDB.rotateSession(hashToken(refreshToken), newSession, access, refresh, idle)
+	if err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error rotating session on storage database %s", err)
+		return nil, err
+	}
+
+	if reused {
+		log.Warn("a refresh token was used again, the session was revoked")
+		return nil, nil
+	}
+	if refreshedSession == nil {
+		return nil, nil
+	}
+
+	refreshedSession.RefreshToken = newSession.RefreshToken
+	log.Infof("refreshed session %s of user %s", refreshedSession.SessionID, refreshedSession.UserID)
+
+	return refreshedSession, nil
+}
+
+// getActiveSessions returns the sessions of the user that aren't expired or idle
+func (interactor *interactor) getActiveSessions(userID string) ([]*session, error) {
+	log.WithFields(map[string]interface{}{"method": "getActiveSessions"})
+	log.Infof("getting active sessions of user %s", userID)
+
+	_, _, idle := interactor.sessionLifetimes()
+	if sessions, err := interactor.storageDB.getActiveSessions(userID, idle); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error getting active sessions on storage database %s", err)
+		return nil, err
+	} else {
+		return sessions, nil
+	}
+}
+
+// revokeSession deletes a session of the user, returning false when it doesn't exist
+func (interactor *interactor) revokeSession(userID string, sessionID string) (bool, error) {
+	log.WithFields(map[string]interface{}{"method": "revokeSession"})
+	log.Infof("revoking session %s of user %s", sessionID, userID)
+
+	if deleted, err := interactor.storageDB.deleteSessionByID(userID, sessionID); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error revoking session %s on storage database %s", sessionID, err)
+		return false, err
+	} else {
+		return deleted, nil
+	}
+}
+
+// revokeOtherSessions deletes the sessions of the user except the current one
+func (interactor *interactor) revokeOtherSessions(userID string, sessionID string) (int64, error) {
+	log.WithFields(map[string]interface{}{"method": "revokeOtherSessions"})
+	log.Infof("revoking the sessions of user %s except %s", userID, sessionID)
+
+	if count, err := interactor.storageDB.deleteOtherSessions(userID, sessionID); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error revoking sessions of user %s on storage database %s", userID, err)
+		return 0, err
+	} else {
+		return count, nil
+	}
+}
+
+// purgeSessions deletes the sessions with the refresh token expired or idle for longer than the idle timeout
+func (interactor *interactor) purgeSessions() (int64, error) {
+	log.WithFields(map[string]interface{}{"method": "purgeSessions"})
+
+	_, _, idle := interactor.sessionLifetimes()
+	count, err := interactor.storageDB.deleteExpiredSessions(idle)
+	if err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error purging sessions on storage database %s", err)
+		return 0, err
+	}
+
+	if count > 0 {
+		log.Infof("purged %d expired sessions", count)
+	}
+
+	return count, nil
+}
+
+// runSessionPurge purges the expired sessions, password resets, login failures and exports on each interval until it is stopped
+func (interactor *interactor) runSessionPurge(stop chan struct{}) {
+	interval := interactor.config.Session.PurgeInterval
+	if interval <= 0 {
+		interval = defaultSessionPurgeInterval
+	}
+
+	ticker := time.NewTicker(time.Duration(interval) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			interactor.purgeSessions()
+			interactor.purgePasswordResets()
+			interactor.purgeLoginFailures()
+			interactor.purgeExports()
+		case <-stop:
+			return
+		}
+	}
+}
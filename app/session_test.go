@@ -0,0 +1,197 @@
+package gomoney
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// fakeSessionStorage rotates the refresh tokens on memory like the storage database, the sessions are kept
+// by the hash of their current refresh token and the used hashes keep their session
+type fakeSessionStorage struct {
+	*fakeStorageDB
+	refreshTokens map[string]*session
+	usedTokens    map[string]string
+}
+
+func newFakeSessionStorage() *fakeSessionStorage {
+	return &fakeSessionStorage{
+		fakeStorageDB: newFakeStorageDB(),
+		refreshTokens: make(map[string]*session),
+		usedTokens:    make(map[string]string),
+	}
+}
+
+func (storage *fakeSessionStorage) rotateSession(refreshToken string, newSession *session, accessLifetime int, refreshLifetime int, idleTimeout int) (*session, bool, error) {
+	if sessionID, ok := storage.usedTokens[refreshToken]; ok {
+		for hash, session := range storage.refreshTokens {
+			if session.SessionID == sessionID {
+				delete(storage.refreshTokens, hash)
+			}
+		}
+		return nil, true, nil
+	}
+
+	current, ok := storage.refreshTokens[refreshToken]
+	if !ok || !current.RefreshExpiresAt.After(time.Now()) {
+		return nil, false, nil
+	}
+
+	delete(storage.refreshTokens, refreshToken)
+	storage.usedTokens[refreshToken] = current.SessionID
+
+	rotated := &session{
+		SessionID:        current.SessionID,
+		UserID:           current.UserID,
+		Original:         newSession.Original,
+		Token:            newSession.Token,
+		UserAgent:        newSession.UserAgent,
+		IP:               newSession.IP,
+		RefreshExpiresAt: time.Now().Add(time.Duration(refreshLifetime) * time.Second),
+	}
+	storage.refreshTokens[hashToken(newSession.RefreshToken)] = rotated
+	return rotated, false, nil
+}
+
+func (storage *fakeSessionStorage) getActiveSessions(userID string, idleTimeout int) ([]*session, error) {
+	sessions := make([]*session, 0)
+	for _, session := range storage.refreshTokens {
+		if session.UserID == userID && (idleTimeout == 0 || time.Since(session.LastUsedAt) < time.Duration(idleTimeout)*time.Second) {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions, nil
+}
+
+func (storage *fakeSessionStorage) deleteSessionByID(userID string, sessionID string) (bool, error) {
+	for hash, session := range storage.refreshTokens {
+		if session.UserID == userID && session.SessionID == sessionID {
+			delete(storage.refreshTokens, hash)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (storage *fakeSessionStorage) deleteOtherSessions(userID string, sessionID string) (int64, error) {
+	var count int64
+	for hash, session := range storage.refreshTokens {
+		if session.UserID == userID && session.SessionID != sessionID {
+			delete(storage.refreshTokens, hash)
+			count++
+		}
+	}
+	return count, nil
+}
+
+func TestRefreshSession(t *testing.T) {
+	storage := newFakeSessionStorage()
+	interactor := newInteractor(storage, nil, newMailerLog(), newLimiterMemory(), &MoneyConfig{})
+
+	storage.refreshTokens[hashToken("first")] = &session{SessionID: "session", UserID: "user", RefreshExpiresAt: time.Now().Add(time.Hour)}
+	storage.refreshTokens[hashToken("expired")] = &session{SessionID: "expired", UserID: "user", RefreshExpiresAt: time.Now().Add(-time.Second)}
+	storage.refreshTokens[hashToken("other")] = &session{SessionID: "other", UserID: "user", RefreshExpiresAt: time.Now().Add(time.Hour)}
+
+	second, err := interactor.refreshSession("first", "agent", "10.0.0.1")
+	if err != nil || second == nil {
+		t.Fatalf("expected a refreshed session, got %v with error %v", second, err)
+	}
+	if second.SessionID != "session" || second.RefreshToken == "" || second.RefreshToken == "first" || second.Token == "" {
+		t.Fatalf("expected new tokens on the session, got %+v", second)
+	}
+	if second.UserAgent != "agent" || second.IP != "10.0.0.1" {
+		t.Errorf("expected the device of the refresh, got %s from %s", second.UserAgent, second.IP)
+	}
+
+	third, err := interactor.refreshSession(second.RefreshToken, "agent", "10.0.0.1")
+	if err != nil || third == nil {
+		t.Fatalf("expected the new refresh token to rotate, got %v with error %v", third, err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "reused refresh token", token: "first"},
+		{name: "refresh token of the revoked session", token: third.RefreshToken},
+		{name: "expired refresh token", token: "expired"},
+		{name: "unknown refresh token", token: "unknown"},
+	}
+
+	for _, test := range tests {
+		if session, err := interactor.refreshSession(test.token, "agent", "10.0.0.1"); err != nil || session != nil {
+			t.Errorf("%s: expected no session, got %v with error %v", test.name, session, err)
+		}
+	}
+
+	if _, ok := storage.refreshTokens[hashToken("other")]; !ok {
+		t.Error("expected the other sessions to stay active")
+	}
+}
+
+func TestActiveSessions(t *testing.T) {
+	storage := newFakeSessionStorage()
+	config := &MoneyConfig{}
+	config.Session.IdleTimeout = 60
+	interactor := newInteractor(storage, nil, newMailerLog(), newLimiterMemory(), config)
+
+	storage.refreshTokens["current"] = &session{SessionID: "current", UserID: "user", LastUsedAt: time.Now()}
+	storage.refreshTokens["phone"] = &session{SessionID: "phone", UserID: "user", LastUsedAt: time.Now(), UserAgent: "phone"}
+	storage.refreshTokens["idle"] = &session{SessionID: "idle", UserID: "user", LastUsedAt: time.Now().Add(-time.Hour)}
+	storage.refreshTokens["other"] = &session{SessionID: "other", UserID: "other", LastUsedAt: time.Now()}
+
+	if sessions, err := interactor.getActiveSessions("user"); err != nil || len(sessions) != 2 {
+		t.Fatalf("expected the 2 active sessions of the user, got %d with error %v", len(sessions), err)
+	}
+
+	tests := []struct {
+		name      string
+		userID    string
+		sessionID string
+		expected  bool
+	}{
+		{name: "session of the user", userID: "user", sessionID: "phone", expected: true},
+		{name: "revoked session", userID: "user", sessionID: "phone"},
+		{name: "session of another user", userID: "user", sessionID: "other"},
+	}
+
+	for _, test := range tests {
+		if deleted, err := interactor.revokeSession(test.userID, test.sessionID); err != nil || deleted != test.expected {
+			t.Errorf("%s: expected revoked %t, got %t with error %v", test.name, test.expected, deleted, err)
+		}
+	}
+
+	if count, err := interactor.revokeOtherSessions("user", "current"); err != nil || count != 1 {
+		t.Errorf("expected the idle session revoked, got %d with error %v", count, err)
+	}
+	if _, ok := storage.refreshTokens["current"]; !ok {
+		t.Error("expected the current session to stay active")
+	}
+	if _, ok := storage.refreshTokens["other"]; !ok {
+		t.Error("expected the sessions of the other users to stay active")
+	}
+}
+
+// the route of the other sessions must not be taken by the route of a session
+func TestSessionRoutes(t *testing.T) {
+	_, client := newTestApi(newFakeStorageDB())
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{path: "/api/1/users/user/sessions/others", expected: "/api/1/users/:user_id/sessions/others"},
+		{path: "/api/1/users/user/sessions/session", expected: "/api/1/users/:user_id/sessions/:session_id"},
+		{path: "/api/1/users/user/sessions", expected: "/api/1/users/:user_id/sessions"},
+		{path: "/api/1/users/user/session", expected: "/api/1/users/:user_id/session"},
+	}
+
+	for _, test := range tests {
+		ctx := client.echo.NewContext(httptest.NewRequest(http.MethodDelete, test.path, nil), httptest.NewRecorder())
+		client.echo.Router().Find(http.MethodDelete, test.path, ctx)
+		if ctx.Path() != test.expected {
+			t.Errorf("%s: expected the route %s, got %s", test.path, test.expected, ctx.Path())
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package gomoney
+
+import (
+	"fmt"
+	img "image"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"golang.org/x/image/draw"
+)
+
+const (
+	mimePdf = "application/pdf"
+
+	statementMargin    = 40.0
+	statementBottom    = 790.0
+	statementIconSize  = 48
+	statementBarWidth  = 180.0
+	statementRowHeight = 16.0
+)
+
+// statement is the monthly statement of a wallet.
+// A positive price leaves the wallet and a negative price enters it, so the balance is the negated sum of the prices.
+type statement struct {
+	wallet       *wallet
+	currency     string
+	from         time.Time
+	to           time.Time
+	opening      decimal.Decimal
+	closing      decimal.Decimal
+	expenses     decimal.Decimal
+	income       decimal.Decimal
+	transactions []*transaction
+	categories   map[string]*category
+	breakdown    []*reportItem
+	icons        map[string]img.Image
+}
+
+func newStatement(wallet *wallet, currency string, year int, month time.Month, transactions []*transaction, categories []*category) *statement {
+	if currency == "" {
+		currency = defaultExportCurrency
+	}
+
+	statement := &statement{
+		wallet:       wallet,
+		currency:     currency,
+		from:         time.Date(year, month, 1, 0, 0, 0, 0, time.UTC),
+		to:           time.Date(year, month+1, 1, 0, 0, 0, 0, time.UTC),
+		opening:      decimal.Zero,
+		expenses:     decimal.Zero,
+		income:       decimal.Zero,
+		transactions: make([]*transaction, 0),
+		categories:   make(map[string]*category),
+		icons:        make(map[string]img.Image),
+	}
+
+	for _, category := range categories {
+		statement.categories[category.CategoryID] = category
+	}
+
+	for _, transaction := range transactions {
+		switch {
+		case transaction.Date.Before(statement.from):
+			statement.opening = statement.opening.Sub(transaction.Price)
+		case transaction.Date.Before(statement.to):
+			statement.transactions = append(statement.transactions, transaction)
+			if transaction.Price.IsNegative() {
+				statement.income = statement.income.Add(transaction.Price.Neg())
+			} else {
+				statement.expenses = statement.expenses.Add(transaction.Price)
+			}
+		}
+	}
+
+	sort.SliceStable(statement.transactions, func(i, j int) bool {
+		return statement.transactions[i].Date.Before(statement.transactions[j].Date)
+	})
+
+	statement.closing = statement.opening.Add(statement.income).Sub(statement.expenses)
+	statement.breakdown = buildReport(statement.transactions, categories, reportPeriodMonth)
+	sort.SliceStable(statement.breakdown, func(i, j int) bool {
+		return statement.breakdown[i].Expenses.Add(statement.breakdown[i].Income).
+			GreaterThan(statement.breakdown[j].Expenses.Add(statement.breakdown[j].Income))
+	})
+
+	return statement
+}
+
+// addIcon scales down the image of a category so it doesn't weight on the document
+func (statement *statement) addIcon(categoryID string, image img.Image) {
+	bounds := image.Bounds()
+	if bounds.Dx() <= statementIconSize && bounds.Dy() <= statementIconSize {
+		statement.icons[categoryID] = image
+		return
+	}
+
+	width, height := statementIconSize, statementIconSize
+	if bounds.Dx() > bounds.Dy() {
+		height = bounds.Dy()*statementIconSize/bounds.Dx() + 1
+	} else {
+		width = bounds.Dx()*statementIconSize/bounds.Dy() + 1
+	}
+
+	scaled := img.NewNRGBA(img.Rect(0, 0, width, height))
+	draw.ApproxBiLinear.Scale(scaled, scaled.Bounds(), image, bounds, draw.Src, nil)
+	statement.icons[categoryID] = scaled
+}
+
+func (statement *statement) amount(value decimal.Decimal) string {
+	return fmt.Sprintf("%s %s", value.StringFixed(2), statement.currency)
+}
+
+func (statement *statement) categoryName(categoryID string) string {
+	if category, ok := statement.categories[categoryID]; ok {
+		return category.Name
+	}
+	return categoryID
+}
+
+// write renders the statement as a pdf with the balances, the category breakdown and the transactions
+func (statement *statement) write(writer io.Writer) error {
+	document := newPdfDocument()
+
+	icons := make(map[string]*pdfImage)
+	for categoryID, icon := range statement.icons {
+		pdfImage, err := document.addImage(icon)
+		if err != nil {
+			return err
+		}
+		icons[categoryID] = pdfImage
+	}
+
+	page := document.addPage()
+	right := pdfPageWidth - statementMargin
+
+	// header
+	page.text(statementMargin, 60, 18, pdfFontBold, "Monthly statement")
+	page.text(statementMargin, 80, 12, pdfFontBold, statement.wallet.Name)
+	page.text(statementMargin, 95, 10, pdfFontRegular, statement.from.Format("January 2006"))
+	page.textRight(right, 95, 8, pdfFontRegular, fmt.Sprintf("Generated at %s", time.Now().UTC().Format("2006-01-02 15:04")))
+
+	// balances
+	page.rect(statementMargin, 110, right-statementMargin, 44, 0.94, 0.94, 0.94)
+	columnWidth := (right - statementMargin) / 4
+	for i, item := range []struct {
+		label string
+		value decimal.Decimal
+	}{
+		{"Opening balance", statement.opening},
+		{"Expenses", statement.expenses.Neg()},
+		{"Income", statement.income},
+		{"Closing balance", statement.closing},
+	} {
+		x := statementMargin + 10 + float64(i)*columnWidth
+		page.text(x, 126, 8, pdfFontRegular, item.label)
+		page.text(x, 144, 12, pdfFontBold, statement.amount(item.value))
+	}
+
+	// category breakdown with a bar chart of the expenses and income of each category
+	y := 185.0
+	page.text(statementMargin, y, 12, pdfFontBold, "Category breakdown")
+	y += 10
+	page.line(statementMargin, y, right, y, 0.6)
+	y += 6
+
+	largest := decimal.Zero
+	for _, item := range statement.breakdown {
+		if item.Expenses.GreaterThan(largest) {
+			largest = item.Expenses
+		}
+		if item.Income.GreaterThan(largest) {
+			largest = item.Income
+		}
+	}
+
+	for _, item := range statement.breakdown {
+		if y+statementRowHeight > statementBottom {
+			page = document.addPage()
+			y = 60
+		}
+
+		if icon, ok := icons[item.CategoryID]; ok {
+			page.image(icon, statementMargin, y, 12, 12)
+		}
+		page.text(statementMargin+18, y+10, 9, pdfFontRegular, pdfTruncate(statement.categoryName(item.CategoryID), 9, 130))
+
+		x := statementMargin + 160
+		if largest.IsPositive() {
+			if item.Expenses.IsPositive() {
+				width, _ := item.Expenses.Div(largest).Float64()
+				page.rect(x, y+1, width*statementBarWidth, 5, 0.85, 0.33, 0.31)
+			}
+			if item.Income.IsPositive() {
+				width, _ := item.Income.Div(largest).Float64()
+				page.rect(x, y+6, width*statementBarWidth, 5, 0.30, 0.69, 0.31)
+			}
+		}
+
+		page.textRight(right, y+10, 9, pdfFontRegular, statement.amount(item.Total))
+		y += statementRowHeight
+	}
+
+	if len(statement.breakdown) == 0 {
+		page.text(statementMargin, y+10, 9, pdfFontRegular, "No transactions on this month")
+		y += statementRowHeight
+	}
+
+	// transactions with the running balance
+	y += 25
+	if y+3*statementRowHeight > statementBottom {
+		page = document.addPage()
+		y = 60
+	}
+	page.text(statementMargin, y, 12, pdfFontBold, "Transactions")
+	y += 10
+
+	header := func() {
+		page.line(statementMargin, y, right, y, 0.6)
+		page.text(statementMargin, y+11, 8, pdfFontBold, "Date")
+		page.text(statementMargin+70, y+11, 8, pdfFontBold, "Category")
+		page.text(statementMargin+200, y+11, 8, pdfFontBold, "Description")
+		page.textRight(right-90, y+11, 8, pdfFontBold, "Amount")
+		page.textRight(right, y+11, 8, pdfFontBold, "Balance")
+		y += statementRowHeight
+		page.line(statementMargin, y, right, y, 0.6)
+		y += 2
+	}
+	header()
+
+	balance := statement.opening
+	for _, transaction := range statement.transactions {
+		if y+statementRowHeight > statementBottom {
+			page = document.addPage()
+			y = 60
+			header()
+		}
+
+		amount := transaction.Price.Neg()
+		balance = balance.Add(amount)
+
+		page.text(statementMargin, y+10, 8, pdfFontRegular, transaction.Date.Format("2006-01-02"))
+		if icon, ok := icons[transaction.CategoryID]; ok {
+			page.image(icon, statementMargin+70, y+2, 10, 10)
+		}
+		page.text(statementMargin+84, y+10, 8, pdfFontRegular, pdfTruncate(statement.categoryName(transaction.CategoryID), 8, 110))
+		page.text(statementMargin+200, y+10, 8, pdfFontRegular, pdfTruncate(transaction.Description, 8, 150))
+		page.textRight(right-90, y+10, 8, pdfFontRegular, statement.amount(amount))
+		page.textRight(right, y+10, 8, pdfFontRegular, statement.amount(balance))
+		y += statementRowHeight - 2
+	}
+	page.line(statementMargin, y+2, right, y+2, 0.6)
+
+	// footer
+	for i, page := range document.pages {
+		page.text(statementMargin, 815, 7, pdfFontRegular, fmt.Sprintf("%s - %s", statement.wallet.Name, statement.from.Format("January 2006")))
+		page.textRight(right, 815, 7, pdfFontRegular, fmt.Sprintf("Page %d of %d", i+1, len(document.pages)))
+	}
+
+	return document.write(writer)
+}
@@ -0,0 +1,96 @@
+package gomoney
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/joaosoft/manager"
+)
+
+const (
+	blobDriverDatabase = "database"
+	blobDriverLocal    = "local"
+	blobDriverS3       = "s3"
+	blobDriverDropbox  = "dropbox"
+)
+
+// blobDriver returns the configured blob driver, falling back to the dropbox toggle of older configurations
+func blobDriver(config *MoneyConfig) string {
+	if config.Blob.Driver != "" {
+		return config.Blob.Driver
+	}
+	if config.Dropbox.Enabled {
+		return blobDriverDropbox
+	}
+	return blobDriverDatabase
+}
+
+// newStorageBlob creates the blob storage of a driver
+func newStorageBlob(driver string, config *MoneyConfig, connection manager.IDB) (iStorageBlob, error) {
+	switch driver {
+	case blobDriverDatabase:
+		return newStorageBlobPostgres(connection), nil
+	case blobDriverLocal:
+		return newStorageLocal(config.Blob.Local.Path)
+	case blobDriverS3:
+		return newStorageS3(config.Blob.S3.Endpoint, config.Blob.S3.Region, config.Blob.S3.Bucket,
+			config.Blob.S3.AccessKey, config.Blob.S3.SecretKey, config.Blob.S3.PathStyle)
+	case blobDriverDropbox:
+		return newStorageDropbox(nil)
+	default:
+		return nil, fmt.Errorf("invalid blob driver %s", driver)
+	}
+}
+
+// blobDriverConfigured checks if the configuration has the storage of a driver, the database is always configured
+func blobDriverConfigured(driver string, config *MoneyConfig) bool {
+	switch driver {
+	case blobDriverDatabase:
+		return true
+	case blobDriverLocal:
+		return config.Blob.Local.Path != ""
+	case blobDriverS3:
+		return config.Blob.S3.Bucket != ""
+	case blobDriverDropbox:
+		return config.Dropbox.Enabled
+	default:
+		return false
+	}
+}
+
+// newStorageBlobs creates the blob storage of the configured driver and of every other driver with a configuration,
+// so the images can be read wherever they are
+func newStorageBlobs(config *MoneyConfig, connection manager.IDB) (map[string]iStorageBlob, error) {
+	storages := make(map[string]iStorageBlob)
+
+	for _, driver := range []string{blobDriverDatabase, blobDriverLocal, blobDriverS3, blobDriverDropbox} {
+		if driver != blobDriver(config) && !blobDriverConfigured(driver, config) {
+			continue
+		}
+
+		storage, err := newStorageBlob(driver, config, connection)
+		if err != nil {
+			return nil, fmt.Errorf("error creating the %s blob storage: %s", driver, err)
+		}
+		storages[driver] = storage
+	}
+
+	if _, ok := storages[blobDriver(config)]; !ok {
+		return nil, fmt.Errorf("invalid blob driver %s", blobDriver(config))
+	}
+
+	return storages, nil
+}
+
+// blobDrivers returns the drivers of the storages, starting with the configured driver
+func blobDrivers(config *MoneyConfig, storages map[string]iStorageBlob) []string {
+	drivers := make([]string, 0, len(storages))
+	for driver := range storages {
+		if driver != blobDriver(config) {
+			drivers = append(drivers, driver)
+		}
+	}
+	sort.Strings(drivers)
+
+	return append([]string{blobDriver(config)}, drivers...)
+}
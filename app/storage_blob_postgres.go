@@ -0,0 +1,86 @@
+package gomoney
+
+import (
+	"database/sql"
+	"io"
+	"io/ioutil"
+
+	"github.com/joaosoft/errors"
+	"github.com/joaosoft/manager"
+)
+
+// storageBlobPostgres stores the blobs on the database
+type storageBlobPostgres struct {
+	conn manager.IDB
+}
+
+// newStorageBlobPostgres ...
+func newStorageBlobPostgres(connection manager.IDB) *storageBlobPostgres {
+	return &storageBlobPostgres{
+		conn: connection,
+	}
+}
+
+func (storage *storageBlobPostgres) upload(path string, data []byte) error {
+	if _, err := storage.conn.Get().Exec(`
+		INSERT INTO money.blobs(path, data)
+		VALUES($1, $2)
+		ON CONFLICT (path) DO UPDATE SET data = EXCLUDED.data
+	`, path, data); err != nil {
+		return errors.New(errors.LevelError, 1, err)
+	}
+
+	return nil
+}
+
+func (storage *storageBlobPostgres) download(path string) ([]byte, error) {
+	row := storage.conn.Get().QueryRow(`
+		SELECT data
+		FROM money.blobs
+		WHERE path = $1
+	`, path)
+
+	var data []byte
+	if err := row.Scan(&data); err != nil {
+		if err != sql.ErrNoRows {
+			return nil, errors.New(errors.LevelError, 1, err)
+		}
+		return nil, nil
+	}
+
+	return data, nil
+}
+
+// uploadFrom reads the blob to memory, the database stores it on a single column
+func (storage *storageBlobPostgres) uploadFrom(path string, reader io.ReadSeeker, size int64) error {
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return errors.New(errors.LevelError, 1, err)
+	}
+	return storage.upload(path, data)
+}
+
+func (storage *storageBlobPostgres) downloadTo(path string, writer io.Writer) (bool, error) {
+	data, err := storage.download(path)
+	if err != nil || data == nil {
+		return false, err
+	}
+
+	if _, err := writer.Write(data); err != nil {
+		return true, errors.New(errors.LevelError, 1, err)
+	}
+	return true, nil
+}
+
+// delete removes the blob or every blob inside of the path, like a folder
+func (storage *storageBlobPostgres) delete(path string) error {
+	if _, err := storage.conn.Get().Exec(`
+		DELETE
+		FROM money.blobs
+		WHERE path = $1 OR path LIKE $1 || '/%'
+	`, path); err != nil {
+		return errors.New(errors.LevelError, 1, err)
+	}
+
+	return nil
+}
@@ -2,6 +2,8 @@ package gomoney
 
 import (
 	"database/sql"
+	"fmt"
+
 	"github.com/joaosoft/errors"
 	"github.com/joaosoft/manager"
 	"github.com/lib/pq"
@@ -27,6 +29,11 @@ func (storage *storagePostgres) getUsers() ([]*user, error) {
 		    name,
 			email,
 			password,
+			token,
+			role,
+			status,
+			totp_secret,
+			totp_enabled,
 			description,
 			updated_at,
 			created_at
@@ -46,6 +53,10 @@ func (storage *storagePostgres) getUsers() ([]*user, error) {
 			&user.Email,
 			&user.Password,
 			&user.Token,
+			&user.Role,
+			&user.Status,
+			&user.TOTPSecret,
+			&user.TOTPEnabled,
 			&user.Description,
 			&user.UpdatedAt,
 			&user.CreatedAt); err != nil {
@@ -69,6 +80,10 @@ func (storage *storagePostgres) getUser(userID string) (*user, error) {
 			email,
 			password,
 			token,
+			role,
+			status,
+			totp_secret,
+			totp_enabled,
 			description,
 			updated_at,
 			created_at
@@ -82,6 +97,10 @@ func (storage *storagePostgres) getUser(userID string) (*user, error) {
 		&user.Email,
 		&user.Password,
 		&user.Token,
+		&user.Role,
+		&user.Status,
+		&user.TOTPSecret,
+		&user.TOTPEnabled,
 		&user.Description,
 		&user.UpdatedAt,
 		&user.CreatedAt); err != nil {
@@ -103,6 +122,10 @@ func (storage *storagePostgres) getUserByEmail(email string) (*user, error) {
 		    name,
 			password,
 			token,
+			role,
+			status,
+			totp_secret,
+			totp_enabled,
 			description,
 			updated_at,
 			created_at
@@ -116,6 +139,10 @@ func (storage *storagePostgres) getUserByEmail(email string) (*user, error) {
 		&user.Name,
 		&user.Password,
 		&user.Token,
+		&user.Role,
+		&user.Status,
+		&user.TOTPSecret,
+		&user.TOTPEnabled,
 		&user.Description,
 		&user.UpdatedAt,
 		&user.CreatedAt); err != nil {
@@ -132,9 +159,9 @@ func (storage *storagePostgres) getUserByEmail(email string) (*user, error) {
 // createUser ...
 func (storage *storagePostgres) createUser(newUser *user) (*user, error) {
 	if result, err := storage.conn.Get().Exec(`
-		INSERT INTO money.users(user_id, name, email, password, token, description)
-		VALUES($1, $2, $3, $4, $5, $6)
-	`, newUser.UserID, newUser.Name, newUser.Email, newUser.Password, newUser.Token, newUser.Description); err != nil {
+		INSERT INTO money.users(user_id, name, email, password, token, status, description)
+		VALUES($1, $2, $3, $4, $5, $6, $7)
+	`, newUser.UserID, newUser.Name, newUser.Email, newUser.Password, newUser.Token, newUser.Status, newUser.Description); err != nil {
 		return nil, errors.New(errors.LevelError, 1, err)
 	} else if rows, _ := result.RowsAffected(); rows > 0 {
 		return storage.getUser(newUser.UserID)
@@ -175,6 +202,391 @@ func (storage *storagePostgres) deleteUser(userID string) error {
 	return nil
 }
 
+// updateUserPassword stores the password hash, removing the legacy token
+func (storage *storagePostgres) updateUserPassword(userID string, password string) error {
+	if _, err := storage.conn.Get().Exec(`
+		UPDATE money.users SET
+			password = $1,
+			token = ''
+		WHERE user_id = $2
+	`, password, userID); err != nil {
+		return errors.New(errors.LevelError, 1, err)
+	}
+
+	return nil
+}
+
+// updateUserRole ...
+func (storage *storagePostgres) updateUserRole(userID string, role string) (*user, error) {
+	if result, err := storage.conn.Get().Exec(`
+		UPDATE money.users SET
+			role = $1
+		WHERE user_id = $2
+	`, role, userID); err != nil {
+		return nil, errors.New(errors.LevelError, 1, err)
+	} else if rows, _ := result.RowsAffected(); rows > 0 {
+		return storage.getUser(userID)
+	}
+
+	return nil, nil
+}
+
+// createPasswordReset stores the hash of a reset token of the user, that expires after the lifetime in seconds
+func (storage *storagePostgres) createPasswordReset(token string, userID string, lifetime int) error {
+	if _, err := storage.conn.Get().Exec(`
+		INSERT INTO money.password_resets(token, user_id, expires_at)
+		VALUES($1, $2, NOW() + $3 * INTERVAL '1 second')
+	`, token, userID, lifetime); err != nil {
+		return errors.New(errors.LevelError, 1, err)
+	}
+
+	return nil
+}
+
+// resetPassword uses the reset token with the hash to set the password of its user, on a single transaction
+// that also invalidates the other reset tokens and deletes the sessions of the user.
+// The mail with the token proves the email, so an unverified user is activated.
+// It returns the user id, or empty when the token is invalid, expired or already used.
+func (storage *storagePostgres) resetPassword(token string, password string) (string, error) {
+	tx, err := storage.conn.Get().Begin()
+	if err != nil {
+		return "", errors.New(errors.LevelError, 1, err)
+	}
+
+	var userID string
+	if err := tx.QueryRow(`
+		UPDATE money.password_resets SET
+			used_at = NOW()
+		WHERE token = $1 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING user_id
+	`, token).Scan(&userID); err != nil {
+		tx.Rollback()
+
+		if err != sql.ErrNoRows {
+			return "", errors.New(errors.LevelError, 1, err)
+		}
+		return "", nil
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE money.users SET
+			password = $1,
+			token = '',
+			status = CASE WHEN status = $3 THEN $4 ELSE status END
+		WHERE user_id = $2
+	`, password, userID, userStatusUnverified, userStatusActive); err != nil {
+		tx.Rollback()
+		return "", errors.New(errors.LevelError, 1, err)
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE money.password_resets SET
+			used_at = NOW()
+		WHERE user_id = $1 AND used_at IS NULL
+	`, userID); err != nil {
+		tx.Rollback()
+		return "", errors.New(errors.LevelError, 1, err)
+	}
+
+	if _, err := tx.Exec(`
+	    DELETE
+		FROM money.sessions
+		WHERE user_id = $1
+	`, userID); err != nil {
+		tx.Rollback()
+		return "", errors.New(errors.LevelError, 1, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", errors.New(errors.LevelError, 1, err)
+	}
+
+	return userID, nil
+}
+
+// deleteExpiredPasswordResets deletes the reset tokens used or expired
+func (storage *storagePostgres) deleteExpiredPasswordResets() (int64, error) {
+	result, err := storage.conn.Get().Exec(`
+		DELETE
+		FROM money.password_resets
+		WHERE used_at IS NOT NULL OR expires_at <= NOW()
+	`)
+	if err != nil {
+		return 0, errors.New(errors.LevelError, 1, err)
+	}
+
+	count, _ := result.RowsAffected()
+	return count, nil
+}
+
+// updateUserStatus ...
+func (storage *storagePostgres) updateUserStatus(userID string, status int) (*user, error) {
+	if result, err := storage.conn.Get().Exec(`
+		UPDATE money.users SET
+			status = $1
+		WHERE user_id = $2
+	`, status, userID); err != nil {
+		return nil, errors.New(errors.LevelError, 1, err)
+	} else if rows, _ := result.RowsAffected(); rows > 0 {
+		return storage.getUser(userID)
+	}
+
+	return nil, nil
+}
+
+// updateUserTOTP sets the secret of the two factor authentication and if it is enabled
+func (storage *storagePostgres) updateUserTOTP(userID string, secret string, enabled bool) error {
+	if _, err := storage.conn.Get().Exec(`
+		UPDATE money.users SET
+			totp_secret = $1,
+			totp_enabled = $2,
+			totp_counter = 0
+		WHERE user_id = $3
+	`, secret, enabled, userID); err != nil {
+		return errors.New(errors.LevelError, 1, err)
+	}
+
+	return nil
+}
+
+// useTOTPCounter stores the counter of a code that was used, returning false when it or a later code was already used
+func (storage *storagePostgres) useTOTPCounter(userID string, counter int64) (bool, error) {
+	result, err := storage.conn.Get().Exec(`
+		UPDATE money.users SET
+			totp_counter = $1
+		WHERE user_id = $2 AND totp_counter < $1
+	`, counter, userID)
+	if err != nil {
+		return false, errors.New(errors.LevelError, 1, err)
+	}
+
+	rows, _ := result.RowsAffected()
+	return rows > 0, nil
+}
+
+// replaceRecoveryCodes replaces the recovery codes of the user by the hashes
+func (storage *storagePostgres) replaceRecoveryCodes(userID string, codes []string) error {
+	tx, err := storage.conn.Get().Begin()
+	if err != nil {
+		return errors.New(errors.LevelError, 1, err)
+	}
+
+	if _, err := tx.Exec(`
+	    DELETE
+		FROM money.recovery_codes
+		WHERE user_id = $1
+	`, userID); err != nil {
+		tx.Rollback()
+		return errors.New(errors.LevelError, 1, err)
+	}
+
+	for _, code := range codes {
+		if _, err := tx.Exec(`
+			INSERT INTO money.recovery_codes(user_id, code)
+			VALUES($1, $2)
+		`, userID, code); err != nil {
+			tx.Rollback()
+			return errors.New(errors.LevelError, 1, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.New(errors.LevelError, 1, err)
+	}
+
+	return nil
+}
+
+// useRecoveryCode marks the recovery code with the hash as used, returning false when it doesn't exist or was used
+func (storage *storagePostgres) useRecoveryCode(userID string, code string) (bool, error) {
+	result, err := storage.conn.Get().Exec(`
+		UPDATE money.recovery_codes SET
+			used_at = NOW()
+		WHERE user_id = $1 AND code = $2 AND used_at IS NULL
+	`, userID, code)
+	if err != nil {
+		return false, errors.New(errors.LevelError, 1, err)
+	}
+
+	rows, _ := result.RowsAffected()
+	return rows > 0, nil
+}
+
+// scanAccessToken ...
+func scanAccessToken(row interface {
+	Scan(dest ...interface{}) error
+}) (*accessToken, error) {
+	accessToken := &accessToken{}
+	var expiresAt, lastUsedAt pq.NullTime
+
+	if err := row.Scan(
+		&accessToken.AccessTokenID,
+		&accessToken.UserID,
+		&accessToken.Name,
+		&accessToken.Prefix,
+		pq.Array(&accessToken.Scopes),
+		&expiresAt,
+		&lastUsedAt,
+		&accessToken.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	if expiresAt.Valid {
+		accessToken.ExpiresAt = &expiresAt.Time
+	}
+	if lastUsedAt.Valid {
+		accessToken.LastUsedAt = &lastUsedAt.Time
+	}
+
+	return accessToken, nil
+}
+
+// createAccessToken stores the hash of the access token, that expires after the seconds or never when they are zero
+func (storage *storagePostgres) createAccessToken(newAccessToken *accessToken, tokenHash string, expiresIn int) (*accessToken, error) {
+	row := storage.conn.Get().QueryRow(`
+		INSERT INTO money.access_tokens(access_token_id, user_id, name, token, prefix, scopes, expires_at)
+		VALUES($1, $2, $3, $4, $5, $6, CASE WHEN $7::INTEGER > 0 THEN NOW() + $7::INTEGER * INTERVAL '1 second' END)
+		RETURNING
+			access_token_id,
+			user_id,
+			name,
+			prefix,
+			scopes,
+			expires_at,
+			last_used_at,
+			created_at
+	`, newAccessToken.AccessTokenID, newAccessToken.UserID, newAccessToken.Name, tokenHash,
+		newAccessToken.Prefix, pq.Array(newAccessToken.Scopes), expiresIn)
+
+	accessToken, err := scanAccessToken(row)
+	if err != nil {
+		return nil, errors.New(errors.LevelError, 1, err)
+	}
+
+	return accessToken, nil
+}
+
+// getAccessTokens returns the access tokens of the user, the last created first
+func (storage *storagePostgres) getAccessTokens(userID string) ([]*accessToken, error) {
+	rows, err := storage.conn.Get().Query(`
+	    SELECT
+			access_token_id,
+			user_id,
+			name,
+			prefix,
+			scopes,
+			expires_at,
+			last_used_at,
+			created_at
+		FROM money.access_tokens
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, errors.New(errors.LevelError, 1, err)
+	}
+	defer rows.Close()
+
+	accessTokens := make([]*accessToken, 0)
+	for rows.Next() {
+		accessToken, err := scanAccessToken(rows)
+		if err != nil {
+			return nil, errors.New(errors.LevelError, 1, err)
+		}
+		accessTokens = append(accessTokens, accessToken)
+	}
+
+	return accessTokens, nil
+}
+
+// deleteAccessToken deletes an access token of the user, returning false when it doesn't exist
+func (storage *storagePostgres) deleteAccessToken(userID string, accessTokenID string) (bool, error) {
+	result, err := storage.conn.Get().Exec(`
+	    DELETE
+		FROM money.access_tokens
+		WHERE user_id = $1 AND access_token_id = $2
+	`, userID, accessTokenID)
+	if err != nil {
+		return false, errors.New(errors.LevelError, 1, err)
+	}
+
+	rows, _ := result.RowsAffected()
+	return rows > 0, nil
+}
+
+// useAccessToken updates when the access token with the hash was last used, returning nil when it doesn't exist or is expired
+func (storage *storagePostgres) useAccessToken(tokenHash string) (*accessToken, error) {
+	row := storage.conn.Get().QueryRow(`
+		UPDATE money.access_tokens SET
+			last_used_at = NOW()
+		WHERE token = $1
+			AND (expires_at IS NULL OR expires_at > NOW())
+		RETURNING
+			access_token_id,
+			user_id,
+			name,
+			prefix,
+			scopes,
+			expires_at,
+			last_used_at,
+			created_at
+	`, tokenHash)
+
+	accessToken, err := scanAccessToken(row)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			return nil, errors.New(errors.LevelError, 1, err)
+		}
+		return nil, nil
+	}
+
+	return accessToken, nil
+}
+
+// getUserLockout returns the seconds until the logins of the user with the email are unlocked, or zero when they aren't locked
+func (storage *storagePostgres) getUserLockout(email string) (int, error) {
+	row := storage.conn.Get().QueryRow(`
+		SELECT CEIL(EXTRACT(EPOCH FROM locked_until - NOW()))::INTEGER
+		FROM money.users
+		WHERE email = $1 AND locked_until > NOW()
+	`, email)
+
+	var seconds int
+	if err := row.Scan(&seconds); err != nil {
+		if err != sql.ErrNoRows {
+			return 0, errors.New(errors.LevelError, 1, err)
+		}
+		return 0, nil
+	}
+
+	return seconds, nil
+}
+
+// lockUserLogin locks the logins of the user with the email for the seconds
+func (storage *storagePostgres) lockUserLogin(email string, duration int) error {
+	if _, err := storage.conn.Get().Exec(`
+		UPDATE money.users SET
+			locked_until = NOW() + $2::INTEGER * INTERVAL '1 second'
+		WHERE email = $1
+	`, email, duration); err != nil {
+		return errors.New(errors.LevelError, 1, err)
+	}
+
+	return nil
+}
+
+// createLoginAttempt ...
+func (storage *storagePostgres) createLoginAttempt(attempt *loginAttempt) error {
+	if _, err := storage.conn.Get().Exec(`
+		INSERT INTO money.login_attempts(login_attempt_id, email, user_id, ip, user_agent, success, reason)
+		VALUES($1, $2, NULLIF($3, ''), $4, $5, $6, $7)
+	`, attempt.LoginAttemptID, attempt.Email, attempt.UserID, attempt.IP, attempt.UserAgent, attempt.Success, attempt.Reason); err != nil {
+		return errors.New(errors.LevelError, 1, err)
+	}
+
+	return nil
+}
+
 // getSessions ...
 func (storage *storagePostgres) getSessions(userID string) ([]*session, error) {
 	rows, err := storage.conn.Get().Query(`
@@ -183,6 +595,11 @@ func (storage *storagePostgres) getSessions(userID string) ([]*session, error) {
 		    original,
 			token,
 			description,
+			user_agent,
+			ip,
+			expires_at,
+			refresh_expires_at,
+			last_used_at,
 			updated_at,
 			created_at
 		FROM money.sessions
@@ -201,6 +618,11 @@ func (storage *storagePostgres) getSessions(userID string) ([]*session, error) {
 			&session.Original,
 			&session.Token,
 			&session.Description,
+			&session.UserAgent,
+			&session.IP,
+			&session.ExpiresAt,
+			&session.RefreshExpiresAt,
+			&session.LastUsedAt,
 			&session.UpdatedAt,
 			&session.CreatedAt); err != nil {
 
@@ -222,6 +644,11 @@ func (storage *storagePostgres) getSession(userID string, token string) (*sessio
 		    session_id,
 			original,
 			description,
+			user_agent,
+			ip,
+			expires_at,
+			refresh_expires_at,
+			last_used_at,
 			updated_at,
 			created_at
 		FROM money.sessions
@@ -233,6 +660,11 @@ func (storage *storagePostgres) getSession(userID string, token string) (*sessio
 		&session.SessionID,
 		&session.Original,
 		&session.Description,
+		&session.UserAgent,
+		&session.IP,
+		&session.ExpiresAt,
+		&session.RefreshExpiresAt,
+		&session.LastUsedAt,
 		&session.UpdatedAt,
 		&session.CreatedAt); err != nil {
 
@@ -245,12 +677,13 @@ func (storage *storagePostgres) getSession(userID string, token string) (*sessio
 	return session, nil
 }
 
-// createSession ...
-func (storage *storagePostgres) createSession(newSession *session) (*session, error) {
+// createSession creates the session with the hash of the refresh token and the lifetimes in seconds
+func (storage *storagePostgres) createSession(newSession *session, refreshToken string, accessLifetime int, refreshLifetime int) (*session, error) {
 	if result, err := storage.conn.Get().Exec(`
-		INSERT INTO money.sessions(session_id, user_id, original, token, description)
-		VALUES($1, $2, $3, $4, $5)
-	`, newSession.SessionID, newSession.UserID, newSession.Original, newSession.Token, newSession.Description); err != nil {
+		INSERT INTO money.sessions(session_id, user_id, original, token, description, user_agent, ip, refresh_token, expires_at, refresh_expires_at, last_used_at)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, NOW() + $9 * INTERVAL '1 second', NOW() + $10 * INTERVAL '1 second', NOW())
+	`, newSession.SessionID, newSession.UserID, newSession.Original, newSession.Token, newSession.Description,
+		newSession.UserAgent, newSession.IP, refreshToken, accessLifetime, refreshLifetime); err != nil {
 		return nil, errors.New(errors.LevelError, 1, err)
 	} else if rows, _ := result.RowsAffected(); rows > 0 {
 		return storage.getSession(newSession.UserID, newSession.Token)
@@ -259,66 +692,318 @@ func (storage *storagePostgres) createSession(newSession *session) (*session, er
 	return nil, nil
 }
 
-// deleteSession ...
-func (storage *storagePostgres) deleteSession(userID string, token string) error {
-	if _, err := storage.conn.Get().Exec(`
-	    DELETE 
-		FROM money.sessions
-		WHERE user_id = $1 AND token = $2
-	`, userID, token); err != nil {
-		return errors.New(errors.LevelError, 1, err)
-	}
+// useSession returns the session of the token when the access token isn't expired and the session isn't idle
+// for longer than the idle timeout in seconds, updating when it was last used
+func (storage *storagePostgres) useSession(token string, idleTimeout int) (*session, error) {
+	row := storage.conn.Get().QueryRow(`
+		UPDATE money.sessions SET
+			last_used_at = NOW()
+		WHERE token = $1
+			AND expires_at > NOW()
+			AND ($2::INTEGER = 0 OR last_used_at > NOW() - $2::INTEGER * INTERVAL '1 second')
+		RETURNING
+			session_id,
+			user_id,
+			original,
+			description,
+			user_agent,
+			ip,
+			expires_at,
+			refresh_expires_at,
+			last_used_at,
+			updated_at,
+			created_at
+	`, token, idleTimeout)
 
-	return nil
-}
+	session := &session{Token: token}
+	if err := row.Scan(
+		&session.SessionID,
+		&session.UserID,
+		&session.Original,
+		&session.Description,
+		&session.UserAgent,
+		&session.IP,
+		&session.ExpiresAt,
+		&session.RefreshExpiresAt,
+		&session.LastUsedAt,
+		&session.UpdatedAt,
+		&session.CreatedAt); err != nil {
 
-// deleteSessions ...
-func (storage *storagePostgres) deleteSessions(userID string) error {
-	if _, err := storage.conn.Get().Exec(`
-	    DELETE 
-		FROM money.sessions
-		WHERE user_id = $1
-	`, userID); err != nil {
-		return errors.New(errors.LevelError, 1, err)
+		if err != sql.ErrNoRows {
+			return nil, errors.New(errors.LevelError, 1, err)
+		}
+		return nil, nil
 	}
 
-	return nil
+	return session, nil
 }
 
-// getWallets ...
-func (storage *storagePostgres) getWallets(userID string) ([]*wallet, error) {
-	rows, err := storage.conn.Get().Query(`
-	     SELECT
-			wallet_id,
-			name,
-			description,
-			password,
-			updated_at,
-			created_at
-		FROM money.wallets
-		WHERE user_id = $1
-	`, userID)
-
-	defer rows.Close()
+// rotateSession replaces the tokens of the session with the hash of the refresh token by the tokens of the new session.
+// The replaced refresh token is kept, so when it is used again the session is deleted and it returns true.
+func (storage *storagePostgres) rotateSession(refreshToken string, newSession *session, accessLifetime int, refreshLifetime int, idleTimeout int) (*session, bool, error) {
+	tx, err := storage.conn.Get().Begin()
 	if err != nil {
-		return nil, errors.New(errors.LevelError, 1, err)
+		return nil, false, errors.New(errors.LevelError, 1, err)
 	}
 
-	wallets := make([]*wallet, 0)
-	for rows.Next() {
-		wallet := &wallet{
-			UserID: userID,
+	var sessionID string
+	err = tx.QueryRow(`
+		SELECT session_id
+		FROM money.session_refresh_tokens
+		WHERE refresh_token = $1
+	`, refreshToken).Scan(&sessionID)
+
+	switch {
+	case err == nil:
+		if _, err := tx.Exec(`
+			DELETE
+			FROM money.sessions
+			WHERE session_id = $1
+		`, sessionID); err != nil {
+			tx.Rollback()
+			return nil, false, errors.New(errors.LevelError, 1, err)
 		}
-		if err := rows.Scan(
-			&wallet.WalletID,
-			&wallet.Name,
-			&wallet.Description,
-			&wallet.Password,
-			&wallet.UpdatedAt,
-			&wallet.CreatedAt); err != nil {
 
-			if err != sql.ErrNoRows {
-				return nil, errors.New(errors.LevelError, 1, err)
+		if err := tx.Commit(); err != nil {
+			return nil, false, errors.New(errors.LevelError, 1, err)
+		}
+		return nil, true, nil
+
+	case err != sql.ErrNoRows:
+		tx.Rollback()
+		return nil, false, errors.New(errors.LevelError, 1, err)
+	}
+
+	var userID string
+	if err := tx.QueryRow(`
+		UPDATE money.sessions SET
+			original = $1,
+			token = $2,
+			refresh_token = $3,
+			expires_at = NOW() + $4 * INTERVAL '1 second',
+			refresh_expires_at = NOW() + $5 * INTERVAL '1 second',
+			last_used_at = NOW(),
+			user_agent = $6,
+			ip = $7
+		WHERE refresh_token = $8
+			AND refresh_expires_at > NOW()
+			AND ($9::INTEGER = 0 OR last_used_at > NOW() - $9::INTEGER * INTERVAL '1 second')
+		RETURNING session_id, user_id
+	`, newSession.Original, newSession.Token, hashToken(newSession.RefreshToken), accessLifetime, refreshLifetime,
+		newSession.UserAgent, newSession.IP, refreshToken, idleTimeout).Scan(&sessionID, &userID); err != nil {
+		tx.Rollback()
+
+		if err != sql.ErrNoRows {
+			return nil, false, errors.New(errors.LevelError, 1, err)
+		}
+		return nil, false, nil
+	}
+
+	if _, err := tx.Exec(`
+		INSERT INTO money.session_refresh_tokens(refresh_token, session_id)
+		VALUES($1, $2)
+	`, refreshToken, sessionID); err != nil {
+		tx.Rollback()
+		return nil, false, errors.New(errors.LevelError, 1, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, false, errors.New(errors.LevelError, 1, err)
+	}
+
+	session, err := storage.getSession(userID, newSession.Token)
+	return session, false, err
+}
+
+// deleteExpiredSessions deletes the sessions with the refresh token expired or idle for longer than the idle timeout in seconds
+func (storage *storagePostgres) deleteExpiredSessions(idleTimeout int) (int64, error) {
+	result, err := storage.conn.Get().Exec(`
+		DELETE
+		FROM money.sessions
+		WHERE refresh_expires_at <= NOW()
+			OR ($1::INTEGER > 0 AND last_used_at <= NOW() - $1::INTEGER * INTERVAL '1 second')
+	`, idleTimeout)
+	if err != nil {
+		return 0, errors.New(errors.LevelError, 1, err)
+	}
+
+	count, _ := result.RowsAffected()
+	return count, nil
+}
+
+// getActiveSessions returns the sessions of the user that can still be used or refreshed, the last used first
+func (storage *storagePostgres) getActiveSessions(userID string, idleTimeout int) ([]*session, error) {
+	rows, err := storage.conn.Get().Query(`
+	    SELECT
+			session_id,
+			description,
+			user_agent,
+			ip,
+			expires_at,
+			refresh_expires_at,
+			last_used_at,
+			updated_at,
+			created_at
+		FROM money.sessions
+		WHERE user_id = $1
+			AND refresh_expires_at > NOW()
+			AND ($2::INTEGER = 0 OR last_used_at > NOW() - $2::INTEGER * INTERVAL '1 second')
+		ORDER BY last_used_at DESC
+	`, userID, idleTimeout)
+	if err != nil {
+		return nil, errors.New(errors.LevelError, 1, err)
+	}
+	defer rows.Close()
+
+	sessions := make([]*session, 0)
+	for rows.Next() {
+		session := &session{UserID: userID}
+		if err := rows.Scan(
+			&session.SessionID,
+			&session.Description,
+			&session.UserAgent,
+			&session.IP,
+			&session.ExpiresAt,
+			&session.RefreshExpiresAt,
+			&session.LastUsedAt,
+			&session.UpdatedAt,
+			&session.CreatedAt); err != nil {
+			return nil, errors.New(errors.LevelError, 1, err)
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, nil
+}
+
+// deleteSessionByID deletes a session of the user, returning false when it doesn't exist
+func (storage *storagePostgres) deleteSessionByID(userID string, sessionID string) (bool, error) {
+	result, err := storage.conn.Get().Exec(`
+	    DELETE
+		FROM money.sessions
+		WHERE user_id = $1 AND session_id = $2
+	`, userID, sessionID)
+	if err != nil {
+		return false, errors.New(errors.LevelError, 1, err)
+	}
+
+	rows, _ := result.RowsAffected()
+	return rows > 0, nil
+}
+
+// deleteOtherSessions deletes the sessions of the user except one
+func (storage *storagePostgres) deleteOtherSessions(userID string, sessionID string) (int64, error) {
+	result, err := storage.conn.Get().Exec(`
+	    DELETE
+		FROM money.sessions
+		WHERE user_id = $1 AND session_id <> $2
+	`, userID, sessionID)
+	if err != nil {
+		return 0, errors.New(errors.LevelError, 1, err)
+	}
+
+	rows, _ := result.RowsAffected()
+	return rows, nil
+}
+
+// deleteSession ...
+func (storage *storagePostgres) deleteSession(userID string, token string) error {
+	if _, err := storage.conn.Get().Exec(`
+	    DELETE 
+		FROM money.sessions
+		WHERE user_id = $1 AND token = $2
+	`, userID, token); err != nil {
+		return errors.New(errors.LevelError, 1, err)
+	}
+
+	return nil
+}
+
+// deleteSessions ...
+func (storage *storagePostgres) deleteSessions(userID string) error {
+	if _, err := storage.conn.Get().Exec(`
+	    DELETE 
+		FROM money.sessions
+		WHERE user_id = $1
+	`, userID); err != nil {
+		return errors.New(errors.LevelError, 1, err)
+	}
+
+	return nil
+}
+
+// walletAccess returns the condition of the wallets of the alias that the user of the parameter can access,
+// as the owner, as a member or as a member of the household of the wallet
+func walletAccess(alias string, param string) string {
+	return fmt.Sprintf(`(%[1]s.user_id = %[2]s OR EXISTS (
+			SELECT 1
+			FROM money.wallet_members
+			WHERE wallet_members.wallet_id = %[1]s.wallet_id AND wallet_members.user_id = %[2]s) OR EXISTS (
+			SELECT 1
+			FROM money.household_members
+			WHERE household_members.household_id = %[1]s.household_id AND household_members.user_id = %[2]s))`, alias, param)
+}
+
+// walletRole returns the role on the wallets of the alias of the user of the parameter,
+// the viewers of the household of the wallet are its viewers and the other members of the household its editors
+func walletRole(alias string, param string) string {
+	return fmt.Sprintf(`CASE WHEN %[1]s.user_id = %[2]s THEN '%[3]s' ELSE COALESCE((
+			SELECT role
+			FROM money.wallet_members
+			WHERE wallet_members.wallet_id = %[1]s.wallet_id AND wallet_members.user_id = %[2]s), (
+			SELECT CASE WHEN household_members.role = '%[4]s' THEN '%[5]s' ELSE '%[6]s' END
+			FROM money.household_members
+			WHERE household_members.household_id = %[1]s.household_id AND household_members.user_id = %[2]s)) END`,
+		alias, param, walletRoleOwner, householdRoleViewer, walletRoleViewer, walletRoleEditor)
+}
+
+// categoryAccess returns the condition of the categories of the alias that the user of the parameter can access,
+// the personal categories of the user and the categories of the households of the user
+func categoryAccess(alias string, param string) string {
+	return fmt.Sprintf(`((%[1]s.user_id = %[2]s AND %[1]s.household_id IS NULL) OR EXISTS (
+			SELECT 1
+			FROM money.household_members
+			WHERE household_members.household_id = %[1]s.household_id AND household_members.user_id = %[2]s))`, alias, param)
+}
+
+// getWallets returns the wallets of the user and the wallets shared with the user or with its households
+func (storage *storagePostgres) getWallets(userID string) ([]*wallet, error) {
+	rows, err := storage.conn.Get().Query(fmt.Sprintf(`
+	     SELECT
+			wallet_id,
+			user_id,
+			name,
+			description,
+			password,
+			COALESCE(household_id, ''),
+			%s,
+			updated_at,
+			created_at
+		FROM money.wallets
+		WHERE %s
+	`, walletRole("wallets", "$1"), walletAccess("wallets", "$1")), userID)
+
+	defer rows.Close()
+	if err != nil {
+		return nil, errors.New(errors.LevelError, 1, err)
+	}
+
+	wallets := make([]*wallet, 0)
+	for rows.Next() {
+		wallet := &wallet{}
+		if err := rows.Scan(
+			&wallet.WalletID,
+			&wallet.UserID,
+			&wallet.Name,
+			&wallet.Description,
+			&wallet.Password,
+			&wallet.HouseholdID,
+			&wallet.Role,
+			&wallet.UpdatedAt,
+			&wallet.CreatedAt); err != nil {
+
+			if err != sql.ErrNoRows {
+				return nil, errors.New(errors.LevelError, 1, err)
 			}
 			return nil, nil
 		}
@@ -328,27 +1013,32 @@ func (storage *storagePostgres) getWallets(userID string) ([]*wallet, error) {
 	return wallets, nil
 }
 
-// getWallet ...
+// getWallet returns the wallet when the user is the owner, a member or a member of its household
 func (storage *storagePostgres) getWallet(userID string, walletID string) (*wallet, error) {
-	row := storage.conn.Get().QueryRow(`
+	row := storage.conn.Get().QueryRow(fmt.Sprintf(`
 	    SELECT
+			user_id,
 			name,
 			description,
 			password,
+			COALESCE(household_id, ''),
+			%s,
 			updated_at,
 			created_at
 		FROM money.wallets
-		WHERE user_id = $1 AND wallet_id = $2
-	`, userID, walletID)
+		WHERE wallet_id = $2 AND %s
+	`, walletRole("wallets", "$1"), walletAccess("wallets", "$1")), userID, walletID)
 
 	wallet := &wallet{
 		WalletID: walletID,
-		UserID:   userID,
 	}
 	if err := row.Scan(
+		&wallet.UserID,
 		&wallet.Name,
 		&wallet.Description,
 		&wallet.Password,
+		&wallet.HouseholdID,
+		&wallet.Role,
 		&wallet.UpdatedAt,
 		&wallet.CreatedAt); err != nil {
 
@@ -407,15 +1097,15 @@ func (storage *storagePostgres) createWallets(newWallets []*wallet) ([]*wallet,
 	return createdWallets, nil
 }
 
-// updateWallet ...
+// updateWallet updates the wallet when the user is the owner or a member
 func (storage *storagePostgres) updateWallet(wallet *wallet) (*wallet, error) {
-	if result, err := storage.conn.Get().Exec(`
-		UPDATE money.wallets SET 
+	if result, err := storage.conn.Get().Exec(fmt.Sprintf(`
+		UPDATE money.wallets SET
 			name = $1,
 			description = $2,
 			password = $3
-		WHERE user_id = $4 AND wallet_id = $5
-	`, wallet.Name, wallet.Description, wallet.Password, wallet.UserID, wallet.WalletID); err != nil {
+		WHERE wallet_id = $5 AND %s
+	`, walletAccess("wallets", "$4")), wallet.Name, wallet.Description, wallet.Password, wallet.UserID, wallet.WalletID); err != nil {
 		return nil, errors.New(errors.LevelError, 1, err)
 	} else if rows, _ := result.RowsAffected(); rows > 0 {
 		return storage.getWallet(wallet.UserID, wallet.WalletID)
@@ -437,84 +1127,72 @@ func (storage *storagePostgres) deleteWallet(userID string, walletID string) err
 	return nil
 }
 
-// getImages ...
-func (storage *storagePostgres) getImages(userID string) ([]*image, error) {
+// getWalletMembers returns the members of the wallet, without the owner
+func (storage *storagePostgres) getWalletMembers(walletID string) ([]*walletMember, error) {
 	rows, err := storage.conn.Get().Query(`
-	     SELECT
-			image_id,
-			name,
-			description,
-			url,
-			file_name,
-			format,
-			raw_image,
-			updated_at,
-			created_at
-		FROM money.images
-		WHERE user_id = $1
-	`, userID)
-
-	defer rows.Close()
+	    SELECT
+			wallet_members.user_id,
+			users.name,
+			users.email,
+			wallet_members.role,
+			wallet_members.invited_by,
+			wallet_members.updated_at,
+			wallet_members.created_at
+		FROM money.wallet_members
+		JOIN money.users ON users.user_id = wallet_members.user_id
+		WHERE wallet_members.wallet_id = $1
+		ORDER BY wallet_members.created_at
+	`, walletID)
 	if err != nil {
 		return nil, errors.New(errors.LevelError, 1, err)
 	}
+	defer rows.Close()
 
-	images := make([]*image, 0)
+	members := make([]*walletMember, 0)
 	for rows.Next() {
-		image := &image{
-			UserID: userID,
-		}
+		member := &walletMember{WalletID: walletID}
 		if err := rows.Scan(
-			&image.ImageID,
-			&image.Name,
-			&image.Description,
-			&image.Url,
-			&image.FileName,
-			&image.Format,
-			&image.RawImage,
-			&image.UpdatedAt,
-			&image.CreatedAt); err != nil {
-
-			if err != sql.ErrNoRows {
-				return nil, errors.New(errors.LevelError, 1, err)
-			}
-			return nil, nil
+			&member.UserID,
+			&member.Name,
+			&member.Email,
+			&member.Role,
+			&member.InvitedBy,
+			&member.UpdatedAt,
+			&member.CreatedAt); err != nil {
+			return nil, errors.New(errors.LevelError, 1, err)
 		}
-		images = append(images, image)
+		members = append(members, member)
 	}
 
-	return images, nil
+	return members, nil
 }
 
-// getImage ...
-func (storage *storagePostgres) getImage(userID string, imageID string) (*image, error) {
+// getWalletMember ...
+func (storage *storagePostgres) getWalletMember(walletID string, userID string) (*walletMember, error) {
 	row := storage.conn.Get().QueryRow(`
 	    SELECT
-			name,
-			description,
-			url,
-			file_name,
-			format,
-			raw_image,
-			updated_at,
-			created_at
-		FROM money.images
-		WHERE user_id = $1 AND image_id = $2
-	`, userID, imageID)
-
-	image := &image{
-		UserID:  userID,
-		ImageID: imageID,
+			users.name,
+			users.email,
+			wallet_members.role,
+			wallet_members.invited_by,
+			wallet_members.updated_at,
+			wallet_members.created_at
+		FROM money.wallet_members
+		JOIN money.users ON users.user_id = wallet_members.user_id
+		WHERE wallet_members.wallet_id = $1 AND wallet_members.user_id = $2
+	`, walletID, userID)
+
+	member := &walletMember{
+		WalletID: walletID,
+		UserID:   userID,
 	}
 	if err := row.Scan(
-		&image.Name,
-		&image.Description,
-		&image.Url,
-		&image.FileName,
-		&image.Format,
-		&image.RawImage,
-		&image.UpdatedAt,
-		&image.CreatedAt); err != nil {
+		&member.Name,
+		&member.Email,
+		&member.Role,
+		&member.InvitedBy,
+		&member.UpdatedAt,
+		&member.CreatedAt); err != nil {
 
 		if err != sql.ErrNoRows {
 			return nil, errors.New(errors.LevelError, 1, err)
@@ -522,40 +1200,1000 @@ func (storage *storagePostgres) getImage(userID string, imageID string) (*image,
 		return nil, nil
 	}
 
-	return image, nil
+	return member, nil
 }
 
-// createImage ...
-func (storage *storagePostgres) createImage(newImage *image) (*image, error) {
+// createWalletMember ...
+func (storage *storagePostgres) createWalletMember(newMember *walletMember) (*walletMember, error) {
+	if _, err := storage.conn.Get().Exec(`
+		INSERT INTO money.wallet_members(wallet_id, user_id, role, invited_by)
+		VALUES($1, $2, $3, $4)
+	`, newMember.WalletID, newMember.UserID, newMember.Role, newMember.InvitedBy); err != nil {
+		return nil, errors.New(errors.LevelError, 1, err)
+	}
+
+	return storage.getWalletMember(newMember.WalletID, newMember.UserID)
+}
+
+// updateWalletMember changes the role of the member, returning nil when the member doesn't exist
+func (storage *storagePostgres) updateWalletMember(walletID string, userID string, role string) (*walletMember, error) {
 	if result, err := storage.conn.Get().Exec(`
-		INSERT INTO money.images(image_id, user_id, name, description, url, file_name, format, raw_image)
-		VALUES($1, $2, $3, $4, $5, $6, $7, $8)
-	`, newImage.ImageID, newImage.UserID, newImage.Name, newImage.Description, newImage.Url, newImage.FileName, newImage.Format, newImage.RawImage); err != nil {
+		UPDATE money.wallet_members SET
+			role = $1,
+			updated_at = NOW()
+		WHERE wallet_id = $2 AND user_id = $3
+	`, role, walletID, userID); err != nil {
 		return nil, errors.New(errors.LevelError, 1, err)
 	} else if rows, _ := result.RowsAffected(); rows > 0 {
-		return storage.getImage(newImage.UserID, newImage.ImageID)
+		return storage.getWalletMember(walletID, userID)
 	}
+
 	return nil, nil
 }
 
-// updateImage ...
-func (storage *storagePostgres) updateImage(updImage *image) (*image, error) {
-	if result, err := storage.conn.Get().Exec(`
-		UPDATE money.images SET 
-			name = $1,
-			description = $2,
-			url = $3,
-			file_name = $4,
-			format = $5,
-			raw_image = $6
-		WHERE user_id = $7 AND image_id = $8
-	`, updImage.Name, updImage.Description, updImage.Url, updImage.FileName, updImage.Format, updImage.RawImage, updImage.UserID, updImage.ImageID); err != nil {
-		return nil, errors.New(errors.LevelError, 1, err)
-	} else if rows, _ := result.RowsAffected(); rows > 0 {
-		return storage.getImage(updImage.UserID, updImage.ImageID)
+// deleteWalletMember removes the member of the wallet, returning false when the member doesn't exist
+func (storage *storagePostgres) deleteWalletMember(walletID string, userID string) (bool, error) {
+	result, err := storage.conn.Get().Exec(`
+	    DELETE
+		FROM money.wallet_members
+		WHERE wallet_id = $1 AND user_id = $2
+	`, walletID, userID)
+	if err != nil {
+		return false, errors.New(errors.LevelError, 1, err)
+	}
+
+	rows, _ := result.RowsAffected()
+	return rows > 0, nil
+}
+
+// getHouseholds returns the households of the user with the role of the user
+func (storage *storagePostgres) getHouseholds(userID string) ([]*household, error) {
+	rows, err := storage.conn.Get().Query(`
+	    SELECT
+			households.household_id,
+			households.name,
+			households.created_by,
+			household_members.role,
+			households.updated_at,
+			households.created_at
+		FROM money.households
+		JOIN money.household_members ON household_members.household_id = households.household_id
+		WHERE household_members.user_id = $1
+		ORDER BY households.created_at
+	`, userID)
+	if err != nil {
+		return nil, errors.New(errors.LevelError, 1, err)
+	}
+	defer rows.Close()
+
+	households := make([]*household, 0)
+	for rows.Next() {
+		household := &household{}
+		if err := rows.Scan(
+			&household.HouseholdID,
+			&household.Name,
+			&household.CreatedBy,
+			&household.Role,
+			&household.UpdatedAt,
+			&household.CreatedAt); err != nil {
+			return nil, errors.New(errors.LevelError, 1, err)
+		}
+		households = append(households, household)
+	}
+
+	return households, nil
+}
+
+// getHousehold returns the household when the user is a member
+func (storage *storagePostgres) getHousehold(userID string, householdID string) (*household, error) {
+	row := storage.conn.Get().QueryRow(`
+	    SELECT
+			households.name,
+			households.created_by,
+			household_members.role,
+			households.updated_at,
+			households.created_at
+		FROM money.households
+		JOIN money.household_members ON household_members.household_id = households.household_id
+		WHERE household_members.user_id = $1 AND households.household_id = $2
+	`, userID, householdID)
+
+	household := &household{
+		HouseholdID: householdID,
+	}
+	if err := row.Scan(
+		&household.Name,
+		&household.CreatedBy,
+		&household.Role,
+		&household.UpdatedAt,
+		&household.CreatedAt); err != nil {
+
+		if err != sql.ErrNoRows {
+			return nil, errors.New(errors.LevelError, 1, err)
+		}
+		return nil, nil
+	}
+
+	return household, nil
+}
+
+// createHousehold creates the household with its creator as the owner
+func (storage *storagePostgres) createHousehold(newHousehold *household) (*household, error) {
+	tx, err := storage.conn.Get().Begin()
+	if err != nil {
+		return nil, errors.New(errors.LevelError, 1, err)
+	}
+
+	if _, err := tx.Exec(`
+		INSERT INTO money.households(household_id, name, created_by)
+		VALUES($1, $2, $3)
+	`, newHousehold.HouseholdID, newHousehold.Name, newHousehold.CreatedBy); err != nil {
+		tx.Rollback()
+		return nil, errors.New(errors.LevelError, 1, err)
+	}
+
+	if _, err := tx.Exec(`
+		INSERT INTO money.household_members(household_id, user_id, role)
+		VALUES($1, $2, $3)
+	`, newHousehold.HouseholdID, newHousehold.CreatedBy, householdRoleOwner); err != nil {
+		tx.Rollback()
+		return nil, errors.New(errors.LevelError, 1, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.New(errors.LevelError, 1, err)
+	}
+
+	return storage.getHousehold(newHousehold.CreatedBy, newHousehold.HouseholdID)
+}
+
+// updateHousehold renames the household, returning nil when the user isn't a member
+func (storage *storagePostgres) updateHousehold(userID string, householdID string, name string) (*household, error) {
+	if result, err := storage.conn.Get().Exec(`
+		UPDATE money.households SET
+			name = $1,
+			updated_at = NOW()
+		WHERE household_id = $2
+	`, name, householdID); err != nil {
+		return nil, errors.New(errors.LevelError, 1, err)
+	} else if rows, _ := result.RowsAffected(); rows > 0 {
+		return storage.getHousehold(userID, householdID)
+	}
+
+	return nil, nil
+}
+
+// deleteHousehold deletes the household, its wallets are detached and its categories are kept by their users
+func (storage *storagePostgres) deleteHousehold(householdID string) error {
+	if _, err := storage.conn.Get().Exec(`
+	    DELETE
+		FROM money.households
+		WHERE household_id = $1
+	`, householdID); err != nil {
+		return errors.New(errors.LevelError, 1, err)
+	}
+
+	return nil
+}
+
+// getHouseholdMembers ...
+func (storage *storagePostgres) getHouseholdMembers(householdID string) ([]*householdMember, error) {
+	rows, err := storage.conn.Get().Query(`
+	    SELECT
+			household_members.user_id,
+			users.name,
+			users.email,
+			household_members.role,
+			household_members.invited_by,
+			household_members.updated_at,
+			household_members.created_at
+		FROM money.household_members
+		JOIN money.users ON users.user_id = household_members.user_id
+		WHERE household_members.household_id = $1
+		ORDER BY household_members.created_at
+	`, householdID)
+	if err != nil {
+		return nil, errors.New(errors.LevelError, 1, err)
+	}
+	defer rows.Close()
+
+	members := make([]*householdMember, 0)
+	for rows.Next() {
+		member := &householdMember{HouseholdID: householdID}
+		if err := rows.Scan(
+			&member.UserID,
+			&member.Name,
+			&member.Email,
+			&member.Role,
+			&member.InvitedBy,
+			&member.UpdatedAt,
+			&member.CreatedAt); err != nil {
+			return nil, errors.New(errors.LevelError, 1, err)
+		}
+		members = append(members, member)
+	}
+
+	return members, nil
+}
+
+// getHouseholdMember ...
+func (storage *storagePostgres) getHouseholdMember(householdID string, userID string) (*householdMember, error) {
+	row := storage.conn.Get().QueryRow(`
+	    SELECT
+			users.name,
+			users.email,
+			household_members.role,
+			household_members.invited_by,
+			household_members.updated_at,
+			household_members.created_at
+		FROM money.household_members
+		JOIN money.users ON users.user_id = household_members.user_id
+		WHERE household_members.household_id = $1 AND household_members.user_id = $2
+	`, householdID, userID)
+
+	member := &householdMember{
+		HouseholdID: householdID,
+		UserID:      userID,
+	}
+	if err := row.Scan(
+		&member.Name,
+		&member.Email,
+		&member.Role,
+		&member.InvitedBy,
+		&member.UpdatedAt,
+		&member.CreatedAt); err != nil {
+
+		if err != sql.ErrNoRows {
+			return nil, errors.New(errors.LevelError, 1, err)
+		}
+		return nil, nil
+	}
+
+	return member, nil
+}
+
+// updateHouseholdMember changes the role of the member, returning nil when the member doesn't exist
+func (storage *storagePostgres) updateHouseholdMember(householdID string, userID string, role string) (*householdMember, error) {
+	if result, err := storage.conn.Get().Exec(`
+		UPDATE money.household_members SET
+			role = $1,
+			updated_at = NOW()
+		WHERE household_id = $2 AND user_id = $3
+	`, role, householdID, userID); err != nil {
+		return nil, errors.New(errors.LevelError, 1, err)
+	} else if rows, _ := result.RowsAffected(); rows > 0 {
+		return storage.getHouseholdMember(householdID, userID)
+	}
+
+	return nil, nil
+}
+
+// deleteHouseholdMember removes the member of the household and detaches the wallets of the member,
+// returning false when the member doesn't exist
+func (storage *storagePostgres) deleteHouseholdMember(householdID string, userID string) (bool, error) {
+	tx, err := storage.conn.Get().Begin()
+	if err != nil {
+		return false, errors.New(errors.LevelError, 1, err)
+	}
+
+	result, err := tx.Exec(`
+	    DELETE
+		FROM money.household_members
+		WHERE household_id = $1 AND user_id = $2
+	`, householdID, userID)
+	if err != nil {
+		tx.Rollback()
+		return false, errors.New(errors.LevelError, 1, err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		tx.Rollback()
+		return false, nil
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE money.wallets SET
+			household_id = NULL
+		WHERE household_id = $1 AND user_id = $2
+	`, householdID, userID); err != nil {
+		tx.Rollback()
+		return false, errors.New(errors.LevelError, 1, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, errors.New(errors.LevelError, 1, err)
+	}
+
+	return true, nil
+}
+
+// getHouseholdInvitations returns the invitations of the household that weren't accepted and didn't expire
+func (storage *storagePostgres) getHouseholdInvitations(householdID string) ([]*householdInvitation, error) {
+	rows, err := storage.conn.Get().Query(`
+	    SELECT
+			invitation_id,
+			email,
+			role,
+			invited_by,
+			expires_at,
+			created_at
+		FROM money.household_invitations
+		WHERE household_id = $1 AND accepted_at IS NULL AND expires_at > NOW()
+		ORDER BY created_at
+	`, householdID)
+	if err != nil {
+		return nil, errors.New(errors.LevelError, 1, err)
+	}
+	defer rows.Close()
+
+	invitations := make([]*householdInvitation, 0)
+	for rows.Next() {
+		invitation := &householdInvitation{HouseholdID: householdID}
+		if err := rows.Scan(
+			&invitation.InvitationID,
+			&invitation.Email,
+			&invitation.Role,
+			&invitation.InvitedBy,
+			&invitation.ExpiresAt,
+			&invitation.CreatedAt); err != nil {
+			return nil, errors.New(errors.LevelError, 1, err)
+		}
+		invitations = append(invitations, invitation)
+	}
+
+	return invitations, nil
+}
+
+// createHouseholdInvitation stores the invitation with the hash of its token, it expires after the lifetime in seconds
+func (storage *storagePostgres) createHouseholdInvitation(newInvitation *householdInvitation, tokenHash string, lifetime int) (*householdInvitation, error) {
+	row := storage.conn.Get().QueryRow(`
+		INSERT INTO money.household_invitations(invitation_id, household_id, email, role, invited_by, token, expires_at)
+		VALUES($1, $2, $3, $4, $5, $6, NOW() + $7::INTEGER * INTERVAL '1 second')
+		RETURNING expires_at, created_at
+	`, newInvitation.InvitationID, newInvitation.HouseholdID, newInvitation.Email, newInvitation.Role, newInvitation.InvitedBy, tokenHash, lifetime)
+
+	if err := row.Scan(&newInvitation.ExpiresAt, &newInvitation.CreatedAt); err != nil {
+		return nil, errors.New(errors.LevelError, 1, err)
+	}
+
+	return newInvitation, nil
+}
+
+// deleteHouseholdInvitation cancels the invitation, returning false when it doesn't exist
+func (storage *storagePostgres) deleteHouseholdInvitation(householdID string, invitationID string) (bool, error) {
+	result, err := storage.conn.Get().Exec(`
+	    DELETE
+		FROM money.household_invitations
+		WHERE household_id = $1 AND invitation_id = $2 AND accepted_at IS NULL
+	`, householdID, invitationID)
+	if err != nil {
+		return false, errors.New(errors.LevelError, 1, err)
+	}
+
+	rows, _ := result.RowsAffected()
+	return rows > 0, nil
+}
+
+// acceptHouseholdInvitation adds the user to the household of the invitation of the token hash, that can only be used once
+// and by the user with the email of the invitation. It returns the household, or empty when the token is invalid or expired.
+func (storage *storagePostgres) acceptHouseholdInvitation(tokenHash string, userID string, email string) (string, error) {
+	tx, err := storage.conn.Get().Begin()
+	if err != nil {
+		return "", errors.New(errors.LevelError, 1, err)
+	}
+
+	var householdID, role, invitedBy string
+	if err := tx.QueryRow(`
+		UPDATE money.household_invitations SET
+			accepted_at = NOW()
+		WHERE token = $1 AND email = $2 AND accepted_at IS NULL AND expires_at > NOW()
+		RETURNING household_id, role, invited_by
+	`, tokenHash, email).Scan(&householdID, &role, &invitedBy); err != nil {
+		tx.Rollback()
+
+		if err != sql.ErrNoRows {
+			return "", errors.New(errors.LevelError, 1, err)
+		}
+		return "", nil
+	}
+
+	if _, err := tx.Exec(`
+		INSERT INTO money.household_members(household_id, user_id, role, invited_by)
+		VALUES($1, $2, $3, $4)
+		ON CONFLICT (household_id, user_id) DO NOTHING
+	`, householdID, userID, role, invitedBy); err != nil {
+		tx.Rollback()
+		return "", errors.New(errors.LevelError, 1, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", errors.New(errors.LevelError, 1, err)
+	}
+
+	return householdID, nil
+}
+
+// attachWallet shares the wallet of the user with the household, returning false when the user isn't the owner of the wallet
+func (storage *storagePostgres) attachWallet(userID string, walletID string, householdID string) (bool, error) {
+	result, err := storage.conn.Get().Exec(`
+		UPDATE money.wallets SET
+			household_id = $1
+		WHERE user_id = $2 AND wallet_id = $3
+	`, householdID, userID, walletID)
+	if err != nil {
+		return false, errors.New(errors.LevelError, 1, err)
+	}
+
+	rows, _ := result.RowsAffected()
+	return rows > 0, nil
+}
+
+// detachWallet stops sharing the wallet with the household, returning false when the wallet isn't on the household
+func (storage *storagePostgres) detachWallet(householdID string, walletID string) (bool, error) {
+	result, err := storage.conn.Get().Exec(`
+		UPDATE money.wallets SET
+			household_id = NULL
+		WHERE household_id = $1 AND wallet_id = $2
+	`, householdID, walletID)
+	if err != nil {
+		return false, errors.New(errors.LevelError, 1, err)
+	}
+
+	rows, _ := result.RowsAffected()
+	return rows > 0, nil
+}
+
+// getHouseholdTransactions returns the transactions of the wallets of the household
+func (storage *storagePostgres) getHouseholdTransactions(householdID string) ([]*transaction, error) {
+	rows, err := storage.conn.Get().Query(`
+	     SELECT
+			transactions.user_id,
+			transactions.wallet_id,
+			transactions.transaction_id,
+			transactions.category_id,
+			transactions.price,
+			transactions.description,
+			transactions.date,
+			transactions.latitude,
+			transactions.longitude,
+			transactions.group_id,
+			COALESCE(transactions.created_by, transactions.user_id),
+			COALESCE(transactions.updated_by, transactions.user_id),
+			transactions.updated_at,
+			transactions.created_at
+		FROM money.transactions
+		JOIN money.wallets ON wallets.wallet_id = transactions.wallet_id
+		WHERE wallets.household_id = $1
+	`, householdID)
+	if err != nil {
+		return nil, errors.New(errors.LevelError, 1, err)
+	}
+	defer rows.Close()
+
+	transactions := make([]*transaction, 0)
+	for rows.Next() {
+		transaction := &transaction{}
+		if err := rows.Scan(
+			&transaction.UserID,
+			&transaction.WalletID,
+			&transaction.TransactionID,
+			&transaction.CategoryID,
+			&transaction.Price,
+			&transaction.Description,
+			&transaction.Date,
+			&transaction.Latitude,
+			&transaction.Longitude,
+			&transaction.GroupID,
+			&transaction.CreatedBy,
+			&transaction.UpdatedBy,
+			&transaction.UpdatedAt,
+			&transaction.CreatedAt); err != nil {
+			return nil, errors.New(errors.LevelError, 1, err)
+		}
+		transactions = append(transactions, transaction)
+	}
+
+	return transactions, nil
+}
+
+// updateHouseholdCategory updates a category of the household
+func (storage *storagePostgres) updateHouseholdCategory(category *category) (*category, error) {
+	if result, err := storage.conn.Get().Exec(`
+		UPDATE money.categories SET
+			image_id = $1,
+			name = $2,
+			description = $3
+		WHERE household_id = $4 AND category_id = $5
+	`, category.ImageID, category.Name, category.Description, category.HouseholdID, category.CategoryID); err != nil {
+		return nil, errors.New(errors.LevelError, 1, err)
+	} else if rows, _ := result.RowsAffected(); rows > 0 {
+		return storage.getCategory(category.UserID, category.CategoryID)
+	}
+
+	return nil, nil
+}
+
+// deleteHouseholdCategory deletes a category of the household, returning false when it doesn't exist
+func (storage *storagePostgres) deleteHouseholdCategory(householdID string, categoryID string) (bool, error) {
+	result, err := storage.conn.Get().Exec(`
+	    DELETE
+		FROM money.categories
+		WHERE household_id = $1 AND category_id = $2
+	`, householdID, categoryID)
+	if err != nil {
+		return false, errors.New(errors.LevelError, 1, err)
+	}
+
+	rows, _ := result.RowsAffected()
+	return rows > 0, nil
+}
+
+// getBudgets ...
+func (storage *storagePostgres) getBudgets(householdID string) ([]*budget, error) {
+	rows, err := storage.conn.Get().Query(`
+	    SELECT
+			budget_id,
+			category_id,
+			amount,
+			period,
+			created_by,
+			updated_at,
+			created_at
+		FROM money.budgets
+		WHERE household_id = $1
+		ORDER BY created_at
+	`, householdID)
+	if err != nil {
+		return nil, errors.New(errors.LevelError, 1, err)
+	}
+	defer rows.Close()
+
+	budgets := make([]*budget, 0)
+	for rows.Next() {
+		budget := &budget{HouseholdID: householdID}
+		if err := rows.Scan(
+			&budget.BudgetID,
+			&budget.CategoryID,
+			&budget.Amount,
+			&budget.Period,
+			&budget.CreatedBy,
+			&budget.UpdatedAt,
+			&budget.CreatedAt); err != nil {
+			return nil, errors.New(errors.LevelError, 1, err)
+		}
+		budgets = append(budgets, budget)
+	}
+
+	return budgets, nil
+}
+
+// getBudget ...
+func (storage *storagePostgres) getBudget(householdID string, budgetID string) (*budget, error) {
+	row := storage.conn.Get().QueryRow(`
+	    SELECT
+			category_id,
+			amount,
+			period,
+			created_by,
+			updated_at,
+			created_at
+		FROM money.budgets
+		WHERE household_id = $1 AND budget_id = $2
+	`, householdID, budgetID)
+
+	budget := &budget{
+		BudgetID:    budgetID,
+		HouseholdID: householdID,
+	}
+	if err := row.Scan(
+		&budget.CategoryID,
+		&budget.Amount,
+		&budget.Period,
+		&budget.CreatedBy,
+		&budget.UpdatedAt,
+		&budget.CreatedAt); err != nil {
+
+		if err != sql.ErrNoRows {
+			return nil, errors.New(errors.LevelError, 1, err)
+		}
+		return nil, nil
+	}
+
+	return budget, nil
+}
+
+// createBudget ...
+func (storage *storagePostgres) createBudget(newBudget *budget) (*budget, error) {
+	if _, err := storage.conn.Get().Exec(`
+		INSERT INTO money.budgets(budget_id, household_id, category_id, amount, period, created_by)
+		VALUES($1, $2, $3, $4, $5, $6)
+	`, newBudget.BudgetID, newBudget.HouseholdID, newBudget.CategoryID, newBudget.Amount, newBudget.Period, newBudget.CreatedBy); err != nil {
+		return nil, errors.New(errors.LevelError, 1, err)
+	}
+
+	return storage.getBudget(newBudget.HouseholdID, newBudget.BudgetID)
+}
+
+// updateBudget returns nil when the budget doesn't exist
+func (storage *storagePostgres) updateBudget(budget *budget) (*budget, error) {
+	if result, err := storage.conn.Get().Exec(`
+		UPDATE money.budgets SET
+			category_id = $1,
+			amount = $2,
+			period = $3,
+			updated_at = NOW()
+		WHERE household_id = $4 AND budget_id = $5
+	`, budget.CategoryID, budget.Amount, budget.Period, budget.HouseholdID, budget.BudgetID); err != nil {
+		return nil, errors.New(errors.LevelError, 1, err)
+	} else if rows, _ := result.RowsAffected(); rows > 0 {
+		return storage.getBudget(budget.HouseholdID, budget.BudgetID)
+	}
+
+	return nil, nil
+}
+
+// deleteBudget returns false when the budget doesn't exist
+func (storage *storagePostgres) deleteBudget(householdID string, budgetID string) (bool, error) {
+	result, err := storage.conn.Get().Exec(`
+	    DELETE
+		FROM money.budgets
+		WHERE household_id = $1 AND budget_id = $2
+	`, householdID, budgetID)
+	if err != nil {
+		return false, errors.New(errors.LevelError, 1, err)
+	}
+
+	rows, _ := result.RowsAffected()
+	return rows > 0, nil
+}
+
+// getImages ...
+func (storage *storagePostgres) getImages(userID string) ([]*image, error) {
+	rows, err := storage.conn.Get().Query(`
+	     SELECT
+			image_id,
+			name,
+			description,
+			url,
+			file_name,
+			format,
+			storage,
+			hash,
+			updated_at,
+			created_at
+		FROM money.images
+		WHERE user_id = $1
+	`, userID)
+
+	defer rows.Close()
+	if err != nil {
+		return nil, errors.New(errors.LevelError, 1, err)
+	}
+
+	images := make([]*image, 0)
+	for rows.Next() {
+		image := &image{
+			UserID: userID,
+		}
+		if err := rows.Scan(
+			&image.ImageID,
+			&image.Name,
+			&image.Description,
+			&image.Url,
+			&image.FileName,
+			&image.Format,
+			&image.Storage,
+			&image.Hash,
+			&image.UpdatedAt,
+			&image.CreatedAt); err != nil {
+
+			if err != sql.ErrNoRows {
+				return nil, errors.New(errors.LevelError, 1, err)
+			}
+			return nil, nil
+		}
+		images = append(images, image)
+	}
+
+	return images, nil
+}
+
+// getImage ...
+func (storage *storagePostgres) getImage(userID string, imageID string) (*image, error) {
+	row := storage.conn.Get().QueryRow(`
+	    SELECT
+			name,
+			description,
+			url,
+			file_name,
+			format,
+			storage,
+			hash,
+			updated_at,
+			created_at
+		FROM money.images
+		WHERE user_id = $1 AND image_id = $2
+	`, userID, imageID)
+
+	image := &image{
+		UserID:  userID,
+		ImageID: imageID,
+	}
+	if err := row.Scan(
+		&image.Name,
+		&image.Description,
+		&image.Url,
+		&image.FileName,
+		&image.Format,
+		&image.Storage,
+		&image.Hash,
+		&image.UpdatedAt,
+		&image.CreatedAt); err != nil {
+
+		if err != sql.ErrNoRows {
+			return nil, errors.New(errors.LevelError, 1, err)
+		}
+		return nil, nil
+	}
+
+	return image, nil
+}
+
+// createImage ...
+func (storage *storagePostgres) createImage(newImage *image) (*image, error) {
+	if result, err := storage.conn.Get().Exec(`
+		INSERT INTO money.images(image_id, user_id, name, description, url, file_name, format, storage, hash)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`, newImage.ImageID, newImage.UserID, newImage.Name, newImage.Description, newImage.Url, newImage.FileName, newImage.Format, newImage.Storage, newImage.Hash); err != nil {
+		return nil, errors.New(errors.LevelError, 1, err)
+	} else if rows, _ := result.RowsAffected(); rows > 0 {
+		return storage.getImage(newImage.UserID, newImage.ImageID)
+	}
+	return nil, nil
+}
+
+// updateImage ...
+func (storage *storagePostgres) updateImage(updImage *image) (*image, error) {
+	if result, err := storage.conn.Get().Exec(`
+		UPDATE money.images SET 
+			name = $1,
+			description = $2,
+			url = $3,
+			file_name = $4,
+			format = $5,
+			storage = $6,
+			hash = $7
+		WHERE user_id = $8 AND image_id = $9
+	`, updImage.Name, updImage.Description, updImage.Url, updImage.FileName, updImage.Format, updImage.Storage, updImage.Hash, updImage.UserID, updImage.ImageID); err != nil {
+		return nil, errors.New(errors.LevelError, 1, err)
+	} else if rows, _ := result.RowsAffected(); rows > 0 {
+		return storage.getImage(updImage.UserID, updImage.ImageID)
+	}
+
+	return nil, nil
+}
+
+// getImagesNotOnStorage returns the images of every user that are not on the storage
+func (storage *storagePostgres) getImagesNotOnStorage(imageStorage string) ([]*image, error) {
+	rows, err := storage.conn.Get().Query(`
+	    SELECT
+			image_id,
+			user_id,
+			name,
+			format,
+			storage,
+			hash
+		FROM money.images
+		WHERE storage <> $1
+		ORDER BY created_at
+	`, imageStorage)
+
+	defer rows.Close()
+	if err != nil {
+		return nil, errors.New(errors.LevelError, 1, err)
+	}
+
+	images := make([]*image, 0)
+	for rows.Next() {
+		image := &image{}
+		if err := rows.Scan(
+			&image.ImageID,
+			&image.UserID,
+			&image.Name,
+			&image.Format,
+			&image.Storage,
+			&image.Hash); err != nil {
+			return nil, errors.New(errors.LevelError, 1, err)
+		}
+		images = append(images, image)
+	}
+
+	return images, nil
+}
+
+// getImagesWithoutHash returns the images of every user stored from before the deduplication
+func (storage *storagePostgres) getImagesWithoutHash() ([]*image, error) {
+	rows, err := storage.conn.Get().Query(`
+	    SELECT
+			image_id,
+			user_id,
+			name,
+			format,
+			storage
+		FROM money.images
+		WHERE hash = ''
+		ORDER BY created_at
+	`)
+
+	defer rows.Close()
+	if err != nil {
+		return nil, errors.New(errors.LevelError, 1, err)
+	}
+
+	images := make([]*image, 0)
+	for rows.Next() {
+		image := &image{}
+		if err := rows.Scan(
+			&image.ImageID,
+			&image.UserID,
+			&image.Name,
+			&image.Format,
+			&image.Storage); err != nil {
+			return nil, errors.New(errors.LevelError, 1, err)
+		}
+		images = append(images, image)
+	}
+
+	return images, nil
+}
+
+// updateImageHash points the image to a shared blob
+func (storage *storagePostgres) updateImageHash(userID string, imageID string, hash string, imageStorage string) error {
+	if _, err := storage.conn.Get().Exec(`
+		UPDATE money.images SET
+			hash = $1,
+			storage = $2
+		WHERE user_id = $3 AND image_id = $4
+	`, hash, imageStorage, userID, imageID); err != nil {
+		return errors.New(errors.LevelError, 1, err)
+	}
+
+	return nil
+}
+
+// acquireImageBlob adds a reference to the blob with the hash, creating it on the storage when it doesn't exist.
+// The blob returned has the storage where the content is and the references, with one reference the content must be uploaded.
+func (storage *storagePostgres) acquireImageBlob(hash string, imageStorage string, size int) (*imageBlob, error) {
+	row := storage.conn.Get().QueryRow(`
+		INSERT INTO money.image_blobs(hash, storage, size, reference_count)
+		VALUES($1, $2, $3, 1)
+		ON CONFLICT (hash) DO UPDATE SET reference_count = money.image_blobs.reference_count + 1
+		RETURNING storage, size, reference_count, uploaded
+	`, hash, imageStorage, size)
+
+	blob := &imageBlob{
+		Hash: hash,
+	}
+	if err := row.Scan(
+		&blob.Storage,
+		&blob.Size,
+		&blob.References,
+		&blob.Uploaded); err != nil {
+		return nil, errors.New(errors.LevelError, 1, err)
+	}
+
+	return blob, nil
+}
+
+// setImageBlobUploaded marks the content of the blob as uploaded to the blob storage
+func (storage *storagePostgres) setImageBlobUploaded(hash string) error {
+	if _, err := storage.conn.Get().Exec(`
+		UPDATE money.image_blobs SET
+			uploaded = TRUE
+		WHERE hash = $1
+	`, hash); err != nil {
+		return errors.New(errors.LevelError, 1, err)
+	}
+
+	return nil
+}
+
+// releaseImageBlob removes a reference to the blob with the hash, deleting the blob when it was the last reference.
+// The row of the blob stays locked until its content is deleted with deleteContent, so an image acquiring the same
+// blob meanwhile waits and creates it again, uploading the content after it was deleted.
+// The blob returned has the remaining references.
+func (storage *storagePostgres) releaseImageBlob(hash string, deleteContent func(blob *imageBlob) error) (*imageBlob, error) {
+	tx, err := storage.conn.Get().Begin()
+	if err != nil {
+		return nil, errors.New(errors.LevelError, 1, err)
+	}
+
+	row := tx.QueryRow(`
+		UPDATE money.image_blobs SET
+			reference_count = reference_count - 1
+		WHERE hash = $1
+		RETURNING storage, size, reference_count
+	`, hash)
+
+	blob := &imageBlob{
+		Hash: hash,
+	}
+	if err := row.Scan(
+		&blob.Storage,
+		&blob.Size,
+		&blob.References); err != nil {
+		tx.Rollback()
+
+		if err != sql.ErrNoRows {
+			return nil, errors.New(errors.LevelError, 1, err)
+		}
+		return nil, nil
+	}
+
+	var contentErr error
+	if blob.References <= 0 {
+		if _, err := tx.Exec(`
+			DELETE
+			FROM money.image_blobs
+			WHERE hash = $1
+		`, hash); err != nil {
+			tx.Rollback()
+			return nil, errors.New(errors.LevelError, 1, err)
+		}
+
+		// the blob is deleted even when its content isn't, the content left is uploaded again by a new blob
+		contentErr = deleteContent(blob)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.New(errors.LevelError, 1, err)
+	}
+
+	return blob, contentErr
+}
+
+// updateImageBlobStorage moves the blob and every image that shares it to the storage
+func (storage *storagePostgres) updateImageBlobStorage(hash string, imageStorage string) error {
+	tx, err := storage.conn.Get().Begin()
+	if err != nil {
+		return errors.New(errors.LevelError, 1, err)
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE money.image_blobs SET
+			storage = $1
+		WHERE hash = $2
+	`, imageStorage, hash); err != nil {
+		tx.Rollback()
+		return errors.New(errors.LevelError, 1, err)
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE money.images SET
+			storage = $1
+		WHERE hash = $2
+	`, imageStorage, hash); err != nil {
+		tx.Rollback()
+		return errors.New(errors.LevelError, 1, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.New(errors.LevelError, 1, err)
+	}
+
+	return nil
+}
+
+// updateImageStorage ...
+func (storage *storagePostgres) updateImageStorage(userID string, imageID string, imageStorage string) error {
+	if _, err := storage.conn.Get().Exec(`
+		UPDATE money.images SET
+			storage = $1
+		WHERE user_id = $2 AND image_id = $3
+	`, imageStorage, userID, imageID); err != nil {
+		return errors.New(errors.LevelError, 1, err)
 	}
 
-	return nil, nil
+	return nil
 }
 
 // deleteImage ...
@@ -572,18 +2210,21 @@ func (storage *storagePostgres) deleteImage(userID string, imageID string) error
 }
 
 // getCategories ...
+// getCategories returns the personal categories of the user and the categories of the households of the user
 func (storage *storagePostgres) getCategories(userID string) ([]*category, error) {
-	rows, err := storage.conn.Get().Query(`
+	rows, err := storage.conn.Get().Query(fmt.Sprintf(`
 	     SELECT
 			category_id,
+			user_id,
+			COALESCE(household_id, ''),
 			image_id,
 			name,
 			description,
 			updated_at,
 			created_at
 		FROM money.categories
-		WHERE user_id = $1
-	`, userID)
+		WHERE %s
+	`, categoryAccess("categories", "$1")), userID)
 
 	defer rows.Close()
 	if err != nil {
@@ -592,11 +2233,11 @@ func (storage *storagePostgres) getCategories(userID string) ([]*category, error
 
 	categories := make([]*category, 0)
 	for rows.Next() {
-		category := &category{
-			UserID: userID,
-		}
+		category := &category{}
 		if err := rows.Scan(
 			&category.CategoryID,
+			&category.UserID,
+			&category.HouseholdID,
 			&category.ImageID,
 			&category.Name,
 			&category.Description,
@@ -614,24 +2255,27 @@ func (storage *storagePostgres) getCategories(userID string) ([]*category, error
 	return categories, nil
 }
 
-// getCategory ...
+// getCategory returns the category when it is a personal category of the user or a category of a household of the user
 func (storage *storagePostgres) getCategory(userID string, categoryID string) (*category, error) {
-	row := storage.conn.Get().QueryRow(`
+	row := storage.conn.Get().QueryRow(fmt.Sprintf(`
 	    SELECT
+			user_id,
+			COALESCE(household_id, ''),
 			image_id,
 			name,
 			description,
 			updated_at,
 			created_at
 		FROM money.categories
-		WHERE user_id = $1 AND category_id = $2
-	`, userID, categoryID)
+		WHERE category_id = $2 AND %s
+	`, categoryAccess("categories", "$1")), userID, categoryID)
 
 	category := &category{
 		CategoryID: categoryID,
-		UserID:     userID,
 	}
 	if err := row.Scan(
+		&category.UserID,
+		&category.HouseholdID,
 		&category.ImageID,
 		&category.Name,
 		&category.Description,
@@ -655,14 +2299,14 @@ func (storage *storagePostgres) createCategories(newCategories []*category) ([]*
 		return nil, errors.New(errors.LevelError, 1, err)
 	}
 
-	stmt, errItem := tx.Prepare(pq.CopyInSchema("money", "categories", "category_id", "user_id", "image_id", "name", "description"))
+	stmt, errItem := tx.Prepare(pq.CopyInSchema("money", "categories", "category_id", "user_id", "household_id", "image_id", "name", "description"))
 	if errItem != nil {
 		tx.Rollback()
 		return nil, errors.New(errors.LevelError, 1, err)
 	}
 
 	for _, newCategory := range newCategories {
-		if _, err := stmt.Exec(newCategory.CategoryID, newCategory.UserID, newCategory.ImageID, newCategory.Name, newCategory.Description); err != nil {
+		if _, err := stmt.Exec(newCategory.CategoryID, newCategory.UserID, sql.NullString{String: newCategory.HouseholdID, Valid: newCategory.HouseholdID != ""}, newCategory.ImageID, newCategory.Name, newCategory.Description); err != nil {
 			tx.Rollback()
 			return nil, errors.New(errors.LevelError, 1, err)
 		}
@@ -693,14 +2337,14 @@ func (storage *storagePostgres) createCategories(newCategories []*category) ([]*
 	return createdCategories, nil
 }
 
-// updateCategory ...
+// updateCategory updates a personal category of the user
 func (storage *storagePostgres) updateCategory(category *category) (*category, error) {
 	if result, err := storage.conn.Get().Exec(`
 		UPDATE money.categories SET 
-			image_id = $1
+			image_id = $1,
 			name = $2,
-			description = $3,
-		WHERE user_id = $4 AND category_id = $5
+			description = $3
+		WHERE user_id = $4 AND category_id = $5 AND household_id IS NULL
 	`, category.ImageID, category.Name, category.Description, category.UserID, category.CategoryID); err != nil {
 		return nil, errors.New(errors.LevelError, 1, err)
 	} else if rows, _ := result.RowsAffected(); rows > 0 {
@@ -710,12 +2354,12 @@ func (storage *storagePostgres) updateCategory(category *category) (*category, e
 	return nil, nil
 }
 
-// deleteCategory ...
+// deleteCategory deletes a personal category of the user
 func (storage *storagePostgres) deleteCategory(userID string, categoryID string) error {
 	if _, err := storage.conn.Get().Exec(`
 	    DELETE 
 		FROM money.categories
-		WHERE user_id = $1 AND category_id = $2
+		WHERE user_id = $1 AND category_id = $2 AND household_id IS NULL
 	`, userID, categoryID); err != nil {
 		return errors.New(errors.LevelError, 1, err)
 	}
@@ -723,21 +2367,28 @@ func (storage *storagePostgres) deleteCategory(userID string, categoryID string)
 	return nil
 }
 
-// getTransactions ...
+// getTransactions returns the transactions of the wallets of the user and of the wallets shared with the user
 func (storage *storagePostgres) getTransactions(userID string) ([]*transaction, error) {
-	rows, err := storage.conn.Get().Query(`
+	rows, err := storage.conn.Get().Query(fmt.Sprintf(`
 	     SELECT
-			wallet_id,
-			transaction_id,
-			category_id,
-			price,
-			description,
-			date,
-			updated_at,
-			created_at
+			transactions.user_id,
+			transactions.wallet_id,
+			transactions.transaction_id,
+			transactions.category_id,
+			transactions.price,
+			transactions.description,
+			transactions.date,
+			transactions.latitude,
+			transactions.longitude,
+			transactions.group_id,
+			COALESCE(transactions.created_by, transactions.user_id),
+			COALESCE(transactions.updated_by, transactions.user_id),
+			transactions.updated_at,
+			transactions.created_at
 		FROM money.transactions
-		WHERE user_id = $1
-	`, userID)
+		JOIN money.wallets ON wallets.wallet_id = transactions.wallet_id
+		WHERE %s
+	`, walletAccess("wallets", "$1")), userID)
 
 	defer rows.Close()
 	if err != nil {
@@ -746,16 +2397,20 @@ func (storage *storagePostgres) getTransactions(userID string) ([]*transaction,
 
 	transactions := make([]*transaction, 0)
 	for rows.Next() {
-		transaction := &transaction{
-			UserID: userID,
-		}
+		transaction := &transaction{}
 		if err := rows.Scan(
+			&transaction.UserID,
 			&transaction.WalletID,
 			&transaction.TransactionID,
 			&transaction.CategoryID,
 			&transaction.Price,
 			&transaction.Description,
 			&transaction.Date,
+			&transaction.Latitude,
+			&transaction.Longitude,
+			&transaction.GroupID,
+			&transaction.CreatedBy,
+			&transaction.UpdatedBy,
 			&transaction.UpdatedAt,
 			&transaction.CreatedAt); err != nil {
 
@@ -770,30 +2425,42 @@ func (storage *storagePostgres) getTransactions(userID string) ([]*transaction,
 	return transactions, nil
 }
 
-// getTransaction ...
+// getTransaction returns the transaction when the user is the owner or a member of its wallet
 func (storage *storagePostgres) getTransaction(userID string, walletID string, transactionID string) (*transaction, error) {
-	row := storage.conn.Get().QueryRow(`
+	row := storage.conn.Get().QueryRow(fmt.Sprintf(`
 	    SELECT
-			category_id,
-			price,
-			description,
-			date,
-			updated_at,
-			created_at
+			transactions.user_id,
+			transactions.category_id,
+			transactions.price,
+			transactions.description,
+			transactions.date,
+			transactions.latitude,
+			transactions.longitude,
+			transactions.group_id,
+			COALESCE(transactions.created_by, transactions.user_id),
+			COALESCE(transactions.updated_by, transactions.user_id),
+			transactions.updated_at,
+			transactions.created_at
 		FROM money.transactions
-		WHERE user_id = $1 AND wallet_id = $2 AND transaction_id = $3
-	`, userID, walletID, transactionID)
+		JOIN money.wallets ON wallets.wallet_id = transactions.wallet_id
+		WHERE transactions.wallet_id = $2 AND transactions.transaction_id = $3 AND %s
+	`, walletAccess("wallets", "$1")), userID, walletID, transactionID)
 
 	transaction := &transaction{
-		UserID:        userID,
 		WalletID:      walletID,
 		TransactionID: transactionID,
 	}
 	if err := row.Scan(
+		&transaction.UserID,
 		&transaction.CategoryID,
 		&transaction.Price,
 		&transaction.Description,
 		&transaction.Date,
+		&transaction.Latitude,
+		&transaction.Longitude,
+		&transaction.GroupID,
+		&transaction.CreatedBy,
+		&transaction.UpdatedBy,
 		&transaction.UpdatedAt,
 		&transaction.CreatedAt); err != nil {
 
@@ -814,14 +2481,14 @@ func (storage *storagePostgres) createTransactions(newTransactions []*transactio
 		return nil, errors.New(errors.LevelError, 1, err)
 	}
 
-	stmt, errItem := tx.Prepare(pq.CopyInSchema("money", "transactions", "transaction_id", "user_id", "wallet_id", "category_id", "price", "description", "date"))
+	stmt, errItem := tx.Prepare(pq.CopyInSchema("money", "transactions", "transaction_id", "user_id", "wallet_id", "category_id", "price", "description", "date", "latitude", "longitude", "group_id", "created_by", "updated_by"))
 	if errItem != nil {
 		tx.Rollback()
 		return nil, errors.New(errors.LevelError, 1, err)
 	}
 
 	for _, newTransaction := range newTransactions {
-		if _, err := stmt.Exec(newTransaction.TransactionID, newTransaction.UserID, newTransaction.WalletID, newTransaction.CategoryID, newTransaction.Price, newTransaction.Description, newTransaction.Date); err != nil {
+		if _, err := stmt.Exec(newTransaction.TransactionID, newTransaction.UserID, newTransaction.WalletID, newTransaction.CategoryID, newTransaction.Price, newTransaction.Description, newTransaction.Date, newTransaction.Latitude, newTransaction.Longitude, newTransaction.GroupID, newTransaction.CreatedBy, newTransaction.UpdatedBy); err != nil {
 			tx.Rollback()
 			return nil, errors.New(errors.LevelError, 1, err)
 		}
@@ -859,9 +2526,13 @@ func (storage *storagePostgres) updateTransaction(transaction *transaction) (*tr
 			category_id = $1, 
 			price = $2,
 			description = $3,
-		  	date = $4
-		WHERE user_id = $5 AND wallet_id = $6 AND transaction_id = $7
-	`, transaction.CategoryID, transaction.Price, transaction.Description, transaction.Date, transaction.UserID, transaction.WalletID, transaction.TransactionID); err != nil {
+		  	date = $4,
+			latitude = $5,
+			longitude = $6,
+			updated_by = $10,
+			group_id = $11
+		WHERE user_id = $7 AND wallet_id = $8 AND transaction_id = $9
+	`, transaction.CategoryID, transaction.Price, transaction.Description, transaction.Date, transaction.Latitude, transaction.Longitude, transaction.UserID, transaction.WalletID, transaction.TransactionID, transaction.UpdatedBy, transaction.GroupID); err != nil {
 		return nil, errors.New(errors.LevelError, 1, err)
 	} else if rows, _ := result.RowsAffected(); rows > 0 {
 		return storage.getTransaction(transaction.UserID, transaction.WalletID, transaction.TransactionID)
@@ -882,3 +2553,420 @@ func (storage *storagePostgres) deleteTransaction(userID string, walletID string
 
 	return nil
 }
+
+// getAttachments ...
+func (storage *storagePostgres) getAttachments(userID string, transactionID string) ([]*attachment, error) {
+	rows, err := storage.conn.Get().Query(`
+	    SELECT
+			attachment_id,
+			file_name,
+			content_type,
+			size,
+			storage,
+			updated_at,
+			created_at
+		FROM money.attachments
+		WHERE user_id = $1 AND transaction_id = $2
+		ORDER BY created_at
+	`, userID, transactionID)
+
+	defer rows.Close()
+	if err != nil {
+		return nil, errors.New(errors.LevelError, 1, err)
+	}
+
+	attachments := make([]*attachment, 0)
+	for rows.Next() {
+		attachment := &attachment{
+			UserID:        userID,
+			TransactionID: transactionID,
+		}
+		if err := rows.Scan(
+			&attachment.AttachmentID,
+			&attachment.FileName,
+			&attachment.ContentType,
+			&attachment.Size,
+			&attachment.Storage,
+			&attachment.UpdatedAt,
+			&attachment.CreatedAt); err != nil {
+			return nil, errors.New(errors.LevelError, 1, err)
+		}
+		attachments = append(attachments, attachment)
+	}
+
+	return attachments, nil
+}
+
+// getAttachment ...
+func (storage *storagePostgres) getAttachment(userID string, transactionID string, attachmentID string) (*attachment, error) {
+	row := storage.conn.Get().QueryRow(`
+	    SELECT
+			file_name,
+			content_type,
+			size,
+			storage,
+			updated_at,
+			created_at
+		FROM money.attachments
+		WHERE user_id = $1 AND transaction_id = $2 AND attachment_id = $3
+	`, userID, transactionID, attachmentID)
+
+	attachment := &attachment{
+		UserID:        userID,
+		TransactionID: transactionID,
+		AttachmentID:  attachmentID,
+	}
+	if err := row.Scan(
+		&attachment.FileName,
+		&attachment.ContentType,
+		&attachment.Size,
+		&attachment.Storage,
+		&attachment.UpdatedAt,
+		&attachment.CreatedAt); err != nil {
+
+		if err != sql.ErrNoRows {
+			return nil, errors.New(errors.LevelError, 1, err)
+		}
+		return nil, nil
+	}
+
+	return attachment, nil
+}
+
+// createAttachment ...
+func (storage *storagePostgres) createAttachment(newAttachment *attachment) (*attachment, error) {
+	if result, err := storage.conn.Get().Exec(`
+		INSERT INTO money.attachments(attachment_id, transaction_id, user_id, file_name, content_type, size, storage)
+		VALUES($1, $2, $3, $4, $5, $6, $7)
+	`, newAttachment.AttachmentID, newAttachment.TransactionID, newAttachment.UserID, newAttachment.FileName, newAttachment.ContentType, newAttachment.Size, newAttachment.Storage); err != nil {
+		return nil, errors.New(errors.LevelError, 1, err)
+	} else if rows, _ := result.RowsAffected(); rows > 0 {
+		return storage.getAttachment(newAttachment.UserID, newAttachment.TransactionID, newAttachment.AttachmentID)
+	}
+	return nil, nil
+}
+
+// deleteAttachment ...
+func (storage *storagePostgres) deleteAttachment(userID string, transactionID string, attachmentID string) error {
+	if _, err := storage.conn.Get().Exec(`
+	    DELETE
+		FROM money.attachments
+		WHERE user_id = $1 AND transaction_id = $2 AND attachment_id = $3
+	`, userID, transactionID, attachmentID); err != nil {
+		return errors.New(errors.LevelError, 1, err)
+	}
+
+	return nil
+}
+
+// getTransactionsInBox returns the transactions with a location inside of the coordinates
+func (storage *storagePostgres) getTransactionsInBox(userID string, minLatitude float64, maxLatitude float64, minLongitude float64, maxLongitude float64) ([]*transaction, error) {
+	rows, err := storage.conn.Get().Query(`
+	     SELECT
+			wallet_id,
+			transaction_id,
+			category_id,
+			latitude,
+			longitude
+		FROM money.transactions
+		WHERE user_id = $1
+			AND latitude BETWEEN $2 AND $3
+			AND longitude BETWEEN $4 AND $5
+	`, userID, minLatitude, maxLatitude, minLongitude, maxLongitude)
+
+	defer rows.Close()
+	if err != nil {
+		return nil, errors.New(errors.LevelError, 1, err)
+	}
+
+	transactions := make([]*transaction, 0)
+	for rows.Next() {
+		transaction := &transaction{
+			UserID: userID,
+		}
+		if err := rows.Scan(
+			&transaction.WalletID,
+			&transaction.TransactionID,
+			&transaction.CategoryID,
+			&transaction.Latitude,
+			&transaction.Longitude); err != nil {
+			return nil, errors.New(errors.LevelError, 1, err)
+		}
+		transactions = append(transactions, transaction)
+	}
+
+	return transactions, nil
+}
+
+// countTransactions ...
+func (storage *storagePostgres) countTransactions(userID string) (int, error) {
+	row := storage.conn.Get().QueryRow(`
+	    SELECT COUNT(1)
+		FROM money.transactions
+		WHERE user_id = $1
+	`, userID)
+
+	var count int
+	if err := row.Scan(&count); err != nil {
+		return 0, errors.New(errors.LevelError, 1, err)
+	}
+
+	return count, nil
+}
+
+// countImages ...
+func (storage *storagePostgres) countImages(userID string) (int, error) {
+	row := storage.conn.Get().QueryRow(`
+	    SELECT COUNT(1)
+		FROM money.images
+		WHERE user_id = $1
+	`, userID)
+
+	var count int
+	if err := row.Scan(&count); err != nil {
+		return 0, errors.New(errors.LevelError, 1, err)
+	}
+
+	return count, nil
+}
+
+// getExport ...
+func (storage *storagePostgres) getExport(userID string, exportID string) (*export, error) {
+	row := storage.conn.Get().QueryRow(`
+	    SELECT
+			status,
+			error,
+			size,
+			storage,
+			updated_at,
+			created_at
+		FROM money.exports
+		WHERE user_id = $1 AND export_id = $2
+	`, userID, exportID)
+
+	export := &export{
+		ExportID: exportID,
+		UserID:   userID,
+	}
+	if err := row.Scan(
+		&export.Status,
+		&export.Error,
+		&export.Size,
+		&export.Storage,
+		&export.UpdatedAt,
+		&export.CreatedAt); err != nil {
+
+		if err != sql.ErrNoRows {
+			return nil, errors.New(errors.LevelError, 1, err)
+		}
+		return nil, nil
+	}
+
+	return export, nil
+}
+
+// createExport ...
+func (storage *storagePostgres) createExport(newExport *export) (*export, error) {
+	if result, err := storage.conn.Get().Exec(`
+		INSERT INTO money.exports(export_id, user_id, status, error, size)
+		VALUES($1, $2, $3, $4, $5)
+	`, newExport.ExportID, newExport.UserID, newExport.Status, newExport.Error, newExport.Size); err != nil {
+		return nil, errors.New(errors.LevelError, 1, err)
+	} else if rows, _ := result.RowsAffected(); rows > 0 {
+		return storage.getExport(newExport.UserID, newExport.ExportID)
+	}
+
+	return nil, nil
+}
+
+// updateExport ...
+func (storage *storagePostgres) updateExport(updExport *export) (*export, error) {
+	if result, err := storage.conn.Get().Exec(`
+		UPDATE money.exports SET
+			status = $1,
+			error = $2,
+			size = $3,
+			storage = $4
+		WHERE user_id = $5 AND export_id = $6
+	`, updExport.Status, updExport.Error, updExport.Size, updExport.Storage, updExport.UserID, updExport.ExportID); err != nil {
+		return nil, errors.New(errors.LevelError, 1, err)
+	} else if rows, _ := result.RowsAffected(); rows > 0 {
+		return storage.getExport(updExport.UserID, updExport.ExportID)
+	}
+
+	return nil, nil
+}
+
+// deleteExpiredExports deletes the exports not updated for longer than the lifetime,
+// returning them to delete their archives from the blob storage
+func (storage *storagePostgres) deleteExpiredExports(lifetime int) ([]*export, error) {
+	rows, err := storage.conn.Get().Query(`
+		DELETE
+		FROM money.exports
+		WHERE updated_at <= NOW() - $1::INTEGER * INTERVAL '1 second'
+		RETURNING export_id, user_id, storage
+	`, lifetime)
+	if err != nil {
+		return nil, errors.New(errors.LevelError, 1, err)
+	}
+	defer rows.Close()
+
+	exports := make([]*export, 0)
+	for rows.Next() {
+		export := &export{}
+		if err := rows.Scan(
+			&export.ExportID,
+			&export.UserID,
+			&export.Storage); err != nil {
+			return nil, errors.New(errors.LevelError, 1, err)
+		}
+		exports = append(exports, export)
+	}
+
+	return exports, nil
+}
+
+// getExistingIDs returns which of the given ids already exist on the column of a table
+func (storage *storagePostgres) getExistingIDs(table string, column string, ids []string) (map[string]bool, error) {
+	existing := make(map[string]bool)
+	if len(ids) == 0 {
+		return existing, nil
+	}
+
+	rows, err := storage.conn.Get().Query(fmt.Sprintf(`
+	    SELECT %s
+		FROM money.%s
+		WHERE %s = ANY($1)
+	`, column, table, column), pq.Array(ids))
+	if err != nil {
+		return nil, errors.New(errors.LevelError, 1, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, errors.New(errors.LevelError, 1, err)
+		}
+		existing[id] = true
+	}
+
+	return existing, nil
+}
+
+// getOwnedIDs returns which of the ids exist on the table and belong to the user
+func (storage *storagePostgres) getOwnedIDs(userID string, table string, column string, ids []string) (map[string]bool, error) {
+	owned := make(map[string]bool)
+	if len(ids) == 0 {
+		return owned, nil
+	}
+
+	rows, err := storage.conn.Get().Query(fmt.Sprintf(`
+	    SELECT %s
+		FROM money.%s
+		WHERE user_id = $1 AND %s = ANY($2)
+	`, column, table, column), userID, pq.Array(ids))
+	if err != nil {
+		return nil, errors.New(errors.LevelError, 1, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, errors.New(errors.LevelError, 1, err)
+		}
+		owned[id] = true
+	}
+
+	return owned, nil
+}
+
+// importAccount inserts the whole account on a single transaction, into the new user or into the existing user
+// while it is empty. It returns false when the existing user isn't empty.
+func (storage *storagePostgres) importAccount(userID string, newUser *user, wallets []*wallet, categories []*category, images []*image, transactions []*transaction) (bool, error) {
+	tx, err := storage.conn.Get().Begin()
+	if err != nil {
+		return false, errors.New(errors.LevelError, 1, err)
+	}
+
+	if newUser != nil {
+		if _, err := tx.Exec(`
+			INSERT INTO money.users(user_id, name, email, password, token, description)
+			VALUES($1, $2, $3, $4, $5, $6)
+		`, newUser.UserID, newUser.Name, newUser.Email, newUser.Password, newUser.Token, newUser.Description); err != nil {
+			tx.Rollback()
+			return false, errors.New(errors.LevelError, 1, err)
+		}
+	} else {
+		// the lock of the user waits for the inserts referencing the user and blocks the new ones until the commit
+		var empty bool
+		if err := tx.QueryRow(`
+			SELECT
+				NOT EXISTS(SELECT 1 FROM money.wallets WHERE user_id = users.user_id)
+				AND NOT EXISTS(SELECT 1 FROM money.categories WHERE user_id = users.user_id)
+				AND NOT EXISTS(SELECT 1 FROM money.images WHERE user_id = users.user_id)
+				AND NOT EXISTS(SELECT 1 FROM money.transactions WHERE user_id = users.user_id)
+			FROM money.users
+			WHERE user_id = $1
+			FOR UPDATE
+		`, userID).Scan(&empty); err != nil {
+			tx.Rollback()
+
+			if err != sql.ErrNoRows {
+				return false, errors.New(errors.LevelError, 1, err)
+			}
+			return false, nil
+		}
+		if !empty {
+			tx.Rollback()
+			return false, nil
+		}
+	}
+
+	for _, newWallet := range wallets {
+		if _, err := tx.Exec(`
+			INSERT INTO money.wallets(wallet_id, user_id, name, description, password, created_at, updated_at)
+			VALUES($1, $2, $3, $4, $5, $6, $7)
+		`, newWallet.WalletID, newWallet.UserID, newWallet.Name, newWallet.Description, newWallet.Password, newWallet.CreatedAt, newWallet.UpdatedAt); err != nil {
+			tx.Rollback()
+			return false, errors.New(errors.LevelError, 1, err)
+		}
+	}
+
+	for _, newImage := range images {
+		if _, err := tx.Exec(`
+			INSERT INTO money.images(image_id, user_id, name, description, url, file_name, format, storage, hash, created_at, updated_at)
+			VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		`, newImage.ImageID, newImage.UserID, newImage.Name, newImage.Description, newImage.Url, newImage.FileName, newImage.Format, newImage.Storage, newImage.Hash, newImage.CreatedAt, newImage.UpdatedAt); err != nil {
+			tx.Rollback()
+			return false, errors.New(errors.LevelError, 1, err)
+		}
+	}
+
+	for _, newCategory := range categories {
+		if _, err := tx.Exec(`
+			INSERT INTO money.categories(category_id, user_id, image_id, name, description, created_at, updated_at)
+			VALUES($1, $2, $3, $4, $5, $6, $7)
+		`, newCategory.CategoryID, newCategory.UserID, newCategory.ImageID, newCategory.Name, newCategory.Description, newCategory.CreatedAt, newCategory.UpdatedAt); err != nil {
+			tx.Rollback()
+			return false, errors.New(errors.LevelError, 1, err)
+		}
+	}
+
+	for _, newTransaction := range transactions {
+		if _, err := tx.Exec(`
+			INSERT INTO money.transactions(transaction_id, user_id, wallet_id, category_id, price, description, date, latitude, longitude, group_id, created_at, updated_at)
+			VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		`, newTransaction.TransactionID, newTransaction.UserID, newTransaction.WalletID, newTransaction.CategoryID, newTransaction.Price, newTransaction.Description, newTransaction.Date, newTransaction.Latitude, newTransaction.Longitude, newTransaction.GroupID, newTransaction.CreatedAt, newTransaction.UpdatedAt); err != nil {
+			tx.Rollback()
+			return false, errors.New(errors.LevelError, 1, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, errors.New(errors.LevelError, 1, err)
+	}
+
+	return true, nil
+}
@@ -1,6 +1,9 @@
 package gomoney
 
 import (
+	"io"
+	"io/ioutil"
+
 	"github.com/joaosoft/dropbox"
 )
 
@@ -9,17 +12,22 @@ type storageDropbox struct {
 	conn *dropbox.Dropbox
 }
 
-// newStoragePostgres ...
-func newStorageDropbox(config *dropbox.DropboxConfig) *storageDropbox {
+// newStorageDropbox ...
+func newStorageDropbox(config *dropbox.DropboxConfig) (*storageDropbox, error) {
 	var conn *dropbox.Dropbox
+	var err error
 	if config == nil {
-		conn, _ = dropbox.NewDropbox()
+		conn, err = dropbox.NewDropbox()
 	} else {
-		conn, _ = dropbox.NewDropbox(dropbox.WithConfiguration(config))
+		conn, err = dropbox.NewDropbox(dropbox.WithConfiguration(config))
+	}
+	if err != nil {
+		return nil, err
 	}
+
 	return &storageDropbox{
 		conn: conn,
-	}
+	}, nil
 }
 
 func (storage *storageDropbox) upload(path string, data []byte) error {
@@ -35,3 +43,22 @@ func (storage *storageDropbox) delete(path string) error {
 	_, err := storage.conn.Folder().DeleteFolder(path)
 	return err
 }
+
+// uploadFrom reads the blob to memory, the dropbox client uploads the whole content
+func (storage *storageDropbox) uploadFrom(path string, reader io.ReadSeeker, size int64) error {
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	return storage.upload(path, data)
+}
+
+func (storage *storageDropbox) downloadTo(path string, writer io.Writer) (bool, error) {
+	data, err := storage.download(path)
+	if err != nil || data == nil {
+		return false, err
+	}
+
+	_, err = writer.Write(data)
+	return true, err
+}
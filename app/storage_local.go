@@ -0,0 +1,114 @@
+package gomoney
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// storageLocal stores the blobs on a folder of the local filesystem
+type storageLocal struct {
+	root string
+}
+
+// newStorageLocal ...
+func newStorageLocal(root string) (*storageLocal, error) {
+	if root == "" {
+		return nil, fmt.Errorf("the path of the local blob storage is not configured")
+	}
+
+	if err := os.MkdirAll(root, 0750); err != nil {
+		return nil, err
+	}
+
+	return &storageLocal{
+		root: root,
+	}, nil
+}
+
+// file converts the blob path into a file inside of the root folder
+func (storage *storageLocal) file(path string) (string, error) {
+	clean := filepath.Clean("/" + filepath.FromSlash(path))
+	if clean == string(filepath.Separator) || strings.Contains(path, "..") {
+		return "", fmt.Errorf("invalid blob path %s", path)
+	}
+	return filepath.Join(storage.root, clean), nil
+}
+
+func (storage *storageLocal) upload(path string, data []byte) error {
+	return storage.uploadFrom(path, bytes.NewReader(data), int64(len(data)))
+}
+
+func (storage *storageLocal) uploadFrom(path string, reader io.ReadSeeker, size int64) error {
+	file, err := storage.file(path)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), 0750); err != nil {
+		return err
+	}
+
+	// write to a temporary file, so a failed upload doesn't leave a partial blob
+	tmp, err := ioutil.TempFile(filepath.Dir(file), ".upload-")
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(tmp, reader); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), file)
+}
+
+func (storage *storageLocal) download(path string) ([]byte, error) {
+	var buffer bytes.Buffer
+	if found, err := storage.downloadTo(path, &buffer); err != nil || !found {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func (storage *storageLocal) downloadTo(path string, writer io.Writer) (bool, error) {
+	file, err := storage.file(path)
+	if err != nil {
+		return false, err
+	}
+
+	reader, err := os.Open(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer reader.Close()
+
+	if _, err := io.Copy(writer, reader); err != nil {
+		return true, err
+	}
+
+	return true, nil
+}
+
+// delete removes the blob or the folder of the path
+func (storage *storageLocal) delete(path string) error {
+	file, err := storage.file(path)
+	if err != nil {
+		return err
+	}
+
+	return os.RemoveAll(file)
+}
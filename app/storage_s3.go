@@ -0,0 +1,329 @@
+package gomoney
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	s3Algorithm = "AWS4-HMAC-SHA256"
+	s3Service   = "s3"
+)
+
+// storageS3 stores the blobs on a s3 compatible bucket (aws, minio, ...), signing the requests with aws signature v4
+type storageS3 struct {
+	endpoint  *url.URL
+	region    string
+	bucket    string
+	accessKey string
+	secretKey string
+	pathStyle bool
+	client    *http.Client
+}
+
+// newStorageS3 ...
+func newStorageS3(endpoint string, region string, bucket string, accessKey string, secretKey string, pathStyle bool) (*storageS3, error) {
+	if bucket == "" {
+		return nil, fmt.Errorf("the bucket of the s3 blob storage is not configured")
+	}
+
+	if endpoint == "" {
+		endpoint = "https://s3.amazonaws.com"
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	parsed, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &storageS3{
+		endpoint:  parsed,
+		region:    region,
+		bucket:    bucket,
+		accessKey: accessKey,
+		secretKey: secretKey,
+		pathStyle: pathStyle,
+		client:    &http.Client{Timeout: 60 * time.Second},
+	}, nil
+}
+
+func (storage *storageS3) upload(path string, data []byte) error {
+	return storage.uploadFrom(path, bytes.NewReader(data), int64(len(data)))
+}
+
+// uploadFrom reads the blob twice, to sign its hash and to send it
+func (storage *storageS3) uploadFrom(path string, reader io.ReadSeeker, size int64) error {
+	hash := sha256.New()
+	if _, err := io.Copy(hash, reader); err != nil {
+		return err
+	}
+	if _, err := reader.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	response, err := storage.doStream(http.MethodPut, s3Key(path), nil, reader, size, hex.EncodeToString(hash.Sum(nil)))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return storage.error(response)
+	}
+
+	return nil
+}
+
+func (storage *storageS3) download(path string) ([]byte, error) {
+	var buffer bytes.Buffer
+	if found, err := storage.downloadTo(path, &buffer); err != nil || !found {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func (storage *storageS3) downloadTo(path string, writer io.Writer) (bool, error) {
+	response, err := storage.do(http.MethodGet, s3Key(path), nil, nil)
+	if err != nil {
+		return false, err
+	}
+	defer response.Body.Close()
+
+	switch response.StatusCode {
+	case http.StatusOK:
+		_, err := io.Copy(writer, response.Body)
+		return true, err
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, storage.error(response)
+	}
+}
+
+// delete removes the object and every object inside of the path, like a folder
+func (storage *storageS3) delete(path string) error {
+	keys, err := storage.list(s3Key(path) + "/")
+	if err != nil {
+		return err
+	}
+
+	for _, key := range append(keys, s3Key(path)) {
+		response, err := storage.do(http.MethodDelete, key, nil, nil)
+		if err != nil {
+			return err
+		}
+		response.Body.Close()
+
+		if response.StatusCode != http.StatusNoContent && response.StatusCode != http.StatusOK && response.StatusCode != http.StatusNotFound {
+			return fmt.Errorf("error deleting %s on s3 with status %d", key, response.StatusCode)
+		}
+	}
+
+	return nil
+}
+
+// list returns the keys of the objects with the prefix
+func (storage *storageS3) list(prefix string) ([]string, error) {
+	keys := make([]string, 0)
+	token := ""
+
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", prefix)
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		response, err := storage.do(http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		if response.StatusCode != http.StatusOK {
+			err := storage.error(response)
+			response.Body.Close()
+			return nil, err
+		}
+
+		result := struct {
+			Contents []struct {
+				Key string `xml:"Key"`
+			} `xml:"Contents"`
+			IsTruncated           bool   `xml:"IsTruncated"`
+			NextContinuationToken string `xml:"NextContinuationToken"`
+		}{}
+
+		err = xml.NewDecoder(response.Body).Decode(&result)
+		response.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, content := range result.Contents {
+			keys = append(keys, content.Key)
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return keys, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// do signs and sends a request to the bucket
+func (storage *storageS3) do(method string, key string, query url.Values, body []byte) (*http.Response, error) {
+	return storage.doStream(method, key, query, bytes.NewReader(body), int64(len(body)), s3Hash(body))
+}
+
+// doStream signs and sends a request to the bucket with a body of the size and the sha256 hash
+func (storage *storageS3) doStream(method string, key string, query url.Values, body io.Reader, size int64, payloadHash string) (*http.Response, error) {
+	host := storage.endpoint.Host
+	path := "/" + key
+	if storage.pathStyle {
+		path = "/" + storage.bucket + path
+	} else {
+		host = storage.bucket + "." + host
+	}
+
+	requestURL := fmt.Sprintf("%s://%s%s", storage.endpoint.Scheme, host, s3Escape(path))
+	if rawQuery := s3Query(query); rawQuery != "" {
+		requestURL += "?" + rawQuery
+	}
+
+	request, err := http.NewRequest(method, requestURL, body)
+	if err != nil {
+		return nil, err
+	}
+	request.ContentLength = size
+
+	storage.sign(request, host, s3Escape(path), payloadHash, time.Now().UTC())
+
+	return storage.client.Do(request)
+}
+
+// sign adds the aws signature v4 headers to the request
+func (storage *storageS3) sign(request *http.Request, host string, canonicalURI string, payloadHash string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	request.Host = host
+	request.Header.Set("X-Amz-Date", amzDate)
+	request.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{
+		"host":                 host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           amzDate,
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&canonicalHeaders, "%s:%s\n", name, headers[name])
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		canonicalURI,
+		request.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := fmt.Sprintf("%s/%s/%s/aws4_request", date, storage.region, s3Service)
+	stringToSign := strings.Join([]string{s3Algorithm, amzDate, scope, s3Hash([]byte(canonicalRequest))}, "\n")
+
+	key := s3Hmac([]byte("AWS4"+storage.secretKey), date)
+	key = s3Hmac(key, storage.region)
+	key = s3Hmac(key, s3Service)
+	key = s3Hmac(key, "aws4_request")
+	signature := hex.EncodeToString(s3Hmac(key, stringToSign))
+
+	request.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, storage.accessKey, scope, signedHeaders, signature))
+}
+
+func (storage *storageS3) error(response *http.Response) error {
+	body, _ := ioutil.ReadAll(response.Body)
+
+	result := struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}{}
+	if err := xml.Unmarshal(body, &result); err == nil && result.Code != "" {
+		return fmt.Errorf("error on s3 with status %d: %s %s", response.StatusCode, result.Code, result.Message)
+	}
+
+	return fmt.Errorf("error on s3 with status %d", response.StatusCode)
+}
+
+// s3Key converts a blob path into an object key
+func s3Key(path string) string {
+	return strings.TrimPrefix(path, "/")
+}
+
+// s3Escape encodes the path as required by the signature, keeping the slashes
+func s3Escape(path string) string {
+	var builder strings.Builder
+	for _, b := range []byte(path) {
+		if b >= 'A' && b <= 'Z' || b >= 'a' && b <= 'z' || b >= '0' && b <= '9' || strings.IndexByte("-_.~/", b) >= 0 {
+			builder.WriteByte(b)
+		} else {
+			fmt.Fprintf(&builder, "%%%02X", b)
+		}
+	}
+	return builder.String()
+}
+
+// s3Query encodes the query sorted by name, as required by the signature
+func s3Query(query url.Values) string {
+	if len(query) == 0 {
+		return ""
+	}
+
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		for _, value := range query[name] {
+			parts = append(parts, s3Escape(name)+"="+strings.Replace(s3Escape(value), "/", "%2F", -1))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func s3Hash(data []byte) string {
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+func s3Hmac(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,146 @@
+package gomoney
+
+import (
+	"bytes"
+	"fmt"
+	img "image"
+	"image/jpeg"
+
+	"golang.org/x/image/draw"
+)
+
+const (
+	imageFitContain = "contain"
+	imageFitCover   = "cover"
+	imageFitFill    = "fill"
+
+	defaultMaxResize = 2048
+)
+
+func isImageFit(fit string) bool {
+	return fit == imageFitContain || fit == imageFitCover || fit == imageFitFill
+}
+
+// thumbnailPath is the path of a thumbnail of an image on the blob storage
+func thumbnailPath(userID string, imageID string, name string) string {
+	return fmt.Sprintf("%s/%s", thumbnailsPath(userID, imageID), name)
+}
+
+// thumbnailsPath is the folder with the thumbnails of an image on the blob storage
+func thumbnailsPath(userID string, imageID string) string {
+	return fmt.Sprintf("/users/%s/thumbnails/%s", userID, imageID)
+}
+
+// resizeImage resizes the image to the width and height.
+// With contain the image fits inside of the size, with cover it fills the size and the exceeding part is cropped,
+// and with fill it is stretched. When the width or the height is zero, it keeps the aspect ratio without enlarging
+// the image and both sides are limited to the max size, so a narrow image can't become a huge one.
+// The contain fit never enlarges the image.
+func resizeImage(image img.Image, width int, height int, fit string, maxSize int) img.Image {
+	bounds := image.Bounds()
+	srcWidth, srcHeight := bounds.Dx(), bounds.Dy()
+	if srcWidth == 0 || srcHeight == 0 {
+		return image
+	}
+	if maxSize <= 0 {
+		maxSize = defaultMaxResize
+	}
+
+	switch {
+	case width <= 0 && height <= 0:
+		return image
+	case width <= 0 || height <= 0:
+		if width <= 0 {
+			width = srcWidth
+		}
+		if height <= 0 {
+			height = srcHeight
+		}
+		// the box of the size on the requested side and unbounded on the other, that the image fits inside of
+		width, height = fitSize(srcWidth, srcHeight, minInt(width, srcWidth, maxSize), minInt(height, srcHeight, maxSize))
+		fit = imageFitFill
+	}
+
+	source := bounds
+	switch fit {
+	case imageFitCover:
+		// crop the center of the source with the aspect ratio of the size
+		if srcWidth*height > srcHeight*width {
+			cropWidth := srcHeight * width / height
+			source.Min.X += (srcWidth - cropWidth) / 2
+			source.Max.X = source.Min.X + cropWidth
+		} else {
+			cropHeight := srcWidth * height / width
+			source.Min.Y += (srcHeight - cropHeight) / 2
+			source.Max.Y = source.Min.Y + cropHeight
+		}
+	case imageFitFill:
+	default:
+		if srcWidth <= width && srcHeight <= height {
+			return image
+		}
+		if srcWidth*height > srcHeight*width {
+			height = srcHeight * width / srcWidth
+		} else {
+			width = srcWidth * height / srcHeight
+		}
+	}
+
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+
+	resized := img.NewRGBA(img.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(resized, resized.Bounds(), image, source, draw.Src, nil)
+
+	return resized
+}
+
+// fitSize returns the size of the source scaled to fit inside of the box, keeping its aspect ratio
+func fitSize(srcWidth int, srcHeight int, boxWidth int, boxHeight int) (int, int) {
+	if srcWidth*boxHeight > srcHeight*boxWidth {
+		return boxWidth, srcHeight * boxWidth / srcWidth
+	}
+	return srcWidth * boxHeight / srcHeight, boxHeight
+}
+
+func minInt(values ...int) int {
+	min := values[0]
+	for _, value := range values[1:] {
+		if value < min {
+			min = value
+		}
+	}
+	return min
+}
+
+// variantFormat is the format of the resized images, the formats without an encoder are converted to png
+func variantFormat(format string) string {
+	switch format {
+	case "jpg", "jpeg", "png", "bmp", "gif":
+		return format
+	default:
+		return "png"
+	}
+}
+
+// encodeVariant encodes a resized image
+func encodeVariant(image img.Image, format string) ([]byte, error) {
+	var buffer bytes.Buffer
+
+	switch format = variantFormat(format); format {
+	case "jpg", "jpeg":
+		if err := jpeg.Encode(&buffer, image, &jpeg.Options{Quality: 85}); err != nil {
+			return nil, err
+		}
+	default:
+		if err := encodeImage(&buffer, image, format); err != nil {
+			return nil, err
+		}
+	}
+
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,42 @@
+package gomoney
+
+import (
+	img "image"
+	"testing"
+)
+
+func TestResizeImage(t *testing.T) {
+	tests := []struct {
+		name           string
+		srcWidth       int
+		srcHeight      int
+		width          int
+		height         int
+		fit            string
+		maxSize        int
+		expectedWidth  int
+		expectedHeight int
+	}{
+		{name: "narrow image by width", srcWidth: 1, srcHeight: 40000, width: 2048, maxSize: 2048, expectedWidth: 1, expectedHeight: 2048},
+		{name: "wide image by height", srcWidth: 40000, srcHeight: 1, height: 2048, maxSize: 2048, expectedWidth: 2048, expectedHeight: 1},
+		{name: "shrink by width", srcWidth: 1000, srcHeight: 500, width: 100, maxSize: 2048, expectedWidth: 100, expectedHeight: 50},
+		{name: "shrink by height", srcWidth: 1000, srcHeight: 500, height: 100, maxSize: 2048, expectedWidth: 200, expectedHeight: 100},
+		{name: "never enlarge by width", srcWidth: 100, srcHeight: 50, width: 400, maxSize: 2048, expectedWidth: 100, expectedHeight: 50},
+		{name: "never enlarge by height", srcWidth: 100, srcHeight: 50, height: 400, maxSize: 2048, expectedWidth: 100, expectedHeight: 50},
+		{name: "limited by the max size", srcWidth: 4000, srcHeight: 3000, width: 4000, maxSize: 1000, expectedWidth: 1000, expectedHeight: 750},
+		{name: "contain", srcWidth: 1000, srcHeight: 500, width: 100, height: 100, fit: imageFitContain, maxSize: 2048, expectedWidth: 100, expectedHeight: 50},
+		{name: "cover", srcWidth: 1000, srcHeight: 500, width: 100, height: 100, fit: imageFitCover, maxSize: 2048, expectedWidth: 100, expectedHeight: 100},
+		{name: "fill", srcWidth: 1000, srcHeight: 500, width: 100, height: 100, fit: imageFitFill, maxSize: 2048, expectedWidth: 100, expectedHeight: 100},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			source := img.NewGray(img.Rect(0, 0, test.srcWidth, test.srcHeight))
+
+			bounds := resizeImage(source, test.width, test.height, test.fit, test.maxSize).Bounds()
+			if bounds.Dx() != test.expectedWidth || bounds.Dy() != test.expectedHeight {
+				t.Errorf("expected %dx%d, got %dx%d", test.expectedWidth, test.expectedHeight, bounds.Dx(), bounds.Dy())
+			}
+		})
+	}
+}
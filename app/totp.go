@@ -0,0 +1,409 @@
+package gomoney
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/joaosoft/errors"
+)
+
+const (
+	totpPeriod             = 30
+	totpDigits             = 6
+	totpSkew               = 1
+	totpSecretSize         = 20
+	recoveryCodes          = 10
+	challengeTokenType     = "challenge"
+	defaultTOTPIssuer      = "Go Money"
+	defaultChallengeExpiry = 5 * 60
+	// defaultChallengeCodes are the wrong codes allowed on a challenge before it is invalidated
+	defaultChallengeCodes = 5
+)
+
+var errTOTPEnabled = errors.New(errors.LevelError, 1, "the two factor authentication is already enabled")
+var errTOTPNotEnrolled = errors.New(errors.LevelError, 1, "the two factor authentication was not enrolled")
+var errInvalidCode = errors.New(errors.LevelError, 1, "invalid code")
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// totpCode returns the code of the counter, as described on rfc 4226 and rfc 6238
+func totpCode(secret []byte, counter uint64) string {
+	message := make([]byte, 8)
+	binary.BigEndian.PutUint64(message, counter)
+
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(message)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000)
+}
+
+// matchTOTP returns the counter of the code inside of the allowed clock skew, or zero when it doesn't match
+func matchTOTP(secret string, code string, now time.Time) uint64 {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil || len(code) != totpDigits {
+		return 0
+	}
+
+	current := uint64(now.Unix() / totpPeriod)
+	for counter := current - totpSkew; counter <= current+totpSkew; counter++ {
+		if hmac.Equal([]byte(totpCode(key, counter)), []byte(code)) {
+			return counter
+		}
+	}
+	return 0
+}
+
+// totpURI returns the otpauth uri of the secret, that the authenticator apps read from a qr code
+func totpURI(issuer string, email string, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprintf("%d", totpDigits))
+	query.Set("period", fmt.Sprintf("%d", totpPeriod))
+
+	label := url.PathEscape(fmt.Sprintf("%s:%s", issuer, email))
+	return fmt.Sprintf("otpauth://totp/%s?%s", label, query.Encode())
+}
+
+// normalizeRecoveryCode removes the separators of a recovery code
+func normalizeRecoveryCode(code string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
+
+// newRecoveryCodes generates the recovery codes, returning them formatted for the user and their hashes
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodes)
+	hashes := make([]string, 0, recoveryCodes)
+
+	for i := 0; i < recoveryCodes; i++ {
+		data := make([]byte, 8)
+		if _, err := rand.Read(data); err != nil {
+			return nil, nil, err
+		}
+		code := totpEncoding.EncodeToString(data)[:10]
+		codes = append(codes, fmt.Sprintf("%s-%s", code[:5], code[5:]))
+		hashes = append(hashes, hashToken(code))
+	}
+
+	return codes, hashes, nil
+}
+
+// totpIssuer ...
+func (interactor *interactor) totpIssuer() string {
+	if interactor.config.TOTP.Issuer != "" {
+		return interactor.config.TOTP.Issuer
+	}
+	return defaultTOTPIssuer
+}
+
+// enrollTOTP generates a new secret for the user, that is only enabled after a code is confirmed
+func (interactor *interactor) enrollTOTP(userID string) (string, string, error) {
+	log.WithFields(map[string]interface{}{"method": "enrollTOTP"})
+	log.Infof("enrolling two factor authentication of user %s", userID)
+
+	user, err := interactor.getUser(userID)
+	if err != nil {
+		return "", "", err
+	}
+	if user == nil {
+		return "", "", nil
+	}
+	if user.TOTPEnabled {
+		return "", "", errTOTPEnabled
+	}
+
+	key := make([]byte, totpSecretSize)
+	if _, err := rand.Read(key); err != nil {
+		return "", "", errors.New(errors.LevelError, 1, err)
+	}
+	secret := totpEncoding.EncodeToString(key)
+
+	if err := interactor.storageDB.updateUserTOTP(userID, secret, false); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error enrolling two factor authentication on storage database %s", err)
+		return "", "", err
+	}
+
+	return secret, totpURI(interactor.totpIssuer(), user.Email, secret), nil
+}
+
+// confirmTOTP enables the enrolled secret with a code of the authenticator, returning the recovery codes
+func (interactor *interactor) confirmTOTP(userID string, code string) ([]string, error) {
+	log.WithFields(map[string]interface{}{"method": "confirmTOTP"})
+	log.Infof("confirming two factor authentication of user %s", userID)
+
+	user, err := interactor.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, nil
+	}
+	if user.TOTPEnabled {
+		return nil, errTOTPEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, errTOTPNotEnrolled
+	}
+
+	counter := matchTOTP(user.TOTPSecret, code, time.Now())
+	if counter == 0 {
+		return nil, errInvalidCode
+	}
+
+	used, err := interactor.storageDB.useTOTPCounter(userID, int64(counter))
+	if err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error using the counter of the two factor authentication on storage database %s", err)
+		return nil, err
+	}
+	if !used {
+		return nil, errInvalidCode
+	}
+
+	if err := interactor.storageDB.updateUserTOTP(userID, user.TOTPSecret, true); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error enabling two factor authentication on storage database %s", err)
+		return nil, err
+	}
+
+	return interactor.replaceRecoveryCodes(userID)
+}
+
+// disableTOTP disables the two factor authentication with a valid code, removing the recovery codes
+func (interactor *interactor) disableTOTP(userID string, code string) (bool, error) {
+	log.WithFields(map[string]interface{}{"method": "disableTOTP"})
+	log.Infof("disabling two factor authentication of user %s", userID)
+
+	user, err := interactor.getUser(userID)
+	if err != nil || user == nil {
+		return false, err
+	}
+	if !user.TOTPEnabled {
+		return false, errTOTPNotEnrolled
+	}
+
+	if valid, err := interactor.verifySecondFactor(user, code); err != nil {
+		return false, err
+	} else if !valid {
+		return false, errInvalidCode
+	}
+
+	if err := interactor.storageDB.updateUserTOTP(userID, "", false); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error disabling two factor authentication on storage database %s", err)
+		return false, err
+	}
+	if err := interactor.storageDB.replaceRecoveryCodes(userID, nil); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error deleting recovery codes on storage database %s", err)
+		return false, err
+	}
+
+	return true, nil
+}
+
+// regenerateRecoveryCodes replaces the recovery codes after a valid code
+func (interactor *interactor) regenerateRecoveryCodes(userID string, code string) ([]string, error) {
+	log.WithFields(map[string]interface{}{"method": "regenerateRecoveryCodes"})
+	log.Infof("regenerating recovery codes of user %s", userID)
+
+	user, err := interactor.getUser(userID)
+	if err != nil || user == nil {
+		return nil, err
+	}
+	if !user.TOTPEnabled {
+		return nil, errTOTPNotEnrolled
+	}
+
+	if valid, err := interactor.verifySecondFactor(user, code); err != nil {
+		return nil, err
+	} else if !valid {
+		return nil, errInvalidCode
+	}
+
+	return interactor.replaceRecoveryCodes(userID)
+}
+
+// replaceRecoveryCodes ...
+func (interactor *interactor) replaceRecoveryCodes(userID string) ([]string, error) {
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, errors.New(errors.LevelError, 1, err)
+	}
+
+	if err := interactor.storageDB.replaceRecoveryCodes(userID, hashes); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error replacing recovery codes on storage database %s", err)
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// verifySecondFactor checks a code of the authenticator or a recovery code of the user.
+// Each code of the authenticator and each recovery code can only be used once.
+func (interactor *interactor) verifySecondFactor(user *user, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+
+	if len(code) == totpDigits {
+		counter := matchTOTP(user.TOTPSecret, code, time.Now())
+		if counter == 0 {
+			return false, nil
+		}
+		return interactor.storageDB.useTOTPCounter(user.UserID, int64(counter))
+	}
+
+	used, err := interactor.storageDB.useRecoveryCode(user.UserID, hashToken(normalizeRecoveryCode(code)))
+	if used {
+		log.Infof("user %s used a recovery code", user.UserID)
+	}
+	return used, err
+}
+
+// challengeLimits returns the configured lifetime of a challenge and the wrong codes allowed on it
+func (interactor *interactor) challengeLimits() (lifetime int, maxCodes int) {
+	lifetime, maxCodes = interactor.config.TOTP.ChallengeLifetime, interactor.config.TOTP.ChallengeCodes
+
+	if lifetime <= 0 {
+		lifetime = defaultChallengeExpiry
+	}
+	if maxCodes <= 0 {
+		maxCodes = defaultChallengeCodes
+	}
+
+	return
+}
+
+// createChallenge returns a short lived token that proves the password of the user, to be exchanged
+// by a session with the second factor
+func (interactor *interactor) createChallenge(user *user) (string, time.Time, error) {
+	lifetime, _ := interactor.challengeLimits()
+	expiresAt := time.Now().Add(time.Duration(lifetime) * time.Second)
+
+	claims := customClaims{
+		TokenType: challengeTokenType,
+		StandardClaims: &jwt.StandardClaims{
+			Id:        genUI(),
+			Subject:   user.UserID,
+			IssuedAt:  time.Now().Unix(),
+			ExpiresAt: expiresAt.Unix(),
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(interactor.secret())
+	if err != nil {
+		return "", time.Time{}, errors.New(errors.LevelError, 1, err)
+	}
+	return token, expiresAt, nil
+}
+
+// completeChallenge validates the challenge token and the second factor with the limits of the logins by user and by ip,
+// returning the user, or nil when they are invalid, and the seconds to wait when the attempt isn't allowed.
+// The challenge is invalidated after too many wrong codes and every attempt is recorded on the audit of the logins.
+func (interactor *interactor) completeChallenge(challenge string, code string, ip string, userAgent string) (*user, int, error) {
+	log.WithFields(map[string]interface{}{"method": "completeChallenge"})
+
+	claims := &customClaims{StandardClaims: &jwt.StandardClaims{}}
+	token, err := jwt.ParseWithClaims(challenge, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method %s", token.Header["alg"])
+		}
+		return interactor.secret(), nil
+	})
+	if err != nil || !token.Valid || claims.TokenType != challengeTokenType || claims.Id == "" {
+		return nil, 0, nil
+	}
+
+	user, err := interactor.getUser(claims.Subject)
+	if err != nil || user == nil || !user.TOTPEnabled {
+		return nil, 0, err
+	}
+	log.Infof("challenge of user %s from %s", user.UserID, ip)
+
+	window, _, _, _, lockoutAfter, lockoutDuration := interactor.loginLimits()
+	lifetime, maxCodes := interactor.challengeLimits()
+	userKey, ipKey := challengeKeys(user.UserID, ip)
+	challengeKey := "challenge:" + claims.Id
+	attempt := &loginAttempt{
+		LoginAttemptID: genUI(),
+		Email:          user.Email,
+		UserID:         user.UserID,
+		IP:             ip,
+		UserAgent:      userAgent,
+	}
+
+	retryAfter, err := interactor.limitRetryAfter(user.Email, userKey, ipKey)
+	if err != nil {
+		return nil, 0, err
+	}
+	if retryAfter > 0 {
+		log.Infof("challenge of user %s from %s is limited for %d seconds", user.UserID, ip, retryAfter)
+		attempt.Reason = loginReasonRateLimited
+		interactor.recordLoginAttempt(attempt)
+		return nil, retryAfter, nil
+	}
+
+	codes, err := interactor.limiter.getFailures(challengeKey, lifetime)
+	if err != nil {
+		return nil, 0, err
+	}
+	if codes.Count >= maxCodes {
+		log.Infof("challenge of user %s was invalidated after %d wrong codes", user.UserID, codes.Count)
+		attempt.Reason = loginReasonChallengeInvalidated
+		interactor.recordLoginAttempt(attempt)
+		return nil, 0, nil
+	}
+
+	if valid, err := interactor.verifySecondFactor(user, code); err != nil {
+		return nil, 0, err
+	} else if !valid {
+		log.Infof("invalid second factor of user %s", user.UserID)
+		attempt.Reason = loginReasonInvalidCode
+		interactor.recordLoginAttempt(attempt)
+
+		if _, err := interactor.limiter.addFailure(challengeKey, lifetime); err != nil {
+			return nil, 0, err
+		}
+		if _, err := interactor.limiter.addFailure(ipKey, window); err != nil {
+			return nil, 0, err
+		}
+		failures, err := interactor.limiter.addFailure(userKey, window)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		if failures >= lockoutAfter {
+			log.Infof("locking the logins of user %s for %d seconds after %d wrong codes", user.UserID, lockoutDuration, failures)
+			if err := interactor.storageDB.lockUserLogin(user.Email, lockoutDuration); err != nil {
+				log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+					Errorf("error locking user on storage database %s", err)
+				return nil, 0, err
+			}
+			interactor.limiter.resetFailures(userKey)
+		}
+		return nil, 0, nil
+	}
+
+	attempt.Success = true
+	interactor.recordLoginAttempt(attempt)
+
+	if err := interactor.limiter.resetFailures(userKey); err != nil {
+		return nil, 0, err
+	}
+
+	return user, 0, nil
+}
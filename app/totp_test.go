@@ -0,0 +1,143 @@
+package gomoney
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeTOTPStorage keeps the counters and the recovery codes of the two factor authentication on memory
+type fakeTOTPStorage struct {
+	*fakeStorageDB
+	counters map[string]int64
+	// recoveryCodes are the hashes of the codes of each user, with true when they were used
+	recoveryCodes map[string]map[string]bool
+}
+
+func newFakeTOTPStorage() *fakeTOTPStorage {
+	return &fakeTOTPStorage{
+		fakeStorageDB: newFakeStorageDB(),
+		counters:      make(map[string]int64),
+		recoveryCodes: make(map[string]map[string]bool),
+	}
+}
+
+func (storage *fakeTOTPStorage) updateUserTOTP(userID string, secret string, enabled bool) error {
+	storage.users[userID].TOTPSecret = secret
+	storage.users[userID].TOTPEnabled = enabled
+	return nil
+}
+
+func (storage *fakeTOTPStorage) useTOTPCounter(userID string, counter int64) (bool, error) {
+	if storage.counters[userID] >= counter {
+		return false, nil
+	}
+	storage.counters[userID] = counter
+	return true, nil
+}
+
+func (storage *fakeTOTPStorage) replaceRecoveryCodes(userID string, codes []string) error {
+	storage.recoveryCodes[userID] = make(map[string]bool)
+	for _, code := range codes {
+		storage.recoveryCodes[userID][code] = false
+	}
+	return nil
+}
+
+func (storage *fakeTOTPStorage) useRecoveryCode(userID string, code string) (bool, error) {
+	if used, ok := storage.recoveryCodes[userID][code]; !ok || used {
+		return false, nil
+	}
+	storage.recoveryCodes[userID][code] = true
+	return true, nil
+}
+
+func TestMatchTOTP(t *testing.T) {
+	// the secret of the test vectors of rfc 6238, with the last six digits of the codes
+	secret := totpEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		now      time.Time
+		expected uint64
+	}{
+		{name: "current code", secret: secret, code: "287082", now: time.Unix(59, 0), expected: 1},
+		{name: "current code of 2005", secret: secret, code: "081804", now: time.Unix(1111111109, 0), expected: 37037036},
+		{name: "current code of 2009", secret: secret, code: "005924", now: time.Unix(1234567890, 0), expected: 41152263},
+		{name: "previous code", secret: secret, code: "081804", now: time.Unix(1111111109+totpPeriod, 0), expected: 37037036},
+		{name: "next code", secret: secret, code: "081804", now: time.Unix(1111111109-totpPeriod, 0), expected: 37037036},
+		{name: "code outside of the skew", secret: secret, code: "081804", now: time.Unix(1111111109+2*totpPeriod, 0), expected: 0},
+		{name: "wrong code", secret: secret, code: "000000", now: time.Unix(1111111109, 0), expected: 0},
+		{name: "short code", secret: secret, code: "81804", now: time.Unix(1111111109, 0), expected: 0},
+		{name: "lower case secret", secret: "gezdgnbvgy3tqojqgezdgnbvgy3tqojq", code: "081804", now: time.Unix(1111111109, 0), expected: 37037036},
+		{name: "invalid secret", secret: "!", code: "081804", now: time.Unix(1111111109, 0), expected: 0},
+	}
+
+	for _, test := range tests {
+		if got := matchTOTP(test.secret, test.code, test.now); got != test.expected {
+			t.Errorf("%s: expected counter %d, got %d", test.name, test.expected, got)
+		}
+	}
+}
+
+func TestVerifySecondFactor(t *testing.T) {
+	storage := newFakeTOTPStorage()
+	interactor := newInteractor(storage, nil, newMailerLog(), newLimiterMemory(), &MoneyConfig{})
+
+	key := []byte("12345678901234567890")
+	user := &user{UserID: "user", Status: userStatusActive, TOTPSecret: totpEncoding.EncodeToString(key)}
+	storage.users[user.UserID] = user
+	current := uint64(time.Now().Unix() / totpPeriod)
+
+	codes, err := interactor.confirmTOTP(user.UserID, totpCode(key, current-1))
+	if err != nil || len(codes) != recoveryCodes {
+		t.Fatalf("expected the recovery codes, got %v with error %v", codes, err)
+	}
+	if !user.TOTPEnabled {
+		t.Fatal("expected the two factor authentication enabled")
+	}
+
+	tests := []struct {
+		name     string
+		code     string
+		expected bool
+	}{
+		{name: "code used on the confirmation", code: totpCode(key, current-1), expected: false},
+		{name: "current code", code: totpCode(key, current), expected: true},
+		{name: "replayed code", code: totpCode(key, current), expected: false},
+		{name: "earlier code", code: totpCode(key, current-1), expected: false},
+		{name: "next code", code: totpCode(key, current+1), expected: true},
+		{name: "recovery code", code: codes[0], expected: true},
+		{name: "replayed recovery code", code: codes[0], expected: false},
+		{name: "recovery code without separator", code: " " + codes[1][:5] + codes[1][6:] + " ", expected: true},
+		{name: "unknown recovery code", code: "AAAAA-AAAAA", expected: false},
+	}
+
+	for _, test := range tests {
+		valid, err := interactor.verifySecondFactor(user, test.code)
+		if err != nil {
+			t.Fatalf("%s: unexpected error %s", test.name, err)
+		}
+		if valid != test.expected {
+			t.Errorf("%s: expected %t, got %t", test.name, test.expected, valid)
+		}
+	}
+}
+
+func TestConfirmTOTPReplay(t *testing.T) {
+	storage := newFakeTOTPStorage()
+	interactor := newInteractor(storage, nil, newMailerLog(), newLimiterMemory(), &MoneyConfig{})
+
+	key := []byte("12345678901234567890")
+	storage.users["user"] = &user{UserID: "user", Status: userStatusActive, TOTPSecret: totpEncoding.EncodeToString(key)}
+	current := uint64(time.Now().Unix() / totpPeriod)
+	storage.counters["user"] = int64(current + 1)
+
+	if _, err := interactor.confirmTOTP("user", totpCode(key, current)); err != errInvalidCode {
+		t.Fatalf("expected an invalid code, got %v", err)
+	}
+	if storage.users["user"].TOTPEnabled {
+		t.Fatal("expected the two factor authentication disabled")
+	}
+}
@@ -2,14 +2,19 @@ package gomoney
 
 import (
 	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	img "image"
+	"image/gif"
 	"image/jpeg"
 	"image/png"
 	"io"
 	"io/ioutil"
+	"net/http"
 	"os"
+	"strings"
 
 	"math/rand"
 	"time"
@@ -17,6 +22,7 @@ import (
 	"github.com/dgrijalva/jwt-go"
 	"github.com/oklog/ulid"
 	"golang.org/x/image/bmp"
+	"golang.org/x/image/webp"
 )
 
 func getEnv() string {
@@ -130,6 +136,9 @@ func encodeImage(writer io.Writer, image img.Image, format string) (err error) {
 	case "bmp":
 		err = bmp.Encode(writer, image)
 
+	case "gif":
+		err = gif.Encode(writer, image, nil)
+
 	default:
 		err = fmt.Errorf("unknown format when writting %v", format)
 	}
@@ -148,6 +157,12 @@ func decodeImage(reader io.Reader, format string) (image img.Image, err error) {
 	case "bmp":
 		image, err = bmp.Decode(reader)
 
+	case "gif":
+		image, err = gif.Decode(reader)
+
+	case "webp":
+		image, err = webp.Decode(reader)
+
 	default:
 		image, _, err = img.Decode(reader)
 	}
@@ -172,6 +187,22 @@ func generateToken(tokenType string, value []byte) (string, error) {
 	return token.SignedString(value)
 }
 
+// generateExpiringToken generates a token like generateToken that is only valid until the expiration
+func generateExpiringToken(tokenType string, value []byte, expiresAt time.Time) (string, error) {
+	claims := customClaims{
+		TokenType: tokenType,
+		StandardClaims: &jwt.StandardClaims{
+			Id:        tokenName,
+			IssuedAt:  time.Now().Unix(),
+			ExpiresAt: expiresAt.Unix(),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+
+	return token.SignedString(value)
+}
+
 func genUI() string {
 	t := time.Now().UTC()
 	entropy := rand.New(rand.NewSource(t.UnixNano()))
@@ -182,3 +213,42 @@ func valUI(id string) error {
 	_, err := ulid.Parse(id)
 	return err
 }
+
+// imagePath is the path of an image on the blob storage
+func imagePath(userID string, imageID string) string {
+	return fmt.Sprintf("/users/%s/images/%s", userID, imageID)
+}
+
+// attachmentPath is the path of an attachment on the blob storage
+func attachmentPath(userID string, attachmentID string) string {
+	return fmt.Sprintf("/users/%s/attachments/%s", userID, attachmentID)
+}
+
+// exportArchivePath is the path of the archive of an export on the blob storage
+func exportArchivePath(userID string, exportID string) string {
+	return fmt.Sprintf("/users/%s/exports/%s.zip", userID, exportID)
+}
+
+// imageContentType returns the mime type of the image format, sniffing the content for unknown formats
+func imageContentType(format string, data []byte) string {
+	switch strings.ToLower(format) {
+	case "jpg", "jpeg":
+		return "image/jpeg"
+	case "png":
+		return "image/png"
+	case "bmp":
+		return "image/bmp"
+	case "gif":
+		return "image/gif"
+	case "webp":
+		return "image/webp"
+	default:
+		return http.DetectContentType(data)
+	}
+}
+
+// imageETag is a strong entity tag of the image content
+func imageETag(data []byte) string {
+	hash := sha256.Sum256(data)
+	return fmt.Sprintf("%q", hex.EncodeToString(hash[:16]))
+}
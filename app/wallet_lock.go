@@ -0,0 +1,193 @@
+package gomoney
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/joaosoft/errors"
+	"github.com/labstack/echo"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	walletGrantType   = "wallet"
+	walletGrantHeader = "X-Wallet-Grant"
+
+	defaultWalletGrantLifetime = 15 * 60
+)
+
+var errWalletNotLocked = errors.New(errors.LevelError, 1, "the wallet doesn't have a password")
+
+// checkWalletPassword compares the password with the bcrypt hash of the wallet
+func checkWalletPassword(wallet *wallet, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(wallet.Password), []byte(password)) == nil
+}
+
+// hashWalletPassword hashes the password of the wallet, a wallet without password isn't locked
+func (interactor *interactor) hashWalletPassword(wallet *wallet) error {
+	if wallet.Password == "" {
+		return nil
+	}
+
+	hash, err := interactor.hashPassword(wallet.Password)
+	if err != nil {
+		return err
+	}
+	wallet.Password = hash
+
+	return nil
+}
+
+// walletGrantLifetime ...
+func (interactor *interactor) walletGrantLifetime() int {
+	if interactor.config.Wallet.GrantLifetime > 0 {
+		return interactor.config.Wallet.GrantLifetime
+	}
+	return defaultWalletGrantLifetime
+}
+
+// unlockWallet checks the password of the wallet and returns a short lived grant of the user to the wallet.
+// The failures delay the next attempts like the logins, returning the seconds to wait when the attempt isn't allowed.
+func (interactor *interactor) unlockWallet(userID string, walletID string, password string) (string, time.Time, int, error) {
+	log.WithFields(map[string]interface{}{"method": "unlockWallet"})
+	log.Infof("unlocking wallet %s of user %s", walletID, userID)
+
+	wallet, err := interactor.getWallet(userID, walletID)
+	if err != nil {
+		return "", time.Time{}, 0, err
+	}
+	if wallet == nil {
+		return "", time.Time{}, 0, errWalletNotFound
+	}
+	if wallet.Password == "" {
+		return "", time.Time{}, 0, errWalletNotLocked
+	}
+
+	window, _, delayAfter, maxDelay, _, _ := interactor.loginLimits()
+	key := walletKey(userID, walletID)
+
+	failures, err := interactor.limiter.getFailures(key, window)
+	if err != nil {
+		return "", time.Time{}, 0, err
+	}
+	if delay := loginDelay(failures.Count, delayAfter, maxDelay) - failures.SinceLast; delay > 0 {
+		return "", time.Time{}, delay, nil
+	}
+
+	if !checkWalletPassword(wallet, password) {
+		log.Infof("invalid password of wallet %s", walletID)
+		_, err := interactor.limiter.addFailure(key, window)
+		return "", time.Time{}, 0, err
+	}
+	interactor.limiter.resetFailures(key)
+
+	expiresAt := time.Now().Add(time.Duration(interactor.walletGrantLifetime()) * time.Second)
+	claims := customClaims{
+		TokenType: walletGrantType,
+		StandardClaims: &jwt.StandardClaims{
+			Subject:   walletID,
+			Audience:  userID,
+			IssuedAt:  time.Now().Unix(),
+			ExpiresAt: expiresAt.Unix(),
+		},
+	}
+
+	grant, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(interactor.secret())
+	if err != nil {
+		return "", time.Time{}, 0, errors.New(errors.LevelError, 1, err)
+	}
+
+	return grant, expiresAt, 0, nil
+}
+
+// validWalletGrant checks that the grant was issued to the user for the wallet and isn't expired
+func (interactor *interactor) validWalletGrant(grant string, userID string, walletID string) bool {
+	claims := &customClaims{StandardClaims: &jwt.StandardClaims{}}
+	token, err := jwt.ParseWithClaims(grant, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method %s", token.Header["alg"])
+		}
+		return interactor.secret(), nil
+	})
+
+	return err == nil && token.Valid && claims.TokenType == walletGrantType &&
+		claims.Subject == walletID && claims.Audience == userID
+}
+
+// walletGrants returns the grants of the request, the routes of many wallets take a grant for each locked wallet
+// on the X-Wallet-Grant header, repeated or separated by commas
+func walletGrants(ctx echo.Context) []string {
+	grants := make([]string, 0)
+	for _, value := range ctx.Request().Header[walletGrantHeader] {
+		for _, grant := range strings.Split(value, ",") {
+			if grant = strings.TrimSpace(grant); grant != "" {
+				grants = append(grants, grant)
+			}
+		}
+	}
+	return grants
+}
+
+// lockedWallets returns the wallets with a password that the user can access and that none of the grants
+// issued to the grantee unlocks. The routes of many wallets leave the transactions of these wallets out.
+func (interactor *interactor) lockedWallets(userID string, grantee string, grants []string) (map[string]bool, error) {
+	wallets, err := interactor.getWallets(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	locked := make(map[string]bool)
+	for _, wallet := range wallets {
+		if wallet.Password == "" {
+			continue
+		}
+		locked[wallet.WalletID] = true
+		for _, grant := range grants {
+			if interactor.validWalletGrant(grant, grantee, wallet.WalletID) {
+				delete(locked, wallet.WalletID)
+				break
+			}
+		}
+	}
+
+	return locked, nil
+}
+
+// lockedWallets returns the locked wallets of the user of the route that the grants of the request don't unlock,
+// it must be used after the authenticate middleware
+func (api *apiWeb) lockedWallets(ctx echo.Context) (map[string]bool, error) {
+	grantee := ctx.Param("user_id")
+	if principal := principalFromContext(ctx); principal != nil {
+		grantee = principal.UserID
+	}
+	return api.interactor.lockedWallets(ctx.Param("user_id"), grantee, walletGrants(ctx))
+}
+
+// requireWalletGrant only allows the routes of a wallet with a password with a grant of the principal to the wallet,
+// it must be used after the authenticate middleware
+func (api *apiWeb) requireWalletGrant() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			principal := principalFromContext(ctx)
+			if principal == nil {
+				return ctx.JSON(http.StatusForbidden, errorResponse{Code: http.StatusForbidden, Message: "forbidden", Cause: ""})
+			}
+
+			wallet, err := api.interactor.getWallet(ctx.Param("user_id"), ctx.Param("wallet_id"))
+			if err != nil {
+				return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+			} else if wallet == nil || wallet.Password == "" {
+				return next(ctx)
+			}
+
+			if !api.interactor.validWalletGrant(ctx.Request().Header.Get(walletGrantHeader), principal.UserID, wallet.WalletID) {
+				return ctx.JSON(http.StatusForbidden, errorResponse{Code: http.StatusForbidden, Message: "the wallet is locked", Cause: ""})
+			}
+
+			return next(ctx)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package gomoney
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// fakeWalletStorage lists the wallets of the fake storage database by user
+type fakeWalletStorage struct {
+	*fakeStorageDB
+}
+
+func (storage *fakeWalletStorage) getWallets(userID string) ([]*wallet, error) {
+	wallets := make([]*wallet, 0)
+	for key, wallet := range storage.wallets {
+		if strings.HasPrefix(key, userID+":") {
+			wallets = append(wallets, wallet)
+		}
+	}
+	return wallets, nil
+}
+
+// newTestLockedWallet adds a wallet with a password to the users, returning the interactor of the storage
+func newTestLockedWallet(walletID string, userIDs ...string) (*interactor, *fakeWalletStorage) {
+	storage := &fakeWalletStorage{fakeStorageDB: newFakeStorageDB()}
+	hash, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	for _, userID := range userIDs {
+		storage.wallets[userID+":"+walletID] = &wallet{WalletID: walletID, UserID: userIDs[0], Password: string(hash)}
+	}
+
+	config := &MoneyConfig{}
+	config.Security.Secret = "secret"
+	config.Login.DelayAfter = 2
+	return newInteractor(storage, nil, newMailerLog(), newLimiterMemory(), config), storage
+}
+
+// signTestGrant signs the claims of a grant with the secret
+func signTestGrant(method jwt.SigningMethod, secret interface{}, tokenType string, userID string, walletID string, expiresAt time.Time) string {
+	grant, _ := jwt.NewWithClaims(method, customClaims{
+		TokenType: tokenType,
+		StandardClaims: &jwt.StandardClaims{
+			Subject:   walletID,
+			Audience:  userID,
+			ExpiresAt: expiresAt.Unix(),
+		},
+	}).SignedString(secret)
+	return grant
+}
+
+func TestUnlockWallet(t *testing.T) {
+	interactor, _ := newTestLockedWallet("wallet", "owner", "member")
+
+	tests := []struct {
+		name     string
+		userID   string
+		walletID string
+		password string
+		grant    bool
+		delayed  bool
+		err      error
+	}{
+		{name: "wrong password", userID: "owner", walletID: "wallet", password: "wrong"},
+		{name: "second wrong password", userID: "owner", walletID: "wallet", password: "wrong"},
+		{name: "right password after the failures", userID: "owner", walletID: "wallet", password: "password", delayed: true},
+		{name: "member not delayed by the owner", userID: "member", walletID: "wallet", password: "password", grant: true},
+		{name: "unknown wallet", userID: "owner", walletID: "unknown", password: "password", err: errWalletNotFound},
+	}
+
+	for _, test := range tests {
+		grant, _, retryAfter, err := interactor.unlockWallet(test.userID, test.walletID, test.password)
+		if err != test.err {
+			t.Fatalf("%s: expected the error %v, got %v", test.name, test.err, err)
+		}
+		if (retryAfter > 0) != test.delayed {
+			t.Errorf("%s: expected delayed %t, got %d seconds", test.name, test.delayed, retryAfter)
+		}
+		if (grant != "") != test.grant {
+			t.Errorf("%s: expected a grant %t, got %q", test.name, test.grant, grant)
+		}
+		if grant != "" && !interactor.validWalletGrant(grant, test.userID, test.walletID) {
+			t.Errorf("%s: expected a valid grant", test.name)
+		}
+	}
+}
+
+func TestValidWalletGrant(t *testing.T) {
+	interactor, _ := newTestLockedWallet("wallet", "owner")
+	grant, _, _, err := interactor.unlockWallet("owner", "wallet", "password")
+	if err != nil || grant == "" {
+		t.Fatalf("expected a grant, got %q with error %v", grant, err)
+	}
+	expiresAt := time.Now().Add(time.Minute)
+
+	tests := []struct {
+		name     string
+		grant    string
+		userID   string
+		walletID string
+		expected bool
+	}{
+		{name: "grant of the unlock", grant: grant, userID: "owner", walletID: "wallet", expected: true},
+		{name: "grant of another user", grant: grant, userID: "member", walletID: "wallet"},
+		{name: "grant of another wallet", grant: grant, userID: "owner", walletID: "other"},
+		{name: "signed grant", grant: signTestGrant(jwt.SigningMethodHS256, []byte("secret"), walletGrantType, "owner", "wallet", expiresAt), userID: "owner", walletID: "wallet", expected: true},
+		{name: "expired grant", grant: signTestGrant(jwt.SigningMethodHS256, []byte("secret"), walletGrantType, "owner", "wallet", time.Now().Add(-time.Minute)), userID: "owner", walletID: "wallet"},
+		{name: "token of another type", grant: signTestGrant(jwt.SigningMethodHS256, []byte("secret"), challengeTokenType, "owner", "wallet", expiresAt), userID: "owner", walletID: "wallet"},
+		{name: "grant of another secret", grant: signTestGrant(jwt.SigningMethodHS256, []byte("other"), walletGrantType, "owner", "wallet", expiresAt), userID: "owner", walletID: "wallet"},
+		{name: "unsigned grant", grant: signTestGrant(jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, walletGrantType, "owner", "wallet", expiresAt), userID: "owner", walletID: "wallet"},
+		{name: "no grant", userID: "owner", walletID: "wallet"},
+	}
+
+	for _, test := range tests {
+		if got := interactor.validWalletGrant(test.grant, test.userID, test.walletID); got != test.expected {
+			t.Errorf("%s: expected %t, got %t", test.name, test.expected, got)
+		}
+	}
+}
+
+func TestLockedWallets(t *testing.T) {
+	interactor, storage := newTestLockedWallet("locked", "owner")
+	storage.wallets["owner:other"] = &wallet{WalletID: "other", UserID: "owner", Password: storage.wallets["owner:locked"].Password}
+	storage.wallets["owner:open"] = &wallet{WalletID: "open", UserID: "owner"}
+	expiresAt := time.Now().Add(time.Minute)
+
+	tests := []struct {
+		name     string
+		grantee  string
+		grants   []string
+		expected []string
+	}{
+		{name: "no grants", grantee: "owner", expected: []string{"locked", "other"}},
+		{name: "grant of a wallet", grantee: "owner", grants: []string{signTestGrant(jwt.SigningMethodHS256, []byte("secret"), walletGrantType, "owner", "locked", expiresAt)}, expected: []string{"other"}},
+		{name: "grants of every wallet", grantee: "owner", grants: []string{
+			signTestGrant(jwt.SigningMethodHS256, []byte("secret"), walletGrantType, "owner", "other", expiresAt),
+			signTestGrant(jwt.SigningMethodHS256, []byte("secret"), walletGrantType, "owner", "locked", expiresAt),
+		}},
+		{name: "grant of another grantee", grantee: "admin", grants: []string{signTestGrant(jwt.SigningMethodHS256, []byte("secret"), walletGrantType, "owner", "locked", expiresAt)}, expected: []string{"locked", "other"}},
+		{name: "invalid grant", grantee: "owner", grants: []string{"invalid"}, expected: []string{"locked", "other"}},
+	}
+
+	for _, test := range tests {
+		locked, err := interactor.lockedWallets("owner", test.grantee, test.grants)
+		if err != nil {
+			t.Fatalf("%s: unexpected error %s", test.name, err)
+		}
+		if len(locked) != len(test.expected) {
+			t.Errorf("%s: expected the locked wallets %v, got %v", test.name, test.expected, locked)
+		}
+		for _, walletID := range test.expected {
+			if !locked[walletID] {
+				t.Errorf("%s: expected the wallet %s locked, got %v", test.name, walletID, locked)
+			}
+		}
+	}
+}
+
+func TestWalletGrants(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Add(walletGrantHeader, "first, second")
+	request.Header.Add(walletGrantHeader, " third ,")
+	ctx := echo.New().NewContext(request, httptest.NewRecorder())
+
+	if grants := walletGrants(ctx); strings.Join(grants, " ") != "first second third" {
+		t.Errorf("expected the grants of every header, got %v", grants)
+	}
+}
+
+func TestRequireWalletGrant(t *testing.T) {
+	interactor, storage := newTestLockedWallet("locked", "owner")
+	storage.wallets["owner:open"] = &wallet{WalletID: "open", UserID: "owner"}
+	api := &apiWeb{interactor: interactor}
+	expiresAt := time.Now().Add(time.Minute)
+
+	tests := []struct {
+		name      string
+		principal *principal
+		walletID  string
+		grant     string
+		expected  int
+	}{
+		{name: "open wallet", principal: &principal{UserID: "owner"}, walletID: "open", expected: http.StatusNoContent},
+		{name: "locked wallet without grant", principal: &principal{UserID: "owner"}, walletID: "locked", expected: http.StatusForbidden},
+		{name: "locked wallet with a grant", principal: &principal{UserID: "owner"}, walletID: "locked", grant: signTestGrant(jwt.SigningMethodHS256, []byte("secret"), walletGrantType, "owner", "locked", expiresAt), expected: http.StatusNoContent},
+		{name: "locked wallet with a grant of another wallet", principal: &principal{UserID: "owner"}, walletID: "locked", grant: signTestGrant(jwt.SigningMethodHS256, []byte("secret"), walletGrantType, "owner", "open", expiresAt), expected: http.StatusForbidden},
+		{name: "locked wallet with a grant of another principal", principal: &principal{UserID: "admin", Role: roleAdmin}, walletID: "locked", grant: signTestGrant(jwt.SigningMethodHS256, []byte("secret"), walletGrantType, "owner", "locked", expiresAt), expected: http.StatusForbidden},
+		{name: "locked wallet with a grant of the principal", principal: &principal{UserID: "admin", Role: roleAdmin}, walletID: "locked", grant: signTestGrant(jwt.SigningMethodHS256, []byte("secret"), walletGrantType, "admin", "locked", expiresAt), expected: http.StatusNoContent},
+		{name: "no principal", walletID: "open", expected: http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		router := echo.New()
+		router.Add(http.MethodGet, "/api/1/users/:user_id/wallets/:wallet_id", func(ctx echo.Context) error {
+			return ctx.NoContent(http.StatusNoContent)
+		}, func(next echo.HandlerFunc) echo.HandlerFunc {
+			return func(ctx echo.Context) error {
+				if test.principal != nil {
+					ctx.Set(principalKey, test.principal)
+				}
+				return next(ctx)
+			}
+		}, api.requireWalletGrant())
+
+		request := httptest.NewRequest(http.MethodGet, "/api/1/users/owner/wallets/"+test.walletID, nil)
+		if test.grant != "" {
+			request.Header.Set(walletGrantHeader, test.grant)
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+
+		if recorder.Code != test.expected {
+			t.Errorf("%s: expected the status %d, got %d with %s", test.name, test.expected, recorder.Code, recorder.Body.String())
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package gomoney
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/joaosoft/errors"
+	"github.com/labstack/echo"
+)
+
+// the roles on a wallet, the owner is the user of the wallet and the others are members
+const (
+	walletRoleViewer = "viewer"
+	walletRoleEditor = "editor"
+	walletRoleAdmin  = "admin"
+	walletRoleOwner  = "owner"
+)
+
+var walletRoleRanks = map[string]int{
+	walletRoleViewer: 1,
+	walletRoleEditor: 2,
+	walletRoleAdmin:  3,
+	walletRoleOwner:  4,
+}
+
+var errInvalidWalletRole = errors.New(errors.LevelError, 1, "invalid role, the roles are viewer, editor and admin")
+var errMemberExists = errors.New(errors.LevelError, 1, "the user is already a member of the wallet")
+var errMemberIsOwner = errors.New(errors.LevelError, 1, "the user is the owner of the wallet")
+var errMemberNotFound = errors.New(errors.LevelError, 1, "the user was not found")
+
+// isWalletMemberRole checks if the role can be given to a member
+func isWalletMemberRole(role string) bool {
+	return role == walletRoleViewer || role == walletRoleEditor || role == walletRoleAdmin
+}
+
+// walletRoleAllows checks if the role has at least the permissions of the required role
+func walletRoleAllows(role string, required string) bool {
+	return walletRoleRanks[role] >= walletRoleRanks[required]
+}
+
+// getWalletMembers returns the owner and the members of a wallet, or nil when the user can't access it
+func (interactor *interactor) getWalletMembers(userID string, walletID string) ([]*walletMember, error) {
+	log.WithFields(map[string]interface{}{"method": "getWalletMembers"})
+	log.Infof("getting members of wallet %s of user %s", walletID, userID)
+
+	wallet, err := interactor.getWallet(userID, walletID)
+	if err != nil || wallet == nil {
+		return nil, err
+	}
+
+	owner, err := interactor.getUser(wallet.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]*walletMember, 0)
+	if owner != nil {
+		members = append(members, &walletMember{
+			WalletID:  walletID,
+			UserID:    owner.UserID,
+			Name:      owner.Name,
+			Email:     owner.Email,
+			Role:      walletRoleOwner,
+			UpdatedAt: wallet.UpdatedAt,
+			CreatedAt: wallet.CreatedAt,
+		})
+	}
+
+	if walletMembers, err := interactor.storageDB.getWalletMembers(walletID); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error getting members of wallet on storage database %s", err)
+		return nil, err
+	} else {
+		return append(members, walletMembers...), nil
+	}
+}
+
+// addWalletMember adds the user with the email to the wallet with the role, and lets the user know by email
+func (interactor *interactor) addWalletMember(userID string, walletID string, email string, role string) (*walletMember, error) {
+	log.WithFields(map[string]interface{}{"method": "addWalletMember"})
+	log.Infof("adding %s to wallet %s of user %s as %s", email, walletID, userID, role)
+
+	if !isWalletMemberRole(role) {
+		return nil, errInvalidWalletRole
+	}
+
+	wallet, err := interactor.getWallet(userID, walletID)
+	if err != nil {
+		return nil, err
+	}
+	if wallet == nil {
+		return nil, errWalletNotFound
+	}
+	if !walletRoleAllows(wallet.Role, walletRoleAdmin) {
+		return nil, newAuthorizationError("the user can't manage the members of the wallet %s", walletID)
+	}
+
+	member, err := interactor.getUserByEmail(email)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil || member.Status == userStatusDeleted {
+		return nil, errMemberNotFound
+	}
+	if member.UserID == wallet.UserID {
+		return nil, errMemberIsOwner
+	}
+
+	if existing, err := interactor.storageDB.getWalletMember(walletID, member.UserID); err != nil {
+		return nil, err
+	} else if existing != nil {
+		return nil, errMemberExists
+	}
+
+	createdMember, err := interactor.storageDB.createWalletMember(&walletMember{
+		WalletID:  walletID,
+		UserID:    member.UserID,
+		Role:      role,
+		InvitedBy: userID,
+	})
+	if err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error adding member to wallet on storage database %s", err)
+		return nil, err
+	}
+
+	body := fmt.Sprintf("Hello %s,\n\n"+
+		"you were added as %s of the wallet %s on Go Money.\n",
+		member.Name, role, wallet.Name)
+
+	if err := interactor.mailer.send(member.Email, "You were added to a Go Money wallet", body); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error sending wallet invitation to user %s %s", member.UserID, err)
+	}
+
+	return createdMember, nil
+}
+
+// updateWalletMember changes the role of a member of the wallet, returning nil when the member doesn't exist
+func (interactor *interactor) updateWalletMember(userID string, walletID string, memberID string, role string) (*walletMember, error) {
+	log.WithFields(map[string]interface{}{"method": "updateWalletMember"})
+	log.Infof("updating member %s of wallet %s of user %s to %s", memberID, walletID, userID, role)
+
+	if !isWalletMemberRole(role) {
+		return nil, errInvalidWalletRole
+	}
+
+	wallet, err := interactor.getWallet(userID, walletID)
+	if err != nil || wallet == nil {
+		return nil, err
+	}
+	if !walletRoleAllows(wallet.Role, walletRoleAdmin) {
+		return nil, newAuthorizationError("the user can't manage the members of the wallet %s", walletID)
+	}
+
+	if member, err := interactor.storageDB.updateWalletMember(walletID, memberID, role); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error updating member of wallet on storage database %s", err)
+		return nil, err
+	} else {
+		return member, nil
+	}
+}
+
+// removeWalletMember removes a member of the wallet, the admins remove any member and the others can only leave.
+// It returns false when the member doesn't exist.
+func (interactor *interactor) removeWalletMember(userID string, walletID string, memberID string) (bool, error) {
+	log.WithFields(map[string]interface{}{"method": "removeWalletMember"})
+	log.Infof("removing member %s of wallet %s of user %s", memberID, walletID, userID)
+
+	wallet, err := interactor.getWallet(userID, walletID)
+	if err != nil || wallet == nil {
+		return false, err
+	}
+	if memberID != userID && !walletRoleAllows(wallet.Role, walletRoleAdmin) {
+		return false, newAuthorizationError("the user can't manage the members of the wallet %s", walletID)
+	}
+
+	if deleted, err := interactor.storageDB.deleteWalletMember(walletID, memberID); err != nil {
+		log.WithFields(map[string]interface{}{"error": err.Error(), "cause": err}).
+			Errorf("error removing member of wallet on storage database %s", err)
+		return false, err
+	} else {
+		return deleted, nil
+	}
+}
+
+// requireWalletRole only allows the users with at least the role on the wallet of the route,
+// it must be used after the authenticate middleware. The wallets the user can't access are left to the handlers.
+func (api *apiWeb) requireWalletRole(role string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			wallet, err := api.interactor.getWallet(ctx.Param("user_id"), ctx.Param("wallet_id"))
+			if err != nil {
+				return ctx.JSON(http.StatusInternalServerError, errorResponse{Code: http.StatusInternalServerError, Message: err.Error(), Cause: ""})
+			} else if wallet != nil && !walletRoleAllows(wallet.Role, role) {
+				log.Infof("user %s is %s of wallet %s and requires %s", ctx.Param("user_id"), wallet.Role, wallet.WalletID, role)
+				return ctx.JSON(http.StatusForbidden, errorResponse{Code: http.StatusForbidden, Message: fmt.Sprintf("the %s role of the wallet is required", role), Cause: ""})
+			}
+
+			return next(ctx)
+		}
+	}
+}
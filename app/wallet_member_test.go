@@ -0,0 +1,120 @@
+package gomoney
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo"
+)
+
+func TestWalletRoleAllows(t *testing.T) {
+	tests := []struct {
+		role     string
+		required string
+		expected bool
+	}{
+		{role: walletRoleViewer, required: walletRoleViewer, expected: true},
+		{role: walletRoleViewer, required: walletRoleEditor, expected: false},
+		{role: walletRoleEditor, required: walletRoleViewer, expected: true},
+		{role: walletRoleEditor, required: walletRoleEditor, expected: true},
+		{role: walletRoleEditor, required: walletRoleAdmin, expected: false},
+		{role: walletRoleAdmin, required: walletRoleEditor, expected: true},
+		{role: walletRoleAdmin, required: walletRoleOwner, expected: false},
+		{role: walletRoleOwner, required: walletRoleOwner, expected: true},
+		{role: walletRoleOwner, required: walletRoleAdmin, expected: true},
+		{role: "", required: walletRoleViewer, expected: false},
+		{role: "unknown", required: walletRoleViewer, expected: false},
+	}
+
+	for _, test := range tests {
+		if got := walletRoleAllows(test.role, test.required); got != test.expected {
+			t.Errorf("%q requiring %q: expected %t, got %t", test.role, test.required, test.expected, got)
+		}
+	}
+}
+
+func TestRequireWalletRole(t *testing.T) {
+	storage := newFakeStorageDB()
+	userID, walletID := genUI(), genUI()
+	storage.wallets[userID+":"+walletID] = &wallet{WalletID: walletID, UserID: genUI(), Role: walletRoleEditor}
+	api, _ := newTestApi(storage)
+
+	tests := []struct {
+		name     string
+		walletID string
+		required string
+		expected int
+	}{
+		{name: "lower role", walletID: walletID, required: walletRoleViewer, expected: http.StatusNoContent},
+		{name: "same role", walletID: walletID, required: walletRoleEditor, expected: http.StatusNoContent},
+		{name: "higher role", walletID: walletID, required: walletRoleAdmin, expected: http.StatusForbidden},
+		{name: "owner role", walletID: walletID, required: walletRoleOwner, expected: http.StatusForbidden},
+		{name: "wallet left to the handler", walletID: genUI(), required: walletRoleOwner, expected: http.StatusNoContent},
+	}
+
+	for _, test := range tests {
+		router := echo.New()
+		router.Add(http.MethodPost, "/api/1/users/:user_id/wallets/:wallet_id", func(ctx echo.Context) error {
+			return ctx.NoContent(http.StatusNoContent)
+		}, api.requireWalletRole(test.required))
+
+		request := httptest.NewRequest(http.MethodPost, "/api/1/users/"+userID+"/wallets/"+test.walletID, nil)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+
+		if recorder.Code != test.expected {
+			t.Errorf("%s: expected the status %d, got %d with %s", test.name, test.expected, recorder.Code, recorder.Body.String())
+		}
+	}
+}
+
+func TestWalletRoleRoutes(t *testing.T) {
+	storage := newFakeStorageDB()
+	ownerID, walletID := genUI(), genUI()
+	tokens := make(map[string]string)
+	ids := make(map[string]string)
+
+	for _, role := range []string{walletRoleOwner, walletRoleAdmin, walletRoleEditor, walletRoleViewer} {
+		userID := ownerID
+		if role != walletRoleOwner {
+			userID = genUI()
+		}
+		tokens[role] = storage.addSession(userID, roleUser)
+		ids[role] = userID
+		storage.wallets[userID+":"+walletID] = &wallet{WalletID: walletID, UserID: ownerID, Role: role}
+	}
+
+	_, client := newTestApi(storage)
+
+	// the routes allowed to the role are covered by TestRequireWalletRole, these never reach the handlers
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		role   string
+	}{
+		{name: "transaction created by a viewer", method: http.MethodPost, path: "/transactions", role: walletRoleViewer},
+		{name: "transaction updated by a viewer", method: http.MethodPut, path: "/transactions/transaction", role: walletRoleViewer},
+		{name: "transaction deleted by a viewer", method: http.MethodDelete, path: "/transactions/transaction", role: walletRoleViewer},
+		{name: "attachments created by a viewer", method: http.MethodPost, path: "/transactions/transaction/attachments", role: walletRoleViewer},
+		{name: "attachment deleted by a viewer", method: http.MethodDelete, path: "/transactions/transaction/attachments/attachment", role: walletRoleViewer},
+		{name: "wallet updated by an editor", method: http.MethodPut, path: "", role: walletRoleEditor},
+		{name: "wallet deleted by an admin", method: http.MethodDelete, path: "", role: walletRoleAdmin},
+		{name: "member added by an editor", method: http.MethodPost, path: "/members", role: walletRoleEditor},
+		{name: "member updated by an editor", method: http.MethodPut, path: "/members/member", role: walletRoleEditor},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := httptest.NewRequest(test.method, "/api/1/users/"+ids[test.role]+"/wallets/"+walletID+test.path, nil)
+			request.Header.Set(session_key, authentication+" "+tokens[test.role])
+			recorder := httptest.NewRecorder()
+			client.echo.ServeHTTP(recorder, request)
+
+			if recorder.Code != http.StatusForbidden {
+				t.Errorf("expected the status %d, got %d with %s", http.StatusForbidden, recorder.Code, recorder.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package gomoney
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	mimeXlsx = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+	xlsxStyleDefault = 0
+	xlsxStyleDate    = 1
+	xlsxStyleNumber  = 2
+	xlsxStyleHeader  = 3
+
+	xlsxMaxSheetName = 31
+)
+
+// xlsxWorkbook is a minimal spreadsheetml writer with string, numeric and date cells
+type xlsxWorkbook struct {
+	sheets []*xlsxSheet
+	names  map[string]bool
+}
+
+// xlsxSheet ...
+type xlsxSheet struct {
+	Name string
+	rows [][]xlsxCell
+}
+
+// xlsxCell ...
+type xlsxCell struct {
+	value interface{}
+	style int
+}
+
+func newXlsxWorkbook() *xlsxWorkbook {
+	return &xlsxWorkbook{
+		names: make(map[string]bool),
+	}
+}
+
+// addSheet adds a sheet with a unique and valid name
+func (workbook *xlsxWorkbook) addSheet(name string) *xlsxSheet {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '-'
+		}
+		return r
+	}, name)
+	if name == "" {
+		name = "Sheet"
+	}
+
+	unique := []rune(name)
+	if len(unique) > xlsxMaxSheetName {
+		unique = unique[:xlsxMaxSheetName]
+	}
+	for i := 2; workbook.names[strings.ToLower(string(unique))]; i++ {
+		suffix := []rune(fmt.Sprintf(" (%d)", i))
+		base := []rune(name)
+		if len(base)+len(suffix) > xlsxMaxSheetName {
+			base = base[:xlsxMaxSheetName-len(suffix)]
+		}
+		unique = append(base, suffix...)
+	}
+	workbook.names[strings.ToLower(string(unique))] = true
+
+	sheet := &xlsxSheet{Name: string(unique)}
+	workbook.sheets = append(workbook.sheets, sheet)
+	return sheet
+}
+
+// addHeader ...
+func (sheet *xlsxSheet) addHeader(values ...string) {
+	row := make([]xlsxCell, 0, len(values))
+	for _, value := range values {
+		row = append(row, xlsxCell{value: value, style: xlsxStyleHeader})
+	}
+	sheet.rows = append(sheet.rows, row)
+}
+
+// addRow adds a row of string, int, decimal or time values
+func (sheet *xlsxSheet) addRow(values ...interface{}) {
+	row := make([]xlsxCell, 0, len(values))
+	for _, value := range values {
+		cell := xlsxCell{value: value}
+		switch value.(type) {
+		case time.Time:
+			cell.style = xlsxStyleDate
+		case decimal.Decimal:
+			cell.style = xlsxStyleNumber
+		}
+		row = append(row, cell)
+	}
+	sheet.rows = append(sheet.rows, row)
+}
+
+// write ...
+func (workbook *xlsxWorkbook) write(writer io.Writer) error {
+	if len(workbook.sheets) == 0 {
+		workbook.addSheet("Sheet")
+	}
+
+	zipWriter := zip.NewWriter(writer)
+
+	var contentTypes, workbookXml, workbookRels bytes.Buffer
+	contentTypes.WriteString(xml.Header)
+	contentTypes.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	contentTypes.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	contentTypes.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	contentTypes.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	contentTypes.WriteString(`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+
+	workbookXml.WriteString(xml.Header)
+	workbookXml.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+
+	workbookRels.WriteString(xml.Header)
+	workbookRels.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+
+	for i, sheet := range workbook.sheets {
+		id := i + 1
+		fmt.Fprintf(&contentTypes, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, id)
+		fmt.Fprintf(&workbookXml, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xlsxEscape(sheet.Name), id, id)
+		fmt.Fprintf(&workbookRels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, id, id)
+
+		fileWriter, err := zipWriter.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", id))
+		if err != nil {
+			return err
+		}
+		if err := sheet.write(fileWriter); err != nil {
+			return err
+		}
+	}
+
+	contentTypes.WriteString(`</Types>`)
+	workbookXml.WriteString(`</sheets></workbook>`)
+	fmt.Fprintf(&workbookRels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, len(workbook.sheets)+1)
+	workbookRels.WriteString(`</Relationships>`)
+
+	parts := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", contentTypes.String()},
+		{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+		{"xl/workbook.xml", workbookXml.String()},
+		{"xl/_rels/workbook.xml.rels", workbookRels.String()},
+		{"xl/styles.xml", xlsxStyles},
+	}
+
+	for _, part := range parts {
+		fileWriter, err := zipWriter.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(fileWriter, part.content); err != nil {
+			return err
+		}
+	}
+
+	return zipWriter.Close()
+}
+
+func (sheet *xlsxSheet) write(writer io.Writer) error {
+	var buffer bytes.Buffer
+	buffer.WriteString(xml.Header)
+	buffer.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	for i, row := range sheet.rows {
+		fmt.Fprintf(&buffer, `<row r="%d">`, i+1)
+		for j, cell := range row {
+			reference := fmt.Sprintf("%s%d", xlsxColumn(j), i+1)
+
+			switch value := cell.value.(type) {
+			case nil:
+				continue
+			case time.Time:
+				if value.IsZero() {
+					continue
+				}
+				fmt.Fprintf(&buffer, `<c r="%s" s="%d"><v>%s</v></c>`, reference, cell.style, xlsxDate(value))
+			case decimal.Decimal:
+				fmt.Fprintf(&buffer, `<c r="%s" s="%d"><v>%s</v></c>`, reference, cell.style, value.String())
+			case int:
+				fmt.Fprintf(&buffer, `<c r="%s" s="%d"><v>%d</v></c>`, reference, cell.style, value)
+			default:
+				fmt.Fprintf(&buffer, `<c r="%s" s="%d" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, reference, cell.style, xlsxEscape(fmt.Sprint(value)))
+			}
+		}
+		buffer.WriteString(`</row>`)
+	}
+
+	buffer.WriteString(`</sheetData></worksheet>`)
+
+	_, err := buffer.WriteTo(writer)
+	return err
+}
+
+// xlsxColumn converts a zero based index into a column name (A, B, ..., AA)
+func xlsxColumn(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
+
+// xlsxDate converts a time into a serial date, keeping the wall clock
+func xlsxDate(value time.Time) string {
+	// the serial date counts the wall clock, so it is computed in UTC to skip the daylight saving changes
+	wall := time.Date(value.Year(), value.Month(), value.Day(), value.Hour(), value.Minute(), value.Second(), value.Nanosecond(), time.UTC)
+	epoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	days := decimal.NewFromFloat(wall.Sub(epoch).Hours() / 24)
+	return days.StringFixed(6)
+}
+
+func xlsxEscape(value string) string {
+	var buffer bytes.Buffer
+	xml.EscapeText(&buffer, []byte(value))
+	return buffer.String()
+}
+
+const xlsxStyles = xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<numFmts count="1"><numFmt numFmtId="164" formatCode="yyyy-mm-dd hh:mm"/></numFmts>` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="4">` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="4" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+	`</cellXfs>` +
+	`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+	`</styleSheet>`
@@ -0,0 +1,30 @@
+package gomoney
+
+import (
+	"testing"
+	"time"
+)
+
+func TestXlsxDate(t *testing.T) {
+	lisbon, err := time.LoadLocation("Europe/Lisbon")
+	if err != nil {
+		t.Skipf("time zone database not available: %s", err)
+	}
+
+	tests := []struct {
+		name     string
+		value    time.Time
+		expected string
+	}{
+		{name: "utc", value: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), expected: "46023.000000"},
+		{name: "winter time", value: time.Date(2026, 1, 1, 12, 0, 0, 0, lisbon), expected: "46023.500000"},
+		{name: "summer time", value: time.Date(2026, 4, 1, 0, 0, 0, 0, lisbon), expected: "46113.000000"},
+		{name: "fixed zone", value: time.Date(2026, 4, 1, 6, 0, 0, 0, time.FixedZone("", -5*3600)), expected: "46113.250000"},
+	}
+
+	for _, test := range tests {
+		if got := xlsxDate(test.value); got != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.name, test.expected, got)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	gomoney "github.com/joaosoft/go-money-backend/app"
+
+	"github.com/joaosoft/logger"
+
+	_ "github.com/go-sql-driver/mysql" // mysql driver
+	_ "github.com/lib/pq"              // postgres driver
+)
+
+var log = logger.NewLogDefault("go-money-cli", logger.InfoLevel)
+
+var commands = map[string]func(app *gomoney.Money, args []string) error{
+	"export": export,
+	"import": importAccount,
+	"ledger": ledger,
+	"role":   role,
+
+	"migrate-images":     migrateImages,
+	"deduplicate-images": deduplicateImages,
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(1)
+	}
+
+	command, ok := commands[os.Args[1]]
+	if !ok {
+		usage()
+		os.Exit(1)
+	}
+
+	app, err := gomoney.NewMoney()
+	if err != nil {
+		log.Error(err)
+		os.Exit(1)
+	}
+
+	if err := command(app, os.Args[2:]); err != nil {
+		log.Error(err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: cli <command> [arguments]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "commands:")
+	fmt.Fprintln(os.Stderr, "  export            exports the account of a user to a zip archive")
+	fmt.Fprintln(os.Stderr, "  import            restores a zip archive into an empty or a new account")
+	fmt.Fprintln(os.Stderr, "  ledger            exports the journal of a user as ledger, hledger or beancount")
+	fmt.Fprintln(os.Stderr, "  role              sets the role of a user, to create the first admin")
+	fmt.Fprintln(os.Stderr, "  migrate-images    copies the images to another blob storage (database, local, s3 or dropbox)")
+	fmt.Fprintln(os.Stderr, "  deduplicate-images")
+	fmt.Fprintln(os.Stderr, "                    collapses the identical images stored before the deduplication into shared blobs")
+}
+
+func export(app *gomoney.Money, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	userID := flags.String("user", "", "user id")
+	output := flags.String("output", "", "archive file (default go-money-<user>.zip)")
+	flags.Parse(args)
+
+	if *userID == "" {
+		flags.Usage()
+		return fmt.Errorf("missing user id")
+	}
+
+	if *output == "" {
+		*output = fmt.Sprintf("go-money-%s.zip", *userID)
+	}
+
+	file, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := app.ExportAccount(*userID, file); err != nil {
+		return err
+	}
+
+	log.Infof("exported account of user %s to %s", *userID, *output)
+	return nil
+}
+
+func importAccount(app *gomoney.Money, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	input := flags.String("archive", "", "archive file")
+	userID := flags.String("user", "", "user id of an empty account")
+	email := flags.String("email", "", "email of the new account (default the archive email)")
+	password := flags.String("password", "", "password of the new account")
+	flags.Parse(args)
+
+	if *input == "" || (*userID == "" && *password == "") {
+		flags.Usage()
+		return fmt.Errorf("missing archive and user id or password")
+	}
+
+	file, err := os.Open(*input)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	if *userID != "" {
+		if err := app.ImportAccount(*userID, file, info.Size()); err != nil {
+			return err
+		}
+	} else {
+		if *userID, err = app.ImportNewAccount(*email, *password, file, info.Size()); err != nil {
+			return err
+		}
+	}
+
+	log.Infof("imported %s into account of user %s", *input, *userID)
+	return nil
+}
+
+func ledger(app *gomoney.Money, args []string) error {
+	flags := flag.NewFlagSet("ledger", flag.ExitOnError)
+	userID := flags.String("user", "", "user id")
+	format := flags.String("format", "ledger", "journal format (ledger, hledger or beancount)")
+	output := flags.String("output", "", "journal file (default stdout)")
+	flags.Parse(args)
+
+	if *userID == "" {
+		flags.Usage()
+		return fmt.Errorf("missing user id")
+	}
+
+	writer := os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		writer = file
+	}
+
+	return app.ExportLedger(*userID, *format, writer)
+}
+
+func migrateImages(app *gomoney.Money, args []string) error {
+	flags := flag.NewFlagSet("migrate-images", flag.ExitOnError)
+	driver := flags.String("to", "", "blob storage driver (database, local, s3 or dropbox)")
+	deleteSource := flags.Bool("delete", false, "delete the images from the previous storage after the copy")
+	flags.Parse(args)
+
+	if *driver == "" {
+		flags.Usage()
+		return fmt.Errorf("missing blob storage driver")
+	}
+
+	migration, err := app.MigrateImages(*driver, *deleteSource)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("migrated %d images to %s, %d missing and %d failed", migration.Migrated, *driver, migration.Missing, migration.Failed)
+	if migration.Failed > 0 {
+		return fmt.Errorf("%d images failed to migrate, run the command again to retry them", migration.Failed)
+	}
+
+	return nil
+}
+
+func deduplicateImages(app *gomoney.Money, args []string) error {
+	flags := flag.NewFlagSet("deduplicate-images", flag.ExitOnError)
+	flags.Parse(args)
+
+	deduplication, err := app.DeduplicateImages()
+	if err != nil {
+		return err
+	}
+
+	log.Infof("deduplicated %d images, %d shared with other images saving %d bytes, %d missing and %d failed",
+		deduplication.Deduplicated, deduplication.Shared, deduplication.Saved, deduplication.Missing, deduplication.Failed)
+	if deduplication.Failed > 0 {
+		return fmt.Errorf("%d images failed to deduplicate, run the command again to retry them", deduplication.Failed)
+	}
+
+	return nil
+}
+
+func role(app *gomoney.Money, args []string) error {
+	flags := flag.NewFlagSet("role", flag.ExitOnError)
+	email := flags.String("email", "", "email of the user")
+	name := flags.String("role", "admin", "role of the user (user or admin)")
+	flags.Parse(args)
+
+	if *email == "" {
+		flags.Usage()
+		return fmt.Errorf("missing email")
+	}
+
+	if err := app.SetUserRole(*email, *name); err != nil {
+		return err
+	}
+
+	log.Infof("the user %s is now %s", *email, *name)
+	return nil
+}
@@ -0,0 +1,298 @@
+-- EXPORTS
+CREATE TABLE money.exports (
+  export_id               TEXT NOT NULL,
+  user_id                 TEXT NOT NULL,
+  status                  TEXT NOT NULL,
+  error                   TEXT NOT NULL DEFAULT '',
+  size                    BIGINT NOT NULL DEFAULT 0,
+  -- the archive is on the blob storage, on the path of the export
+  storage                 TEXT NOT NULL DEFAULT '',
+  created_at              TIMESTAMP DEFAULT NOW(),
+  updated_at              TIMESTAMP DEFAULT NOW(),
+  FOREIGN KEY(user_id) REFERENCES money.users(user_id),
+  PRIMARY KEY(export_id)
+);
+
+CREATE TRIGGER trigger_exports_updated_at BEFORE UPDATE
+  ON money.exports FOR EACH ROW EXECUTE PROCEDURE money.function_updated_at();
+
+
+-- BLOBS
+CREATE TABLE money.blobs (
+  path                    TEXT NOT NULL,
+  data                    BYTEA NOT NULL,
+  created_at              TIMESTAMP DEFAULT NOW(),
+  updated_at              TIMESTAMP DEFAULT NOW(),
+  PRIMARY KEY(path)
+);
+
+CREATE TRIGGER trigger_blobs_updated_at BEFORE UPDATE
+  ON money.blobs FOR EACH ROW EXECUTE PROCEDURE money.function_updated_at();
+
+-- the images are now on the blob storage, the database driver keeps them on the blobs table
+INSERT INTO money.blobs(path, data)
+  SELECT '/users/' || user_id || '/images/' || image_id, raw_image
+  FROM money.images
+  WHERE raw_image IS NOT NULL
+ON CONFLICT (path) DO NOTHING;
+
+-- the blob storage of each image, empty for the images from before it was tracked
+ALTER TABLE money.images ADD COLUMN storage TEXT NOT NULL DEFAULT '';
+UPDATE money.images SET storage = 'database' WHERE raw_image IS NOT NULL;
+
+ALTER TABLE money.images DROP COLUMN raw_image;
+
+
+-- IMAGE BLOBS
+-- the content of the images is stored once by its sha256 hash and shared by every image with the same content
+CREATE TABLE money.image_blobs (
+  hash                    TEXT NOT NULL,
+  storage                 TEXT NOT NULL,
+  size                    INTEGER NOT NULL DEFAULT 0,
+  reference_count         INTEGER NOT NULL DEFAULT 0,
+  -- the content is on the blob storage, until then the images that share the blob upload it too
+  uploaded                BOOLEAN NOT NULL DEFAULT FALSE,
+  created_at              TIMESTAMP DEFAULT NOW(),
+  updated_at              TIMESTAMP DEFAULT NOW(),
+  PRIMARY KEY(hash)
+);
+
+CREATE TRIGGER trigger_image_blobs_updated_at BEFORE UPDATE
+  ON money.image_blobs FOR EACH ROW EXECUTE PROCEDURE money.function_updated_at();
+
+-- the hash of the blob of each image, empty for the images from before the deduplication
+ALTER TABLE money.images ADD COLUMN hash TEXT NOT NULL DEFAULT '';
+CREATE INDEX index_images_hash ON money.images(hash);
+
+
+-- ATTACHMENTS
+CREATE TABLE money.attachments (
+  attachment_id           TEXT NOT NULL,
+  transaction_id          TEXT NOT NULL,
+  user_id                 TEXT NOT NULL,
+  file_name               TEXT NOT NULL,
+  content_type            TEXT NOT NULL,
+  size                    INTEGER NOT NULL DEFAULT 0,
+  storage                 TEXT NOT NULL DEFAULT '',
+  created_at              TIMESTAMP DEFAULT NOW(),
+  updated_at              TIMESTAMP DEFAULT NOW(),
+  FOREIGN KEY(user_id) REFERENCES money.users(user_id),
+  FOREIGN KEY(transaction_id) REFERENCES money.transactions(transaction_id) ON DELETE CASCADE,
+  PRIMARY KEY(attachment_id)
+);
+
+CREATE INDEX index_attachments_transaction_id ON money.attachments(transaction_id);
+
+CREATE TRIGGER trigger_attachments_updated_at BEFORE UPDATE
+  ON money.attachments FOR EACH ROW EXECUTE PROCEDURE money.function_updated_at();
+
+
+-- the location of the transactions, to suggest the wallet and the category of the receipts taken nearby
+ALTER TABLE money.transactions ADD COLUMN latitude DOUBLE PRECISION;
+ALTER TABLE money.transactions ADD COLUMN longitude DOUBLE PRECISION;
+CREATE INDEX index_transactions_location ON money.transactions(user_id, latitude, longitude) WHERE latitude IS NOT NULL;
+
+-- the transactions with the same group are the parts of a transfer between wallets or of a split between categories
+ALTER TABLE money.transactions ADD COLUMN group_id TEXT NOT NULL DEFAULT '';
+
+-- the passwords are stored as bcrypt hashes, the accounts from before keep only the legacy token until the next login
+UPDATE money.users SET password = '' WHERE password NOT LIKE '$2%';
+
+
+-- SESSIONS
+-- the access tokens expire and are renewed with a refresh token, that is stored as a sha256 hash.
+-- The sessions from before have no refresh token and expire now.
+ALTER TABLE money.sessions ADD COLUMN refresh_token TEXT UNIQUE;
+ALTER TABLE money.sessions ADD COLUMN expires_at TIMESTAMP NOT NULL DEFAULT NOW();
+ALTER TABLE money.sessions ADD COLUMN refresh_expires_at TIMESTAMP NOT NULL DEFAULT NOW();
+ALTER TABLE money.sessions ADD COLUMN last_used_at TIMESTAMP NOT NULL DEFAULT NOW();
+CREATE INDEX index_sessions_refresh_expires_at ON money.sessions(refresh_expires_at);
+
+-- the refresh tokens already rotated, when one is used again the session is revoked
+CREATE TABLE money.session_refresh_tokens (
+  refresh_token           TEXT NOT NULL,
+  session_id              TEXT NOT NULL,
+  created_at              TIMESTAMP DEFAULT NOW(),
+  FOREIGN KEY(session_id) REFERENCES money.sessions(session_id) ON DELETE CASCADE,
+  PRIMARY KEY(refresh_token)
+);
+
+CREATE INDEX index_session_refresh_tokens_session_id ON money.session_refresh_tokens(session_id);
+
+
+-- the role of the users, the admins can access the resources of every user
+ALTER TABLE money.users ADD COLUMN role TEXT NOT NULL DEFAULT 'user';
+
+-- the device of the sessions, updated when the session is refreshed
+ALTER TABLE money.sessions ADD COLUMN user_agent TEXT NOT NULL DEFAULT '';
+ALTER TABLE money.sessions ADD COLUMN ip TEXT NOT NULL DEFAULT '';
+
+
+-- PASSWORD RESETS
+-- the reset tokens are stored as a sha256 hash and can only be used once
+CREATE TABLE money.password_resets (
+  token                   TEXT NOT NULL,
+  user_id                 TEXT NOT NULL,
+  expires_at              TIMESTAMP NOT NULL,
+  used_at                 TIMESTAMP,
+  created_at              TIMESTAMP DEFAULT NOW(),
+  FOREIGN KEY(user_id) REFERENCES money.users(user_id) ON DELETE CASCADE,
+  PRIMARY KEY(token)
+);
+
+CREATE INDEX index_password_resets_user_id ON money.password_resets(user_id);
+
+-- the status of the users: 0 active, 1 unverified, 2 locked and 3 deleted
+UPDATE money.users SET status = 0 WHERE status IS NULL;
+ALTER TABLE money.users ALTER COLUMN status SET NOT NULL;
+
+
+-- TWO FACTOR AUTHENTICATION
+-- the totp counter is the last code used, so a code can't be used twice
+ALTER TABLE money.users ADD COLUMN totp_secret TEXT NOT NULL DEFAULT '';
+ALTER TABLE money.users ADD COLUMN totp_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE money.users ADD COLUMN totp_counter BIGINT NOT NULL DEFAULT 0;
+
+-- the recovery codes are stored as a sha256 hash
+CREATE TABLE money.recovery_codes (
+  user_id                 TEXT NOT NULL,
+  code                    TEXT NOT NULL,
+  used_at                 TIMESTAMP,
+  created_at              TIMESTAMP DEFAULT NOW(),
+  FOREIGN KEY(user_id) REFERENCES money.users(user_id) ON DELETE CASCADE,
+  PRIMARY KEY(user_id, code)
+);
+
+
+-- PERSONAL ACCESS TOKENS
+-- the tokens are stored as a sha256 hash, the prefix identifies them to the user
+CREATE TABLE money.access_tokens (
+  access_token_id         TEXT NOT NULL,
+  user_id                 TEXT NOT NULL,
+  name                    TEXT NOT NULL,
+  token                   TEXT NOT NULL UNIQUE,
+  prefix                  TEXT NOT NULL,
+  scopes                  TEXT[] NOT NULL,
+  expires_at              TIMESTAMP,
+  last_used_at            TIMESTAMP,
+  created_at              TIMESTAMP DEFAULT NOW(),
+  FOREIGN KEY(user_id) REFERENCES money.users(user_id) ON DELETE CASCADE,
+  PRIMARY KEY(access_token_id)
+);
+
+
+-- LOGIN LIMITS
+-- the logins of a user are locked until locked_until after too many failures
+ALTER TABLE money.users ADD COLUMN locked_until TIMESTAMP;
+
+-- the failed logins by email and by ip, when the instances share the postgres limiter
+CREATE TABLE money.login_failures (
+  key                     TEXT NOT NULL,
+  failures                INTEGER NOT NULL,
+  first_failure_at        TIMESTAMP NOT NULL,
+  last_failure_at         TIMESTAMP NOT NULL,
+  PRIMARY KEY(key)
+);
+
+-- the audit of the logins
+CREATE TABLE money.login_attempts (
+  login_attempt_id        TEXT NOT NULL,
+  email                   TEXT NOT NULL,
+  user_id                 TEXT,
+  ip                      TEXT NOT NULL,
+  user_agent              TEXT NOT NULL,
+  success                 BOOLEAN NOT NULL,
+  reason                  TEXT NOT NULL,
+  created_at              TIMESTAMP DEFAULT NOW(),
+  FOREIGN KEY(user_id) REFERENCES money.users(user_id) ON DELETE SET NULL,
+  PRIMARY KEY(login_attempt_id)
+);
+
+CREATE INDEX login_attempts_email_idx ON money.login_attempts(email, created_at);
+
+
+-- SHARED WALLETS
+-- the owner of a wallet is its user, the members have the role viewer, editor or admin
+CREATE TABLE money.wallet_members (
+  wallet_id               TEXT NOT NULL,
+  user_id                 TEXT NOT NULL,
+  role                    TEXT NOT NULL CHECK (role IN ('viewer', 'editor', 'admin')),
+  invited_by              TEXT NOT NULL DEFAULT '',
+  updated_at              TIMESTAMP DEFAULT NOW(),
+  created_at              TIMESTAMP DEFAULT NOW(),
+  FOREIGN KEY(wallet_id) REFERENCES money.wallets(wallet_id) ON DELETE CASCADE,
+  FOREIGN KEY(user_id) REFERENCES money.users(user_id) ON DELETE CASCADE,
+  PRIMARY KEY(wallet_id, user_id)
+);
+
+CREATE INDEX wallet_members_user_idx ON money.wallet_members(user_id);
+
+-- the users that created and last updated the transactions, the older transactions were made by their owner
+ALTER TABLE money.transactions ADD COLUMN created_by TEXT;
+ALTER TABLE money.transactions ADD COLUMN updated_by TEXT;
+
+
+-- HOUSEHOLDS
+-- a household groups users that share categories, budgets and the wallets they attach to it
+CREATE TABLE money.households (
+  household_id            TEXT NOT NULL,
+  name                    TEXT NOT NULL,
+  created_by              TEXT NOT NULL,
+  updated_at              TIMESTAMP DEFAULT NOW(),
+  created_at              TIMESTAMP DEFAULT NOW(),
+  PRIMARY KEY(household_id)
+);
+
+-- the members of a household have the role owner, admin, member or viewer
+CREATE TABLE money.household_members (
+  household_id            TEXT NOT NULL,
+  user_id                 TEXT NOT NULL,
+  role                    TEXT NOT NULL CHECK (role IN ('viewer', 'member', 'admin', 'owner')),
+  invited_by              TEXT NOT NULL DEFAULT '',
+  updated_at              TIMESTAMP DEFAULT NOW(),
+  created_at              TIMESTAMP DEFAULT NOW(),
+  FOREIGN KEY(household_id) REFERENCES money.households(household_id) ON DELETE CASCADE,
+  FOREIGN KEY(user_id) REFERENCES money.users(user_id) ON DELETE CASCADE,
+  PRIMARY KEY(household_id, user_id)
+);
+
+CREATE INDEX household_members_user_idx ON money.household_members(user_id);
+
+-- the invitations by email, the token is the sha256 of the token sent on the email
+CREATE TABLE money.household_invitations (
+  invitation_id           TEXT NOT NULL,
+  household_id            TEXT NOT NULL,
+  email                   TEXT NOT NULL,
+  role                    TEXT NOT NULL CHECK (role IN ('viewer', 'member', 'admin')),
+  invited_by              TEXT NOT NULL,
+  token                   TEXT NOT NULL UNIQUE,
+  expires_at              TIMESTAMP NOT NULL,
+  accepted_at             TIMESTAMP,
+  created_at              TIMESTAMP DEFAULT NOW(),
+  FOREIGN KEY(household_id) REFERENCES money.households(household_id) ON DELETE CASCADE,
+  PRIMARY KEY(invitation_id)
+);
+
+-- the budgets of a household by month or year, on a category or on every expense when the category is empty
+CREATE TABLE money.budgets (
+  budget_id               TEXT NOT NULL,
+  household_id            TEXT NOT NULL,
+  category_id             TEXT NOT NULL DEFAULT '',
+  amount                  NUMERIC NOT NULL CHECK (amount > 0),
+  period                  TEXT NOT NULL CHECK (period IN ('month', 'year')),
+  created_by              TEXT NOT NULL,
+  updated_at              TIMESTAMP DEFAULT NOW(),
+  created_at              TIMESTAMP DEFAULT NOW(),
+  FOREIGN KEY(household_id) REFERENCES money.households(household_id) ON DELETE CASCADE,
+  PRIMARY KEY(budget_id)
+);
+
+-- the wallets attached to a household are shared with its members, the others stay private
+ALTER TABLE money.wallets ADD COLUMN household_id TEXT REFERENCES money.households(household_id) ON DELETE SET NULL;
+
+-- the shared categories of a household, they become personal categories of their user when the household is deleted
+ALTER TABLE money.categories ADD COLUMN household_id TEXT REFERENCES money.households(household_id) ON DELETE SET NULL;
+
+-- the passwords of the wallets are stored as bcrypt hashes, with the password_cost of the configuration
+CREATE EXTENSION IF NOT EXISTS pgcrypto;
+UPDATE money.wallets SET password = crypt(password, gen_salt('bf', 12)) WHERE password <> '' AND password NOT LIKE '$2%';
@@ -1,3 +1,29 @@
+DROP TABLE IF EXISTS money.budgets;
+DROP TABLE IF EXISTS money.household_invitations;
+DROP TABLE IF EXISTS money.household_members;
+DROP TABLE IF EXISTS money.households CASCADE;
+DROP TABLE IF EXISTS money.wallet_members;
+DROP TABLE IF EXISTS money.login_attempts;
+DROP TABLE IF EXISTS money.login_failures;
+DROP TABLE IF EXISTS money.access_tokens;
+DROP TABLE IF EXISTS money.recovery_codes;
+
+DROP TABLE IF EXISTS money.password_resets;
+
+DROP TABLE IF EXISTS money.session_refresh_tokens;
+
+DROP TABLE IF EXISTS money.attachments;
+DROP TRIGGER IF EXISTS trigger_attachments_updated_at on money.attachments;
+
+DROP TABLE IF EXISTS money.image_blobs;
+DROP TRIGGER IF EXISTS trigger_image_blobs_updated_at on money.image_blobs;
+
+DROP TABLE IF EXISTS money.blobs;
+DROP TRIGGER IF EXISTS trigger_blobs_updated_at on money.blobs;
+
+DROP TABLE IF EXISTS money.exports;
+DROP TRIGGER IF EXISTS trigger_exports_updated_at on money.exports;
+
 DROP TABLE IF EXISTS money.users;
 DROP TRIGGER IF EXISTS trigger_users_updated_at on money.users;
 